@@ -38,6 +38,7 @@ import (
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/node"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/prometheus"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/tracing"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/utils"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
@@ -79,6 +80,8 @@ var (
 		"Namespace of the feature state switch configmap in supervisor cluster")
 	internalFSSName      = flag.String("fss-name", "", "Name of the feature state switch configmap")
 	internalFSSNamespace = flag.String("fss-namespace", "", "Namespace of the feature state switch configmap")
+	enableTracing        = flag.Bool("enable-tracing", false, "Export traces to an OTLP collector. "+
+		"The collector is configured with the OTEL_EXPORTER_OTLP_* environment variables.")
 )
 
 // main for vsphere syncer.
@@ -98,6 +101,17 @@ func main() {
 	if err != nil {
 		log.Errorf("failed retrieving cluster flavor. Error: %v", err)
 	}
+
+	shutdownTracing := func(context.Context) error { return nil }
+	if *enableTracing {
+		shutdownTracing, err = tracing.InitTracerProvider(ctx, "vsphere-syncer")
+		if err != nil {
+			log.Errorf("failed to initialize tracing. Error: %v", err)
+			os.Exit(1)
+		}
+		log.Info("Tracing is enabled")
+	}
+
 	commonco.SetInitParams(ctx, clusterFlavor, &syncer.COInitParams, *supervisorFSSName, *supervisorFSSNamespace,
 		*internalFSSName, *internalFSSNamespace, "", *operationMode)
 	admissionhandler.COInitParams = &syncer.COInitParams
@@ -118,6 +132,9 @@ func main() {
 			if sig == syscall.SIGTERM {
 				log.Info("SIGTERM signal received")
 				utils.LogoutAllvCenterSessions(ctx)
+				if err := shutdownTracing(ctx); err != nil {
+					log.Errorf("failed to flush traces. Error: %v", err)
+				}
 				os.Exit(0)
 			}
 		}
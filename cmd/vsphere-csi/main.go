@@ -25,6 +25,7 @@ import (
 	"syscall"
 
 	csiconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/tracing"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/utils"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
@@ -41,6 +42,8 @@ var (
 		"Namespace of the feature state switch configmap in supervisor cluster")
 	internalFSSName      = flag.String("fss-name", "", "Name of the feature state switch configmap")
 	internalFSSNamespace = flag.String("fss-namespace", "", "Namespace of the feature state switch configmap")
+	enableTracing        = flag.Bool("enable-tracing", false, "Export traces to an OTLP collector. "+
+		"The collector is configured with the OTEL_EXPORTER_OTLP_* environment variables.")
 )
 
 // main is ignored when this package is built as a go plug-in.
@@ -60,6 +63,17 @@ func main() {
 	if err != nil {
 		log.Errorf("failed retrieving the cluster flavor. Error: %v", err)
 	}
+
+	shutdownTracing := func(context.Context) error { return nil }
+	if *enableTracing {
+		shutdownTracing, err = tracing.InitTracerProvider(ctx, "vsphere-csi-driver")
+		if err != nil {
+			log.Errorf("failed to initialize tracing. Error: %v", err)
+			os.Exit(1)
+		}
+		log.Info("Tracing is enabled")
+	}
+
 	serviceMode := os.Getenv(csitypes.EnvVarMode)
 	commonco.SetInitParams(ctx, clusterFlavor, &service.COInitParams, *supervisorFSSName, *supervisorFSSNamespace,
 		*internalFSSName, *internalFSSNamespace, serviceMode, "")
@@ -87,6 +101,9 @@ func main() {
 			if sig == syscall.SIGTERM {
 				log.Info("SIGTERM signal received")
 				utils.LogoutAllvCenterSessions(ctx)
+				if err := shutdownTracing(ctx); err != nil {
+					log.Errorf("failed to flush traces. Error: %v", err)
+				}
 				os.Exit(0)
 			}
 		}
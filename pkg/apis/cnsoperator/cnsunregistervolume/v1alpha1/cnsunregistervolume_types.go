@@ -39,6 +39,14 @@ type CnsUnregisterVolumeStatus struct {
 	// This field must only be set by the entity completing the export
 	// operation, i.e. the CNS Operator.
 	Error string `json:"error,omitempty"`
+
+	// FcdID is the ID of the first class disk which backed the volume. The
+	// disk is retained after the volume is unregistered.
+	FcdID string `json:"fcdID,omitempty"`
+
+	// DiskPath is the datastore path of the virtual disk which backed the
+	// volume, e.g. "[datastore1] fcd/disk.vmdk".
+	DiskPath string `json:"diskPath,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
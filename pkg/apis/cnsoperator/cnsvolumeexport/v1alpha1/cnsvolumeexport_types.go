@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CnsVolumeExportSpec defines the desired state of CnsVolumeExport
+// +k8s:openapi-gen=true
+type CnsVolumeExportSpec struct {
+	// PvcName indicates the name of the PVC, in the namespace of the
+	// CnsVolumeExport instance, whose volume is to be exported.
+	PvcName string `json:"pvcName"`
+}
+
+// CnsVolumeExportStatus defines the observed state of CnsVolumeExport
+// +k8s:openapi-gen=true
+type CnsVolumeExportStatus struct {
+	// Indicates the volume is successfully exported.
+	// This field must only be set by the entity completing the export
+	// operation, i.e. the CNS Operator.
+	Exported bool `json:"exported"`
+
+	// VolumeID is the volume handle of the exported volume. It is to be
+	// used in the CnsVolumeImport instance on the destination cluster.
+	VolumeID string `json:"volumeID,omitempty"`
+
+	// DiskPath is the datastore path of the virtual disk which backs the
+	// volume, e.g. "[datastore1] fcd/disk.vmdk".
+	DiskPath string `json:"diskPath,omitempty"`
+
+	// The last error encountered during export operation, if any.
+	// This field must only be set by the entity completing the export
+	// operation, i.e. the CNS Operator.
+	Error string `json:"error,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CnsVolumeExport is the Schema for the cnsvolumeexports API
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+type CnsVolumeExport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CnsVolumeExportSpec   `json:"spec,omitempty"`
+	Status CnsVolumeExportStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CnsVolumeExportList contains a list of CnsVolumeExport
+type CnsVolumeExportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CnsVolumeExport `json:"items"`
+}
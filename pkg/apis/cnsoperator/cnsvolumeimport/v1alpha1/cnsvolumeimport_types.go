@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CnsVolumeImportSpec defines the desired state of CnsVolumeImport
+// +k8s:openapi-gen=true
+type CnsVolumeImportSpec struct {
+	// VolumeID indicates the volume handle of the volume exported by the
+	// source cluster.
+	VolumeID string `json:"volumeID"`
+
+	// PvcName indicates the name of the PVC to be created for the volume in
+	// the namespace of the CnsVolumeImport instance.
+	PvcName string `json:"pvcName"`
+}
+
+// CnsVolumeImportStatus defines the observed state of CnsVolumeImport
+// +k8s:openapi-gen=true
+type CnsVolumeImportStatus struct {
+	// Indicates the volume is successfully imported.
+	// This field must only be set by the entity completing the import
+	// operation, i.e. the CNS Operator.
+	Imported bool `json:"imported"`
+
+	// The last error encountered during import operation, if any.
+	// This field must only be set by the entity completing the import
+	// operation, i.e. the CNS Operator.
+	Error string `json:"error,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CnsVolumeImport is the Schema for the cnsvolumeimports API
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+type CnsVolumeImport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CnsVolumeImportSpec   `json:"spec,omitempty"`
+	Status CnsVolumeImportStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CnsVolumeImportList contains a list of CnsVolumeImport
+type CnsVolumeImportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CnsVolumeImport `json:"items"`
+}
@@ -27,6 +27,21 @@ var EmbedCnsUnregisterVolumeCRFile embed.FS
 
 const EmbedCnsUnregisterVolumeCRFileName = "cnsunregistervolume_crd.yaml"
 
+//go:embed cnsvolumeexport_crd.yaml
+var EmbedCnsVolumeExportCRFile embed.FS
+
+const EmbedCnsVolumeExportCRFileName = "cnsvolumeexport_crd.yaml"
+
+//go:embed cnsvolumeimport_crd.yaml
+var EmbedCnsVolumeImportCRFile embed.FS
+
+const EmbedCnsVolumeImportCRFileName = "cnsvolumeimport_crd.yaml"
+
+//go:embed orphanvolume_crd.yaml
+var EmbedOrphanVolumeCRFile embed.FS
+
+const EmbedOrphanVolumeCRFileName = "orphanvolume_crd.yaml"
+
 //go:embed cns.vmware.com_storagepolicyquotas.yaml
 var EmbedStoragePolicyQuotaCRFile embed.FS
 
@@ -0,0 +1,105 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// OrphanVolumeSpec defines the desired state of OrphanVolume
+// +k8s:openapi-gen=true
+type OrphanVolumeSpec struct {
+	// VolumeID is the ID of the orphan volume in CNS.
+	VolumeID string `json:"volumeID"`
+
+	// VCenter is the vCenter on which the volume resides.
+	VCenter string `json:"vCenter"`
+
+	// VolumeType is the type of the volume, either BLOCK or FILE.
+	VolumeType string `json:"volumeType,omitempty"`
+
+	// DatastoreURL is the URL of the datastore on which the volume resides.
+	DatastoreURL string `json:"datastoreURL,omitempty"`
+
+	// CapacityInMb is the size of the volume in MB.
+	CapacityInMb int64 `json:"capacityInMb,omitempty"`
+
+	// StoragePolicyID is the ID of the storage policy of the volume.
+	StoragePolicyID string `json:"storagePolicyID,omitempty"`
+
+	// PvName is the name of the PV of the volume last known to CNS.
+	PvName string `json:"pvName,omitempty"`
+
+	// PvcName is the name of the PVC of the volume last known to CNS.
+	PvcName string `json:"pvcName,omitempty"`
+
+	// PvcNamespace is the namespace of the PVC of the volume last known to
+	// CNS.
+	PvcNamespace string `json:"pvcNamespace,omitempty"`
+
+	// FirstSeenTimeStamp is the time at which full sync first found the
+	// volume missing in kubernetes for two consecutive cycles.
+	FirstSeenTimeStamp metav1.Time `json:"firstSeenTimeStamp"`
+
+	// Restore requests the PV and PVC of the volume to be recreated from
+	// this record.
+	Restore bool `json:"restore,omitempty"`
+
+	// Cleanup approves the removal of the CNS metadata of the volume by full
+	// sync, regardless of its grace period and of orphan volume auto cleanup.
+	// Restore takes precedence over Cleanup.
+	Cleanup bool `json:"cleanup,omitempty"`
+}
+
+// OrphanVolumeStatus defines the observed state of OrphanVolume
+// +k8s:openapi-gen=true
+type OrphanVolumeStatus struct {
+	// Indicates the PV and PVC of the volume are successfully recreated.
+	// This field must only be set by the entity completing the restore
+	// operation, i.e. the CNS Operator.
+	Restored bool `json:"restored"`
+
+	// The last error encountered during restore operation, if any.
+	// This field must only be set by the entity completing the restore
+	// operation, i.e. the CNS Operator.
+	Error string `json:"error,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// OrphanVolume is the Schema for the orphanvolumes API. An OrphanVolume is
+// created by full sync for a CNS volume of the cluster which has no PV in
+// kubernetes, instead of removing the CNS metadata of the volume.
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+type OrphanVolume struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   OrphanVolumeSpec   `json:"spec,omitempty"`
+	Status OrphanVolumeStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// OrphanVolumeList contains a list of OrphanVolume
+type OrphanVolumeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []OrphanVolume `json:"items"`
+}
@@ -29,7 +29,10 @@ import (
 	cnsnodevmattachmentv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsnodevmattachment/v1alpha1"
 	cnsregistervolumev1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsregistervolume/v1alpha1"
 	cnsunregistervolumev1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsunregistervolume/v1alpha1"
+	cnsvolumeexportv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsvolumeexport/v1alpha1"
+	cnsvolumeimportv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsvolumeimport/v1alpha1"
 	cnsvolumemetadatav1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsvolumemetadata/v1alpha1"
+	orphanvolumev1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/orphanvolume/v1alpha1"
 	storagepolicyv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/storagepolicy/v1alpha1"
 	storagepolicyv1alpha2 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/storagepolicy/v1alpha2"
 )
@@ -57,6 +60,12 @@ var (
 	CnsRegisterVolumePlural = "cnsregistervolumes"
 	// CnsUnregisterVolumePlural is plural of CnsUnregisterVolume
 	CnsUnregisterVolumePlural = "cnsunregistervolumes"
+	// CnsVolumeExportPlural is plural of CnsVolumeExport
+	CnsVolumeExportPlural = "cnsvolumeexports"
+	// CnsVolumeImportPlural is plural of CnsVolumeImport
+	CnsVolumeImportPlural = "cnsvolumeimports"
+	// OrphanVolumePlural is plural of OrphanVolume
+	OrphanVolumePlural = "orphanvolumes"
 	// CnsFileAccessConfigPlural is plural of CnsFileAccessConfig
 	CnsFileAccessConfigPlural = "cnsfileaccessconfigs"
 	// CnsStoragePolicyUsageSingular is singular of StoragePolicyUsage
@@ -107,6 +116,24 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&cnsunregistervolumev1alpha1.CnsUnregisterVolumeList{},
 	)
 
+	scheme.AddKnownTypes(
+		SchemeGroupVersion,
+		&cnsvolumeexportv1alpha1.CnsVolumeExport{},
+		&cnsvolumeexportv1alpha1.CnsVolumeExportList{},
+	)
+
+	scheme.AddKnownTypes(
+		SchemeGroupVersion,
+		&cnsvolumeimportv1alpha1.CnsVolumeImport{},
+		&cnsvolumeimportv1alpha1.CnsVolumeImportList{},
+	)
+
+	scheme.AddKnownTypes(
+		SchemeGroupVersion,
+		&orphanvolumev1alpha1.OrphanVolume{},
+		&orphanvolumev1alpha1.OrphanVolumeList{},
+	)
+
 	scheme.AddKnownTypes(
 		SchemeGroupVersion,
 		&cnsvolumemetadatav1alpha1.CnsVolumeMetadata{},
@@ -31,6 +31,7 @@ import (
 	"github.com/vmware/govmomi/vim25/soap"
 	vim25types "github.com/vmware/govmomi/vim25/types"
 	"github.com/vmware/govmomi/vslm"
+	vslmtypes "github.com/vmware/govmomi/vslm/types"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -38,6 +39,7 @@ import (
 	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
 	csifault "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/fault"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/prometheus"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/tracing"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsvolumeoperationrequest"
 )
@@ -56,6 +58,10 @@ const (
 
 	listviewAdditionError = "failed to add task to list view"
 
+	// ExportedFromMetadataKey is the FCD metadata key holding the ID of the
+	// cluster which exported a volume to be imported by another cluster.
+	ExportedFromMetadataKey = "csi.vsphere.exportedfrom"
+
 	// defaultOpsExpirationTimeInHours is expiration time for create volume operations.
 	// TODO: This timeout will be configurable in future releases
 	defaultOpsExpirationTimeInHours = 1
@@ -86,6 +92,31 @@ type Manager interface {
 	// extraParams can be used to send in any values not present in the CnsVolumeCreateSpec param.
 	CreateVolume(ctx context.Context, spec *cnstypes.CnsVolumeCreateSpec, extraParams interface{}) (
 		*CnsVolumeInfo, string, error)
+	// CloneVolume creates a new volume as a full copy of the given source volume
+	// and registers it with CNS using the given spec.
+	// When CloneVolume failed, the second return value (faultType) and third return value(error) need to be set, and
+	// should not be nil.
+	CloneVolume(ctx context.Context, sourceVolumeID string, spec *cnstypes.CnsVolumeCreateSpec,
+		extraParams interface{}) (*CnsVolumeInfo, string, error)
+	// CreateVolumeWithProvisioningType creates a new volume whose backing disk
+	// uses the given provisioning type and registers it with CNS using the given spec.
+	// When CreateVolumeWithProvisioningType failed, the second return value (faultType) and
+	// third return value(error) need to be set, and should not be nil.
+	CreateVolumeWithProvisioningType(ctx context.Context, spec *cnstypes.CnsVolumeCreateSpec,
+		provisioningType string, extraParams interface{}) (*CnsVolumeInfo, string, error)
+	// ApplyVolumeIOAllocation applies the Storage I/O Control settings of a volume
+	// to its virtual disk attached to the given virtual machine.
+	// When ApplyVolumeIOAllocation failed, the first return value (faultType) and second return value(error)
+	// need to be set, and should not be nil.
+	ApplyVolumeIOAllocation(ctx context.Context, vm *cnsvsphere.VirtualMachine, volumeID string,
+		ioAllocation *vim25types.StorageIOAllocationInfo) (string, error)
+	// SetVolumeExportedFrom records in the metadata of the FCD of a volume that
+	// the volume has been exported by the cluster with the given ID. An empty
+	// clusterID removes the record.
+	SetVolumeExportedFrom(ctx context.Context, volumeID string, clusterID string) error
+	// GetVolumeExportedFrom returns the ID of the cluster which exported the
+	// volume, or an empty string if the volume has not been exported.
+	GetVolumeExportedFrom(ctx context.Context, volumeID string) (string, error)
 	// AttachVolume attaches a volume to a virtual machine given the spec.
 	// When AttachVolume failed, the second return value (faultType) and third return value(error) need to be set, and
 	// should not be nil.
@@ -95,6 +126,16 @@ type Manager interface {
 	// When DetachVolume failed, the first return value (faultType) and second return value(error) need to be set, and
 	// should not be nil.
 	DetachVolume(ctx context.Context, vm *cnsvsphere.VirtualMachine, volumeID string) (string, error)
+	// BatchAttachVolumes attaches the volumes to a virtual machine with a single CNS AttachVolume task.
+	// The result of each volume is returned in the order of volumeIDs. When the task failed as a whole,
+	// the second return value (faultType) and third return value(error) are set instead.
+	BatchAttachVolumes(ctx context.Context, vm *cnsvsphere.VirtualMachine, volumeIDs []string,
+		checkNVMeController bool) ([]BatchAttachDetachResult, string, error)
+	// BatchDetachVolumes detaches the volumes from a virtual machine with a single CNS DetachVolume task.
+	// The result of each volume is returned in the order of volumeIDs. When the task failed as a whole,
+	// the second return value (faultType) and third return value(error) are set instead.
+	BatchDetachVolumes(ctx context.Context, vm *cnsvsphere.VirtualMachine, volumeIDs []string) (
+		[]BatchAttachDetachResult, string, error)
 	// DeleteVolume deletes a volume given its spec.
 	// When DeleteVolume failed, the first return value (faultType) and second return value(error) need to be set, and
 	// should not be nil.
@@ -118,6 +159,13 @@ type Manager interface {
 	QueryVolume(ctx context.Context, queryFilter cnstypes.CnsQueryFilter) (*cnstypes.CnsQueryResult, error)
 	// RelocateVolume migrates volumes to their target datastore as specified in relocateSpecList.
 	RelocateVolume(ctx context.Context, relocateSpecList ...cnstypes.BaseCnsVolumeRelocateSpec) (*object.Task, error)
+	// UpdateVolumeStoragePolicy applies the given storage policy to a volume. When datastore is set,
+	// the volume is relocated to the datastore along with the policy change. An empty storagePolicyID
+	// relocates the volume to the datastore without changing its policy.
+	// When UpdateVolumeStoragePolicy failed, the first return value (faultType) and second return value(error)
+	// need to be set, and should not be nil.
+	UpdateVolumeStoragePolicy(ctx context.Context, volumeID string, storagePolicyID string,
+		datastore *vim25types.ManagedObjectReference) (string, error)
 	// ExpandVolume expands a volume to a new size.
 	// When ExpandVolume failed, the first return value (faultType) and second return value(error) need to be set, and
 	// should not be nil.
@@ -130,10 +178,23 @@ type Manager interface {
 	RegisterDisk(ctx context.Context, path string, name string) (string, error)
 	// RetrieveVStorageObject helps in retreiving virtual disk information for a given volume id.
 	RetrieveVStorageObject(ctx context.Context, volumeID string) (*vim25types.VStorageObject, error)
+	// RetrieveSnapshotDetails retrieves the details, including the changed block tracking ID,
+	// of a snapshot of the given volume using Vslm endpoint.
+	RetrieveSnapshotDetails(ctx context.Context, volumeID string, snapshotID string) (
+		*vim25types.VStorageObjectSnapshotDetails, error)
+	// QueryChangedDiskAreas returns the areas of a snapshot of the given volume, starting at
+	// startOffset, which changed since changeID using Vslm endpoint. A changeID of "*" returns
+	// all the allocated areas of the snapshot.
+	QueryChangedDiskAreas(ctx context.Context, volumeID string, snapshotID string, startOffset int64,
+		changeID string) (*vim25types.DiskChangeInfo, error)
 	// ProtectVolumeFromVMDeletion sets keepAfterDeleteVm control flag on migrated volume
 	ProtectVolumeFromVMDeletion(ctx context.Context, volumeID string) error
 	// CreateSnapshot helps create a snapshot for a block volume
 	CreateSnapshot(ctx context.Context, volumeID string, desc string, extraParams interface{}) (*CnsSnapshotInfo, error)
+	// CreateGroupSnapshot creates the snapshots of the block volumes of a volume group snapshot
+	// with a single CNS CreateSnapshots task.
+	CreateGroupSnapshot(ctx context.Context, groupSnapshotName string, volumeIDs []string) (
+		[]*CnsSnapshotInfo, error)
 	// DeleteSnapshot helps delete a snapshot for a block volume
 	DeleteSnapshot(ctx context.Context, volumeID string, snapshotID string,
 		extraParams interface{}) (*CnsSnapshotInfo, error)
@@ -150,6 +211,17 @@ type Manager interface {
 	GetOperationStore() cnsvolumeoperationrequest.VolumeOperationRequest
 }
 
+// BatchAttachDetachResult holds the result of a volume attached or detached
+// by BatchAttachVolumes or BatchDetachVolumes.
+type BatchAttachDetachResult struct {
+	VolumeID string
+	// DiskUUID is the UUID of the attached disk. It is only set by
+	// BatchAttachVolumes.
+	DiskUUID  string
+	FaultType string
+	Err       error
+}
+
 // CnsVolumeInfo hold information related to volume created by CNS.
 type CnsVolumeInfo struct {
 	DatastoreURL string
@@ -866,6 +938,8 @@ func (m *defaultManager) CreateVolume(ctx context.Context, spec *cnstypes.CnsVol
 	extraParams interface{}) (*CnsVolumeInfo, string, error) {
 	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
 	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.CreateVolume")
+	defer span.End()
 	internalCreateVolume := func() (*CnsVolumeInfo, string, error) {
 		log := logger.GetLogger(ctx)
 		var faultType string
@@ -892,6 +966,7 @@ func (m *defaultManager) CreateVolume(ctx context.Context, spec *cnstypes.CnsVol
 	log := logger.GetLogger(ctx)
 	log.Debugf("internalCreateVolume: returns fault %q", faultType)
 	if err != nil {
+		tracing.SetSpanError(span, err)
 		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsCreateVolumeOpType,
 			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
 	} else {
@@ -919,6 +994,8 @@ func (m *defaultManager) AttachVolume(ctx context.Context,
 	vm *cnsvsphere.VirtualMachine, volumeID string, checkNVMeController bool) (string, string, error) {
 	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
 	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.AttachVolume")
+	defer span.End()
 	internalAttachVolume := func() (string, string, error) {
 		log := logger.GetLogger(ctx)
 		var faultType string
@@ -981,34 +1058,14 @@ func (m *defaultManager) AttachVolume(ctx context.Context,
 					taskInfo.Task.Value, taskInfo.ActivationId)
 		}
 
-		volumeOperationRes := taskResult.GetCnsVolumeOperationResult()
-		if volumeOperationRes.Fault != nil {
-			faultType = ExtractFaultTypeFromVolumeResponseResult(ctx, volumeOperationRes)
-			_, isResourceInUseFault := volumeOperationRes.Fault.Fault.(*vim25types.ResourceInUse)
-			if isResourceInUseFault {
-				log.Infof("observed ResourceInUse fault while attaching volume: %q with vm: %q", volumeID, vm.String())
-				// Check if volume is already attached to the requested node.
-				diskUUID, err := IsDiskAttached(ctx, vm, volumeID, checkNVMeController)
-				if err != nil {
-					return "", faultType, err
-				}
-				if diskUUID != "" {
-					return diskUUID, "", nil
-				}
-			}
-			return "", faultType, logger.LogNewErrorf(log, "failed to attach cns volume: %q to node vm: %q. fault: %q. opId: %q",
-				volumeID, vm.String(), spew.Sdump(volumeOperationRes.Fault), taskInfo.ActivationId)
-		}
-		diskUUID := interface{}(taskResult).(*cnstypes.CnsVolumeAttachResult).DiskUUID
-		log.Infof("AttachVolume: Volume attached successfully. volumeID: %q, opId: %q, vm: %q, diskUUID: %q",
-			volumeID, taskInfo.ActivationId, vm.String(), diskUUID)
-		return diskUUID, "", nil
+		return getAttachVolumeResult(ctx, vm, volumeID, taskResult, taskInfo.ActivationId, checkNVMeController)
 	}
 	start := time.Now()
 	resp, faultType, err := internalAttachVolume()
 	log := logger.GetLogger(ctx)
 	log.Debugf("internalAttachVolume: returns fault %q for volume %q", faultType, volumeID)
 	if err != nil {
+		tracing.SetSpanError(span, err)
 		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsAttachVolumeOpType,
 			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
 	} else {
@@ -1023,6 +1080,8 @@ func (m *defaultManager) DetachVolume(ctx context.Context, vm *cnsvsphere.Virtua
 	error) {
 	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
 	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.DetachVolume")
+	defer span.End()
 	internalDetachVolume := func() (string, error) {
 		log := logger.GetLogger(ctx)
 		var faultType string
@@ -1104,51 +1163,14 @@ func (m *defaultManager) DetachVolume(ctx context.Context, vm *cnsvsphere.Virtua
 				logger.LogNewErrorf(log, "taskResult is empty for DetachVolume task: %q, opId: %q",
 					taskInfo.Task.Value, taskInfo.ActivationId)
 		}
-		volumeOperationRes := taskResult.GetCnsVolumeOperationResult()
-		if volumeOperationRes.Fault != nil {
-			faultType = ExtractFaultTypeFromVolumeResponseResult(ctx, volumeOperationRes)
-
-			if volumeOperationRes.Fault.Fault != nil {
-				fault, isManagedObjectNotFoundFault := volumeOperationRes.Fault.Fault.(*vim25types.ManagedObjectNotFound)
-				if isManagedObjectNotFoundFault && fault.Obj.Type == cnsDetachSpec.Vm.Type &&
-					fault.Obj.Value == cnsDetachSpec.Vm.Value {
-					// Detach failed with managed object not found, marking detach as
-					// successful, as Node VM is deleted and not present in the vCenter
-					// inventory.
-					log.Infof("DetachVolume: Node VM: %v not found on vCenter. Marking Detach for volume:%q successful.",
-						vm, volumeID)
-					return "", nil
-				}
-				_, isNotFoundFault := volumeOperationRes.Fault.Fault.(*vim25types.NotFound)
-				if isNotFoundFault {
-					// Check if volume is already detached from the VM
-					log.Infof("DetachVolume: VolumeID: %q not found. Checking whether the volume is already detached",
-						volumeID)
-					diskUUID, err := IsDiskAttached(ctx, vm, volumeID, false)
-					if err != nil {
-						log.Errorf("DetachVolume fault: %+v. Unable to check if volume: %q is already detached from vm: %+v",
-							spew.Sdump(volumeOperationRes.Fault), volumeID, vm)
-						return faultType, err
-					}
-					if diskUUID == "" {
-						log.Infof("DetachVolume: volumeID: %q not found on vm: %+v. Assuming it is already detached",
-							volumeID, vm)
-						return "", nil
-					}
-				}
-			}
-			return faultType, logger.LogNewErrorf(log, "failed to detach cns volume: %q from node vm: %+v. fault: %+v, opId: %q",
-				volumeID, vm, spew.Sdump(volumeOperationRes.Fault), taskInfo.ActivationId)
-		}
-		log.Infof("DetachVolume: Volume detached successfully. volumeID: %q, vm: %q, opId: %q",
-			volumeID, vm.String(), taskInfo.ActivationId)
-		return "", nil
+		return getDetachVolumeResult(ctx, vm, volumeID, taskResult, taskInfo.ActivationId)
 	}
 	start := time.Now()
 	faultType, err := internalDetachVolume()
 	log := logger.GetLogger(ctx)
 	log.Debugf("internalDetachVolume: returns fault %q for volume %q", faultType, volumeID)
 	if err != nil {
+		tracing.SetSpanError(span, err)
 		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsDetachVolumeOpType,
 			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
 	} else {
@@ -1158,10 +1180,256 @@ func (m *defaultManager) DetachVolume(ctx context.Context, vm *cnsvsphere.Virtua
 	return faultType, err
 }
 
+// BatchAttachVolumes attaches the volumes to a virtual machine with a single
+// CNS AttachVolume task.
+func (m *defaultManager) BatchAttachVolumes(ctx context.Context, vm *cnsvsphere.VirtualMachine,
+	volumeIDs []string, checkNVMeController bool) ([]BatchAttachDetachResult, string, error) {
+	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
+	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.BatchAttachVolumes")
+	defer span.End()
+	internalBatchAttachVolumes := func() ([]BatchAttachDetachResult, string, error) {
+		log := logger.GetLogger(ctx)
+		taskResults, opID, faultType, err := m.runBatchAttachDetachTask(ctx, vm, volumeIDs, true)
+		if err != nil {
+			return nil, faultType, err
+		}
+		log.Infof("AttachVolume: volumeIDs: %v, vm: %q, opId: %q", volumeIDs, vm.String(), opID)
+		results := make([]BatchAttachDetachResult, len(volumeIDs))
+		for i, volumeID := range volumeIDs {
+			results[i].VolumeID = volumeID
+			taskResult, ok := taskResults[volumeID]
+			if !ok {
+				results[i].FaultType = csifault.CSITaskResultEmptyFault
+				results[i].Err = logger.LogNewErrorf(log, "taskResult is empty for volume %q in AttachVolume "+
+					"task, opId: %q", volumeID, opID)
+				continue
+			}
+			results[i].DiskUUID, results[i].FaultType, results[i].Err = getAttachVolumeResult(ctx, vm, volumeID,
+				taskResult, opID, checkNVMeController)
+		}
+		return results, "", nil
+	}
+	start := time.Now()
+	resp, faultType, err := internalBatchAttachVolumes()
+	log := logger.GetLogger(ctx)
+	log.Debugf("internalBatchAttachVolumes: returns fault %q for volumes %v", faultType, volumeIDs)
+	if err != nil {
+		tracing.SetSpanError(span, err)
+		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsBatchAttachVolumeOpType,
+			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsBatchAttachVolumeOpType,
+			prometheus.PrometheusPassStatus).Observe(time.Since(start).Seconds())
+	}
+	return resp, faultType, err
+}
+
+// BatchDetachVolumes detaches the volumes from a virtual machine with a
+// single CNS DetachVolume task.
+func (m *defaultManager) BatchDetachVolumes(ctx context.Context, vm *cnsvsphere.VirtualMachine,
+	volumeIDs []string) ([]BatchAttachDetachResult, string, error) {
+	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
+	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.BatchDetachVolumes")
+	defer span.End()
+	internalBatchDetachVolumes := func() ([]BatchAttachDetachResult, string, error) {
+		log := logger.GetLogger(ctx)
+		taskResults, opID, faultType, err := m.runBatchAttachDetachTask(ctx, vm, volumeIDs, false)
+		if err != nil {
+			if cnsvsphere.IsManagedObjectNotFound(err, vm.Reference()) {
+				// Node VM is deleted and not present in the vCenter inventory,
+				// marking detach of all the volumes as successful.
+				log.Infof("Node VM: %v not found on vCenter. Marking Detach for volumes: %v successful. err: %v",
+					vm, volumeIDs, err)
+				results := make([]BatchAttachDetachResult, len(volumeIDs))
+				for i, volumeID := range volumeIDs {
+					results[i].VolumeID = volumeID
+				}
+				return results, "", nil
+			}
+			return nil, faultType, err
+		}
+		log.Infof("DetachVolume: volumeIDs: %v, vm: %q, opId: %q", volumeIDs, vm.String(), opID)
+		results := make([]BatchAttachDetachResult, len(volumeIDs))
+		for i, volumeID := range volumeIDs {
+			results[i].VolumeID = volumeID
+			taskResult, ok := taskResults[volumeID]
+			if !ok {
+				results[i].FaultType = csifault.CSITaskResultEmptyFault
+				results[i].Err = logger.LogNewErrorf(log, "taskResult is empty for volume %q in DetachVolume "+
+					"task, opId: %q", volumeID, opID)
+				continue
+			}
+			results[i].FaultType, results[i].Err = getDetachVolumeResult(ctx, vm, volumeID, taskResult, opID)
+		}
+		return results, "", nil
+	}
+	start := time.Now()
+	resp, faultType, err := internalBatchDetachVolumes()
+	log := logger.GetLogger(ctx)
+	log.Debugf("internalBatchDetachVolumes: returns fault %q for volumes %v", faultType, volumeIDs)
+	if err != nil {
+		tracing.SetSpanError(span, err)
+		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsBatchDetachVolumeOpType,
+			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsBatchDetachVolumeOpType,
+			prometheus.PrometheusPassStatus).Observe(time.Since(start).Seconds())
+	}
+	return resp, faultType, err
+}
+
+// runBatchAttachDetachTask runs a CNS AttachVolume task, or a DetachVolume
+// task if attach is false, for the volumes and vm, and returns the task
+// results keyed by volume ID along with the opId of the task.
+func (m *defaultManager) runBatchAttachDetachTask(ctx context.Context, vm *cnsvsphere.VirtualMachine,
+	volumeIDs []string, attach bool) (map[string]cnstypes.BaseCnsVolumeOperationResult, string, string, error) {
+	log := logger.GetLogger(ctx)
+	err := validateManager(ctx, m)
+	if err != nil {
+		return nil, "", ExtractFaultTypeFromErr(ctx, err), err
+	}
+	// Set up the VC connection.
+	err = m.virtualCenter.ConnectCns(ctx)
+	if err != nil {
+		log.Errorf("ConnectCns failed with err: %+v", err)
+		return nil, "", ExtractFaultTypeFromErr(ctx, err), err
+	}
+	operation := "DetachVolume"
+	if attach {
+		operation = "AttachVolume"
+	}
+	specList := make([]cnstypes.CnsVolumeAttachDetachSpec, 0, len(volumeIDs))
+	for _, volumeID := range volumeIDs {
+		specList = append(specList, cnstypes.CnsVolumeAttachDetachSpec{
+			VolumeId: cnstypes.CnsVolumeId{
+				Id: volumeID,
+			},
+			Vm: vm.Reference(),
+		})
+	}
+	var task *object.Task
+	if attach {
+		task, err = m.virtualCenter.CnsClient.AttachVolume(ctx, specList)
+	} else {
+		task, err = m.virtualCenter.CnsClient.DetachVolume(ctx, specList)
+	}
+	if err != nil {
+		log.Errorf("CNS %s failed from vCenter %q with err: %v", operation, m.virtualCenter.Config.Host, err)
+		return nil, "", ExtractFaultTypeFromErr(ctx, err), err
+	}
+	taskInfo, err := m.waitOnTask(ctx, task.Reference())
+	if err != nil || taskInfo == nil {
+		log.Errorf("failed to get taskInfo for %s task from vCenter %q with err: %v",
+			operation, m.virtualCenter.Config.Host, err)
+		if err != nil {
+			return nil, "", ExtractFaultTypeFromErr(ctx, err), err
+		}
+		return nil, "", csifault.CSITaskInfoEmptyFault, logger.LogNewErrorf(log,
+			"taskInfo is empty for %s task", operation)
+	}
+	taskResults, err := cns.GetTaskResultArray(ctx, taskInfo)
+	if err != nil {
+		log.Errorf("unable to find %s task results from vCenter %q with taskID %s. err: %v",
+			operation, m.virtualCenter.Config.Host, taskInfo.Task.Value, err)
+		return nil, taskInfo.ActivationId, ExtractFaultTypeFromErr(ctx, err), err
+	}
+	taskResultMap := make(map[string]cnstypes.BaseCnsVolumeOperationResult)
+	for _, taskResult := range taskResults {
+		if taskResult == nil {
+			continue
+		}
+		taskResultMap[taskResult.GetCnsVolumeOperationResult().VolumeId.Id] = taskResult
+	}
+	return taskResultMap, taskInfo.ActivationId, "", nil
+}
+
+// getAttachVolumeResult returns the disk UUID of the volume attached to vm by
+// the CNS AttachVolume task with the given opId, given the task result of the
+// volume. A volume which is already attached to vm is reported as attached.
+func getAttachVolumeResult(ctx context.Context, vm *cnsvsphere.VirtualMachine, volumeID string,
+	taskResult cnstypes.BaseCnsVolumeOperationResult, opID string, checkNVMeController bool) (string, string, error) {
+	log := logger.GetLogger(ctx)
+	volumeOperationRes := taskResult.GetCnsVolumeOperationResult()
+	if volumeOperationRes.Fault != nil {
+		faultType := ExtractFaultTypeFromVolumeResponseResult(ctx, volumeOperationRes)
+		_, isResourceInUseFault := volumeOperationRes.Fault.Fault.(*vim25types.ResourceInUse)
+		if isResourceInUseFault {
+			log.Infof("observed ResourceInUse fault while attaching volume: %q with vm: %q", volumeID, vm.String())
+			// Check if volume is already attached to the requested node.
+			diskUUID, err := IsDiskAttached(ctx, vm, volumeID, checkNVMeController)
+			if err != nil {
+				return "", faultType, err
+			}
+			if diskUUID != "" {
+				return diskUUID, "", nil
+			}
+		}
+		return "", faultType, logger.LogNewErrorf(log, "failed to attach cns volume: %q to node vm: %q. fault: %q. opId: %q",
+			volumeID, vm.String(), spew.Sdump(volumeOperationRes.Fault), opID)
+	}
+	diskUUID := interface{}(taskResult).(*cnstypes.CnsVolumeAttachResult).DiskUUID
+	log.Infof("AttachVolume: Volume attached successfully. volumeID: %q, opId: %q, vm: %q, diskUUID: %q",
+		volumeID, opID, vm.String(), diskUUID)
+	return diskUUID, "", nil
+}
+
+// getDetachVolumeResult returns the result of the volume detached from vm by
+// the CNS DetachVolume task with the given opId, given the task result of the
+// volume. A volume which is already detached, or whose vm no longer exists, is
+// reported as detached.
+func getDetachVolumeResult(ctx context.Context, vm *cnsvsphere.VirtualMachine, volumeID string,
+	taskResult cnstypes.BaseCnsVolumeOperationResult, opID string) (string, error) {
+	log := logger.GetLogger(ctx)
+	vmRef := vm.Reference()
+	volumeOperationRes := taskResult.GetCnsVolumeOperationResult()
+	if volumeOperationRes.Fault != nil {
+		faultType := ExtractFaultTypeFromVolumeResponseResult(ctx, volumeOperationRes)
+
+		if volumeOperationRes.Fault.Fault != nil {
+			fault, isManagedObjectNotFoundFault := volumeOperationRes.Fault.Fault.(*vim25types.ManagedObjectNotFound)
+			if isManagedObjectNotFoundFault && fault.Obj.Type == vmRef.Type &&
+				fault.Obj.Value == vmRef.Value {
+				// Detach failed with managed object not found, marking detach as
+				// successful, as Node VM is deleted and not present in the vCenter
+				// inventory.
+				log.Infof("DetachVolume: Node VM: %v not found on vCenter. Marking Detach for volume:%q successful.",
+					vm, volumeID)
+				return "", nil
+			}
+			_, isNotFoundFault := volumeOperationRes.Fault.Fault.(*vim25types.NotFound)
+			if isNotFoundFault {
+				// Check if volume is already detached from the VM
+				log.Infof("DetachVolume: VolumeID: %q not found. Checking whether the volume is already detached",
+					volumeID)
+				diskUUID, err := IsDiskAttached(ctx, vm, volumeID, false)
+				if err != nil {
+					log.Errorf("DetachVolume fault: %+v. Unable to check if volume: %q is already detached from vm: %+v",
+						spew.Sdump(volumeOperationRes.Fault), volumeID, vm)
+					return faultType, err
+				}
+				if diskUUID == "" {
+					log.Infof("DetachVolume: volumeID: %q not found on vm: %+v. Assuming it is already detached",
+						volumeID, vm)
+					return "", nil
+				}
+			}
+		}
+		return faultType, logger.LogNewErrorf(log, "failed to detach cns volume: %q from node vm: %+v. fault: %+v, opId: %q",
+			volumeID, vm, spew.Sdump(volumeOperationRes.Fault), opID)
+	}
+	log.Infof("DetachVolume: Volume detached successfully. volumeID: %q, vm: %q, opId: %q",
+		volumeID, vm.String(), opID)
+	return "", nil
+}
+
 // DeleteVolume deletes a volume given its spec.
 func (m *defaultManager) DeleteVolume(ctx context.Context, volumeID string, deleteDisk bool) (string, error) {
 	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
 	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.DeleteVolume")
+	defer span.End()
 	internalDeleteVolume := func() (string, error) {
 		log := logger.GetLogger(ctx)
 		var faultType string
@@ -1188,6 +1456,7 @@ func (m *defaultManager) DeleteVolume(ctx context.Context, volumeID string, dele
 	log := logger.GetLogger(ctx)
 	log.Debugf("internalDeleteVolume: returns fault %q for volume %q", faultType, volumeID)
 	if err != nil {
+		tracing.SetSpanError(span, err)
 		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsDeleteVolumeOpType,
 			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
 	} else {
@@ -1435,6 +1704,8 @@ func (m *defaultManager) deleteVolumeWithImprovedIdempotency(ctx context.Context
 func (m *defaultManager) UpdateVolumeMetadata(ctx context.Context, spec *cnstypes.CnsVolumeMetadataUpdateSpec) error {
 	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
 	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.UpdateVolumeMetadata")
+	defer span.End()
 	internalUpdateVolumeMetadata := func() error {
 		log := logger.GetLogger(ctx)
 		err := validateManager(ctx, m)
@@ -1510,6 +1781,7 @@ func (m *defaultManager) UpdateVolumeMetadata(ctx context.Context, spec *cnstype
 	start := time.Now()
 	err := internalUpdateVolumeMetadata()
 	if err != nil {
+		tracing.SetSpanError(span, err)
 		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsUpdateVolumeMetadataOpType,
 			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
 	} else {
@@ -1524,6 +1796,8 @@ func (m *defaultManager) ExpandVolume(ctx context.Context, volumeID string, size
 	extraParams interface{}) (string, error) {
 	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
 	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.ExpandVolume")
+	defer span.End()
 	internalExpandVolume := func() (string, error) {
 		log := logger.GetLogger(ctx)
 		var faultType string
@@ -1551,6 +1825,7 @@ func (m *defaultManager) ExpandVolume(ctx context.Context, volumeID string, size
 	log := logger.GetLogger(ctx)
 	log.Debugf("internalExpandVolume: returns fault %q for volume %q", faultType, volumeID)
 	if err != nil {
+		tracing.SetSpanError(span, err)
 		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsExpandVolumeOpType,
 			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
 	} else {
@@ -1861,6 +2136,8 @@ func (m *defaultManager) QueryVolume(ctx context.Context,
 	queryFilter cnstypes.CnsQueryFilter) (*cnstypes.CnsQueryResult, error) {
 	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
 	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.QueryVolume")
+	defer span.End()
 	internalQueryVolume := func() (*cnstypes.CnsQueryResult, error) {
 		log := logger.GetLogger(ctx)
 		err := validateManager(ctx, m)
@@ -1885,6 +2162,7 @@ func (m *defaultManager) QueryVolume(ctx context.Context,
 	start := time.Now()
 	resp, err := internalQueryVolume()
 	if err != nil {
+		tracing.SetSpanError(span, err)
 		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsQueryVolumeOpType,
 			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
 	} else {
@@ -1899,6 +2177,8 @@ func (m *defaultManager) QueryAllVolume(ctx context.Context, queryFilter cnstype
 	querySelection cnstypes.CnsQuerySelection) (*cnstypes.CnsQueryResult, error) {
 	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
 	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.QueryAllVolume")
+	defer span.End()
 	internalQueryAllVolume := func() (*cnstypes.CnsQueryResult, error) {
 		log := logger.GetLogger(ctx)
 		err := validateManager(ctx, m)
@@ -1923,6 +2203,7 @@ func (m *defaultManager) QueryAllVolume(ctx context.Context, queryFilter cnstype
 	start := time.Now()
 	resp, err := internalQueryAllVolume()
 	if err != nil {
+		tracing.SetSpanError(span, err)
 		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsQueryAllVolumeOpType,
 			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
 	} else {
@@ -1938,6 +2219,8 @@ func (m *defaultManager) QueryVolumeInfo(ctx context.Context,
 	volumeIDList []cnstypes.CnsVolumeId) (*cnstypes.CnsQueryVolumeInfoResult, error) {
 	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
 	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.QueryVolumeInfo")
+	defer span.End()
 	internalQueryVolumeInfo := func() (*cnstypes.CnsQueryVolumeInfoResult, error) {
 		log := logger.GetLogger(ctx)
 		err := validateManager(ctx, m)
@@ -1991,6 +2274,7 @@ func (m *defaultManager) QueryVolumeInfo(ctx context.Context,
 	start := time.Now()
 	resp, err := internalQueryVolumeInfo()
 	if err != nil {
+		tracing.SetSpanError(span, err)
 		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsQueryVolumeInfoOpType,
 			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
 	} else {
@@ -2004,6 +2288,8 @@ func (m *defaultManager) RelocateVolume(ctx context.Context,
 	relocateSpecList ...cnstypes.BaseCnsVolumeRelocateSpec) (*object.Task, error) {
 	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
 	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.RelocateVolume")
+	defer span.End()
 	internalRelocateVolume := func() (*object.Task, error) {
 		log := logger.GetLogger(ctx)
 		err := validateManager(ctx, m)
@@ -2028,6 +2314,7 @@ func (m *defaultManager) RelocateVolume(ctx context.Context,
 	start := time.Now()
 	resp, err := internalRelocateVolume()
 	if err != nil {
+		tracing.SetSpanError(span, err)
 		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsRelocateVolumeOpType,
 			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
 	} else {
@@ -2041,6 +2328,8 @@ func (m *defaultManager) RelocateVolume(ctx context.Context,
 func (m *defaultManager) ConfigureVolumeACLs(ctx context.Context, spec cnstypes.CnsVolumeACLConfigureSpec) error {
 	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
 	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.ConfigureVolumeACLs")
+	defer span.End()
 	internalConfigureVolumeACLs := func() error {
 		log := logger.GetLogger(ctx)
 		err := validateManager(ctx, m)
@@ -2098,6 +2387,7 @@ func (m *defaultManager) ConfigureVolumeACLs(ctx context.Context, spec cnstypes.
 	start := time.Now()
 	err := internalConfigureVolumeACLs()
 	if err != nil {
+		tracing.SetSpanError(span, err)
 		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsConfigureVolumeACLOpType,
 			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
 	} else {
@@ -2167,6 +2457,61 @@ func (m *defaultManager) RetrieveVStorageObject(ctx context.Context,
 	return vStorageObject, nil
 }
 
+// RetrieveSnapshotDetails retrieves the details of the snapshot of the given
+// volume id.
+func (m *defaultManager) RetrieveSnapshotDetails(ctx context.Context, volumeID string, snapshotID string) (
+	*vim25types.VStorageObjectSnapshotDetails, error) {
+	log := logger.GetLogger(ctx)
+	err := validateManager(ctx, m)
+	if err != nil {
+		log.Errorf("failed to validate volume manager with err: %+v", err)
+		return nil, err
+	}
+	// Set up the VC connection
+	err = m.virtualCenter.ConnectVslm(ctx)
+	if err != nil {
+		log.Errorf("ConnectVslm failed with err: %+v", err)
+		return nil, err
+	}
+	globalObjectManager := vslm.NewGlobalObjectManager(m.virtualCenter.VslmClient)
+	snapshotDetails, err := globalObjectManager.RetrieveSnapshotDetails(ctx, vim25types.ID{Id: volumeID},
+		vim25types.ID{Id: snapshotID})
+	if err != nil {
+		log.Errorf("failed to retrieve details of snapshot %q of volume %q with err: %v",
+			snapshotID, volumeID, err)
+		return nil, err
+	}
+	log.Debugf("Details of snapshot %q of volume %q are %+v", snapshotID, volumeID, snapshotDetails)
+	return snapshotDetails, nil
+}
+
+// QueryChangedDiskAreas returns the changed areas of the snapshot of the given
+// volume id, starting at startOffset.
+func (m *defaultManager) QueryChangedDiskAreas(ctx context.Context, volumeID string, snapshotID string,
+	startOffset int64, changeID string) (*vim25types.DiskChangeInfo, error) {
+	log := logger.GetLogger(ctx)
+	err := validateManager(ctx, m)
+	if err != nil {
+		log.Errorf("failed to validate volume manager with err: %+v", err)
+		return nil, err
+	}
+	// Set up the VC connection
+	err = m.virtualCenter.ConnectVslm(ctx)
+	if err != nil {
+		log.Errorf("ConnectVslm failed with err: %+v", err)
+		return nil, err
+	}
+	globalObjectManager := vslm.NewGlobalObjectManager(m.virtualCenter.VslmClient)
+	diskChangeInfo, err := globalObjectManager.QueryChangedDiskAreas(ctx, vim25types.ID{Id: volumeID},
+		vim25types.ID{Id: snapshotID}, startOffset, changeID)
+	if err != nil {
+		log.Errorf("failed to query changed areas of snapshot %q of volume %q at offset %d since change %q "+
+			"with err: %v", snapshotID, volumeID, startOffset, changeID, err)
+		return nil, err
+	}
+	return diskChangeInfo, nil
+}
+
 // QueryVolumeAsync returns volumes matching the given filter by using
 // CnsQueryAsync API. QueryVolumeAsync takes querySelection spec which helps
 // to specify which fields for the query entities to be returned. All volume
@@ -2176,6 +2521,8 @@ func (m *defaultManager) QueryVolumeAsync(ctx context.Context, queryFilter cnsty
 	querySelection *cnstypes.CnsQuerySelection) (*cnstypes.CnsQueryResult, error) {
 	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
 	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.QueryVolumeAsync")
+	defer span.End()
 	log := logger.GetLogger(ctx)
 	err := validateManager(ctx, m)
 	if err != nil {
@@ -2239,6 +2586,8 @@ func (m *defaultManager) QuerySnapshots(ctx context.Context, snapshotQueryFilter
 	*cnstypes.CnsSnapshotQueryResult, error) {
 	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
 	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.QuerySnapshots")
+	defer span.End()
 	internalQuerySnapshots := func() (*cnstypes.CnsSnapshotQueryResult, error) {
 		log := logger.GetLogger(ctx)
 		err := validateManager(ctx, m)
@@ -2276,6 +2625,7 @@ func (m *defaultManager) QuerySnapshots(ctx context.Context, snapshotQueryFilter
 	start := time.Now()
 	resp, err := internalQuerySnapshots()
 	if err != nil {
+		tracing.SetSpanError(span, err)
 		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusQuerySnapshotsOpType,
 			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
 	} else {
@@ -2595,6 +2945,8 @@ func (m *defaultManager) CreateSnapshot(
 	ctx context.Context, volumeID string, snapshotName string, extraParams interface{}) (*CnsSnapshotInfo, error) {
 	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
 	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.CreateSnapshot")
+	defer span.End()
 	internalCreateSnapshot := func() (*CnsSnapshotInfo, error) {
 		log := logger.GetLogger(ctx)
 		err := validateManager(ctx, m)
@@ -2613,6 +2965,7 @@ func (m *defaultManager) CreateSnapshot(
 	start := time.Now()
 	cnsSnapshotInfo, err := internalCreateSnapshot()
 	if err != nil {
+		tracing.SetSpanError(span, err)
 		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsCreateSnapshotOpType,
 			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
 	} else {
@@ -2622,47 +2975,69 @@ func (m *defaultManager) CreateSnapshot(
 	return cnsSnapshotInfo, err
 }
 
-// Helper function for create snapshot with different behaviors in the idempotency handling
+// CreateGroupSnapshot creates the snapshots of the volumes of a volume group
+// snapshot with a single CNS CreateSnapshots task, so that the snapshots are
+// taken at the same point in time. The groupSnapshotName parameter is
+// expected to be filled with the CSI CreateVolumeGroupSnapshotRequest Name.
+// The snapshots are either all created, or none of them is kept.
+func (m *defaultManager) CreateGroupSnapshot(ctx context.Context, groupSnapshotName string,
+	volumeIDs []string) ([]*CnsSnapshotInfo, error) {
+	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
+	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.CreateGroupSnapshot")
+	defer span.End()
+	internalCreateGroupSnapshot := func() ([]*CnsSnapshotInfo, error) {
+		log := logger.GetLogger(ctx)
+		err := validateManager(ctx, m)
+		if err != nil {
+			return nil, err
+		}
+		if len(volumeIDs) == 0 {
+			return nil, logger.LogNewErrorf(log, "no volumes specified for group snapshot %q", groupSnapshotName)
+		}
+		// Set up the VC connection
+		err = m.virtualCenter.ConnectCns(ctx)
+		if err != nil {
+			return nil, logger.LogNewErrorf(log, "ConnectCns failed with err: %+v", err)
+		}
+
+		return m.createGroupSnapshotWithImprovedIdempotencyCheck(ctx, groupSnapshotName, volumeIDs)
+	}
+
+	start := time.Now()
+	cnsSnapshotInfos, err := internalCreateGroupSnapshot()
+	if err != nil {
+		tracing.SetSpanError(span, err)
+		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsCreateGroupSnapshotOpType,
+			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsCreateGroupSnapshotOpType,
+			prometheus.PrometheusPassStatus).Observe(time.Since(start).Seconds())
+	}
+	return cnsSnapshotInfos, err
+}
+
+// Helper function for create group snapshot with different behaviors in the idempotency handling
 // depends on whether the improved idempotency FSS is enabled.
-func (m *defaultManager) deleteSnapshotWithImprovedIdempotencyCheck(
-	ctx context.Context, volumeID string, snapshotID string, extraParams interface{}) (*CnsSnapshotInfo, error) {
+func (m *defaultManager) createGroupSnapshotWithImprovedIdempotencyCheck(ctx context.Context,
+	groupSnapshotName string, volumeIDs []string) ([]*CnsSnapshotInfo, error) {
 	log := logger.GetLogger(ctx)
 	var (
-		// Reference to the DeleteVolume task on CNS.
-		deleteSnapshotTask *object.Task
+		// Reference to the CreateSnapshots task on CNS.
+		createSnapshotsTask *object.Task
 		// Name of the CnsVolumeOperationRequest instance.
-		instanceName = "deletesnapshot-" + volumeID + "-" + snapshotID
-		// quotaInfo consists of values required to populate QuotaDetails in CnsVolumeOperationRequest CR.
-		quotaInfo *cnsvolumeoperationrequest.QuotaDetails
-		// Local instance of DeleteSnapshot details that needs to be persisted.
+		instanceName = groupSnapshotName
+		// Local instance of CreateVolumeGroupSnapshot details that needs to be persisted.
 		volumeOperationDetails *cnsvolumeoperationrequest.VolumeOperationRequestDetails
+		// Snapshots created by the CreateSnapshots task.
+		cnsSnapshotInfos []*CnsSnapshotInfo
+		// OpID of the CreateSnapshots task.
+		opID string
+		// Error message of a failed CreateSnapshots task.
+		errMsg string
 		// error
 		err error
-		// StorageQuotaM2
-		isStorageQuotaM2FSSEnabled bool
-		// cnsSnapshotInfo
-		cnsSnapshotInfo *CnsSnapshotInfo
 	)
-
-	if extraParams != nil {
-		deleteSnapParams, ok := extraParams.(*DeletesnapshotExtraParams)
-		if !ok {
-			return nil, logger.LogNewErrorf(log, "unrecognised type for Deletesnapshot params: %+v", extraParams)
-		}
-		log.Infof("Received Deletesnapshot extraParams: %+v", *deleteSnapParams)
-		isStorageQuotaM2FSSEnabled = deleteSnapParams.IsStorageQuotaM2FSSEnabled
-		if isStorageQuotaM2FSSEnabled && m.clusterFlavor == cnstypes.CnsClusterFlavorWorkload {
-			quotaInfo = &cnsvolumeoperationrequest.QuotaDetails{
-				Reserved:         deleteSnapParams.Capacity,
-				StoragePolicyId:  deleteSnapParams.StoragePolicyID,
-				StorageClassName: deleteSnapParams.StorageClassName,
-				Namespace:        deleteSnapParams.Namespace,
-			}
-			log.Infof("QuotaInfo during DeleteSnapshot call: %+v", *quotaInfo)
-		}
-	}
-	// Get the taskInfo
-	var deleteSnapshotsTaskInfo *vim25types.TaskInfo
 	if m.idempotencyHandlingEnabled {
 		if m.operationStore == nil {
 			return nil, logger.LogNewError(log, "operation store cannot be nil")
@@ -2671,44 +3046,308 @@ func (m *defaultManager) deleteSnapshotWithImprovedIdempotencyCheck(
 		volumeOperationDetails, err = m.operationStore.GetRequestDetails(ctx, instanceName)
 		switch {
 		case err == nil:
-			if volumeOperationDetails.OperationDetails != nil {
-				// Validate if previous attempt was successful.
-				if volumeOperationDetails.OperationDetails.TaskStatus == taskInvocationStatusSuccess {
-					log.Infof("Snapshot id %q on Volume %q is already deleted on CNS with opId: %q.",
-						snapshotID, volumeID, volumeOperationDetails.OperationDetails.OpID)
-					cnsSnapshotInfo = &CnsSnapshotInfo{
-						SnapshotID:     volumeOperationDetails.SnapshotID,
-						SourceVolumeID: volumeOperationDetails.VolumeID,
-					}
-					if isStorageQuotaM2FSSEnabled && m.clusterFlavor == cnstypes.CnsClusterFlavorWorkload {
-						if volumeOperationDetails.QuotaDetails != nil {
-							if volumeOperationDetails.QuotaDetails.AggregatedSnapshotSize != nil {
-								cnsSnapshotInfo.AggregatedSnapshotCapacityInMb =
-									volumeOperationDetails.QuotaDetails.AggregatedSnapshotSize.Value()
-
-							}
-							if !volumeOperationDetails.QuotaDetails.SnapshotLatestOperationCompleteTime.IsZero() {
-								cnsSnapshotInfo.SnapshotLatestOperationCompleteTime =
-									volumeOperationDetails.QuotaDetails.SnapshotLatestOperationCompleteTime.Time
-							}
-						}
-					}
-					return cnsSnapshotInfo, nil
-				}
-				// Validate if previous operation is pending.
-				if IsTaskPending(volumeOperationDetails) {
-					taskMoRef := vim25types.ManagedObjectReference{
-						Type:  "Task",
-						Value: volumeOperationDetails.OperationDetails.TaskID,
-					}
-					deleteSnapshotTask = object.NewTask(m.virtualCenter.Client.Client, taskMoRef)
+			// Validate if previous operation was successful.
+			if volumeOperationDetails.OperationDetails.TaskStatus == taskInvocationStatusSuccess &&
+				len(volumeOperationDetails.GroupSnapshotMembers) != 0 {
+				log.Infof("Group snapshot with name %q is already created on CNS with opId: %q.",
+					instanceName, volumeOperationDetails.OperationDetails.OpID)
+				var cnsSnapshotInfos []*CnsSnapshotInfo
+				for _, member := range volumeOperationDetails.GroupSnapshotMembers {
+					cnsSnapshotInfos = append(cnsSnapshotInfos, &CnsSnapshotInfo{
+						SnapshotID:          member.SnapshotID,
+						SourceVolumeID:      member.VolumeID,
+						SnapshotDescription: getGroupSnapshotMemberName(groupSnapshotName, member.VolumeID),
+						SnapshotLatestOperationCompleteTime: volumeOperationDetails.OperationDetails.
+							TaskInvocationTimestamp.Time,
+					})
 				}
+				return cnsSnapshotInfos, nil
 			}
-		case apierrors.IsNotFound(err):
-			volumeOperationDetails = createRequestDetails(instanceName, "", "", 0, nil, metav1.Now(),
-				"", "", "", taskInvocationStatusInProgress, "")
-		default:
-			return nil, err
+			// Validate if previous operation is pending.
+			if IsTaskPending(volumeOperationDetails) {
+				log.Infof("Group snapshot with name %s has CreateSnapshots task %s pending on CNS.",
+					instanceName, volumeOperationDetails.OperationDetails.TaskID)
+				taskMoRef := vim25types.ManagedObjectReference{
+					Type:  "Task",
+					Value: volumeOperationDetails.OperationDetails.TaskID,
+				}
+				createSnapshotsTask = object.NewTask(m.virtualCenter.Client.Client, taskMoRef)
+			}
+		case apierrors.IsNotFound(err):
+			// Instance doesn't exist. This is likely the first attempt to create the group snapshot.
+			volumeOperationDetails = createRequestDetails(
+				instanceName, "", "", 0, nil, metav1.Now(), "", "", "",
+				taskInvocationStatusInProgress, "")
+		default:
+			return nil, err
+		}
+	} else {
+		// get group snapshot task details from an in-memory map
+		createSnapshotsTask = getPendingCreateSnapshotTaskFromMap(ctx, instanceName)
+	}
+
+	defer func() {
+		// Persist the operation details before returning if the improved idempotency is enabled. Only success or error
+		// needs to be stored as InProgress details are stored when the task is created on CNS.
+		if m.idempotencyHandlingEnabled &&
+			volumeOperationDetails != nil && volumeOperationDetails.OperationDetails != nil &&
+			volumeOperationDetails.OperationDetails.TaskStatus != taskInvocationStatusInProgress {
+			if err := m.operationStore.StoreRequestDetails(ctx, volumeOperationDetails); err != nil {
+				log.Warnf("failed to store CreateVolumeGroupSnapshot details with error: %v", err)
+			}
+		}
+		if !m.idempotencyHandlingEnabled && errMsg != "" {
+			// Remove the task details from map when the current task fails
+			func() {
+				snapshotTaskMapLock.Lock()
+				defer snapshotTaskMapLock.Unlock()
+				delete(snapshotTaskMap, instanceName)
+			}()
+		}
+	}()
+
+	if createSnapshotsTask == nil {
+		createSnapshotsTask, err = invokeCNSCreateGroupSnapshot(ctx, m.virtualCenter, groupSnapshotName, volumeIDs)
+		if err != nil {
+			if m.idempotencyHandlingEnabled {
+				volumeOperationDetails = createRequestDetails(instanceName, "", "", 0, nil,
+					volumeOperationDetails.OperationDetails.TaskInvocationTimestamp, "", "", "",
+					taskInvocationStatusError, err.Error())
+			}
+			return nil, logger.LogNewErrorf(log, "failed to create group snapshot with error: %v", err)
+		}
+
+		if m.idempotencyHandlingEnabled {
+			// Persist the volume operation details.
+			volumeOperationDetails = createRequestDetails(instanceName, "", "", 0, nil,
+				volumeOperationDetails.OperationDetails.TaskInvocationTimestamp,
+				createSnapshotsTask.Reference().Value, "", "", taskInvocationStatusInProgress, "")
+			if err := m.operationStore.StoreRequestDetails(ctx, volumeOperationDetails); err != nil {
+				// Don't return if CreateVolumeGroupSnapshot details can't be stored.
+				log.Warnf("failed to store CreateVolumeGroupSnapshot details with error: %v", err)
+			}
+		} else {
+			// store task details into snapshotTaskMap
+			var taskDetails createSnapshotTaskDetails
+			taskDetails.task = createSnapshotsTask
+			taskDetails.expirationTime = time.Now().Add(time.Hour * time.Duration(
+				defaultOpsExpirationTimeInHours))
+			func() {
+				snapshotTaskMapLock.Lock()
+				defer snapshotTaskMapLock.Unlock()
+				snapshotTaskMap[instanceName] = &taskDetails
+			}()
+		}
+	}
+
+	createSnapshotsTaskInfo, err := m.waitOnTask(ctx, createSnapshotsTask.Reference())
+	switch {
+	case err != nil && cnsvsphere.IsManagedObjectNotFound(err, createSnapshotsTask.Reference()):
+		log.Infof("CreateSnapshots task %s not found in vCenter. Querying CNS "+
+			"to determine if the snapshots of group snapshot %s were successfully created.",
+			createSnapshotsTask.Reference().Value, instanceName)
+		for _, volumeID := range volumeIDs {
+			queriedCnsSnapshot, ok := queryCreatedSnapshotByName(ctx, m, volumeID,
+				getGroupSnapshotMemberName(groupSnapshotName, volumeID))
+			if !ok {
+				errMsg = fmt.Sprintf("snapshot of group snapshot %s on volume %q is not present in CNS. "+
+					"Marking task %s as failed.", instanceName, volumeID, createSnapshotsTask.Reference().Value)
+				continue
+			}
+			cnsSnapshotInfos = append(cnsSnapshotInfos, &CnsSnapshotInfo{
+				SnapshotID:                          queriedCnsSnapshot.SnapshotId.Id,
+				SourceVolumeID:                      volumeID,
+				SnapshotDescription:                 queriedCnsSnapshot.Description,
+				SnapshotLatestOperationCompleteTime: queriedCnsSnapshot.CreateTime,
+			})
+		}
+	case err != nil:
+		errMsg = fmt.Sprintf("failed to get taskInfo for CreateSnapshots task from vCenter %q with err: %v",
+			m.virtualCenter.Config.Host, err)
+	default:
+		opID = createSnapshotsTaskInfo.ActivationId
+		log.Infof("CreateSnapshots: group snapshot: %q, VolumeIDs: %v, opId: %q", instanceName, volumeIDs, opID)
+		var taskResults []cnstypes.BaseCnsVolumeOperationResult
+		taskResults, err = cns.GetTaskResultArray(ctx, createSnapshotsTaskInfo)
+		if err != nil {
+			errMsg = fmt.Sprintf("unable to find the task results for CreateSnapshots task from vCenter %q. "+
+				"taskID: %q, opId: %q, err: %v", m.virtualCenter.Config.Host, createSnapshotsTaskInfo.Task.Value,
+				opID, err)
+			break
+		}
+		for _, taskResult := range taskResults {
+			if taskResult == nil {
+				continue
+			}
+			operationRes := taskResult.GetCnsVolumeOperationResult()
+			if operationRes.Fault != nil {
+				errMsg = fmt.Sprintf("failed to create snapshot of group snapshot %q on volume %q with "+
+					"fault: %q, opID: %q", instanceName, operationRes.VolumeId.Id, spew.Sdump(operationRes.Fault),
+					opID)
+				// The snapshot got created if CNS failed in post-processing. It needs to be
+				// deleted together with the other snapshots of the group.
+				if createdFault, ok := operationRes.Fault.Fault.(cnstypes.CnsSnapshotCreatedFault); ok {
+					cnsSnapshotInfos = append(cnsSnapshotInfos, &CnsSnapshotInfo{
+						SnapshotID:     createdFault.SnapshotId.Id,
+						SourceVolumeID: operationRes.VolumeId.Id,
+					})
+				}
+				log.Error(errMsg)
+				continue
+			}
+			snapshotCreateResult, ok := taskResult.(*cnstypes.CnsSnapshotCreateResult)
+			if !ok {
+				errMsg = fmt.Sprintf("unexpected task result %+v for CreateSnapshots task, opID: %q",
+					taskResult, opID)
+				continue
+			}
+			cnsSnapshotInfos = append(cnsSnapshotInfos, &CnsSnapshotInfo{
+				SnapshotID:                          snapshotCreateResult.Snapshot.SnapshotId.Id,
+				SourceVolumeID:                      snapshotCreateResult.Snapshot.VolumeId.Id,
+				SnapshotDescription:                 snapshotCreateResult.Snapshot.Description,
+				SnapshotLatestOperationCompleteTime: *createSnapshotsTaskInfo.CompleteTime,
+			})
+		}
+		if errMsg == "" && len(cnsSnapshotInfos) != len(volumeIDs) {
+			errMsg = fmt.Sprintf("CreateSnapshots task returned %d snapshots for %d volumes of group snapshot "+
+				"%q, opID: %q", len(cnsSnapshotInfos), len(volumeIDs), instanceName, opID)
+		}
+	}
+
+	if errMsg != "" {
+		// The snapshots of a group are either all created or none of them is kept.
+		m.deleteGroupSnapshotMembers(ctx, instanceName, cnsSnapshotInfos)
+		if m.idempotencyHandlingEnabled {
+			volumeOperationDetails = createRequestDetails(instanceName, "", "", 0, nil,
+				volumeOperationDetails.OperationDetails.TaskInvocationTimestamp,
+				createSnapshotsTask.Reference().Value, "", opID, taskInvocationStatusError, errMsg)
+		}
+		return nil, logger.LogNewError(log, errMsg)
+	}
+
+	if m.idempotencyHandlingEnabled {
+		// create the volumeOperationDetails object for persistence
+		volumeOperationDetails = createRequestDetails(instanceName, "", "", 0, nil,
+			volumeOperationDetails.OperationDetails.TaskInvocationTimestamp,
+			createSnapshotsTask.Reference().Value, "", opID, taskInvocationStatusSuccess, "")
+		for _, cnsSnapshotInfo := range cnsSnapshotInfos {
+			volumeOperationDetails.GroupSnapshotMembers = append(volumeOperationDetails.GroupSnapshotMembers,
+				cnsvolumeoperationrequest.GroupSnapshotMember{
+					VolumeID:   cnsSnapshotInfo.SourceVolumeID,
+					SnapshotID: cnsSnapshotInfo.SnapshotID,
+				})
+		}
+	}
+	log.Infof("CreateGroupSnapshot: Group snapshot %q created successfully with %d snapshots, opId: %q",
+		instanceName, len(cnsSnapshotInfos), opID)
+	return cnsSnapshotInfos, nil
+}
+
+// deleteGroupSnapshotMembers deletes the snapshots created for a volume group
+// snapshot which could not be created as a whole. Failures are only logged,
+// the snapshots left behind need to be cleaned up manually.
+func (m *defaultManager) deleteGroupSnapshotMembers(ctx context.Context, groupSnapshotName string,
+	cnsSnapshotInfos []*CnsSnapshotInfo) {
+	log := logger.GetLogger(ctx)
+	for _, cnsSnapshotInfo := range cnsSnapshotInfos {
+		log.Infof("Deleting snapshot %q on volume %q of failed group snapshot %q",
+			cnsSnapshotInfo.SnapshotID, cnsSnapshotInfo.SourceVolumeID, groupSnapshotName)
+		_, err := m.DeleteSnapshot(ctx, cnsSnapshotInfo.SourceVolumeID, cnsSnapshotInfo.SnapshotID, nil)
+		if err != nil {
+			log.Errorf("failed to delete snapshot %q on volume %q of failed group snapshot %q. "+
+				"You need to manually cleanup this snapshot. err: %v", cnsSnapshotInfo.SnapshotID,
+				cnsSnapshotInfo.SourceVolumeID, groupSnapshotName, err)
+		}
+	}
+}
+
+// Helper function for create snapshot with different behaviors in the idempotency handling
+// depends on whether the improved idempotency FSS is enabled.
+func (m *defaultManager) deleteSnapshotWithImprovedIdempotencyCheck(
+	ctx context.Context, volumeID string, snapshotID string, extraParams interface{}) (*CnsSnapshotInfo, error) {
+	log := logger.GetLogger(ctx)
+	var (
+		// Reference to the DeleteVolume task on CNS.
+		deleteSnapshotTask *object.Task
+		// Name of the CnsVolumeOperationRequest instance.
+		instanceName = "deletesnapshot-" + volumeID + "-" + snapshotID
+		// quotaInfo consists of values required to populate QuotaDetails in CnsVolumeOperationRequest CR.
+		quotaInfo *cnsvolumeoperationrequest.QuotaDetails
+		// Local instance of DeleteSnapshot details that needs to be persisted.
+		volumeOperationDetails *cnsvolumeoperationrequest.VolumeOperationRequestDetails
+		// error
+		err error
+		// StorageQuotaM2
+		isStorageQuotaM2FSSEnabled bool
+		// cnsSnapshotInfo
+		cnsSnapshotInfo *CnsSnapshotInfo
+	)
+
+	if extraParams != nil {
+		deleteSnapParams, ok := extraParams.(*DeletesnapshotExtraParams)
+		if !ok {
+			return nil, logger.LogNewErrorf(log, "unrecognised type for Deletesnapshot params: %+v", extraParams)
+		}
+		log.Infof("Received Deletesnapshot extraParams: %+v", *deleteSnapParams)
+		isStorageQuotaM2FSSEnabled = deleteSnapParams.IsStorageQuotaM2FSSEnabled
+		if isStorageQuotaM2FSSEnabled && m.clusterFlavor == cnstypes.CnsClusterFlavorWorkload {
+			quotaInfo = &cnsvolumeoperationrequest.QuotaDetails{
+				Reserved:         deleteSnapParams.Capacity,
+				StoragePolicyId:  deleteSnapParams.StoragePolicyID,
+				StorageClassName: deleteSnapParams.StorageClassName,
+				Namespace:        deleteSnapParams.Namespace,
+			}
+			log.Infof("QuotaInfo during DeleteSnapshot call: %+v", *quotaInfo)
+		}
+	}
+	// Get the taskInfo
+	var deleteSnapshotsTaskInfo *vim25types.TaskInfo
+	if m.idempotencyHandlingEnabled {
+		if m.operationStore == nil {
+			return nil, logger.LogNewError(log, "operation store cannot be nil")
+		}
+
+		volumeOperationDetails, err = m.operationStore.GetRequestDetails(ctx, instanceName)
+		switch {
+		case err == nil:
+			if volumeOperationDetails.OperationDetails != nil {
+				// Validate if previous attempt was successful.
+				if volumeOperationDetails.OperationDetails.TaskStatus == taskInvocationStatusSuccess {
+					log.Infof("Snapshot id %q on Volume %q is already deleted on CNS with opId: %q.",
+						snapshotID, volumeID, volumeOperationDetails.OperationDetails.OpID)
+					cnsSnapshotInfo = &CnsSnapshotInfo{
+						SnapshotID:     volumeOperationDetails.SnapshotID,
+						SourceVolumeID: volumeOperationDetails.VolumeID,
+					}
+					if isStorageQuotaM2FSSEnabled && m.clusterFlavor == cnstypes.CnsClusterFlavorWorkload {
+						if volumeOperationDetails.QuotaDetails != nil {
+							if volumeOperationDetails.QuotaDetails.AggregatedSnapshotSize != nil {
+								cnsSnapshotInfo.AggregatedSnapshotCapacityInMb =
+									volumeOperationDetails.QuotaDetails.AggregatedSnapshotSize.Value()
+
+							}
+							if !volumeOperationDetails.QuotaDetails.SnapshotLatestOperationCompleteTime.IsZero() {
+								cnsSnapshotInfo.SnapshotLatestOperationCompleteTime =
+									volumeOperationDetails.QuotaDetails.SnapshotLatestOperationCompleteTime.Time
+							}
+						}
+					}
+					return cnsSnapshotInfo, nil
+				}
+				// Validate if previous operation is pending.
+				if IsTaskPending(volumeOperationDetails) {
+					taskMoRef := vim25types.ManagedObjectReference{
+						Type:  "Task",
+						Value: volumeOperationDetails.OperationDetails.TaskID,
+					}
+					deleteSnapshotTask = object.NewTask(m.virtualCenter.Client.Client, taskMoRef)
+				}
+			}
+		case apierrors.IsNotFound(err):
+			volumeOperationDetails = createRequestDetails(instanceName, "", "", 0, nil, metav1.Now(),
+				"", "", "", taskInvocationStatusInProgress, "")
+		default:
+			return nil, err
 		}
 	}
 
@@ -2913,6 +3552,8 @@ func (m *defaultManager) DeleteSnapshot(ctx context.Context, volumeID string, sn
 	extraParams interface{}) (*CnsSnapshotInfo, error) {
 	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
 	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.DeleteSnapshot")
+	defer span.End()
 	internalDeleteSnapshot := func() (*CnsSnapshotInfo, error) {
 		log := logger.GetLogger(ctx)
 		err := validateManager(ctx, m)
@@ -2932,6 +3573,7 @@ func (m *defaultManager) DeleteSnapshot(ctx context.Context, volumeID string, sn
 	start := time.Now()
 	cnsSnapshotInfo, err := internalDeleteSnapshot()
 	if err != nil {
+		tracing.SetSpanError(span, err)
 		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsDeleteSnapshotOpType,
 			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
 	} else {
@@ -3017,6 +3659,576 @@ func (m *defaultManager) ProtectVolumeFromVMDeletion(ctx context.Context, volume
 	return nil
 }
 
+// CloneVolume creates a full copy of the FCD backing sourceVolumeID and
+// registers the copy with CNS as a new volume. The copy is placed on the
+// first datastore in spec.Datastores with the storage policy in spec.Profile,
+// and is extended when the capacity in spec is larger than the source.
+// A copy left behind by a previous attempt for the same volume name is reused,
+// so retries do not clone the source again.
+func (m *defaultManager) CloneVolume(ctx context.Context, sourceVolumeID string,
+	spec *cnstypes.CnsVolumeCreateSpec, extraParams interface{}) (*CnsVolumeInfo, string, error) {
+	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
+	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.CloneVolume")
+	defer span.End()
+	internalCloneVolume := func() (*CnsVolumeInfo, string, error) {
+		log := logger.GetLogger(ctx)
+		err := validateManager(ctx, m)
+		if err != nil {
+			log.Errorf("failed to validate manager with error: %v", err)
+			return nil, ExtractFaultTypeFromErr(ctx, err), err
+		}
+		if len(spec.Datastores) == 0 {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorf(log,
+				"no target datastore specified to clone volume %q", sourceVolumeID)
+		}
+		backingDetails, ok := spec.BackingObjectDetails.(*cnstypes.CnsBlockBackingDetails)
+		if !ok {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorf(log,
+				"unsupported backing object details %+v to clone volume %q", spec.BackingObjectDetails, sourceVolumeID)
+		}
+		// Set up the VC connection.
+		err = m.virtualCenter.ConnectVslm(ctx)
+		if err != nil {
+			log.Errorf("ConnectVslm failed with err: %+v", err)
+			return nil, ExtractFaultTypeFromErr(ctx, err), err
+		}
+		globalObjectManager := vslm.NewGlobalObjectManager(m.virtualCenter.VslmClient)
+		clonedVolumeID, err := getVStorageObjectIDByName(ctx, globalObjectManager, spec.Name)
+		if err != nil {
+			return nil, ExtractFaultTypeFromErr(ctx, err), err
+		}
+		if clonedVolumeID != "" {
+			log.Infof("Found FCD %q with name %q from a previous attempt to clone volume %q",
+				clonedVolumeID, spec.Name, sourceVolumeID)
+		} else {
+			cloneSpec := vim25types.VslmCloneSpec{
+				VslmMigrateSpec: vim25types.VslmMigrateSpec{
+					BackingSpec: &vim25types.VslmCreateSpecDiskFileBackingSpec{
+						VslmCreateSpecBackingSpec: vim25types.VslmCreateSpecBackingSpec{
+							Datastore: spec.Datastores[0],
+						},
+					},
+					Profile: spec.Profile,
+				},
+				Name: spec.Name,
+			}
+			log.Infof("Cloning volume %q to datastore %q with name %q", sourceVolumeID,
+				spec.Datastores[0].Value, spec.Name)
+			task, err := globalObjectManager.Clone(ctx, vim25types.ID{Id: sourceVolumeID}, cloneSpec)
+			if err != nil {
+				log.Errorf("failed to clone volume %q with err: %v", sourceVolumeID, err)
+				return nil, ExtractFaultTypeFromErr(ctx, err), err
+			}
+			res, err := task.Wait(ctx, time.Duration(VolumeOperationTimeoutInSeconds)*time.Second)
+			if err != nil {
+				log.Errorf("clone task for volume %q failed with err: %v", sourceVolumeID, err)
+				return nil, ExtractFaultTypeFromErr(ctx, err), err
+			}
+			switch vStorageObject := res.(type) {
+			case vim25types.VStorageObject:
+				clonedVolumeID = vStorageObject.Config.Id.Id
+			case *vim25types.VStorageObject:
+				clonedVolumeID = vStorageObject.Config.Id.Id
+			default:
+				return nil, csifault.CSITaskResultEmptyFault, logger.LogNewErrorf(log,
+					"unexpected result %+v from clone task for volume %q", res, sourceVolumeID)
+			}
+			log.Infof("Successfully cloned volume %q to FCD %q", sourceVolumeID, clonedVolumeID)
+		}
+
+		vStorageObject, err := globalObjectManager.Retrieve(ctx, vim25types.ID{Id: clonedVolumeID})
+		if err != nil {
+			log.Errorf("failed to retrieve cloned FCD %q with err: %v", clonedVolumeID, err)
+			return nil, ExtractFaultTypeFromErr(ctx, err), err
+		}
+		if backingDetails.CapacityInMb > vStorageObject.Config.CapacityInMB {
+			log.Infof("Extending cloned FCD %q from %d MB to %d MB", clonedVolumeID,
+				vStorageObject.Config.CapacityInMB, backingDetails.CapacityInMb)
+			task, err := globalObjectManager.ExtendDisk(ctx, vim25types.ID{Id: clonedVolumeID},
+				backingDetails.CapacityInMb)
+			if err != nil {
+				log.Errorf("failed to extend cloned FCD %q with err: %v", clonedVolumeID, err)
+				return nil, ExtractFaultTypeFromErr(ctx, err), err
+			}
+			_, err = task.Wait(ctx, time.Duration(VolumeOperationTimeoutInSeconds)*time.Second)
+			if err != nil {
+				log.Errorf("extend task for cloned FCD %q failed with err: %v", clonedVolumeID, err)
+				return nil, ExtractFaultTypeFromErr(ctx, err), err
+			}
+		}
+
+		// Register the cloned FCD with CNS. Placement and storage policy were
+		// already applied by the clone task.
+		registerSpec := *spec
+		registerSpec.Datastores = nil
+		registerSpec.Profile = nil
+		registerSpec.BackingObjectDetails = &cnstypes.CnsBlockBackingDetails{BackingDiskId: clonedVolumeID}
+		return m.CreateVolume(ctx, &registerSpec, extraParams)
+	}
+	start := time.Now()
+	resp, faultType, err := internalCloneVolume()
+	if err != nil {
+		tracing.SetSpanError(span, err)
+		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsCloneVolumeOpType,
+			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsCloneVolumeOpType,
+			prometheus.PrometheusPassStatus).Observe(time.Since(start).Seconds())
+	}
+	return resp, faultType, err
+}
+
+// CreateVolumeWithProvisioningType creates an FCD with the given provisioning
+// type and registers it with CNS as a new volume. CNS does not accept a
+// provisioning type in CnsVolumeCreateSpec, so the FCD is created through vslm
+// on the first datastore in spec.Datastores with the storage policy in
+// spec.Profile and is then passed to CNS as the backing disk of the volume.
+// An FCD left behind by a previous attempt for the same volume name is reused.
+func (m *defaultManager) CreateVolumeWithProvisioningType(ctx context.Context, spec *cnstypes.CnsVolumeCreateSpec,
+	provisioningType string, extraParams interface{}) (*CnsVolumeInfo, string, error) {
+	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
+	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.CreateVolumeWithProvisioningType")
+	defer span.End()
+	internalCreateVolume := func() (*CnsVolumeInfo, string, error) {
+		log := logger.GetLogger(ctx)
+		err := validateManager(ctx, m)
+		if err != nil {
+			log.Errorf("failed to validate manager with error: %v", err)
+			return nil, ExtractFaultTypeFromErr(ctx, err), err
+		}
+		if len(spec.Datastores) == 0 {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorf(log,
+				"no target datastore specified to create volume %q", spec.Name)
+		}
+		backingDetails, ok := spec.BackingObjectDetails.(*cnstypes.CnsBlockBackingDetails)
+		if !ok {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorf(log,
+				"unsupported backing object details %+v to create volume %q", spec.BackingObjectDetails, spec.Name)
+		}
+		// Set up the VC connection.
+		err = m.virtualCenter.ConnectVslm(ctx)
+		if err != nil {
+			log.Errorf("ConnectVslm failed with err: %+v", err)
+			return nil, ExtractFaultTypeFromErr(ctx, err), err
+		}
+		globalObjectManager := vslm.NewGlobalObjectManager(m.virtualCenter.VslmClient)
+		diskID, err := getVStorageObjectIDByName(ctx, globalObjectManager, spec.Name)
+		if err != nil {
+			return nil, ExtractFaultTypeFromErr(ctx, err), err
+		}
+		if diskID != "" {
+			log.Infof("Found FCD %q with name %q from a previous attempt to create the volume", diskID, spec.Name)
+		} else {
+			createSpec := vim25types.VslmCreateSpec{
+				Name:         spec.Name,
+				CapacityInMB: backingDetails.CapacityInMb,
+				BackingSpec: &vim25types.VslmCreateSpecDiskFileBackingSpec{
+					VslmCreateSpecBackingSpec: vim25types.VslmCreateSpecBackingSpec{
+						Datastore: spec.Datastores[0],
+					},
+					ProvisioningType: provisioningType,
+				},
+				Profile: spec.Profile,
+			}
+			log.Infof("Creating FCD with name %q and provisioning type %q on datastore %q", spec.Name,
+				provisioningType, spec.Datastores[0].Value)
+			task, err := globalObjectManager.CreateDisk(ctx, createSpec)
+			if err != nil {
+				log.Errorf("failed to create FCD %q with err: %v", spec.Name, err)
+				return nil, ExtractFaultTypeFromErr(ctx, err), err
+			}
+			res, err := task.Wait(ctx, time.Duration(VolumeOperationTimeoutInSeconds)*time.Second)
+			if err != nil {
+				log.Errorf("create task for FCD %q failed with err: %v", spec.Name, err)
+				return nil, ExtractFaultTypeFromErr(ctx, err), err
+			}
+			switch vStorageObject := res.(type) {
+			case vim25types.VStorageObject:
+				diskID = vStorageObject.Config.Id.Id
+			case *vim25types.VStorageObject:
+				diskID = vStorageObject.Config.Id.Id
+			default:
+				return nil, csifault.CSITaskResultEmptyFault, logger.LogNewErrorf(log,
+					"unexpected result %+v from create task for FCD %q", res, spec.Name)
+			}
+			log.Infof("Successfully created FCD %q with name %q", diskID, spec.Name)
+		}
+
+		// Register the FCD with CNS. Placement and storage policy were already
+		// applied when the FCD was created.
+		registerSpec := *spec
+		registerSpec.Datastores = nil
+		registerSpec.Profile = nil
+		registerSpec.BackingObjectDetails = &cnstypes.CnsBlockBackingDetails{BackingDiskId: diskID}
+		return m.CreateVolume(ctx, &registerSpec, extraParams)
+	}
+	start := time.Now()
+	resp, faultType, err := internalCreateVolume()
+	if err != nil {
+		tracing.SetSpanError(span, err)
+		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsCreateVolumeWithProvisioningTypeOpType,
+			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsCreateVolumeWithProvisioningTypeOpType,
+			prometheus.PrometheusPassStatus).Observe(time.Since(start).Seconds())
+	}
+	return resp, faultType, err
+}
+
+// SetVolumeExportedFrom records the ID of the cluster which exported the volume
+// in the metadata of its FCD. The record outlives the CNS metadata of the
+// volume, which the exporting cluster removes, and marks the FCD as free to be
+// imported by another cluster.
+func (m *defaultManager) SetVolumeExportedFrom(ctx context.Context, volumeID string, clusterID string) error {
+	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
+	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.SetVolumeExportedFrom")
+	defer span.End()
+	log := logger.GetLogger(ctx)
+	err := validateManager(ctx, m)
+	if err != nil {
+		log.Errorf("failed to validate manager with error: %v", err)
+		return err
+	}
+	var metadata []vim25types.KeyValue
+	var deleteKeys []string
+	if clusterID != "" {
+		metadata = append(metadata, vim25types.KeyValue{Key: ExportedFromMetadataKey, Value: clusterID})
+	} else {
+		deleteKeys = append(deleteKeys, ExportedFromMetadataKey)
+	}
+	err = m.virtualCenter.ConnectVslm(ctx)
+	if err != nil {
+		log.Errorf("ConnectVslm failed with err: %+v", err)
+		return err
+	}
+	globalObjectManager := vslm.NewGlobalObjectManager(m.virtualCenter.VslmClient)
+	task, err := globalObjectManager.UpdateMetadata(ctx, vim25types.ID{Id: volumeID}, metadata, deleteKeys)
+	if err != nil {
+		log.Errorf("failed to update metadata of volume %q with err: %v", volumeID, err)
+		return err
+	}
+	_, err = task.Wait(ctx, time.Duration(VolumeOperationTimeoutInSeconds)*time.Second)
+	if err != nil {
+		log.Errorf("update metadata task for volume %q failed with err: %v", volumeID, err)
+		return err
+	}
+	log.Infof("Successfully set exporting cluster of volume %q to %q", volumeID, clusterID)
+	return nil
+}
+
+// GetVolumeExportedFrom returns the ID of the cluster recorded by
+// SetVolumeExportedFrom in the metadata of the FCD of the volume.
+func (m *defaultManager) GetVolumeExportedFrom(ctx context.Context, volumeID string) (string, error) {
+	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
+	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.GetVolumeExportedFrom")
+	defer span.End()
+	log := logger.GetLogger(ctx)
+	err := validateManager(ctx, m)
+	if err != nil {
+		log.Errorf("failed to validate manager with error: %v", err)
+		return "", err
+	}
+	err = m.virtualCenter.ConnectVslm(ctx)
+	if err != nil {
+		log.Errorf("ConnectVslm failed with err: %+v", err)
+		return "", err
+	}
+	globalObjectManager := vslm.NewGlobalObjectManager(m.virtualCenter.VslmClient)
+	metadata, err := globalObjectManager.RetrieveMetadata(ctx, vim25types.ID{Id: volumeID}, nil,
+		ExportedFromMetadataKey)
+	if err != nil {
+		log.Errorf("failed to retrieve metadata of volume %q with err: %v", volumeID, err)
+		return "", err
+	}
+	for _, kv := range metadata {
+		if kv.Key == ExportedFromMetadataKey {
+			return kv.Value, nil
+		}
+	}
+	return "", nil
+}
+
+// ApplyVolumeIOAllocation sets the IOPS limit and shares of the virtual disk
+// backing the volume on the given VM. The reservation of the disk is left
+// unchanged and the VM is only reconfigured if the settings differ.
+func (m *defaultManager) ApplyVolumeIOAllocation(ctx context.Context, vm *cnsvsphere.VirtualMachine,
+	volumeID string, ioAllocation *vim25types.StorageIOAllocationInfo) (string, error) {
+	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
+	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.ApplyVolumeIOAllocation")
+	defer span.End()
+	internalApplyVolumeIOAllocation := func() (string, error) {
+		log := logger.GetLogger(ctx)
+		if ioAllocation == nil {
+			return "", nil
+		}
+		devices, err := vm.Device(ctx)
+		if err != nil {
+			log.Errorf("failed to get devices of vm %q with err: %v", vm.String(), err)
+			return ExtractFaultTypeFromErr(ctx, err), err
+		}
+		for _, device := range devices.SelectByType((*vim25types.VirtualDisk)(nil)) {
+			disk, ok := device.(*vim25types.VirtualDisk)
+			if !ok || disk.VDiskId == nil || disk.VDiskId.Id != volumeID {
+				continue
+			}
+			current := disk.StorageIOAllocation
+			if current == nil {
+				current = &vim25types.StorageIOAllocationInfo{}
+			}
+			if isIOAllocationApplied(current, ioAllocation) {
+				log.Debugf("IO allocation of volume %q on vm %q is up to date", volumeID, vm.String())
+				return "", nil
+			}
+			updated := *current
+			if ioAllocation.Limit != nil {
+				updated.Limit = ioAllocation.Limit
+			}
+			if ioAllocation.Shares != nil {
+				updated.Shares = ioAllocation.Shares
+			}
+			disk.StorageIOAllocation = &updated
+			err = vm.EditDevice(ctx, disk)
+			if err != nil {
+				log.Errorf("failed to set IO allocation of volume %q on vm %q with err: %v",
+					volumeID, vm.String(), err)
+				return ExtractFaultTypeFromErr(ctx, err), err
+			}
+			log.Infof("Successfully applied IO allocation %s to volume %q on vm %q",
+				spew.Sdump(ioAllocation), volumeID, vm.String())
+			return "", nil
+		}
+		return csifault.CSINotFoundFault, logger.LogNewErrorf(log,
+			"volume %q is not attached to vm %q", volumeID, vm.String())
+	}
+	start := time.Now()
+	faultType, err := internalApplyVolumeIOAllocation()
+	if err != nil {
+		tracing.SetSpanError(span, err)
+		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsApplyVolumeIOAllocationOpType,
+			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsApplyVolumeIOAllocationOpType,
+			prometheus.PrometheusPassStatus).Observe(time.Since(start).Seconds())
+	}
+	return faultType, err
+}
+
+// getVStorageObjectIDByName returns the ID of the FCD with the given name
+// from the global catalog, or an empty string if there is no such FCD.
+func getVStorageObjectIDByName(ctx context.Context, globalObjectManager *vslm.GlobalObjectManager,
+	name string) (string, error) {
+	log := logger.GetLogger(ctx)
+	querySpec := []vslmtypes.VslmVsoVStorageObjectQuerySpec{
+		{
+			QueryField:    string(vslmtypes.VslmVsoVStorageObjectQuerySpecQueryFieldEnumName),
+			QueryOperator: string(vslmtypes.VslmVsoVStorageObjectQuerySpecQueryOperatorEnumEquals),
+			QueryValue:    []string{name},
+		},
+	}
+	queryResult, err := globalObjectManager.ListObjectsForSpec(ctx, querySpec, 1)
+	if err != nil {
+		log.Errorf("failed to list FCDs with name %q with err: %v", name, err)
+		return "", err
+	}
+	if queryResult == nil || len(queryResult.Id) == 0 {
+		return "", nil
+	}
+	return queryResult.Id[0].Id, nil
+}
+
+// UpdateVolumeStoragePolicy applies the given storage policy to the volume.
+// When datastore is set, the volume is relocated to it along with the policy
+// change, otherwise the policy is reconfigured in place. An empty
+// storagePolicyID only relocates the volume.
+func (m *defaultManager) UpdateVolumeStoragePolicy(ctx context.Context, volumeID string, storagePolicyID string,
+	datastore *vim25types.ManagedObjectReference) (string, error) {
+	ctx, cancelFunc := ensureOperationContextHasATimeout(ctx)
+	defer cancelFunc()
+	ctx, span := tracing.StartSpan(ctx, "cns.UpdateVolumeStoragePolicy")
+	defer span.End()
+	internalUpdateVolumeStoragePolicy := func() (string, error) {
+		log := logger.GetLogger(ctx)
+		err := validateManager(ctx, m)
+		if err != nil {
+			log.Errorf("validateManager failed with err: %+v", err)
+			return ExtractFaultTypeFromErr(ctx, err), err
+		}
+		// Set up the VC connection.
+		err = m.virtualCenter.ConnectCns(ctx)
+		if err != nil {
+			log.Errorf("ConnectCns failed with err: %+v", err)
+			return ExtractFaultTypeFromErr(ctx, err), err
+		}
+		return m.updateVolumeStoragePolicy(ctx, volumeID, storagePolicyID, datastore)
+	}
+	start := time.Now()
+	faultType, err := internalUpdateVolumeStoragePolicy()
+	log := logger.GetLogger(ctx)
+	log.Debugf("internalUpdateVolumeStoragePolicy: returns fault %q for volume %q", faultType, volumeID)
+	if err != nil {
+		tracing.SetSpanError(span, err)
+		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsUpdateVolumePolicyOpType,
+			prometheus.PrometheusFailStatus).Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CnsControlOpsHistVec.WithLabelValues(prometheus.PrometheusCnsUpdateVolumePolicyOpType,
+			prometheus.PrometheusPassStatus).Observe(time.Since(start).Seconds())
+	}
+	return faultType, err
+}
+
+// updateVolumeStoragePolicy invokes CNS ReconfigVolumePolicy or RelocateVolume.
+// When idempotency handling is enabled, the CNS task is persisted through the
+// VolumeOperationRequest interface so that a retried request waits on the
+// pending task instead of invoking CNS again.
+func (m *defaultManager) updateVolumeStoragePolicy(ctx context.Context, volumeID string, storagePolicyID string,
+	datastore *vim25types.ManagedObjectReference) (faultType string, finalErr error) {
+	log := logger.GetLogger(ctx)
+	var (
+		// Reference to the ReconfigVolumePolicy or RelocateVolume task.
+		task *object.Task
+		// Details to be persisted.
+		volumeOperationDetails *cnsvolumeoperationrequest.VolumeOperationRequestDetails
+		// CnsVolumeOperationRequest instance name.
+		instanceName = "modify-" + volumeID + "-" + storagePolicyID
+	)
+	if m.idempotencyHandlingEnabled {
+		if m.operationStore == nil {
+			return csifault.CSIInternalFault, logger.LogNewError(log, "operation store cannot be nil")
+		}
+		volumeOperationDetails, finalErr = m.operationStore.GetRequestDetails(ctx, instanceName)
+		switch {
+		case finalErr == nil:
+			if volumeOperationDetails.OperationDetails != nil {
+				if IsTaskPending(volumeOperationDetails) {
+					log.Infof("Volume with ID %s has storage policy update task %s pending on CNS.",
+						volumeID, volumeOperationDetails.OperationDetails.TaskID)
+					taskMoRef := vim25types.ManagedObjectReference{
+						Type:  "Task",
+						Value: volumeOperationDetails.OperationDetails.TaskID,
+					}
+					task = object.NewTask(m.virtualCenter.Client.Client, taskMoRef)
+				}
+			}
+		case !apierrors.IsNotFound(finalErr):
+			return csifault.CSIInternalFault, finalErr
+		}
+		defer func() {
+			// Persist the operation details before returning. The details are
+			// deleted once the policy is applied, as the instance name is not
+			// unique to the request and the volume may be changed back to the
+			// same policy later on.
+			if volumeOperationDetails == nil || volumeOperationDetails.OperationDetails == nil {
+				return
+			}
+			taskStatus := volumeOperationDetails.OperationDetails.TaskStatus
+			if taskStatus == taskInvocationStatusSuccess {
+				err := m.operationStore.DeleteRequestDetails(ctx, instanceName)
+				if err != nil {
+					log.Warnf("failed to delete storage policy update details with error: %v", err)
+				}
+			} else if taskStatus != taskInvocationStatusInProgress {
+				err := m.operationStore.StoreRequestDetails(ctx, volumeOperationDetails)
+				if err != nil {
+					log.Warnf("failed to store storage policy update details with error: %v", err)
+				}
+			}
+		}()
+	}
+
+	if task == nil {
+		var profileSpec []vim25types.BaseVirtualMachineProfileSpec
+		if storagePolicyID != "" {
+			profileSpec = append(profileSpec, &vim25types.VirtualMachineDefinedProfileSpec{
+				ProfileId: storagePolicyID,
+			})
+		}
+		if datastore != nil {
+			relocateSpec := cnstypes.NewCnsBlockVolumeRelocateSpec(volumeID, *datastore, profileSpec...)
+			log.Infof("Calling CnsClient.RelocateVolume: VolumeID [%q] Datastore [%q] StoragePolicyID [%q]",
+				volumeID, datastore.Value, storagePolicyID)
+			task, finalErr = m.virtualCenter.CnsClient.RelocateVolume(ctx, relocateSpec)
+		} else {
+			reconfigSpec := []cnstypes.CnsVolumePolicyReconfigSpec{
+				{
+					VolumeId: cnstypes.CnsVolumeId{Id: volumeID},
+					Profile:  profileSpec,
+				},
+			}
+			log.Infof("Calling CnsClient.ReconfigVolumePolicy: VolumeID [%q] StoragePolicyID [%q]",
+				volumeID, storagePolicyID)
+			task, finalErr = m.virtualCenter.CnsClient.ReconfigVolumePolicy(ctx, reconfigSpec)
+		}
+		if finalErr != nil {
+			faultType = ExtractFaultTypeFromErr(ctx, finalErr)
+			if cnsvsphere.IsNotFoundError(finalErr) {
+				return faultType, logger.LogNewErrorf(log,
+					"volume %q not found. Cannot update storage policy.", volumeID)
+			}
+			log.Errorf("CNS storage policy update failed from the vCenter %q with err: %v",
+				m.virtualCenter.Config.Host, finalErr)
+			volumeOperationDetails = createRequestDetails(instanceName, volumeID, "", 0, nil,
+				metav1.Now(), "", m.virtualCenter.Config.Host, "", taskInvocationStatusError, finalErr.Error())
+			return faultType, finalErr
+		}
+		if m.idempotencyHandlingEnabled {
+			volumeOperationDetails = createRequestDetails(instanceName, volumeID, "", 0, nil,
+				metav1.Now(), task.Reference().Value, m.virtualCenter.Config.Host, "",
+				taskInvocationStatusInProgress, "")
+			err := m.operationStore.StoreRequestDetails(ctx, volumeOperationDetails)
+			if err != nil {
+				log.Warnf("failed to store storage policy update details with error: %v", err)
+			}
+		}
+	}
+
+	taskInfo, err := m.waitOnTask(ctx, task.Reference())
+	if err != nil || taskInfo == nil {
+		if err != nil {
+			faultType = ExtractFaultTypeFromErr(ctx, err)
+		} else {
+			faultType = csifault.CSITaskInfoEmptyFault
+			err = logger.LogNewErrorf(log, "taskInfo is empty for storage policy update task: %q",
+				task.Reference().Value)
+		}
+		log.Errorf("failed to update storage policy of volume %q with error %+v", volumeID, err)
+		volumeOperationDetails = createRequestDetails(instanceName, volumeID, "", 0, nil, metav1.Now(),
+			task.Reference().Value, m.virtualCenter.Config.Host, "", taskInvocationStatusError, err.Error())
+		return faultType, err
+	}
+	log.Infof("UpdateVolumeStoragePolicy: volumeID: %q, opId: %q", volumeID, taskInfo.ActivationId)
+	// Get the task results for the given task.
+	taskResult, err := getTaskResultFromTaskInfo(ctx, taskInfo)
+	if taskResult == nil {
+		return csifault.CSITaskResultEmptyFault,
+			logger.LogNewErrorf(log, "taskResult is empty for storage policy update task: %q, opID: %q",
+				taskInfo.Task.Value, taskInfo.ActivationId)
+	}
+	if err != nil {
+		log.Errorf("failed to get task result for task %s and volume ID %s with error: %v",
+			task.Reference().Value, volumeID, err)
+		return ExtractFaultTypeFromErr(ctx, err), err
+	}
+	volumeOperationRes := taskResult.GetCnsVolumeOperationResult()
+	if volumeOperationRes.Fault != nil {
+		faultType = ExtractFaultTypeFromVolumeResponseResult(ctx, volumeOperationRes)
+		volumeOperationDetails = createRequestDetails(instanceName, volumeID, "", 0, nil, metav1.Now(),
+			task.Reference().Value, m.virtualCenter.Config.Host, taskInfo.ActivationId, taskInvocationStatusError,
+			volumeOperationRes.Fault.LocalizedMessage)
+		return faultType, logger.LogNewErrorf(log, "failed to update storage policy of volume: %q, fault: %q, opID: %q",
+			volumeID, spew.Sdump(volumeOperationRes.Fault), taskInfo.ActivationId)
+	}
+	log.Infof("UpdateVolumeStoragePolicy: Storage policy %q applied successfully. volumeID: %q, opId: %q",
+		storagePolicyID, volumeID, taskInfo.ActivationId)
+	volumeOperationDetails = createRequestDetails(instanceName, volumeID, "", 0, nil, metav1.Now(),
+		task.Reference().Value, m.virtualCenter.Config.Host, taskInfo.ActivationId, taskInvocationStatusSuccess, "")
+	return "", nil
+}
+
 // GetAllManagerInstances returns all Manager instances
 func GetAllManagerInstances(ctx context.Context) map[string]*defaultManager {
 	newManagerInstanceMap := make(map[string]*defaultManager)
@@ -2,11 +2,21 @@ package volume
 
 import (
 	"context"
+	"reflect"
 	"testing"
 	"time"
 
+	"github.com/agiledragon/gomonkey/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/vmware/govmomi/cns"
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	"github.com/vmware/govmomi/object"
 	vim25types "github.com/vmware/govmomi/vim25/types"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsvolumeoperationrequest"
 )
 
 const createVolumeTaskTimeout = 3 * time.Second
@@ -50,3 +60,104 @@ func performSlowTask(ch chan TaskResult, delay time.Duration) {
 		Err:      nil,
 	}
 }
+
+func TestIsIOAllocationApplied(t *testing.T) {
+	limit := int64(1000)
+	otherLimit := int64(500)
+	current := &vim25types.StorageIOAllocationInfo{
+		Limit:  &limit,
+		Shares: &vim25types.SharesInfo{Level: vim25types.SharesLevelCustom, Shares: 2000},
+	}
+	assert.True(t, isIOAllocationApplied(current, &vim25types.StorageIOAllocationInfo{Limit: &limit}))
+	assert.True(t, isIOAllocationApplied(current, &vim25types.StorageIOAllocationInfo{
+		Shares: &vim25types.SharesInfo{Level: vim25types.SharesLevelCustom, Shares: 2000}}))
+	assert.False(t, isIOAllocationApplied(current, &vim25types.StorageIOAllocationInfo{Limit: &otherLimit}))
+	assert.False(t, isIOAllocationApplied(current, &vim25types.StorageIOAllocationInfo{
+		Shares: &vim25types.SharesInfo{Level: vim25types.SharesLevelCustom, Shares: 1000}}))
+	assert.False(t, isIOAllocationApplied(current, &vim25types.StorageIOAllocationInfo{
+		Shares: &vim25types.SharesInfo{Level: vim25types.SharesLevelHigh}}))
+	assert.False(t, isIOAllocationApplied(&vim25types.StorageIOAllocationInfo{},
+		&vim25types.StorageIOAllocationInfo{Limit: &limit}))
+}
+
+// fakeOperationStore keeps the VolumeOperationRequestDetails in memory.
+type fakeOperationStore struct {
+	details map[string]*cnsvolumeoperationrequest.VolumeOperationRequestDetails
+}
+
+func (f *fakeOperationStore) GetRequestDetails(_ context.Context,
+	name string) (*cnsvolumeoperationrequest.VolumeOperationRequestDetails, error) {
+	instance, ok := f.details[name]
+	if !ok {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "cnsvolumeoperationrequests"}, name)
+	}
+	return instance, nil
+}
+
+func (f *fakeOperationStore) StoreRequestDetails(_ context.Context,
+	instance *cnsvolumeoperationrequest.VolumeOperationRequestDetails) error {
+	f.details[instance.Name] = instance
+	return nil
+}
+
+func (f *fakeOperationStore) DeleteRequestDetails(_ context.Context, name string) error {
+	delete(f.details, name)
+	return nil
+}
+
+// fakeListView completes every added task successfully.
+type fakeListView struct {
+	ListViewIf
+}
+
+func (f *fakeListView) AddTask(_ context.Context, taskMoRef vim25types.ManagedObjectReference,
+	ch chan TaskResult) error {
+	go func() {
+		ch <- TaskResult{
+			TaskInfo: &vim25types.TaskInfo{
+				Task:  taskMoRef,
+				State: vim25types.TaskInfoStateSuccess,
+				Result: cnstypes.CnsVolumeOperationBatchResult{
+					VolumeResults: []cnstypes.BaseCnsVolumeOperationResult{&cnstypes.CnsVolumeOperationResult{}},
+				},
+			},
+		}
+	}()
+	return nil
+}
+
+func (f *fakeListView) RemoveTask(_ context.Context, _ vim25types.ManagedObjectReference) error {
+	return nil
+}
+
+func TestUpdateVolumeStoragePolicyBackAndForth(t *testing.T) {
+	var appliedPolicyIDs []string
+	patches := gomonkey.ApplyMethod(reflect.TypeOf(&cns.Client{}), "ReconfigVolumePolicy",
+		func(_ *cns.Client, _ context.Context, specs []cnstypes.CnsVolumePolicyReconfigSpec) (*object.Task, error) {
+			profile := specs[0].Profile[0].(*vim25types.VirtualMachineDefinedProfileSpec)
+			appliedPolicyIDs = append(appliedPolicyIDs, profile.ProfileId)
+			return object.NewTask(nil, vim25types.ManagedObjectReference{Type: "Task", Value: "task-42"}), nil
+		})
+	defer patches.Reset()
+	operationStore := &fakeOperationStore{
+		details: make(map[string]*cnsvolumeoperationrequest.VolumeOperationRequestDetails),
+	}
+	m := &defaultManager{
+		virtualCenter: &cnsvsphere.VirtualCenter{
+			Config:    &cnsvsphere.VirtualCenterConfig{Host: "vc1.example.com"},
+			CnsClient: &cns.Client{},
+		},
+		operationStore:             operationStore,
+		idempotencyHandlingEnabled: true,
+		listViewIf:                 &fakeListView{},
+	}
+
+	// Every change is applied, including the changes back to an earlier policy.
+	policyIDs := []string{"policy-a", "policy-b", "policy-a", "policy-b"}
+	for _, policyID := range policyIDs {
+		_, err := m.updateVolumeStoragePolicy(context.TODO(), "volume-1", policyID, nil)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, policyIDs, appliedPolicyIDs)
+	assert.Empty(t, operationStore.details)
+}
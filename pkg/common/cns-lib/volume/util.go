@@ -410,6 +410,39 @@ func invokeCNSCreateSnapshot(ctx context.Context, virtualCenter *cnsvsphere.Virt
 	return task, err
 }
 
+// invokeCNSCreateGroupSnapshot invokes a single CreateSnapshot operation on
+// CNS for all the volumes of a volume group snapshot. The snapshot of each
+// volume is described with getGroupSnapshotMemberName.
+func invokeCNSCreateGroupSnapshot(ctx context.Context, virtualCenter *cnsvsphere.VirtualCenter,
+	groupSnapshotName string, volumeIDs []string) (*object.Task, error) {
+	log := logger.GetLogger(ctx)
+	cnsSnapshotCreateSpecList := make([]cnstypes.CnsSnapshotCreateSpec, 0, len(volumeIDs))
+	for _, volumeID := range volumeIDs {
+		cnsSnapshotCreateSpecList = append(cnsSnapshotCreateSpecList, cnstypes.CnsSnapshotCreateSpec{
+			VolumeId: cnstypes.CnsVolumeId{
+				Id: volumeID,
+			},
+			Description: getGroupSnapshotMemberName(groupSnapshotName, volumeID),
+		})
+	}
+
+	log.Infof("Calling CnsClient.CreateSnapshots: VolumeIDs %v GroupSnapshotName [%q]"+
+		" cnsSnapshotCreateSpecList [%#v]", volumeIDs, groupSnapshotName, cnsSnapshotCreateSpecList)
+	task, err := virtualCenter.CnsClient.CreateSnapshots(ctx, cnsSnapshotCreateSpecList)
+	if err != nil {
+		log.Errorf("CNS CreateSnapshots failed from vCenter %q with err: %v", virtualCenter.Config.Host, err)
+		return nil, err
+	}
+
+	return task, err
+}
+
+// getGroupSnapshotMemberName returns the description of the snapshot of the
+// volume in the volume group snapshot.
+func getGroupSnapshotMemberName(groupSnapshotName string, volumeID string) string {
+	return groupSnapshotName + "-" + volumeID
+}
+
 // invokeCNSDeleteSnapshot invokes DeleteSnapshot operation for that volume on CNS.
 func invokeCNSDeleteSnapshot(ctx context.Context, virtualCenter *cnsvsphere.VirtualCenter,
 	volumeID string, snapshotID string) (*object.Task, error) {
@@ -557,3 +590,20 @@ func IsNotFoundFault(ctx context.Context, faultType string) bool {
 	return faultType == "vim.fault.NotFound"
 
 }
+
+// isIOAllocationApplied returns true if the limit and shares requested in
+// desired are already set in current.
+func isIOAllocationApplied(current *types.StorageIOAllocationInfo, desired *types.StorageIOAllocationInfo) bool {
+	if desired.Limit != nil && (current.Limit == nil || *current.Limit != *desired.Limit) {
+		return false
+	}
+	if desired.Shares != nil {
+		if current.Shares == nil || current.Shares.Level != desired.Shares.Level {
+			return false
+		}
+		if desired.Shares.Level == types.SharesLevelCustom && current.Shares.Shares != desired.Shares.Shares {
+			return false
+		}
+	}
+	return true
+}
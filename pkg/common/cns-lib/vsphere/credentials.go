@@ -0,0 +1,437 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vsphere
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+)
+
+const (
+	// credentialProviderTimeout is the time allowed to the exec and http
+	// credential providers to return the credentials.
+	credentialProviderTimeout = 30 * time.Second
+	// credentialExpirySkew is the time before their expiration at which the
+	// credentials are fetched again.
+	credentialExpirySkew = 30 * time.Second
+	// vCenterHostEnvVar is the environment variable holding the vCenter host
+	// passed to the command of the exec credential provider.
+	vCenterHostEnvVar = "VSPHERE_CSI_VCENTER_HOST"
+	// vaultTokenHeader is the header holding the token sent by the http
+	// credential provider.
+	vaultTokenHeader = "X-Vault-Token"
+)
+
+var (
+	// credentialProviders maps the vCenter host to its credential provider.
+	// Configs of the vCenter reloaded with unchanged credential provider
+	// settings get the same provider, which keeps the credentials it cached.
+	credentialProviders      = make(map[string]*sharedCredentialProvider)
+	credentialProvidersMutex = &sync.Mutex{}
+)
+
+// Credentials are the credentials used to log in to a vCenter.
+type Credentials struct {
+	// Username is the vCenter username.
+	Username string `json:"username"`
+	// Password is the vCenter password in clear text.
+	Password string `json:"password"`
+	// ExpirationTimestamp is the time at which the credentials expire.
+	// Optional; credentials without expiration are fetched again after the
+	// refresh interval of the provider.
+	ExpirationTimestamp *time.Time `json:"expirationTimestamp,omitempty"`
+}
+
+// CredentialProvider returns the current credentials of a vCenter. The
+// credentials returned may change over time, in which case a new session is
+// created with the new credentials.
+type CredentialProvider interface {
+	// GetCredentials returns the current credentials of the vCenter.
+	GetCredentials(ctx context.Context) (*Credentials, error)
+}
+
+// execCredential is the output of the command of the exec credential
+// provider. It follows the layout of the client-go ExecCredential.
+type execCredential struct {
+	APIVersion string       `json:"apiVersion,omitempty"`
+	Kind       string       `json:"kind,omitempty"`
+	Status     *Credentials `json:"status"`
+}
+
+// vaultSecret is the response of the http credential provider endpoint. The
+// credentials are read from data for Vault KV version 1 secrets, and from
+// data.data for KV version 2 secrets.
+type vaultSecret struct {
+	LeaseDuration int             `json:"lease_duration"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// NewCredentialProvider returns the credential provider configured for the
+// vCenter host in vcConfig, or nil if the static user and password of
+// vcConfig are used. The provider of the vCenter is reused if its settings
+// did not change.
+func NewCredentialProvider(host string, vcConfig *config.VirtualCenterConfig) (CredentialProvider, error) {
+	if vcConfig.CredentialProvider == "" {
+		return nil, nil
+	}
+	settings := newCredentialSettings(vcConfig)
+	credentialProvidersMutex.Lock()
+	defer credentialProvidersMutex.Unlock()
+	if provider, ok := credentialProviders[host]; ok && provider.settings == settings {
+		return provider, nil
+	}
+	provider, err := newCredentialProvider(host, vcConfig)
+	if err != nil {
+		return nil, err
+	}
+	sharedProvider := &sharedCredentialProvider{CredentialProvider: provider, settings: settings}
+	credentialProviders[host] = sharedProvider
+	return sharedProvider, nil
+}
+
+// newCredentialProvider creates the credential provider configured for the
+// vCenter host in vcConfig.
+func newCredentialProvider(host string, vcConfig *config.VirtualCenterConfig) (CredentialProvider, error) {
+	refreshInterval := time.Duration(vcConfig.CredentialRefreshIntervalInSec) * time.Second
+	if refreshInterval <= 0 {
+		refreshInterval = config.DefaultCredentialRefreshIntervalInSec * time.Second
+	}
+	switch vcConfig.CredentialProvider {
+	case config.CredentialProviderFile:
+		return &fileCredentialProvider{path: vcConfig.CredentialFile}, nil
+	case config.CredentialProviderExec:
+		return &cachedCredentialProvider{
+			refreshInterval: refreshInterval,
+			fetch: (&execCredentialProvider{
+				host:    host,
+				command: vcConfig.CredentialExecCommand,
+				args:    vcConfig.CredentialExecArgs,
+			}).fetch,
+		}, nil
+	case config.CredentialProviderHTTP:
+		httpClient, err := newCredentialHTTPClient(vcConfig.CredentialCAFile)
+		if err != nil {
+			return nil, err
+		}
+		return &cachedCredentialProvider{
+			refreshInterval: refreshInterval,
+			fetch: (&httpCredentialProvider{
+				url:        vcConfig.CredentialURL,
+				tokenFile:  vcConfig.CredentialTokenFile,
+				httpClient: httpClient,
+			}).fetch,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown credential provider %q",
+		config.ErrInvalidCredentialProvider, vcConfig.CredentialProvider)
+}
+
+// credentialSettings are the settings of a vCenter config which its
+// credential provider is created from.
+type credentialSettings struct {
+	provider        string
+	file            string
+	execCommand     string
+	execArgs        string
+	url             string
+	tokenFile       string
+	caFile          string
+	refreshInterval int
+}
+
+func newCredentialSettings(vcConfig *config.VirtualCenterConfig) credentialSettings {
+	return credentialSettings{
+		provider:        vcConfig.CredentialProvider,
+		file:            vcConfig.CredentialFile,
+		execCommand:     vcConfig.CredentialExecCommand,
+		execArgs:        strings.Join(vcConfig.CredentialExecArgs, "\x00"),
+		url:             vcConfig.CredentialURL,
+		tokenFile:       vcConfig.CredentialTokenFile,
+		caFile:          vcConfig.CredentialCAFile,
+		refreshInterval: vcConfig.CredentialRefreshIntervalInSec,
+	}
+}
+
+// sharedCredentialProvider is the credential provider shared by the configs
+// of a vCenter. It remembers the credentials it returned last, which are the
+// ones the current session of the vCenter was created with.
+type sharedCredentialProvider struct {
+	CredentialProvider
+	settings    credentialSettings
+	mutex       sync.Mutex
+	credentials *Credentials
+}
+
+func (p *sharedCredentialProvider) GetCredentials(ctx context.Context) (*Credentials, error) {
+	credentials, err := p.CredentialProvider.GetCredentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.credentials = credentials
+	return credentials, nil
+}
+
+// lastCredentials returns the credentials returned last by the provider, or
+// nil if it did not return any yet.
+func (p *sharedCredentialProvider) lastCredentials() *Credentials {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.credentials
+}
+
+// setCredentialProvider sets the credential provider configured in cfg for
+// the vCenter of vcConfig. If the provider is kept from a previous config of
+// the vCenter, the credentials it returned last are set as well, so that the
+// username is known before connecting and the session is not recreated.
+func (vcConfig *VirtualCenterConfig) setCredentialProvider(cfg *config.VirtualCenterConfig) error {
+	provider, err := NewCredentialProvider(vcConfig.Host, cfg)
+	if err != nil {
+		return err
+	}
+	vcConfig.CredentialProvider = provider
+	if sharedProvider, ok := provider.(*sharedCredentialProvider); ok {
+		if credentials := sharedProvider.lastCredentials(); credentials != nil {
+			vcConfig.Username = credentials.Username
+			vcConfig.Password = credentials.Password
+		}
+	}
+	return nil
+}
+
+// validateCredentials checks that the credentials have a username and a
+// password.
+func validateCredentials(credentials *Credentials) error {
+	if credentials == nil || credentials.Username == "" {
+		return config.ErrUsernameMissing
+	}
+	// The username is recorded in the CNS metadata of the volumes and must
+	// contain the domain, like the user configured in the config secret.
+	if !config.IsValidvCenterUsernameWithDomain(credentials.Username) {
+		return config.ErrInvalidUsername
+	}
+	if credentials.Password == "" {
+		return config.ErrPasswordMissing
+	}
+	return nil
+}
+
+// cachedCredentialProvider caches the credentials returned by fetch until
+// the refresh interval elapsed or the credentials are about to expire.
+type cachedCredentialProvider struct {
+	refreshInterval time.Duration
+	fetch           func(ctx context.Context) (*Credentials, error)
+	mutex           sync.Mutex
+	credentials     *Credentials
+	fetchedAt       time.Time
+}
+
+func (p *cachedCredentialProvider) GetCredentials(ctx context.Context) (*Credentials, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	now := time.Now()
+	if p.credentials != nil && now.Sub(p.fetchedAt) < p.refreshInterval &&
+		(p.credentials.ExpirationTimestamp == nil ||
+			now.Add(credentialExpirySkew).Before(*p.credentials.ExpirationTimestamp)) {
+		return p.credentials, nil
+	}
+	credentials, err := p.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = validateCredentials(credentials); err != nil {
+		return nil, err
+	}
+	p.credentials = credentials
+	p.fetchedAt = now
+	return credentials, nil
+}
+
+// fileCredentialProvider reads the credentials from a JSON file. The file is
+// read again whenever its modification time changes, so rotated Kubernetes
+// secret mounts are picked up.
+type fileCredentialProvider struct {
+	path        string
+	mutex       sync.Mutex
+	credentials *Credentials
+	modTime     time.Time
+}
+
+func (p *fileCredentialProvider) GetCredentials(ctx context.Context) (*Credentials, error) {
+	log := logger.GetLogger(ctx)
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to stat credential file %q. Err: %v", p.path, err)
+	}
+	if p.credentials != nil && info.ModTime().Equal(p.modTime) {
+		return p.credentials, nil
+	}
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to read credential file %q. Err: %v", p.path, err)
+	}
+	credentials := &Credentials{}
+	if err = json.Unmarshal(data, credentials); err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to parse credential file %q. Err: %v", p.path, err)
+	}
+	if err = validateCredentials(credentials); err != nil {
+		return nil, logger.LogNewErrorf(log, "invalid credentials in file %q. Err: %v", p.path, err)
+	}
+	log.Infof("Read vCenter credentials from file %q", p.path)
+	p.credentials = credentials
+	p.modTime = info.ModTime()
+	return credentials, nil
+}
+
+// execCredentialProvider runs a command printing the credentials on stdout,
+// like the client-go exec credential plugins. The vCenter host is passed to
+// the command in the VSPHERE_CSI_VCENTER_HOST environment variable.
+type execCredentialProvider struct {
+	host    string
+	command string
+	args    []string
+}
+
+func (p *execCredentialProvider) fetch(ctx context.Context) (*Credentials, error) {
+	log := logger.GetLogger(ctx)
+	ctx, cancel := context.WithTimeout(ctx, credentialProviderTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, p.command, p.args...)
+	cmd.Env = append(os.Environ(), vCenterHostEnvVar+"="+p.host)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, logger.LogNewErrorf(log, "credential command %q failed. Err: %v, stderr: %q",
+			p.command, err, strings.TrimSpace(stderr.String()))
+	}
+	output := &execCredential{}
+	if err := json.Unmarshal(stdout.Bytes(), output); err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to parse output of credential command %q. Err: %v",
+			p.command, err)
+	}
+	if output.Status == nil {
+		return nil, logger.LogNewErrorf(log, "output of credential command %q has no status", p.command)
+	}
+	log.Infof("Fetched vCenter credentials for %q with command %q", p.host, p.command)
+	return output.Status, nil
+}
+
+// httpCredentialProvider reads the credentials from an HTTP secret endpoint
+// compatible with the Vault KV secrets engine.
+type httpCredentialProvider struct {
+	url        string
+	tokenFile  string
+	httpClient *http.Client
+}
+
+// newCredentialHTTPClient returns the HTTP client of the http credential
+// provider, verifying the endpoint with the CA certificate in caFile if set.
+func newCredentialHTTPClient(caFile string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if caFile != "" {
+		caCert, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read credential CA file %q. Err: %v", caFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to parse credential CA file %q", caFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &http.Client{Transport: transport, Timeout: credentialProviderTimeout}, nil
+}
+
+func (p *httpCredentialProvider) fetch(ctx context.Context) (*Credentials, error) {
+	log := logger.GetLogger(ctx)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to create request for credential URL %q. Err: %v", p.url, err)
+	}
+	if p.tokenFile != "" {
+		// The token is read on every request as it may be rotated as well.
+		token, err := os.ReadFile(p.tokenFile)
+		if err != nil {
+			return nil, logger.LogNewErrorf(log, "failed to read credential token file %q. Err: %v",
+				p.tokenFile, err)
+		}
+		req.Header.Set(vaultTokenHeader, strings.TrimSpace(string(token)))
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to fetch credentials from %q. Err: %v", p.url, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to read credentials from %q. Err: %v", p.url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, logger.LogNewErrorf(log, "failed to fetch credentials from %q. Status: %s", p.url, resp.Status)
+	}
+	credentials, err := parseVaultSecret(body, time.Now())
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to parse credentials from %q. Err: %v", p.url, err)
+	}
+	log.Infof("Fetched vCenter credentials from %q", p.url)
+	return credentials, nil
+}
+
+// parseVaultSecret returns the credentials in the Vault KV secret body. The
+// lease duration of the secret, if any, is used as expiration of credentials
+// which do not have one.
+func parseVaultSecret(body []byte, now time.Time) (*Credentials, error) {
+	secret := &vaultSecret{}
+	if err := json.Unmarshal(body, secret); err != nil {
+		return nil, err
+	}
+	if len(secret.Data) == 0 {
+		return nil, fmt.Errorf("secret has no data")
+	}
+	kvV2 := struct {
+		Data *Credentials `json:"data"`
+	}{}
+	credentials := &Credentials{}
+	if err := json.Unmarshal(secret.Data, &kvV2); err == nil && kvV2.Data != nil {
+		credentials = kvV2.Data
+	} else if err := json.Unmarshal(secret.Data, credentials); err != nil {
+		return nil, err
+	}
+	if credentials.ExpirationTimestamp == nil && secret.LeaseDuration > 0 {
+		expiration := now.Add(time.Duration(secret.LeaseDuration) * time.Second)
+		credentials.ExpirationTimestamp = &expiration
+	}
+	return credentials, nil
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vsphere
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+)
+
+func TestFileCredentialProviderRotation(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	err := os.WriteFile(path, []byte(`{"username": "user-1@vsphere.local", "password": "pass-1"}`), 0600)
+	assert.Nil(t, err)
+	provider, err := NewCredentialProvider("vc-file", &config.VirtualCenterConfig{
+		CredentialProvider: config.CredentialProviderFile,
+		CredentialFile:     path,
+	})
+	assert.Nil(t, err)
+	vc := &VirtualCenter{Config: &VirtualCenterConfig{Host: "vc-file", CredentialProvider: provider}}
+
+	rotated, err := vc.refreshCredentials(ctx)
+	assert.Nil(t, err)
+	assert.True(t, rotated)
+	assert.Equal(t, "user-1@vsphere.local", vc.Config.Username)
+	rotated, err = vc.refreshCredentials(ctx)
+	assert.Nil(t, err)
+	assert.False(t, rotated)
+
+	err = os.WriteFile(path, []byte(`{"username": "user-1@vsphere.local", "password": "pass-2"}`), 0600)
+	assert.Nil(t, err)
+	err = os.Chtimes(path, time.Now(), time.Now().Add(time.Minute))
+	assert.Nil(t, err)
+	rotated, err = vc.refreshCredentials(ctx)
+	assert.Nil(t, err)
+	assert.True(t, rotated)
+	assert.Equal(t, "pass-2", vc.Config.Password)
+}
+
+func TestExecCredentialProvider(t *testing.T) {
+	ctx := context.Background()
+	provider, err := NewCredentialProvider("vc-exec", &config.VirtualCenterConfig{
+		CredentialProvider:    config.CredentialProviderExec,
+		CredentialExecCommand: "sh",
+		CredentialExecArgs: []string{"-c", `echo '{"apiVersion": "v1", "kind": "ExecCredential", ` +
+			`"status": {"username": "user@'$VSPHERE_CSI_VCENTER_HOST'", "password": "pass"}}'`},
+	})
+	assert.Nil(t, err)
+	credentials, err := provider.GetCredentials(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "user@vc-exec", credentials.Username)
+	assert.Equal(t, "pass", credentials.Password)
+
+	provider, err = NewCredentialProvider("vc-exec", &config.VirtualCenterConfig{
+		CredentialProvider:    config.CredentialProviderExec,
+		CredentialExecCommand: "sh",
+		CredentialExecArgs:    []string{"-c", `echo '{"status": {"username": "user@vsphere.local"}}'`},
+	})
+	assert.Nil(t, err)
+	_, err = provider.GetCredentials(ctx)
+	assert.ErrorIs(t, err, config.ErrPasswordMissing)
+
+	// The username is recorded in the CNS metadata and must contain the domain.
+	provider, err = NewCredentialProvider("vc-exec", &config.VirtualCenterConfig{
+		CredentialProvider:    config.CredentialProviderExec,
+		CredentialExecCommand: "sh",
+		CredentialExecArgs:    []string{"-c", `echo '{"status": {"username": "user", "password": "pass"}}'`},
+	})
+	assert.Nil(t, err)
+	_, err = provider.GetCredentials(ctx)
+	assert.ErrorIs(t, err, config.ErrInvalidUsername)
+}
+
+func TestHTTPCredentialProvider(t *testing.T) {
+	ctx := context.Background()
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get(vaultTokenHeader) != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"lease_duration": 0, "data": {"data": {"username": "user@vsphere.local", ` +
+			`"password": "pass"}, "metadata": {"version": 1}}}`))
+	}))
+	defer server.Close()
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	err := os.WriteFile(tokenFile, []byte("token\n"), 0600)
+	assert.Nil(t, err)
+
+	provider, err := NewCredentialProvider("vc-http", &config.VirtualCenterConfig{
+		CredentialProvider:             config.CredentialProviderHTTP,
+		CredentialURL:                  server.URL,
+		CredentialTokenFile:            tokenFile,
+		CredentialRefreshIntervalInSec: 3600,
+	})
+	assert.Nil(t, err)
+	credentials, err := provider.GetCredentials(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "user@vsphere.local", credentials.Username)
+	assert.Equal(t, "pass", credentials.Password)
+	// The credentials are cached until the refresh interval elapsed.
+	_, err = provider.GetCredentials(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, requests)
+}
+
+func TestCredentialProviderReusedForUnchangedSettings(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	err := os.WriteFile(path, []byte(`{"username": "user@vsphere.local", "password": "pass"}`), 0600)
+	assert.Nil(t, err)
+	cfg := &config.Config{
+		VirtualCenter: map[string]*config.VirtualCenterConfig{
+			"vc-reuse": {
+				VCenterPort:        "443",
+				CredentialProvider: config.CredentialProviderFile,
+				CredentialFile:     path,
+			},
+		},
+	}
+
+	vcConfig, err := GetVirtualCenterConfig(ctx, cfg)
+	assert.Nil(t, err)
+	// The user is not known before the provider returned the credentials.
+	assert.Equal(t, "", vcConfig.Username)
+	vc := &VirtualCenter{Config: vcConfig}
+	_, err = vc.refreshCredentials(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "user@vsphere.local", vc.Config.Username)
+
+	// Reloading the config with the same settings keeps the provider and
+	// the credentials it returned.
+	reloadedVCConfig, err := GetVirtualCenterConfig(ctx, cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, vcConfig.CredentialProvider, reloadedVCConfig.CredentialProvider)
+	assert.Equal(t, "user@vsphere.local", reloadedVCConfig.Username)
+	assert.Equal(t, "pass", reloadedVCConfig.Password)
+	reloadedVCConfigs, err := GetVirtualCenterConfigs(ctx, cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, vcConfig.CredentialProvider, reloadedVCConfigs[0].CredentialProvider)
+	assert.Equal(t, "user@vsphere.local", reloadedVCConfigs[0].Username)
+
+	// Changing the settings replaces the provider.
+	cfg.VirtualCenter["vc-reuse"].CredentialRefreshIntervalInSec = 60
+	changedVCConfig, err := GetVirtualCenterConfig(ctx, cfg)
+	assert.Nil(t, err)
+	assert.NotEqual(t, vcConfig.CredentialProvider, changedVCConfig.CredentialProvider)
+	assert.Equal(t, "", changedVCConfig.Username)
+}
+
+func TestGetVCenterUser(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.VirtualCenterConfig{User: "user@vsphere.local"}
+	assert.Equal(t, "user@vsphere.local", GetVCenterUser(ctx, "vc-user", cfg))
+
+	// With a credential provider, the user is the one the registered
+	// virtual center got from the provider.
+	cfg = &config.VirtualCenterConfig{CredentialProvider: config.CredentialProviderFile}
+	_, err := GetVirtualCenterManager(ctx).RegisterVirtualCenter(ctx, &VirtualCenterConfig{
+		Host:     "vc-user",
+		Username: "provided@vsphere.local",
+	})
+	assert.Nil(t, err)
+	defer func() {
+		_ = GetVirtualCenterManager(ctx).UnregisterVirtualCenter(ctx, "vc-user")
+	}()
+	assert.Equal(t, "provided@vsphere.local", GetVCenterUser(ctx, "vc-user", cfg))
+}
+
+func TestParseVaultSecret(t *testing.T) {
+	now := time.Now()
+	credentials, err := parseVaultSecret([]byte(`{"lease_duration": 600, `+
+		`"data": {"username": "user", "password": "pass"}}`), now)
+	assert.Nil(t, err)
+	assert.Equal(t, "user", credentials.Username)
+	assert.Equal(t, "pass", credentials.Password)
+	assert.Equal(t, now.Add(10*time.Minute), *credentials.ExpirationTimestamp)
+
+	_, err = parseVaultSecret([]byte(`{"errors": ["permission denied"]}`), now)
+	assert.NotNil(t, err)
+}
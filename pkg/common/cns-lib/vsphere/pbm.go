@@ -23,6 +23,7 @@ import (
 	"github.com/vmware/govmomi/pbm"
 	pbmmethods "github.com/vmware/govmomi/pbm/methods"
 	pbmtypes "github.com/vmware/govmomi/pbm/types"
+	"github.com/vmware/govmomi/vim25"
 	vimtypes "github.com/vmware/govmomi/vim25/types"
 
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
@@ -53,6 +54,16 @@ type SpbmPolicyContent struct {
 	Profiles []SpbmPolicySubProfile `json:"profiles"`
 }
 
+// NewPbmClient creates a new PBM client.
+func NewPbmClient(ctx context.Context, c *vim25.Client) (*pbm.Client, error) {
+	pbmClient, err := pbm.NewClient(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	pbmClient.RoundTripper = &MetricRoundTripper{"pbm", newThrottledRoundTripper(c, "pbm", pbmClient.RoundTripper)}
+	return pbmClient, nil
+}
+
 // ConnectPbm creates a PBM client for the virtual center.
 func (vc *VirtualCenter) ConnectPbm(ctx context.Context) error {
 	log := logger.GetLogger(ctx)
@@ -62,7 +73,7 @@ func (vc *VirtualCenter) ConnectPbm(ctx context.Context) error {
 		return err
 	}
 	if vc.PbmClient == nil {
-		if vc.PbmClient, err = pbm.NewClient(ctx, vc.Client.Client); err != nil {
+		if vc.PbmClient, err = NewPbmClient(ctx, vc.Client.Client); err != nil {
 			log.Errorf("failed to create pbm client with err: %v", err)
 			return err
 		}
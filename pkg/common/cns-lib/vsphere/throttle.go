@@ -0,0 +1,302 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"golang.org/x/time/rate"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/prometheus"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+)
+
+// propertyCollectorClientName is the client name under which the property
+// collector calls of the vim25 client are rate limited, separately from its
+// other calls.
+const propertyCollectorClientName = "propertycollector"
+
+// ErrCircuitBreakerOpen is returned for calls to a vCenter which are rejected
+// because the circuit breaker of the vCenter is open.
+var ErrCircuitBreakerOpen = errors.New("vCenter circuit breaker is open")
+
+var (
+	// vCenterThrottles maps the vCenter host to the rate limiters and circuit
+	// breaker of its API calls. They are kept across clients of the vCenter.
+	vCenterThrottles      = make(map[string]*vCenterThrottle)
+	vCenterThrottlesMutex = &sync.RWMutex{}
+)
+
+// vCenterThrottle holds the rate limiters of the API calls to a vCenter,
+// keyed by client name, and its circuit breaker.
+type vCenterThrottle struct {
+	config   config.VCenterRateLimitConfig
+	limiters map[string]*rate.Limiter
+	breaker  *circuitBreaker
+}
+
+// circuitBreaker rejects calls to a vCenter for openDuration once
+// failureThreshold consecutive calls failed. A single call is let through
+// afterwards, which closes the circuit breaker if it succeeds.
+type circuitBreaker struct {
+	host                string
+	failureThreshold    int
+	openDuration        time.Duration
+	mutex               sync.Mutex
+	consecutiveFailures int
+	openUntil           time.Time
+	probeInFlight       bool
+}
+
+// IsCircuitBreakerOpenError returns true if err was caused by a call rejected
+// by the circuit breaker of a vCenter. The error message is checked as errors
+// are often wrapped without preserving the original error.
+func IsCircuitBreakerOpenError(err error) bool {
+	return err != nil && (errors.Is(err, ErrCircuitBreakerOpen) ||
+		strings.Contains(err.Error(), ErrCircuitBreakerOpen.Error()))
+}
+
+// configureVCenterThrottle sets up the rate limiters and circuit breaker for
+// the API calls to host. The existing ones are kept if the configuration did
+// not change.
+func configureVCenterThrottle(ctx context.Context, host string, cfg config.VCenterRateLimitConfig) {
+	log := logger.GetLogger(ctx)
+	vCenterThrottlesMutex.Lock()
+	defer vCenterThrottlesMutex.Unlock()
+	if throttle, ok := vCenterThrottles[host]; ok && throttle.config == cfg {
+		return
+	}
+	throttle := &vCenterThrottle{
+		config:   cfg,
+		limiters: make(map[string]*rate.Limiter),
+	}
+	for clientName, limit := range map[string][2]int{
+		"cns":                       {cfg.CnsQPS, cfg.CnsBurst},
+		"pbm":                       {cfg.PbmQPS, cfg.PbmBurst},
+		"vslm":                      {cfg.VslmQPS, cfg.VslmBurst},
+		"vsan":                      {cfg.VsanQPS, cfg.VsanBurst},
+		"soap":                      {cfg.VimQPS, cfg.VimBurst},
+		propertyCollectorClientName: {cfg.PropertyCollectorQPS, cfg.PropertyCollectorBurst},
+	} {
+		qps, burst := limit[0], limit[1]
+		if qps <= 0 {
+			continue
+		}
+		if burst < qps {
+			burst = qps
+		}
+		throttle.limiters[clientName] = rate.NewLimiter(rate.Limit(qps), burst)
+		log.Infof("Limiting %s API calls to vCenter %q to %d per second with a burst of %d",
+			clientName, host, qps, burst)
+	}
+	if cfg.CircuitBreakerFailureThreshold > 0 {
+		throttle.breaker = &circuitBreaker{
+			host:             host,
+			failureThreshold: cfg.CircuitBreakerFailureThreshold,
+			openDuration:     time.Duration(cfg.CircuitBreakerOpenDurationInSec) * time.Second,
+		}
+		log.Infof("Enabled circuit breaker for vCenter %q after %d consecutive failures",
+			host, cfg.CircuitBreakerFailureThreshold)
+	}
+	vCenterThrottles[host] = throttle
+}
+
+// getVCenterThrottle returns the rate limiters and circuit breaker for the
+// API calls to host, or nil if they are not configured.
+func getVCenterThrottle(host string) *vCenterThrottle {
+	vCenterThrottlesMutex.RLock()
+	defer vCenterThrottlesMutex.RUnlock()
+	return vCenterThrottles[host]
+}
+
+// allow returns true if a call may be made to the vCenter.
+func (cb *circuitBreaker) allow() bool {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	if cb.consecutiveFailures < cb.failureThreshold {
+		return true
+	}
+	if time.Now().Before(cb.openUntil) || cb.probeInFlight {
+		return false
+	}
+	// Let a single call through to check if the vCenter recovered.
+	cb.probeInFlight = true
+	return true
+}
+
+// abort releases a call allowed by allow which was not made.
+func (cb *circuitBreaker) abort() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.probeInFlight = false
+}
+
+// record records the result of a call allowed by allow.
+func (cb *circuitBreaker) record(failed bool) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.probeInFlight = false
+	if !failed {
+		cb.consecutiveFailures = 0
+		prometheus.VCenterCircuitBreakerOpenGaugeVec.WithLabelValues(cb.host).Set(0)
+		return
+	}
+	cb.consecutiveFailures++
+	if cb.consecutiveFailures >= cb.failureThreshold {
+		cb.openUntil = time.Now().Add(cb.openDuration)
+		prometheus.VCenterCircuitBreakerOpenGaugeVec.WithLabelValues(cb.host).Set(1)
+	}
+}
+
+// isVCenterUnavailableError returns true if err indicates that the vCenter is
+// unavailable, i.e. the call failed with an HTTP error other than a SOAP
+// fault, a network error or a session error. Faults returned for the call
+// itself are not counted.
+func isVCenterUnavailableError(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	if soap.IsSoapFault(err) {
+		_, ok := soap.ToSoapFault(err).VimFault().(types.NotAuthenticated)
+		return ok
+	}
+	return !soap.IsVimFault(err)
+}
+
+// call makes a call of clientName to host once the rate limiter and circuit
+// breaker of the vCenter allow it. makeCall returns whether the vCenter is
+// unavailable and the error of the call.
+func (throttle *vCenterThrottle) call(ctx context.Context, host string, clientName string,
+	makeCall func() (bool, error)) error {
+	if throttle.breaker != nil && !throttle.breaker.allow() {
+		prometheus.VCenterRejectedRequestsCounterVec.WithLabelValues(host, clientName).Inc()
+		return fmt.Errorf("%w for %q", ErrCircuitBreakerOpen, host)
+	}
+	if limiter, ok := throttle.limiters[clientName]; ok && !limiter.Allow() {
+		prometheus.VCenterThrottledRequestsCounterVec.WithLabelValues(host, clientName).Inc()
+		if err := limiter.Wait(ctx); err != nil {
+			if throttle.breaker != nil {
+				throttle.breaker.abort()
+			}
+			return err
+		}
+	}
+	unavailable, err := makeCall()
+	if throttle.breaker != nil {
+		throttle.breaker.record(unavailable)
+	}
+	return err
+}
+
+// throttledRoundTripper applies the rate limiter and circuit breaker of the
+// vCenter to the calls made by a client.
+type throttledRoundTripper struct {
+	host         string
+	clientName   string
+	roundTripper soap.RoundTripper
+}
+
+// newThrottledRoundTripper returns a round tripper which applies the rate
+// limiter and circuit breaker of the vCenter of c to the calls made with rt.
+func newThrottledRoundTripper(c *vim25.Client, clientName string, rt soap.RoundTripper) soap.RoundTripper {
+	return &throttledRoundTripper{
+		host:         c.URL().Hostname(),
+		clientName:   clientName,
+		roundTripper: rt,
+	}
+}
+
+func (trt *throttledRoundTripper) RoundTrip(ctx context.Context, req, resp soap.HasFault) error {
+	throttle := getVCenterThrottle(trt.host)
+	if throttle == nil {
+		return trt.roundTripper.RoundTrip(ctx, req, resp)
+	}
+	clientName := trt.clientName
+	if clientName == "soap" && isPropertyCollectorCall(req) {
+		clientName = propertyCollectorClientName
+	}
+	return throttle.call(ctx, trt.host, clientName, func() (bool, error) {
+		err := trt.roundTripper.RoundTrip(ctx, req, resp)
+		return isVCenterUnavailableError(ctx, err), err
+	})
+}
+
+// isPropertyCollectorCall returns true if req is a call to a property
+// collector.
+func isPropertyCollectorCall(req soap.HasFault) bool {
+	switch req.(type) {
+	case *methods.RetrievePropertiesBody, *methods.RetrievePropertiesExBody,
+		*methods.ContinueRetrievePropertiesExBody, *methods.CancelRetrievePropertiesExBody,
+		*methods.WaitForUpdatesBody, *methods.WaitForUpdatesExBody, *methods.CheckForUpdatesBody,
+		*methods.CancelWaitForUpdatesBody, *methods.CreateFilterBody, *methods.DestroyPropertyFilterBody,
+		*methods.CreatePropertyCollectorBody, *methods.DestroyPropertyCollectorBody:
+		return true
+	}
+	return false
+}
+
+// throttledTransport applies the rate limiter and circuit breaker of the
+// vCenter to the HTTP requests of clients which do not allow wrapping their
+// SOAP round tripper, like the vSLM client.
+type throttledTransport struct {
+	host       string
+	clientName string
+	transport  http.RoundTripper
+}
+
+// newThrottledTransport returns an HTTP transport which applies the rate
+// limiter and circuit breaker of the vCenter of c to the requests sent with
+// transport.
+func newThrottledTransport(c *soap.Client, clientName string, transport http.RoundTripper) http.RoundTripper {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &throttledTransport{
+		host:       c.URL().Hostname(),
+		clientName: clientName,
+		transport:  transport,
+	}
+}
+
+func (tt *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	throttle := getVCenterThrottle(tt.host)
+	if throttle == nil {
+		return tt.transport.RoundTrip(req)
+	}
+	var resp *http.Response
+	err := throttle.call(req.Context(), tt.host, tt.clientName, func() (bool, error) {
+		var err error
+		resp, err = tt.transport.RoundTrip(req)
+		if err != nil {
+			return req.Context().Err() == nil, err
+		}
+		// SOAP faults are returned with status 500.
+		return resp.StatusCode > http.StatusInternalServerError, nil
+	})
+	return resp, err
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	cb := &circuitBreaker{
+		host:             "vc-1",
+		failureThreshold: 2,
+		openDuration:     time.Hour,
+	}
+	assert.True(t, cb.allow())
+	cb.record(true)
+	assert.True(t, cb.allow())
+	cb.record(true)
+	// The circuit breaker is open after 2 consecutive failures.
+	assert.False(t, cb.allow())
+
+	// A single call is let through once the open duration has passed.
+	cb.openUntil = time.Now()
+	assert.True(t, cb.allow())
+	assert.False(t, cb.allow())
+	cb.record(false)
+	assert.True(t, cb.allow())
+	assert.True(t, cb.allow())
+}
+
+func TestVCenterThrottleCall(t *testing.T) {
+	ctx := context.Background()
+	configureVCenterThrottle(ctx, "vc-throttle-test", config.VCenterRateLimitConfig{
+		CnsQPS:                          1,
+		CircuitBreakerFailureThreshold:  1,
+		CircuitBreakerOpenDurationInSec: 3600,
+	})
+	throttle := getVCenterThrottle("vc-throttle-test")
+	assert.NotNil(t, throttle)
+	assert.NotNil(t, throttle.limiters["cns"])
+	assert.Nil(t, throttle.limiters["pbm"])
+
+	calls := 0
+	err := throttle.call(ctx, "vc-throttle-test", "pbm", func() (bool, error) {
+		calls++
+		return true, errors.New("503 Service Unavailable")
+	})
+	assert.NotNil(t, err)
+	err = throttle.call(ctx, "vc-throttle-test", "cns", func() (bool, error) {
+		calls++
+		return false, nil
+	})
+	assert.True(t, IsCircuitBreakerOpenError(err))
+	assert.True(t, IsCircuitBreakerOpenError(fmt.Errorf("failed to create volume. Error: %v", err)))
+	assert.Equal(t, 1, calls)
+}
+
+// fakeRoundTripper counts the calls made through it.
+type fakeRoundTripper struct {
+	calls int
+}
+
+func (rt *fakeRoundTripper) RoundTrip(ctx context.Context, req, resp soap.HasFault) error {
+	rt.calls++
+	return nil
+}
+
+func TestThrottledRoundTripperBuckets(t *testing.T) {
+	ctx := context.Background()
+	host := "vc-property-collector-test"
+	configureVCenterThrottle(ctx, host, config.VCenterRateLimitConfig{
+		VimQPS:               1,
+		PropertyCollectorQPS: 1,
+		VsanQPS:              1,
+	})
+	throttle := getVCenterThrottle(host)
+	assert.NotNil(t, throttle.limiters["vsan"])
+	vimRoundTripper := &fakeRoundTripper{}
+	trt := &throttledRoundTripper{host: host, clientName: "soap", roundTripper: vimRoundTripper}
+
+	// A property collector call only takes a token from its own bucket.
+	err := trt.RoundTrip(ctx, &methods.RetrievePropertiesExBody{}, &methods.RetrievePropertiesExBody{})
+	assert.NoError(t, err)
+	assert.Less(t, throttle.limiters[propertyCollectorClientName].Tokens(), 1.0)
+	assert.Equal(t, 1.0, throttle.limiters["soap"].Tokens())
+
+	err = trt.RoundTrip(ctx, &methods.LoginBody{}, &methods.LoginBody{})
+	assert.NoError(t, err)
+	assert.Less(t, throttle.limiters["soap"].Tokens(), 1.0)
+	assert.Equal(t, 2, vimRoundTripper.calls)
+}
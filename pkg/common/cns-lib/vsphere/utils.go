@@ -150,6 +150,23 @@ func GetContainerCluster(clusterid string, username string, clusterflavor cnstyp
 	}
 }
 
+// GetVCenterUser returns the vCenter user to set in the container cluster of
+// the volumes on the vCenter host. It is the user in cfg, unless cfg has a
+// credential provider, in which case it is the user the registered virtual
+// center got from the provider.
+func GetVCenterUser(ctx context.Context, host string, cfg *config.VirtualCenterConfig) string {
+	if cfg.CredentialProvider == "" {
+		return cfg.User
+	}
+	vc, err := GetVirtualCenterManager(ctx).GetVirtualCenter(ctx, host)
+	if err != nil || vc.Config == nil {
+		logger.GetLogger(ctx).Errorf("failed to get the user of vCenter %q from its credential provider. Err: %v",
+			host, err)
+		return cfg.User
+	}
+	return vc.Config.Username
+}
+
 // CreateCnsKuberenetesEntityReference returns an EntityReference object to
 // which the given entity refers to.
 func CreateCnsKuberenetesEntityReference(entityType string, entityName string,
@@ -198,6 +215,11 @@ func GetVirtualCenterConfig(ctx context.Context, cfg *config.Config) (*VirtualCe
 		ListVolumeThreshold:         cfg.Global.ListVolumeThreshold,
 		MigrationDataStoreURL:       cfg.VirtualCenter[host].MigrationDataStoreURL,
 		FileVolumeActivated:         cfg.VirtualCenter[host].FileVolumeActivated,
+		RateLimit:                   cfg.VCenterRateLimit,
+	}
+	err = vcConfig.setCredentialProvider(cfg.VirtualCenter[host])
+	if err != nil {
+		return nil, err
 	}
 
 	log.Debugf("Setting the queryLimit = %v, ListVolumeThreshold = %v", vcConfig.QueryLimit, vcConfig.ListVolumeThreshold)
@@ -244,6 +266,11 @@ func GetVirtualCenterConfigs(ctx context.Context, cfg *config.Config) ([]*Virtua
 			QueryLimit:                  cfg.Global.QueryLimit,
 			ListVolumeThreshold:         cfg.Global.ListVolumeThreshold,
 			FileVolumeActivated:         cfg.VirtualCenter[vCenterIP].FileVolumeActivated,
+			RateLimit:                   cfg.VCenterRateLimit,
+		}
+		err = vcConfig.setCredentialProvider(cfg.VirtualCenter[vCenterIP])
+		if err != nil {
+			return nil, err
 		}
 		if vcConfig.CAFile == "" {
 			vcConfig.CAFile = cfg.Global.CAFile
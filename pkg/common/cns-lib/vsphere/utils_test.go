@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/vmware/govmomi/cns/methods"
+	cnstypes "github.com/vmware/govmomi/cns/types"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/vim25/types"
 )
@@ -61,3 +63,19 @@ func TestFilterSuspendedDatastoresWhenDatastoreIsNotSuspended(t *testing.T) {
 	assert.Equal(t, 1, len(outputDsInfo))
 
 }
+
+func TestGetTaskIDFromResponse(t *testing.T) {
+	createVolumeResp := &methods.CnsCreateVolumeBody{
+		Res: &cnstypes.CnsCreateVolumeResponse{
+			Returnval: types.ManagedObjectReference{Type: "Task", Value: "task-1"},
+		},
+	}
+	assert.Equal(t, "task-1", getTaskIDFromResponse(createVolumeResp))
+
+	// Calls which fail or do not return a task have no task ID.
+	assert.Equal(t, "", getTaskIDFromResponse(&methods.CnsCreateVolumeBody{}))
+	queryVolumeResp := &methods.CnsQueryVolumeBody{
+		Res: &cnstypes.CnsQueryVolumeResponse{},
+	}
+	assert.Equal(t, "", getTaskIDFromResponse(queryVolumeResp))
+}
@@ -48,6 +48,7 @@ import (
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
 
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/prometheus"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/tracing"
 )
 
 const (
@@ -148,6 +149,12 @@ type VirtualCenterConfig struct {
 	ReloadVCConfigForNewClient bool
 	// FileVolumeActivated indicates whether file service has been enabled on any vSAN cluster or not
 	FileVolumeActivated bool
+	// RateLimit specifies the rate limits and circuit breaker for API calls
+	// to the virtual center.
+	RateLimit config.VCenterRateLimitConfig
+	// CredentialProvider returns the credentials used to log in to the virtual
+	// center. Username and Password are used if it is nil.
+	CredentialProvider CredentialProvider
 }
 
 // NewClient creates a new govmomi Client instance.
@@ -163,6 +170,7 @@ func (vc *VirtualCenter) NewClient(ctx context.Context, useragent string) (*govm
 		return nil, err
 	}
 
+	configureVCenterThrottle(ctx, vc.Config.Host, vc.Config.RateLimit)
 	soapClient := soap.NewClient(url, vc.Config.Insecure)
 	if len(vc.Config.CAFile) > 0 && !vc.Config.Insecure {
 		if err := soapClient.SetRootCAs(vc.Config.CAFile); err != nil {
@@ -215,7 +223,8 @@ func (vc *VirtualCenter) NewClient(ctx context.Context, useragent string) (*govm
 	if vc.Config.RoundTripperCount == 0 {
 		vc.Config.RoundTripperCount = DefaultRoundTripperCount
 	}
-	rt := vim25.Retry(client.RoundTripper, vim25.TemporaryNetworkError(vc.Config.RoundTripperCount))
+	rt := vim25.Retry(newThrottledRoundTripper(vimClient, "soap", client.RoundTripper),
+		vim25.TemporaryNetworkError(vc.Config.RoundTripperCount))
 	client.RoundTripper = &MetricRoundTripper{"soap", rt}
 	return client, nil
 }
@@ -302,6 +311,9 @@ func (vc *VirtualCenter) connect(ctx context.Context, requestNewSession bool) er
 				return err
 			}
 		}
+		if _, err = vc.refreshCredentials(ctx); err != nil {
+			return err
+		}
 		log.Infof("VirtualCenter.connect() creating new client")
 		if vc.Client, err = vc.NewClient(ctx, useragent); err != nil {
 			log.Errorf("failed to create govmomi client with err: %v", err)
@@ -313,6 +325,16 @@ func (vc *VirtualCenter) connect(ctx context.Context, requestNewSession bool) er
 		log.Infof("VirtualCenter.connect() successfully created new client")
 		return nil
 	}
+	if !requestNewSession {
+		// Log in again if the credential provider rotated the credentials.
+		if rotated, err := vc.refreshCredentials(ctx); err != nil {
+			log.Warnf("failed to refresh credentials for vCenter %q, keeping the current session. err: %v",
+				vc.Config.Host, err)
+		} else if rotated {
+			log.Infof("Credentials for vCenter %q were rotated, requesting a new session", vc.Config.Host)
+			requestNewSession = true
+		}
+	}
 	if !requestNewSession {
 		// If session hasn't expired, nothing to do.
 		sessionMgr := session.NewManager(vc.Client.Client)
@@ -342,6 +364,9 @@ func (vc *VirtualCenter) connect(ctx context.Context, requestNewSession bool) er
 			return err
 		}
 	}
+	if _, err = vc.refreshCredentials(ctx); err != nil {
+		return err
+	}
 	if vc.Client, err = vc.NewClient(ctx, useragent); err != nil {
 		log.Errorf("failed to create govmomi client with err: %v", err)
 		if !vc.Config.Insecure {
@@ -351,11 +376,10 @@ func (vc *VirtualCenter) connect(ctx context.Context, requestNewSession bool) er
 	}
 	// Recreate PbmClient if created using timed out VC Client.
 	if vc.PbmClient != nil {
-		if vc.PbmClient, err = pbm.NewClient(ctx, vc.Client.Client); err != nil {
+		if vc.PbmClient, err = NewPbmClient(ctx, vc.Client.Client); err != nil {
 			log.Errorf("failed to create pbm client with err: %v", err)
 			return err
 		}
-		vc.PbmClient.RoundTripper = &MetricRoundTripper{"pbm", vc.PbmClient.RoundTripper}
 	}
 	// Recreate CNSClient if created using timed out VC Client.
 	if vc.CnsClient != nil {
@@ -379,11 +403,31 @@ func (vc *VirtualCenter) connect(ctx context.Context, requestNewSession bool) er
 			log.Errorf("failed to create vsan client with err: %v", err)
 			return err
 		}
-		vc.VsanClient.RoundTripper = &MetricRoundTripper{"vsan", vc.VsanClient.RoundTripper}
+		vc.VsanClient.RoundTripper = &MetricRoundTripper{"vsan",
+			newThrottledRoundTripper(vc.Client.Client, "vsan", vc.VsanClient.RoundTripper)}
 	}
 	return nil
 }
 
+// refreshCredentials sets the username and password of the virtual center to
+// the current credentials of its credential provider, if any. It returns true
+// if the credentials changed.
+func (vc *VirtualCenter) refreshCredentials(ctx context.Context) (bool, error) {
+	if vc.Config.CredentialProvider == nil {
+		return false, nil
+	}
+	credentials, err := vc.Config.CredentialProvider.GetCredentials(ctx)
+	if err != nil {
+		return false, err
+	}
+	if credentials.Username == vc.Config.Username && credentials.Password == vc.Config.Password {
+		return false, nil
+	}
+	vc.Config.Username = credentials.Username
+	vc.Config.Password = credentials.Password
+	return true, nil
+}
+
 // ReadVCConfigs will ensure we are always reading the latest config
 // before attempting to create a new govmomi client.
 // It works in case of both vanilla (including multi-vc) and wcp
@@ -778,15 +822,44 @@ func (vc *VirtualCenter) GetAllVirtualMachines(ctx context.Context,
 func (mrt *MetricRoundTripper) RoundTrip(ctx context.Context, req, resp soap.HasFault) error {
 	vreq := reflect.ValueOf(req).Elem().FieldByName("Req").Elem()
 	requestName := vreq.Type().Name()
+	ctx, span := tracing.StartSpan(ctx, mrt.clientName+"."+requestName)
+	defer span.End()
 	requestTime := time.Now()
 	err := mrt.roundTripper.RoundTrip(ctx, req, resp)
 	if err != nil {
 		timeTaken := time.Since(requestTime).Seconds()
 		prometheus.RequestOpsMetric.WithLabelValues(requestName, mrt.clientName, statusFailUnknown).Observe(timeTaken)
+		tracing.SetSpanError(span, err)
 		return err
 	}
 
 	timeTaken := time.Since(requestTime).Seconds()
 	prometheus.RequestOpsMetric.WithLabelValues(requestName, mrt.clientName, statusSuccess).Observe(timeTaken)
+	// Link the span to the vCenter task created by the call, if any.
+	if taskID := getTaskIDFromResponse(resp); taskID != "" {
+		tracing.SetTaskAttributes(ctx, taskID, "")
+	}
 	return nil
 }
+
+// getTaskIDFromResponse returns the ID of the task returned by a SOAP call,
+// or an empty string if the call did not return a task.
+func getTaskIDFromResponse(resp soap.HasFault) string {
+	vresp := reflect.ValueOf(resp)
+	if vresp.Kind() != reflect.Ptr || vresp.IsNil() {
+		return ""
+	}
+	vres := vresp.Elem().FieldByName("Res")
+	if !vres.IsValid() || vres.Kind() != reflect.Ptr || vres.IsNil() {
+		return ""
+	}
+	returnval := vres.Elem().FieldByName("Returnval")
+	if !returnval.IsValid() {
+		return ""
+	}
+	ref, ok := returnval.Interface().(types.ManagedObjectReference)
+	if !ok || ref.Type != "Task" {
+		return ""
+	}
+	return ref.Value
+}
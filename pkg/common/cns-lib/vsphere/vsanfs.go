@@ -0,0 +1,230 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vsphere
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+)
+
+// The vSAN file share snapshot APIs of the VsanFileServiceSystem are not part
+// of the govmomi vsan bindings, so the types and methods needed by the driver
+// are declared below.
+
+// VsanFileServiceSystemInstance is the vSAN file service system instance
+// served by the vSAN health service.
+var VsanFileServiceSystemInstance = types.ManagedObjectReference{
+	Type:  "VsanFileServiceSystem",
+	Value: "vsan-cluster-file-service-system",
+}
+
+// VsanFileShareSnapshotConfig identifies a snapshot of a vSAN file share.
+type VsanFileShareSnapshotConfig struct {
+	types.DynamicData
+
+	Name      string `xml:"name"`
+	ShareUuid string `xml:"shareUuid"`
+}
+
+func init() {
+	types.Add("vsan:VsanFileShareSnapshotConfig", reflect.TypeOf((*VsanFileShareSnapshotConfig)(nil)).Elem())
+}
+
+// VsanFileShareSnapshot is a snapshot of a vSAN file share.
+type VsanFileShareSnapshot struct {
+	types.DynamicData
+
+	Config       VsanFileShareSnapshotConfig `xml:"config"`
+	CreationTime *time.Time                  `xml:"creationTime,omitempty"`
+	UsedCapacity int64                       `xml:"usedCapacity,omitempty"`
+}
+
+func init() {
+	types.Add("vsan:VsanFileShareSnapshot", reflect.TypeOf((*VsanFileShareSnapshot)(nil)).Elem())
+}
+
+// VsanFileShareSnapshotQuerySpec selects the snapshots of a vSAN file share.
+type VsanFileShareSnapshotQuerySpec struct {
+	types.DynamicData
+
+	ShareUuid string   `xml:"shareUuid"`
+	Names     []string `xml:"names,omitempty"`
+}
+
+func init() {
+	types.Add("vsan:VsanFileShareSnapshotQuerySpec", reflect.TypeOf((*VsanFileShareSnapshotQuerySpec)(nil)).Elem())
+}
+
+// VsanCreateFileShareSnapshot is the request of the CreateFileShareSnapshot method.
+type VsanCreateFileShareSnapshot struct {
+	This         types.ManagedObjectReference  `xml:"_this"`
+	SnapshotSpec VsanFileShareSnapshotConfig   `xml:"snapshotSpec"`
+	Cluster      *types.ManagedObjectReference `xml:"cluster,omitempty"`
+}
+
+func init() {
+	types.Add("vsan:VsanCreateFileShareSnapshot", reflect.TypeOf((*VsanCreateFileShareSnapshot)(nil)).Elem())
+}
+
+// VsanCreateFileShareSnapshotResponse is the response of the CreateFileShareSnapshot method.
+type VsanCreateFileShareSnapshotResponse struct {
+	Returnval types.ManagedObjectReference `xml:"returnval"`
+}
+
+type vsanCreateFileShareSnapshotBody struct {
+	Req    *VsanCreateFileShareSnapshot         `xml:"urn:vsan VsanCreateFileShareSnapshot,omitempty"`
+	Res    *VsanCreateFileShareSnapshotResponse `xml:"urn:vsan VsanCreateFileShareSnapshotResponse,omitempty"`
+	Fault_ *soap.Fault                          `xml:"http://schemas.xmlsoap.org/soap/envelope/ Fault,omitempty"`
+}
+
+func (b *vsanCreateFileShareSnapshotBody) Fault() *soap.Fault { return b.Fault_ }
+
+// VsanRemoveFileShareSnapshot is the request of the RemoveFileShareSnapshot method.
+type VsanRemoveFileShareSnapshot struct {
+	This         types.ManagedObjectReference  `xml:"_this"`
+	SnapshotSpec VsanFileShareSnapshotConfig   `xml:"snapshotSpec"`
+	Cluster      *types.ManagedObjectReference `xml:"cluster,omitempty"`
+}
+
+func init() {
+	types.Add("vsan:VsanRemoveFileShareSnapshot", reflect.TypeOf((*VsanRemoveFileShareSnapshot)(nil)).Elem())
+}
+
+// VsanRemoveFileShareSnapshotResponse is the response of the RemoveFileShareSnapshot method.
+type VsanRemoveFileShareSnapshotResponse struct {
+	Returnval types.ManagedObjectReference `xml:"returnval"`
+}
+
+type vsanRemoveFileShareSnapshotBody struct {
+	Req    *VsanRemoveFileShareSnapshot         `xml:"urn:vsan VsanRemoveFileShareSnapshot,omitempty"`
+	Res    *VsanRemoveFileShareSnapshotResponse `xml:"urn:vsan VsanRemoveFileShareSnapshotResponse,omitempty"`
+	Fault_ *soap.Fault                          `xml:"http://schemas.xmlsoap.org/soap/envelope/ Fault,omitempty"`
+}
+
+func (b *vsanRemoveFileShareSnapshotBody) Fault() *soap.Fault { return b.Fault_ }
+
+// VsanQueryFileShareSnapshots is the request of the QueryFileShareSnapshots method.
+type VsanQueryFileShareSnapshots struct {
+	This      types.ManagedObjectReference   `xml:"_this"`
+	QuerySpec VsanFileShareSnapshotQuerySpec `xml:"querySpec"`
+	Cluster   *types.ManagedObjectReference  `xml:"cluster,omitempty"`
+}
+
+func init() {
+	types.Add("vsan:VsanQueryFileShareSnapshots", reflect.TypeOf((*VsanQueryFileShareSnapshots)(nil)).Elem())
+}
+
+// VsanQueryFileShareSnapshotsResponse is the response of the QueryFileShareSnapshots method.
+type VsanQueryFileShareSnapshotsResponse struct {
+	Returnval []VsanFileShareSnapshot `xml:"returnval,omitempty"`
+}
+
+type vsanQueryFileShareSnapshotsBody struct {
+	Req    *VsanQueryFileShareSnapshots         `xml:"urn:vsan VsanQueryFileShareSnapshots,omitempty"`
+	Res    *VsanQueryFileShareSnapshotsResponse `xml:"urn:vsan VsanQueryFileShareSnapshotsResponse,omitempty"`
+	Fault_ *soap.Fault                          `xml:"http://schemas.xmlsoap.org/soap/envelope/ Fault,omitempty"`
+}
+
+func (b *vsanQueryFileShareSnapshotsBody) Fault() *soap.Fault { return b.Fault_ }
+
+// CreateFileShareSnapshot takes a snapshot with the given name of the vSAN
+// file share in the given cluster.
+func (vc *VirtualCenter) CreateFileShareSnapshot(ctx context.Context, cluster types.ManagedObjectReference,
+	shareUUID string, snapshotName string) (*object.Task, error) {
+	log := logger.GetLogger(ctx)
+	if err := vc.ConnectVsan(ctx); err != nil {
+		log.Errorf("failed to connect to vSAN health service on vCenter %q. Err: %v", vc.Config.Host, err)
+		return nil, err
+	}
+	reqBody := vsanCreateFileShareSnapshotBody{
+		Req: &VsanCreateFileShareSnapshot{
+			This:         VsanFileServiceSystemInstance,
+			SnapshotSpec: VsanFileShareSnapshotConfig{Name: snapshotName, ShareUuid: shareUUID},
+			Cluster:      &cluster,
+		},
+	}
+	var resBody vsanCreateFileShareSnapshotBody
+	if err := vc.VsanClient.RoundTrip(ctx, &reqBody, &resBody); err != nil {
+		return nil, err
+	}
+	if resBody.Res == nil {
+		return nil, logger.LogNewErrorf(log, "empty response to CreateFileShareSnapshot of share %q on vCenter %q",
+			shareUUID, vc.Config.Host)
+	}
+	return object.NewTask(vc.Client.Client, resBody.Res.Returnval), nil
+}
+
+// RemoveFileShareSnapshot removes the snapshot with the given name of the
+// vSAN file share in the given cluster.
+func (vc *VirtualCenter) RemoveFileShareSnapshot(ctx context.Context, cluster types.ManagedObjectReference,
+	shareUUID string, snapshotName string) (*object.Task, error) {
+	log := logger.GetLogger(ctx)
+	if err := vc.ConnectVsan(ctx); err != nil {
+		log.Errorf("failed to connect to vSAN health service on vCenter %q. Err: %v", vc.Config.Host, err)
+		return nil, err
+	}
+	reqBody := vsanRemoveFileShareSnapshotBody{
+		Req: &VsanRemoveFileShareSnapshot{
+			This:         VsanFileServiceSystemInstance,
+			SnapshotSpec: VsanFileShareSnapshotConfig{Name: snapshotName, ShareUuid: shareUUID},
+			Cluster:      &cluster,
+		},
+	}
+	var resBody vsanRemoveFileShareSnapshotBody
+	if err := vc.VsanClient.RoundTrip(ctx, &reqBody, &resBody); err != nil {
+		return nil, err
+	}
+	if resBody.Res == nil {
+		return nil, logger.LogNewErrorf(log, "empty response to RemoveFileShareSnapshot of share %q on vCenter %q",
+			shareUUID, vc.Config.Host)
+	}
+	return object.NewTask(vc.Client.Client, resBody.Res.Returnval), nil
+}
+
+// QueryFileShareSnapshots returns the snapshots of the vSAN file share in the
+// given cluster. All the snapshots of the file share are returned when no
+// snapshot names are given.
+func (vc *VirtualCenter) QueryFileShareSnapshots(ctx context.Context, cluster types.ManagedObjectReference,
+	shareUUID string, snapshotNames []string) ([]VsanFileShareSnapshot, error) {
+	log := logger.GetLogger(ctx)
+	if err := vc.ConnectVsan(ctx); err != nil {
+		log.Errorf("failed to connect to vSAN health service on vCenter %q. Err: %v", vc.Config.Host, err)
+		return nil, err
+	}
+	reqBody := vsanQueryFileShareSnapshotsBody{
+		Req: &VsanQueryFileShareSnapshots{
+			This:      VsanFileServiceSystemInstance,
+			QuerySpec: VsanFileShareSnapshotQuerySpec{ShareUuid: shareUUID, Names: snapshotNames},
+			Cluster:   &cluster,
+		},
+	}
+	var resBody vsanQueryFileShareSnapshotsBody
+	if err := vc.VsanClient.RoundTrip(ctx, &reqBody, &resBody); err != nil {
+		return nil, err
+	}
+	if resBody.Res == nil {
+		return nil, logger.LogNewErrorf(log, "empty response to QueryFileShareSnapshots of share %q on vCenter %q",
+			shareUUID, vc.Config.Host)
+	}
+	return resBody.Res.Returnval, nil
+}
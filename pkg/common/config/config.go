@@ -92,6 +92,27 @@ const (
 	// DefaultListVolumeThreshold specifies the default maximum number of differences in volumes between CNS
 	// and kubernetes
 	DefaultListVolumeThreshold = 50
+	// DefaultOrphanVolumeGracePeriodInMin is the default time for which an
+	// orphan volume is kept before its CNS metadata is removed.
+	// Current default value is set to 24 hours.
+	DefaultOrphanVolumeGracePeriodInMin = 1440
+	// DefaultAttachDetachBatchWindowInMsec is the default time for which attach
+	// and detach requests of the same node VM are collected into a batch.
+	DefaultAttachDetachBatchWindowInMsec = 100
+	// DefaultVCenterCircuitBreakerOpenDurationInSec is the default time for
+	// which calls to a vCenter are rejected once its circuit breaker opens.
+	DefaultVCenterCircuitBreakerOpenDurationInSec = 30
+	// DefaultCredentialRefreshIntervalInSec is the default interval after which
+	// the vCenter credentials are fetched again from the credential provider.
+	DefaultCredentialRefreshIntervalInSec = 60
+	// CredentialProviderFile reads the vCenter credentials from a JSON file.
+	CredentialProviderFile = "file"
+	// CredentialProviderExec reads the vCenter credentials from the JSON output
+	// of a command.
+	CredentialProviderExec = "exec"
+	// CredentialProviderHTTP reads the vCenter credentials from an HTTP secret
+	// endpoint, e.g. a Vault KV secret.
+	CredentialProviderHTTP = "http"
 	// supervisorIDPrefix is added before the SupervisorID
 	// Using this CNS UI can form an appropriate URL to navigate from CNS UI to WCP UI
 	supervisorIDPrefix = "vSphereSupervisorID-"
@@ -149,6 +170,10 @@ var (
 	ErrMissingTopologyCategoriesForMultiVCenterSetup = errors.New("vsphere CSI config requires " +
 		"topology-categories to be specified for multi vCenter deployment")
 
+	// ErrInvalidCredentialProvider is returned when the credential provider of
+	// a vCenter is unknown or its configuration is incomplete.
+	ErrInvalidCredentialProvider = errors.New("invalid credential provider config")
+
 	// ErrMaxVCenterSupportedForMultiVCenterSetup is returned when vSphere config secret has more than 5 vCenter
 	// servers
 	ErrMaxVCenterSupportedForMultiVCenterSetup = errors.New("max 5 vCenters are supported for multi " +
@@ -323,9 +348,9 @@ func FromEnv(ctx context.Context, cfg *Config) error {
 	return nil
 }
 
-// Check if username is valid or not. If username is not a fully qualified domain name, then
-// we consider it as an invalid username.
-func isValidvCenterUsernameWithDomain(username string) bool {
+// IsValidvCenterUsernameWithDomain checks if username is valid or not. If username is not a fully qualified
+// domain name, then we consider it as an invalid username.
+func IsValidvCenterUsernameWithDomain(username string) bool {
 	// Regular expression to validate vCenter server username.
 	// Allowed username is in the format "userName@domainName" or "domainName\\userName".
 	// If domain name is not provided in username, then functions like HasUserPrivilegeOnEntities
@@ -335,6 +360,36 @@ func isValidvCenterUsernameWithDomain(username string) bool {
 	return match
 }
 
+// validateCredentialProviderConfig checks that the options required by the
+// credential provider of vcConfig are set, and sets the default refresh
+// interval.
+func validateCredentialProviderConfig(vcConfig *VirtualCenterConfig) error {
+	switch vcConfig.CredentialProvider {
+	case CredentialProviderFile:
+		if vcConfig.CredentialFile == "" {
+			return fmt.Errorf("%w: credential-file is required for the %q credential provider",
+				ErrInvalidCredentialProvider, vcConfig.CredentialProvider)
+		}
+	case CredentialProviderExec:
+		if vcConfig.CredentialExecCommand == "" {
+			return fmt.Errorf("%w: credential-exec-command is required for the %q credential provider",
+				ErrInvalidCredentialProvider, vcConfig.CredentialProvider)
+		}
+	case CredentialProviderHTTP:
+		if vcConfig.CredentialURL == "" {
+			return fmt.Errorf("%w: credential-url is required for the %q credential provider",
+				ErrInvalidCredentialProvider, vcConfig.CredentialProvider)
+		}
+	default:
+		return fmt.Errorf("%w: unknown credential provider %q",
+			ErrInvalidCredentialProvider, vcConfig.CredentialProvider)
+	}
+	if vcConfig.CredentialRefreshIntervalInSec <= 0 {
+		vcConfig.CredentialRefreshIntervalInSec = DefaultCredentialRefreshIntervalInSec
+	}
+	return nil
+}
+
 func validateConfig(ctx context.Context, cfg *Config) error {
 	log := logger.GetLogger(ctx)
 	// Fix default global values.
@@ -375,27 +430,36 @@ func validateConfig(ctx context.Context, cfg *Config) error {
 			return ErrInvalidVCenterIP
 		}
 
-		if vcConfig.User == "" {
-			vcConfig.User = cfg.Global.User
+		if vcConfig.CredentialProvider != "" {
+			// Credentials are fetched from the credential provider when
+			// connecting to the vCenter.
+			if err := validateCredentialProviderConfig(vcConfig); err != nil {
+				log.Errorf("invalid credential provider config for vc %s. Err: %v", vcServer, err)
+				return err
+			}
+		} else {
 			if vcConfig.User == "" {
-				log.Errorf("vcConfig.User is empty for vc %s!", vcServer)
-				return ErrUsernameMissing
+				vcConfig.User = cfg.Global.User
+				if vcConfig.User == "" {
+					log.Errorf("vcConfig.User is empty for vc %s!", vcServer)
+					return ErrUsernameMissing
+				}
 			}
-		}
 
-		// vCenter server username provided in vSphere config secret should contain domain name,
-		// CSI driver will crash if username doesn't contain domain name.
-		if !isValidvCenterUsernameWithDomain(vcConfig.User) {
-			log.Errorf("username %v specified in vSphere config secret is invalid, "+
-				"make sure that username is a fully qualified domain name.", vcConfig.User)
-			return ErrInvalidUsername
-		}
+			// vCenter server username provided in vSphere config secret should contain domain name,
+			// CSI driver will crash if username doesn't contain domain name.
+			if !IsValidvCenterUsernameWithDomain(vcConfig.User) {
+				log.Errorf("username %v specified in vSphere config secret is invalid, "+
+					"make sure that username is a fully qualified domain name.", vcConfig.User)
+				return ErrInvalidUsername
+			}
 
-		if vcConfig.Password == "" {
-			vcConfig.Password = cfg.Global.Password
 			if vcConfig.Password == "" {
-				log.Errorf("vcConfig.Password is empty for vc %s!", vcServer)
-				return ErrPasswordMissing
+				vcConfig.Password = cfg.Global.Password
+				if vcConfig.Password == "" {
+					log.Errorf("vcConfig.Password is empty for vc %s!", vcServer)
+					return ErrPasswordMissing
+				}
 			}
 		}
 		if vcConfig.VCenterPort == "" {
@@ -459,6 +523,12 @@ func validateConfig(ctx context.Context, cfg *Config) error {
 		cfg.Global.CnsVolumeOperationRequestCleanupIntervalInMin =
 			DefaultCnsVolumeOperationRequestCleanupIntervalInMin
 	}
+	if cfg.Global.AttachDetachBatchWindowInMsec == 0 {
+		cfg.Global.AttachDetachBatchWindowInMsec = DefaultAttachDetachBatchWindowInMsec
+	}
+	if cfg.Global.OrphanVolumeGracePeriodInMin == 0 {
+		cfg.Global.OrphanVolumeGracePeriodInMin = DefaultOrphanVolumeGracePeriodInMin
+	}
 	if cfg.Snapshot.GlobalMaxSnapshotsPerBlockVolume == 0 {
 		cfg.Snapshot.GlobalMaxSnapshotsPerBlockVolume = DefaultGlobalMaxSnapshotsPerBlockVolume
 	}
@@ -500,6 +570,13 @@ func validateConfig(ctx context.Context, cfg *Config) error {
 		cfg.Global.ListVolumeThreshold = DefaultListVolumeThreshold
 		log.Debugf("Setting default list volume threshold to %v", cfg.Global.ListVolumeThreshold)
 	}
+
+	if cfg.VCenterRateLimit.CircuitBreakerFailureThreshold > 0 &&
+		cfg.VCenterRateLimit.CircuitBreakerOpenDurationInSec <= 0 {
+		cfg.VCenterRateLimit.CircuitBreakerOpenDurationInSec = DefaultVCenterCircuitBreakerOpenDurationInSec
+		log.Debugf("Setting default vCenter circuit breaker open duration to %v seconds",
+			cfg.VCenterRateLimit.CircuitBreakerOpenDurationInSec)
+	}
 	return nil
 }
 
@@ -71,6 +71,17 @@ type Config struct {
 		// ListVolumeThreshold specifies the maximum number of differences in volume that can exist between CNS
 		// and kubernetes
 		ListVolumeThreshold int `gcfg:"list-volume-threshold"`
+		// OrphanVolumeGracePeriodInMin specifies the time for which a volume missing in kubernetes
+		// is kept in its OrphanVolume instance before its CNS metadata is removed by full sync.
+		OrphanVolumeGracePeriodInMin int `gcfg:"orphan-volume-grace-period-inmin"`
+		// OrphanVolumeAutoCleanup enables removing the CNS metadata of orphan volumes once their
+		// grace period expires. Otherwise orphan volumes are kept until the user approves their
+		// cleanup in their OrphanVolume instance. Deleting the instance does not release the volume,
+		// full sync records it again.
+		OrphanVolumeAutoCleanup bool `gcfg:"orphan-volume-auto-cleanup"`
+		// AttachDetachBatchWindowInMsec specifies the time for which attach and detach requests of
+		// the same node VM are collected before they are sent to CNS in a single call.
+		AttachDetachBatchWindowInMsec int `gcfg:"attach-detach-batch-window-inmsec"`
 	}
 
 	// Multiple sets of Net Permissions applied to all file shares
@@ -83,6 +94,9 @@ type Config struct {
 	// Snapshot configurations.
 	Snapshot SnapshotConfig
 
+	// Rate limit and circuit breaker configurations for vCenter API calls.
+	VCenterRateLimit VCenterRateLimitConfig
+
 	// Guest Cluster configurations, only used by GC
 	GC GCConfig
 
@@ -157,6 +171,31 @@ type VirtualCenterConfig struct {
 	MigrationDataStoreURL string `gcfg:"migration-datastore-url"`
 	// FileVolumeActivated indicates whether file service has been enabled on any vSAN cluster or not
 	FileVolumeActivated bool
+	// CredentialProvider specifies where the vCenter credentials are fetched
+	// from: "file", "exec" or "http". Optional; if not configured, User and
+	// Password are used.
+	CredentialProvider string `gcfg:"credential-provider"`
+	// CredentialFile is the path of the JSON file holding the credentials for
+	// the "file" credential provider. The file is re-read when it changes.
+	CredentialFile string `gcfg:"credential-file"`
+	// CredentialExecCommand is the command printing the credentials as JSON on
+	// stdout for the "exec" credential provider.
+	CredentialExecCommand string `gcfg:"credential-exec-command"`
+	// CredentialExecArgs are the arguments passed to CredentialExecCommand.
+	CredentialExecArgs []string `gcfg:"credential-exec-arg"`
+	// CredentialURL is the URL of the secret holding the credentials for the
+	// "http" credential provider, e.g. a Vault KV secret.
+	CredentialURL string `gcfg:"credential-url"`
+	// CredentialTokenFile is the path of the file holding the token sent in the
+	// X-Vault-Token header of the requests to CredentialURL. Optional.
+	CredentialTokenFile string `gcfg:"credential-token-file"`
+	// CredentialCAFile is the path of the CA certificate in PEM format used to
+	// verify CredentialURL. Optional; if not configured, the system's CA
+	// certificates will be used.
+	CredentialCAFile string `gcfg:"credential-ca-file"`
+	// CredentialRefreshIntervalInSec specifies the interval after which the
+	// "exec" and "http" credential providers fetch the credentials again.
+	CredentialRefreshIntervalInSec int `gcfg:"credential-refresh-interval-insec"`
 }
 
 // GCConfig contains information used by guest cluster to access a supervisor
@@ -190,5 +229,39 @@ type SnapshotConfig struct {
 	GranularMaxSnapshotsPerBlockVolumeInVVOL int `gcfg:"granular-max-snapshots-per-block-volume-vvol"`
 }
 
+// VCenterRateLimitConfig contains the client-side rate limits and circuit
+// breaker configuration applied to the API calls made to each vCenter.
+// A QPS of 0 disables rate limiting of the corresponding calls.
+type VCenterRateLimitConfig struct {
+	// CnsQPS and CnsBurst limit the rate of CNS API calls.
+	CnsQPS   int `gcfg:"cns-qps"`
+	CnsBurst int `gcfg:"cns-burst"`
+	// PbmQPS and PbmBurst limit the rate of PBM API calls.
+	PbmQPS   int `gcfg:"pbm-qps"`
+	PbmBurst int `gcfg:"pbm-burst"`
+	// VslmQPS and VslmBurst limit the rate of vSLM API calls.
+	VslmQPS   int `gcfg:"vslm-qps"`
+	VslmBurst int `gcfg:"vslm-burst"`
+	// VimQPS and VimBurst limit the rate of vim25 API calls other than the
+	// property collector calls.
+	VimQPS   int `gcfg:"vim-qps"`
+	VimBurst int `gcfg:"vim-burst"`
+	// PropertyCollectorQPS and PropertyCollectorBurst limit the rate of
+	// property collector calls, which retrieve the properties of managed
+	// objects and wait for updates to them.
+	PropertyCollectorQPS   int `gcfg:"property-collector-qps"`
+	PropertyCollectorBurst int `gcfg:"property-collector-burst"`
+	// VsanQPS and VsanBurst limit the rate of vSAN API calls.
+	VsanQPS   int `gcfg:"vsan-qps"`
+	VsanBurst int `gcfg:"vsan-burst"`
+	// CircuitBreakerFailureThreshold specifies the number of consecutive calls
+	// failing with server or session errors after which calls to the vCenter
+	// are rejected. 0 disables the circuit breaker.
+	CircuitBreakerFailureThreshold int `gcfg:"circuit-breaker-failure-threshold"`
+	// CircuitBreakerOpenDurationInSec specifies the time for which calls are
+	// rejected before a call is let through to check if the vCenter recovered.
+	CircuitBreakerOpenDurationInSec int `gcfg:"circuit-breaker-open-duration-insec"`
+}
+
 // EnvClusterFlavor is the k8s cluster type on which CSI Driver is being deployed
 const EnvClusterFlavor = "CLUSTER_FLAVOR"
@@ -49,6 +49,22 @@ const (
 	PrometheusListSnapshotsOpType = "list-snapshot"
 	// PrometheusListVolumeOpType represents the ListVolumes operation.
 	PrometheusListVolumeOpType = "list-volume"
+	// PrometheusGetCapacityOpType represents the GetCapacity operation.
+	PrometheusGetCapacityOpType = "get-capacity"
+	// PrometheusControllerGetVolumeOpType represents the ControllerGetVolume operation.
+	PrometheusControllerGetVolumeOpType = "controller-get-volume"
+	// PrometheusControllerModifyVolumeOpType represents the ControllerModifyVolume operation.
+	PrometheusControllerModifyVolumeOpType = "controller-modify-volume"
+	// PrometheusCreateVolumeGroupSnapshotOpType represents the CreateVolumeGroupSnapshot operation.
+	PrometheusCreateVolumeGroupSnapshotOpType = "create-volume-group-snapshot"
+	// PrometheusDeleteVolumeGroupSnapshotOpType represents the DeleteVolumeGroupSnapshot operation.
+	PrometheusDeleteVolumeGroupSnapshotOpType = "delete-volume-group-snapshot"
+	// PrometheusGetVolumeGroupSnapshotOpType represents the GetVolumeGroupSnapshot operation.
+	PrometheusGetVolumeGroupSnapshotOpType = "get-volume-group-snapshot"
+	// PrometheusGetMetadataAllocatedOpType represents the GetMetadataAllocated operation.
+	PrometheusGetMetadataAllocatedOpType = "get-metadata-allocated"
+	// PrometheusGetMetadataDeltaOpType represents the GetMetadataDelta operation.
+	PrometheusGetMetadataDeltaOpType = "get-metadata-delta"
 
 	// CNS operation types
 
@@ -80,6 +96,24 @@ const (
 	PrometheusCnsCreateSnapshotOpType = "create-snapshot"
 	// PrometheusCnsDeleteSnapshotOpType represents DeleteSnapshot operation.
 	PrometheusCnsDeleteSnapshotOpType = "delete-snapshot"
+	// PrometheusCnsCreateGroupSnapshotOpType represents the CreateSnapshot operation
+	// creating the snapshots of a volume group snapshot.
+	PrometheusCnsCreateGroupSnapshotOpType = "create-group-snapshot"
+	// PrometheusCnsCloneVolumeOpType represents CloneVolume operation.
+	PrometheusCnsCloneVolumeOpType = "clone-volume"
+	// PrometheusCnsCreateVolumeWithProvisioningTypeOpType represents CreateVolumeWithProvisioningType operation.
+	PrometheusCnsCreateVolumeWithProvisioningTypeOpType = "create-volume-with-provisioning-type"
+	// PrometheusCnsApplyVolumeIOAllocationOpType represents ApplyVolumeIOAllocation operation.
+	PrometheusCnsApplyVolumeIOAllocationOpType = "apply-volume-io-allocation"
+	// PrometheusCnsUpdateVolumePolicyOpType represents the ReconfigVolumePolicy and RelocateVolume
+	// operations used to change the storage policy of a volume.
+	PrometheusCnsUpdateVolumePolicyOpType = "update-volume-policy"
+	// PrometheusCnsBatchAttachVolumeOpType represents the AttachVolume operation
+	// attaching a batch of volumes to a VM.
+	PrometheusCnsBatchAttachVolumeOpType = "batch-attach-volume"
+	// PrometheusCnsBatchDetachVolumeOpType represents the DetachVolume operation
+	// detaching a batch of volumes from a VM.
+	PrometheusCnsBatchDetachVolumeOpType = "batch-detach-volume"
 	// PrometheusAccessibleVolumes represents accessible volumes.
 	PrometheusAccessibleVolumes = "accessible-volumes"
 	// PrometheusInaccessibleVolumes represents inaccessible volumes.
@@ -142,6 +176,13 @@ var (
 		// Possible volume_health_type - "accessible-volumes", "inaccessible-volumes"
 		[]string{"volume_health_type"})
 
+	// VolumeHealthPerVolumeGaugeVec is a gauge metric to observe the health of each volume.
+	VolumeHealthPerVolumeGaugeVec = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "vsphere_volume_health_per_volume_gauge",
+		Help: "Gauge for the health of each volume, 1 if the volume is accessible and 0 if it is inaccessible",
+	},
+		[]string{"namespace", "pvc", "datastore", "storage_policy"})
+
 	// FullSyncOpsHistVec is a histogram vector metric to observe CSI Full Sync.
 	FullSyncOpsHistVec = promauto.NewHistogramVec(prometheus.HistogramOpts{
 		Name: "vsphere_full_sync_ops_histogram",
@@ -159,4 +200,35 @@ var (
 		Help:    "Histogram vector for individual request to vCenter",
 		Buckets: []float64{2, 5, 10, 15, 20, 25, 30, 60, 120, 180},
 	}, []string{"request", "client", "status"})
+
+	// AttachDetachBatchSizeHistVec is a histogram vector metric to observe the number of
+	// volumes attached to or detached from a node VM by a single CNS call.
+	AttachDetachBatchSizeHistVec = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "vsphere_cns_attach_detach_batch_size",
+		Help:    "Histogram vector for the number of volumes in CNS attach and detach batches",
+		Buckets: []float64{1, 2, 4, 8, 16, 32, 64},
+	},
+		// Possible optype - "batch-attach-volume", "batch-detach-volume"
+		[]string{"optype"})
+
+	// VCenterThrottledRequestsCounterVec is a counter metric to observe the requests to vCenter
+	// which were delayed by the client-side rate limiter.
+	VCenterThrottledRequestsCounterVec = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "vsphere_vcenter_throttled_requests_total",
+		Help: "Total number of requests to vCenter delayed by the client-side rate limiter",
+	}, []string{"vcenter", "client"})
+
+	// VCenterRejectedRequestsCounterVec is a counter metric to observe the requests to vCenter
+	// which were rejected because the circuit breaker of the vCenter was open.
+	VCenterRejectedRequestsCounterVec = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "vsphere_vcenter_rejected_requests_total",
+		Help: "Total number of requests to vCenter rejected by the open circuit breaker",
+	}, []string{"vcenter", "client"})
+
+	// VCenterCircuitBreakerOpenGaugeVec is a gauge metric to observe the state of the
+	// circuit breaker of each vCenter, 1 if it is open and 0 otherwise.
+	VCenterCircuitBreakerOpenGaugeVec = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "vsphere_vcenter_circuit_breaker_open",
+		Help: "Gauge for the state of the vCenter circuit breaker, 1 if it is open and 0 otherwise",
+	}, []string{"vcenter"})
 )
@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// tracerName is the name of the tracer creating the spans of the driver.
+	tracerName = "sigs.k8s.io/vsphere-csi-driver"
+
+	// AttributeTaskID is the span attribute holding the vCenter task ID.
+	AttributeTaskID = "vsphere.task_id"
+	// AttributeOpID is the span attribute holding the vCenter OpID of a task.
+	AttributeOpID = "vsphere.op_id"
+)
+
+// InitTracerProvider sets up the global tracer provider to export the spans
+// of serviceName to an OTLP collector over gRPC. The collector is configured
+// with the standard OTEL_EXPORTER_OTLP_* environment variables and defaults
+// to a collector listening on localhost:4317. Spans are dropped if tracing is
+// not initialized.
+// The returned function flushes the pending spans and must be called on
+// shutdown.
+func InitTracerProvider(ctx context.Context, serviceName string) (func(context.Context) error, error) {
+	exporter, err := otlptracegrpc.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, err
+	}
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+	return tracerProvider.Shutdown, nil
+}
+
+// StartSpan starts a span with the given name as a child of the span in ctx.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// SetSpanError records err on span and marks the span as failed.
+func SetSpanError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// SetTaskAttributes links the span in ctx to the vCenter task with the given
+// ID and OpID.
+func SetTaskAttributes(ctx context.Context, taskID string, opID string) {
+	attrs := []attribute.KeyValue{attribute.String(AttributeTaskID, taskID)}
+	if opID != "" {
+		attrs = append(attrs, attribute.String(AttributeOpID, opID))
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attrs...)
+}
+
+// TraceID returns the trace ID of the span in ctx, or an empty string if ctx
+// is not part of a trace.
+func TraceID(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unittestcommon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/agiledragon/gomonkey/v2"
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	cnsoperatorapis "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator"
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	cnsconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	k8s "sigs.k8s.io/vsphere-csi-driver/v3/pkg/kubernetes"
+	cnsoperatorutil "sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer/cnsoperator/util"
+)
+
+// Fixtures of the unit tests of the CnsOperator controllers.
+const (
+	TestClusterID        = "test-cluster"
+	TestVCHost           = "vc1.example.com"
+	TestNamespace        = "test-ns"
+	TestVolumeID         = "fcd-1"
+	TestPVName           = "test-pv"
+	TestPVCName          = "test-pvc"
+	TestDiskPath         = "[ds] fcd/disk-1.vmdk"
+	TestDatastoreURL     = "ds:///vmfs/volumes/ds/"
+	TestCapacityInMB     = int64(1024)
+	TestEventsBufferSize = 1024
+)
+
+// NewFakeCnsOperatorClient returns the scheme of the CnsOperator APIs and a
+// fake client tracking the given CnsOperator instance.
+func NewFakeCnsOperatorClient(instance client.Object) (client.Client, *runtime.Scheme) {
+	s := scheme.Scheme
+	s.AddKnownTypes(cnsoperatorapis.SchemeGroupVersion, instance)
+	return fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(instance).Build(), s
+}
+
+// NewCnsOperatorConfigInfo returns the configuration of the TestClusterID
+// cluster with the given vCenter hosts.
+func NewCnsOperatorConfigInfo(vcHosts ...string) *cnsconfig.ConfigurationInfo {
+	configInfo := &cnsconfig.ConfigurationInfo{Cfg: &cnsconfig.Config{}}
+	configInfo.Cfg.Global.ClusterID = TestClusterID
+	configInfo.Cfg.VirtualCenter = make(map[string]*cnsconfig.VirtualCenterConfig)
+	for _, host := range vcHosts {
+		configInfo.Cfg.VirtualCenter[host] = &cnsconfig.VirtualCenterConfig{User: "user@vsphere.local"}
+	}
+	return configInfo
+}
+
+// PatchVanillaCnsOperatorCalls registers the given vCenter hosts and patches
+// the calls of the vanilla CnsOperator reconciles which reach the API server
+// or the nodes of the cluster. PVCs are reported as bound when isBound is set.
+func PatchVanillaCnsOperatorCalls(t *testing.T, k8sclient clientset.Interface, isBound *bool,
+	vcHosts ...string) *gomonkey.Patches {
+	ctx := context.Background()
+	for _, host := range vcHosts {
+		_, err := cnsvsphere.GetVirtualCenterManager(ctx).RegisterVirtualCenter(ctx,
+			&cnsvsphere.VirtualCenterConfig{Host: host})
+		if err != nil && err != cnsvsphere.ErrVCAlreadyRegistered {
+			t.Fatalf("failed to register vCenter %q. Error: %v", host, err)
+		}
+	}
+	patches := gomonkey.ApplyFunc(k8s.NewClient, func(_ context.Context) (clientset.Interface, error) {
+		return k8sclient, nil
+	})
+	patches.ApplyFunc(cnsoperatorutil.GetVolumeNodeAffinityForVanilla, func(_ context.Context, _ client.Client,
+		_ *cnsvsphere.VirtualCenter, _ string) (*v1.VolumeNodeAffinity, error) {
+		return nil, nil
+	})
+	patches.ApplyFunc(cnsoperatorutil.IsPVCBound, func(_ context.Context, _ clientset.Interface,
+		_ *v1.PersistentVolumeClaim, _ time.Duration) (bool, error) {
+		return *isBound, nil
+	})
+	return patches
+}
+
+// NewRegisteredVolumeManager returns a FakeVolumeManager with the TestVolumeID
+// block volume registered with CNS, along with the metadata of its PV and PVC,
+// by the TestClusterID cluster and by the given other clusters.
+func NewRegisteredVolumeManager(otherClusterIDs ...string) *FakeVolumeManager {
+	volumeManager := NewFakeVolumeManager(&FakeFcd{
+		ID:           TestVolumeID,
+		DiskPath:     TestDiskPath,
+		CapacityInMB: TestCapacityInMB,
+	})
+	volume := &cnstypes.CnsVolume{
+		VolumeId:   cnstypes.CnsVolumeId{Id: TestVolumeID},
+		VolumeType: common.BlockVolumeType,
+	}
+	for _, clusterID := range append([]string{TestClusterID}, otherClusterIDs...) {
+		volume.Metadata.ContainerClusterArray = append(volume.Metadata.ContainerClusterArray,
+			cnstypes.CnsContainerCluster{ClusterId: clusterID})
+		volume.Metadata.EntityMetadata = append(volume.Metadata.EntityMetadata,
+			&cnstypes.CnsKubernetesEntityMetadata{
+				CnsEntityMetadata: cnstypes.CnsEntityMetadata{EntityName: TestPVName, ClusterID: clusterID},
+				EntityType:        string(cnstypes.CnsKubernetesEntityTypePV),
+			},
+			&cnstypes.CnsKubernetesEntityMetadata{
+				CnsEntityMetadata: cnstypes.CnsEntityMetadata{EntityName: TestPVCName, ClusterID: clusterID},
+				EntityType:        string(cnstypes.CnsKubernetesEntityTypePVC),
+				Namespace:         TestNamespace,
+			})
+	}
+	volumeManager.Volumes[TestVolumeID] = volume
+	return volumeManager
+}
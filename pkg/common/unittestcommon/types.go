@@ -20,6 +20,8 @@ import (
 	"context"
 	"sync"
 
+	cnstypes "github.com/vmware/govmomi/cns/types"
+
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/migration"
 	cnsvolume "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
 	cnsconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
@@ -72,6 +74,31 @@ type fakeVolumeOperationRequestInterface struct {
 	volumeOperationRequestMap map[string]*cnsvolumeoperationrequest.VolumeOperationRequestDetails
 }
 
+// FakeFcd is a first class disk on the vCenter of a FakeVolumeManager.
+type FakeFcd struct {
+	ID              string
+	DiskPath        string
+	DatastoreURL    string
+	StoragePolicyID string
+	CapacityInMB    int64
+	// ExportedFrom is the ID of the cluster which exported the FCD, if any.
+	ExportedFrom string
+}
+
+// FakeVolumeManager implements the parts of the cnsvolume.Manager interface
+// used by the CNS operator controllers, by keeping the FCDs and the CNS volumes
+// of a single vCenter in memory. Calling any other method panics.
+type FakeVolumeManager struct {
+	cnsvolume.Manager
+	mutex sync.Mutex
+	// Fcds maps FCD IDs to the FCDs on the vCenter, registered with CNS or not.
+	Fcds map[string]*FakeFcd
+	// Volumes maps volume IDs to the volumes registered with CNS.
+	Volumes map[string]*cnstypes.CnsVolume
+	// Errors maps the names of the Manager methods to the errors they fail with.
+	Errors map[string]error
+}
+
 // mockControllerVolumeTopology is a mock of the k8sorchestrator controllerVolumeTopology type.
 type mockControllerVolumeTopology struct {
 }
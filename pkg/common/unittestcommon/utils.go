@@ -27,16 +27,20 @@ import (
 
 	"github.com/vmware/govmomi/simulator"
 	"github.com/vmware/govmomi/simulator/vpx"
+	"github.com/vmware/govmomi/vim25/soap"
+	vim25types "github.com/vmware/govmomi/vim25/types"
 	"google.golang.org/grpc/codes"
 	storagev1 "k8s.io/api/storage/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 
 	cnssim "github.com/vmware/govmomi/cns/simulator"
+	cnstypes "github.com/vmware/govmomi/cns/types"
 	pbmsim "github.com/vmware/govmomi/pbm/simulator"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/migration"
 	cnsvolume "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
 	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+	csifault "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/fault"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
 	commoncotypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco/types"
@@ -72,6 +76,25 @@ func GetFakeContainerOrchestratorInterface(orchestratorType int) (commonco.COCom
 				"multi-vcenter-csi-topology":        "true",
 				"listview-tasks":                    "true",
 				"storage-quota-m2":                  "false",
+				"get-capacity":                      "true",
+				"block-volume-clone":                "true",
+				"controller-get-volume":             "true",
+				"controller-modify-volume":          "true",
+				"node-volume-encryption":            "true",
+				"node-volume-condition":             "true",
+				"fsck-on-stage":                     "true",
+				"mkfs-options":                      "true",
+				"disk-format":                       "true",
+				"iops-allocation":                   "true",
+				"vanilla-cns-register-volume":       "true",
+				"vanilla-cns-unregister-volume":     "true",
+				"vanilla-volume-handoff":            "true",
+				"orphan-volume-quarantine":          "true",
+				"batch-attach-detach":               "true",
+				"volume-group-snapshot":             "true",
+				"snapshot-restore-relocation":       "true",
+				"file-volume-snapshot":              "true",
+				"snapshot-metadata-service":         "true",
 				// Adding FSS from `wcp-cluster-capabilities` configmap in supervisor here for simplicity.
 				"Workload_Domain_Isolation_Supported": "true",
 			},
@@ -383,6 +406,8 @@ func configFromVCSimWithTLS(tlsConfig *tls.Config, vcsimParams VcsimParams, inse
 	model.Service.RegisterSDK(cnssim.New())
 	// PBM Service simulator.
 	model.Service.RegisterSDK(pbmsim.New())
+	// vSAN file service simulator.
+	model.Service.RegisterSDK(NewVsanFileServiceSimulator())
 
 	cfg.Global.InsecureFlag = insecureAllowed
 	cfg.Global.VCenterIP = s.URL.Hostname()
@@ -449,3 +474,233 @@ func ConfigFromEnvOrVCSim(ctx context.Context, vcsimParams VcsimParams, isTopolo
 	}
 	return cfg, func() {}
 }
+
+// NewFakeVolumeManager returns a FakeVolumeManager with the given FCDs, none
+// of which is registered with CNS.
+func NewFakeVolumeManager(fcds ...*FakeFcd) *FakeVolumeManager {
+	m := &FakeVolumeManager{
+		Fcds:    make(map[string]*FakeFcd),
+		Volumes: make(map[string]*cnstypes.CnsVolume),
+		Errors:  make(map[string]error),
+	}
+	for _, fcd := range fcds {
+		m.Fcds[fcd.ID] = fcd
+	}
+	return m
+}
+
+// NewNotFoundFault returns the error of a vCenter call which failed with a
+// NotFound fault.
+func NewNotFoundFault() error {
+	fault := &soap.Fault{}
+	fault.Detail.Fault = vim25types.NotFound{}
+	return soap.WrapSoapFault(fault)
+}
+
+// CreateVolume registers the FCD in the backing object details of the spec
+// with CNS. Like CNS, registering an FCD again returns the existing volume.
+func (m *FakeVolumeManager) CreateVolume(ctx context.Context, spec *cnstypes.CnsVolumeCreateSpec,
+	extraParams interface{}) (*cnsvolume.CnsVolumeInfo, string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if err := m.Errors["CreateVolume"]; err != nil {
+		return nil, csifault.CSIInternalFault, err
+	}
+	backing, ok := spec.BackingObjectDetails.(*cnstypes.CnsBlockBackingDetails)
+	if !ok {
+		return nil, csifault.CSIInvalidArgumentFault,
+			fmt.Errorf("unsupported backing object details %T", spec.BackingObjectDetails)
+	}
+	var fcd *FakeFcd
+	for _, f := range m.Fcds {
+		if f.ID == backing.BackingDiskId ||
+			(backing.BackingDiskUrlPath != "" && f.DiskPath == backing.BackingDiskUrlPath) {
+			fcd = f
+			break
+		}
+	}
+	if fcd == nil {
+		return nil, csifault.CSINotFoundFault, NewNotFoundFault()
+	}
+	if _, ok := m.Volumes[fcd.ID]; !ok {
+		m.Volumes[fcd.ID] = &cnstypes.CnsVolume{
+			VolumeId:        cnstypes.CnsVolumeId{Id: fcd.ID},
+			Name:            spec.Name,
+			VolumeType:      spec.VolumeType,
+			DatastoreUrl:    fcd.DatastoreURL,
+			StoragePolicyId: fcd.StoragePolicyID,
+			Metadata:        spec.Metadata,
+			BackingObjectDetails: &cnstypes.CnsBlockBackingDetails{
+				CnsBackingObjectDetails: cnstypes.CnsBackingObjectDetails{CapacityInMb: fcd.CapacityInMB},
+				BackingDiskId:           fcd.ID,
+			},
+		}
+	}
+	return &cnsvolume.CnsVolumeInfo{
+		DatastoreURL: fcd.DatastoreURL,
+		VolumeID:     cnstypes.CnsVolumeId{Id: fcd.ID},
+	}, "", nil
+}
+
+// DeleteVolume removes the volume from CNS, and deletes its FCD if deleteDisk
+// is set.
+func (m *FakeVolumeManager) DeleteVolume(ctx context.Context, volumeID string, deleteDisk bool) (string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if err := m.Errors["DeleteVolume"]; err != nil {
+		return csifault.CSIInternalFault, err
+	}
+	if _, ok := m.Volumes[volumeID]; !ok {
+		return csifault.CSINotFoundFault, NewNotFoundFault()
+	}
+	delete(m.Volumes, volumeID)
+	if deleteDisk {
+		delete(m.Fcds, volumeID)
+	}
+	return "", nil
+}
+
+// QueryVolume returns the CNS volumes with the IDs in the query filter.
+func (m *FakeVolumeManager) QueryVolume(ctx context.Context,
+	queryFilter cnstypes.CnsQueryFilter) (*cnstypes.CnsQueryResult, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if err := m.Errors["QueryVolume"]; err != nil {
+		return nil, err
+	}
+	queryResult := &cnstypes.CnsQueryResult{}
+	for _, volumeID := range queryFilter.VolumeIds {
+		volume, ok := m.Volumes[volumeID.Id]
+		if !ok {
+			continue
+		}
+		// Callers get their own copy of the entity metadata.
+		result := *volume
+		result.Metadata.EntityMetadata = nil
+		for _, entity := range volume.Metadata.EntityMetadata {
+			k8sEntity := *entity.(*cnstypes.CnsKubernetesEntityMetadata)
+			result.Metadata.EntityMetadata = append(result.Metadata.EntityMetadata, &k8sEntity)
+		}
+		queryResult.Volumes = append(queryResult.Volumes, result)
+	}
+	return queryResult, nil
+}
+
+// UpdateVolumeMetadata adds, replaces or, when their Delete flag is set,
+// removes the kubernetes entity metadata in the spec. A container cluster is
+// removed from the volume once none of its entity metadata is left.
+func (m *FakeVolumeManager) UpdateVolumeMetadata(ctx context.Context,
+	spec *cnstypes.CnsVolumeMetadataUpdateSpec) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if err := m.Errors["UpdateVolumeMetadata"]; err != nil {
+		return err
+	}
+	volume, ok := m.Volumes[spec.VolumeId.Id]
+	if !ok {
+		return NewNotFoundFault()
+	}
+	isSameEntity := func(a, b *cnstypes.CnsKubernetesEntityMetadata) bool {
+		return a.ClusterID == b.ClusterID && a.EntityType == b.EntityType && a.EntityName == b.EntityName &&
+			a.Namespace == b.Namespace
+	}
+	for _, entity := range spec.Metadata.EntityMetadata {
+		update := *entity.(*cnstypes.CnsKubernetesEntityMetadata)
+		var entities []cnstypes.BaseCnsEntityMetadata
+		for _, existing := range volume.Metadata.EntityMetadata {
+			if !isSameEntity(existing.(*cnstypes.CnsKubernetesEntityMetadata), &update) {
+				entities = append(entities, existing)
+			}
+		}
+		if !update.Delete {
+			entities = append(entities, &update)
+		}
+		volume.Metadata.EntityMetadata = entities
+	}
+	hasEntities := func(clusterID string) bool {
+		for _, entity := range volume.Metadata.EntityMetadata {
+			if entity.(*cnstypes.CnsKubernetesEntityMetadata).ClusterID == clusterID {
+				return true
+			}
+		}
+		return false
+	}
+	var containerClusters []cnstypes.CnsContainerCluster
+	isUpdatedClusterListed := false
+	for _, containerCluster := range volume.Metadata.ContainerClusterArray {
+		if hasEntities(containerCluster.ClusterId) {
+			containerClusters = append(containerClusters, containerCluster)
+			if containerCluster.ClusterId == spec.Metadata.ContainerCluster.ClusterId {
+				isUpdatedClusterListed = true
+			}
+		}
+	}
+	if !isUpdatedClusterListed && hasEntities(spec.Metadata.ContainerCluster.ClusterId) {
+		containerClusters = append(containerClusters, spec.Metadata.ContainerCluster)
+	}
+	volume.Metadata.ContainerClusterArray = containerClusters
+	return nil
+}
+
+// QueryVolumeAsync returns the CNS volumes with the IDs in the query filter.
+// The query selection is ignored and all the fields of the volumes are set.
+func (m *FakeVolumeManager) QueryVolumeAsync(ctx context.Context, queryFilter cnstypes.CnsQueryFilter,
+	querySelection *cnstypes.CnsQuerySelection) (*cnstypes.CnsQueryResult, error) {
+	return m.QueryVolume(ctx, queryFilter)
+}
+
+// RetrieveVStorageObject returns the FCD with the given ID.
+func (m *FakeVolumeManager) RetrieveVStorageObject(ctx context.Context,
+	volumeID string) (*vim25types.VStorageObject, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if err := m.Errors["RetrieveVStorageObject"]; err != nil {
+		return nil, err
+	}
+	fcd, ok := m.Fcds[volumeID]
+	if !ok {
+		return nil, NewNotFoundFault()
+	}
+	return &vim25types.VStorageObject{
+		Config: vim25types.VStorageObjectConfigInfo{
+			BaseConfigInfo: vim25types.BaseConfigInfo{
+				Id: vim25types.ID{Id: fcd.ID},
+				Backing: &vim25types.BaseConfigInfoDiskFileBackingInfo{
+					BaseConfigInfoFileBackingInfo: vim25types.BaseConfigInfoFileBackingInfo{
+						FilePath: fcd.DiskPath,
+					},
+				},
+			},
+			CapacityInMB: fcd.CapacityInMB,
+		},
+	}, nil
+}
+
+// SetVolumeExportedFrom records the ID of the cluster which exported the FCD.
+func (m *FakeVolumeManager) SetVolumeExportedFrom(ctx context.Context, volumeID string, clusterID string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if err := m.Errors["SetVolumeExportedFrom"]; err != nil {
+		return err
+	}
+	fcd, ok := m.Fcds[volumeID]
+	if !ok {
+		return NewNotFoundFault()
+	}
+	fcd.ExportedFrom = clusterID
+	return nil
+}
+
+// GetVolumeExportedFrom returns the ID of the cluster which exported the FCD.
+func (m *FakeVolumeManager) GetVolumeExportedFrom(ctx context.Context, volumeID string) (string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if err := m.Errors["GetVolumeExportedFrom"]; err != nil {
+		return "", err
+	}
+	fcd, ok := m.Fcds[volumeID]
+	if !ok {
+		return "", NewNotFoundFault()
+	}
+	return fcd.ExportedFrom, nil
+}
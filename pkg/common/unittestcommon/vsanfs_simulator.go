@@ -0,0 +1,144 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unittestcommon
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/soap"
+	vimtypes "github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/govmomi/vsan"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+)
+
+// NewVsanFileServiceSimulator returns the vSAN health service registry of a
+// simulated VsanFileServiceSystem, serving the file share snapshot methods.
+func NewVsanFileServiceSimulator() *simulator.Registry {
+	r := simulator.NewRegistry()
+	r.Namespace = vsan.Namespace
+	r.Path = vsan.Path
+	r.Put(&VsanFileServiceSystem{
+		ManagedObjectReference: vsphere.VsanFileServiceSystemInstance,
+		snapshots:              make(map[string][]vsphere.VsanFileShareSnapshot),
+	})
+	return r
+}
+
+// VsanFileServiceSystem simulates the file share snapshots of the vSAN file
+// service. The file shares themselves are not simulated.
+type VsanFileServiceSystem struct {
+	vimtypes.ManagedObjectReference
+
+	mutex     sync.Mutex
+	snapshots map[string][]vsphere.VsanFileShareSnapshot
+}
+
+type vsanCreateFileShareSnapshotBody struct {
+	Res    *vsphere.VsanCreateFileShareSnapshotResponse `xml:"urn:vsan VsanCreateFileShareSnapshotResponse,omitempty"`
+	Fault_ *soap.Fault                                  `xml:"http://schemas.xmlsoap.org/soap/envelope/ Fault,omitempty"`
+}
+
+func (b *vsanCreateFileShareSnapshotBody) Fault() *soap.Fault { return b.Fault_ }
+
+type vsanRemoveFileShareSnapshotBody struct {
+	Res    *vsphere.VsanRemoveFileShareSnapshotResponse `xml:"urn:vsan VsanRemoveFileShareSnapshotResponse,omitempty"`
+	Fault_ *soap.Fault                                  `xml:"http://schemas.xmlsoap.org/soap/envelope/ Fault,omitempty"`
+}
+
+func (b *vsanRemoveFileShareSnapshotBody) Fault() *soap.Fault { return b.Fault_ }
+
+type vsanQueryFileShareSnapshotsBody struct {
+	Res    *vsphere.VsanQueryFileShareSnapshotsResponse `xml:"urn:vsan VsanQueryFileShareSnapshotsResponse,omitempty"`
+	Fault_ *soap.Fault                                  `xml:"http://schemas.xmlsoap.org/soap/envelope/ Fault,omitempty"`
+}
+
+func (b *vsanQueryFileShareSnapshotsBody) Fault() *soap.Fault { return b.Fault_ }
+
+// VsanCreateFileShareSnapshot takes a snapshot of the file share. Snapshot
+// names are unique per file share.
+func (s *VsanFileServiceSystem) VsanCreateFileShareSnapshot(ctx *simulator.Context,
+	req *vsphere.VsanCreateFileShareSnapshot) soap.HasFault {
+	task := simulator.CreateTask(s, "createFileShareSnapshot", func(*simulator.Task) (vimtypes.AnyType,
+		vimtypes.BaseMethodFault) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		config := req.SnapshotSpec
+		for _, snapshot := range s.snapshots[config.ShareUuid] {
+			if snapshot.Config.Name == config.Name {
+				return nil, &vimtypes.AlreadyExists{Name: config.Name}
+			}
+		}
+		creationTime := time.Now()
+		s.snapshots[config.ShareUuid] = append(s.snapshots[config.ShareUuid], vsphere.VsanFileShareSnapshot{
+			Config:       config,
+			CreationTime: &creationTime,
+		})
+		return nil, nil
+	})
+	return &vsanCreateFileShareSnapshotBody{
+		Res: &vsphere.VsanCreateFileShareSnapshotResponse{
+			Returnval: task.Run(ctx),
+		},
+	}
+}
+
+// VsanRemoveFileShareSnapshot removes the snapshot of the file share.
+func (s *VsanFileServiceSystem) VsanRemoveFileShareSnapshot(ctx *simulator.Context,
+	req *vsphere.VsanRemoveFileShareSnapshot) soap.HasFault {
+	task := simulator.CreateTask(s, "removeFileShareSnapshot", func(*simulator.Task) (vimtypes.AnyType,
+		vimtypes.BaseMethodFault) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		config := req.SnapshotSpec
+		snapshots := s.snapshots[config.ShareUuid]
+		for i, snapshot := range snapshots {
+			if snapshot.Config.Name == config.Name {
+				s.snapshots[config.ShareUuid] = append(snapshots[:i:i], snapshots[i+1:]...)
+				return nil, nil
+			}
+		}
+		return nil, &vimtypes.NotFound{}
+	})
+	return &vsanRemoveFileShareSnapshotBody{
+		Res: &vsphere.VsanRemoveFileShareSnapshotResponse{
+			Returnval: task.Run(ctx),
+		},
+	}
+}
+
+// VsanQueryFileShareSnapshots returns the snapshots of the file share, or only
+// the ones with the names in the query spec if any.
+func (s *VsanFileServiceSystem) VsanQueryFileShareSnapshots(ctx *simulator.Context,
+	req *vsphere.VsanQueryFileShareSnapshots) soap.HasFault {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var snapshots []vsphere.VsanFileShareSnapshot
+	for _, snapshot := range s.snapshots[req.QuerySpec.ShareUuid] {
+		if len(req.QuerySpec.Names) == 0 || slices.Contains(req.QuerySpec.Names, snapshot.Config.Name) {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	return &vsanQueryFileShareSnapshotsBody{
+		Res: &vsphere.VsanQueryFileShareSnapshotsResponse{
+			Returnval: snapshots,
+		},
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 
@@ -26,8 +27,18 @@ var (
 	ctx                     context.Context
 	commonUtilsTestInstance *commonUtilsTest
 	onceForControllerTest   sync.Once
+	// simConfigDir is the temporary directory of the vcsim config file.
+	simConfigDir string
 )
 
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if simConfigDir != "" {
+		os.RemoveAll(simConfigDir)
+	}
+	os.Exit(code)
+}
+
 type commonUtilsTest struct {
 	config  *cnsconfig.Config
 	vcenter *cnsvsphere.VirtualCenter
@@ -69,13 +80,18 @@ func configFromCustomizedSimWithTLS(tlsConfig *tls.Config, insecureAllowed bool)
 	cfg.Global.Password, _ = s.URL.User.Password()
 	cfg.Global.Datacenters = "DC0"
 
-	// Write values to test_vsphere.conf
-	os.Setenv("VSPHERE_CSI_CONFIG", "test_vsphere.conf")
+	// Write values to test_vsphere.conf in a temporary directory.
+	simConfigDir, err = os.MkdirTemp("", "vsphere-csi-config")
+	if err != nil {
+		log.Fatal(err)
+	}
+	confPath := filepath.Join(simConfigDir, "test_vsphere.conf")
+	os.Setenv("VSPHERE_CSI_CONFIG", confPath)
 	conf := []byte(fmt.Sprintf("[Global]\ninsecure-flag = \"%t\"\n"+
 		"[VirtualCenter \"%s\"]\nuser = \"%s\"\npassword = \"%s\"\ndatacenters = \"%s\"\nport = \"%s\"",
 		cfg.Global.InsecureFlag, cfg.Global.VCenterIP, cfg.Global.User, cfg.Global.Password,
 		cfg.Global.Datacenters, cfg.Global.VCenterPort))
-	err = os.WriteFile("test_vsphere.conf", conf, 0644)
+	err = os.WriteFile(confPath, conf, 0644)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -72,6 +72,49 @@ const (
 	// the given storage policy. For Example: HostLocal: "True".
 	AttributeHostLocal = "hostlocal"
 
+	// AttributeEncrypted represents a request for node-side LUKS encryption of
+	// a block volume. For Example: Encrypted: "true".
+	AttributeEncrypted = "encrypted"
+
+	// AttributeFsckOnStage represents a request to check and repair the
+	// filesystem of a block volume before it is mounted on the node.
+	// For Example: FsckOnStage: "true".
+	AttributeFsckOnStage = "fsckonstage"
+
+	// AttributeMkfsOptions represents the options passed to mkfs when a block
+	// volume is formatted for the first time. For Example:
+	// MkfsOptions: "-i 8192 -m 1".
+	AttributeMkfsOptions = "mkfsoptions"
+
+	// AttributeDiskFormat represents the provisioning type of the backing
+	// disk of a block volume. For Example: DiskFormat: "eagerzeroedthick".
+	AttributeDiskFormat = "diskformat"
+
+	// DiskFormatThin is the diskformat value for a thin provisioned disk.
+	DiskFormatThin = "thin"
+
+	// DiskFormatLazyZeroedThick is the diskformat value for a lazy zeroed
+	// thick provisioned disk.
+	DiskFormatLazyZeroedThick = "lazyzeroedthick"
+
+	// DiskFormatEagerZeroedThick is the diskformat value for an eager zeroed
+	// thick provisioned disk.
+	DiskFormatEagerZeroedThick = "eagerzeroedthick"
+
+	// AttributeIopsLimit represents the upper limit of IOPS of a block volume
+	// while it is attached to a node VM. For Example: IopsLimit: "1000".
+	AttributeIopsLimit = "iopslimit"
+
+	// AttributeIopsShares represents the Storage I/O Control shares of a block
+	// volume while it is attached to a node VM. Accepted values are "low",
+	// "normal", "high" or a positive number of custom shares.
+	// For Example: IopsShares: "high".
+	AttributeIopsShares = "iopsshares"
+
+	// LuksPassphraseKey is the key in the node stage secret that holds the
+	// LUKS passphrase for an encrypted volume.
+	LuksPassphraseKey = "passphrase"
+
 	// AttributePvName represents the name of the PV
 	AttributePvName = "csi.storage.k8s.io/pv/name"
 
@@ -84,6 +127,10 @@ const (
 	// AttributeStorageClassName represents name of the Storage Class.
 	AttributeStorageClassName = "csi.storage.k8s.io/sc/name"
 
+	// CSIParameterPrefix is the prefix of the Storage Class parameters reserved
+	// for the CSI sidecars.
+	CSIParameterPrefix = "csi.storage.k8s.io/"
+
 	// HostMoidAnnotationKey represents the Node annotation key that has the value
 	// of VC's ESX host moid of this node.
 	HostMoidAnnotationKey = "vmware-system-esxi-node-moid"
@@ -286,6 +333,14 @@ const (
 	// VSphereCSISnapshotIdDelimiter is the delimiter for concatenating CNS VolumeID and CNS SnapshotID
 	VSphereCSISnapshotIdDelimiter = "+"
 
+	// VSphereCSIFileSnapshotIdDelimiter is the delimiter for concatenating the file volume ID and the name
+	// of a vSAN file share snapshot. The distinct delimiter lets the controller route file snapshots
+	// to the vSAN file service.
+	VSphereCSIFileSnapshotIdDelimiter = "@"
+
+	// FileVolumeIDPrefix is the prefix of the CNS volume ID of file share volumes.
+	FileVolumeIDPrefix = "file:"
+
 	// TopologyLabelsDomain is the domain name used to identify user-defined
 	// topology labels applied on the node by vSphere CSI driver.
 	TopologyLabelsDomain = "topology.csi.vmware.com"
@@ -426,6 +481,52 @@ const (
 	// WorkloadDomainIsolation is the name of the WCP capability which determines if
 	// workload domain isolation feature is available on a supervisor cluster.
 	WorkloadDomainIsolation = "Workload_Domain_Isolation_Supported"
+	// GetCapacity enables the CSI GetCapacity API used for storage capacity tracking.
+	GetCapacity = "get-capacity"
+	// BlockVolumeClone enables creating block volumes from an existing block volume.
+	BlockVolumeClone = "block-volume-clone"
+	// ControllerGetVolume enables the CSI ControllerGetVolume API and volume condition reporting.
+	ControllerGetVolume = "controller-get-volume"
+	// ControllerModifyVolume enables changing the storage policy of a volume through ControllerModifyVolume.
+	ControllerModifyVolume = "controller-modify-volume"
+	// NodeVolumeEncryption enables node-side LUKS encryption of block volumes.
+	NodeVolumeEncryption = "node-volume-encryption"
+	// NodeVolumeCondition enables reporting the volume condition from NodeGetVolumeStats.
+	NodeVolumeCondition = "node-volume-condition"
+	// FsckOnStage enables checking the filesystem of block volumes before they are staged.
+	FsckOnStage = "fsck-on-stage"
+	// MkfsOptions enables the mkfsoptions StorageClass parameter for block volumes.
+	MkfsOptions = "mkfs-options"
+	// DiskFormat enables the diskformat StorageClass parameter for block volumes.
+	DiskFormat = "disk-format"
+	// IopsAllocation enables the iopslimit and iopsshares StorageClass parameters for block volumes.
+	IopsAllocation = "iops-allocation"
+	// VanillaCnsRegisterVolume enables importing volumes through CnsRegisterVolume in vanilla clusters.
+	VanillaCnsRegisterVolume = "vanilla-cns-register-volume"
+	// VanillaCnsUnregisterVolume enables detaching volumes from the cluster through CnsUnregisterVolume
+	// in vanilla clusters.
+	VanillaCnsUnregisterVolume = "vanilla-cns-unregister-volume"
+	// VanillaVolumeHandoff enables handing volumes over between vanilla clusters on the same vCenter
+	// through CnsVolumeExport and CnsVolumeImport.
+	VanillaVolumeHandoff = "vanilla-volume-handoff"
+	// OrphanVolumeQuarantine enables recording volumes missing in kubernetes in OrphanVolume instances,
+	// instead of removing their CNS metadata during full sync.
+	OrphanVolumeQuarantine = "orphan-volume-quarantine"
+	// BatchAttachDetach enables attaching and detaching the volumes of the same node VM requested within
+	// a short window with a single CNS call.
+	BatchAttachDetach = "batch-attach-detach"
+	// VolumeGroupSnapshot enables the CSI GroupController service taking crash-consistent snapshots
+	// of a group of block volumes.
+	VolumeGroupSnapshot = "volume-group-snapshot"
+	// SnapshotRestoreRelocation enables restoring a snapshot onto a datastore other than the one of the
+	// snapshot, by relocating the restored volume to a datastore matching the StorageClass and topology.
+	SnapshotRestoreRelocation = "snapshot-restore-relocation"
+	// FileVolumeSnapshot enables creating, deleting and listing snapshots of vSAN file share volumes.
+	// Restoring a file share snapshot to a new volume is not supported.
+	FileVolumeSnapshot = "file-volume-snapshot"
+	// SnapshotMetadataService enables the CSI SnapshotMetadata service reporting the allocated and
+	// changed blocks of block volume snapshots from the changed block tracking of the FCDs.
+	SnapshotMetadataService = "snapshot-metadata-service"
 )
 
 var WCPFeatureStates = map[string]struct{}{
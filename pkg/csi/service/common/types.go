@@ -87,6 +87,10 @@ type CreateVolumeSpec struct {
 	VolumeType              string
 	VsanDatastoreURL        string // Datastore URL used by host local volumes (vSAN Direct/vSAN SNA)
 	ContentSourceSnapshotID string // SnapshotID from VolumeContentSource in CreateVolumeRequest
+	ContentSourceVolumeID   string // VolumeID from VolumeContentSource in CreateVolumeRequest
+	// AllowRestoreRelocation allows restoring ContentSourceSnapshotID on the datastore of the snapshot
+	// when it is not among the candidate datastores, so that the volume can be relocated afterwards.
+	AllowRestoreRelocation bool
 }
 
 // StorageClassParams represents the storage class parameterss
@@ -95,4 +99,10 @@ type StorageClassParams struct {
 	StoragePolicyName string
 	CSIMigration      string
 	Datastore         string
+	Encrypted         bool
+	FsckOnStage       bool
+	MkfsOptions       string
+	DiskFormat        string
+	IopsLimit         string
+	IopsShares        string
 }
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -170,8 +171,8 @@ func validateVolumeCapabilities(volCaps []*csi.VolumeCapability,
 	// Validate if all capabilities of the volume are supported.
 	for _, volCap := range volCaps {
 		found := false
-		for _, validAccessMode := range validAccessModes {
-			if volCap.AccessMode.GetMode() == validAccessMode.GetMode() {
+		for i := range validAccessModes {
+			if volCap.AccessMode.GetMode() == validAccessModes[i].GetMode() {
 				found = true
 				break
 			}
@@ -238,6 +239,36 @@ func ParseStorageClassParams(ctx context.Context, params map[string]string,
 				scParams.StoragePolicyName = value
 			} else if param == AttributeFsType {
 				log.Warnf("param 'fstype' is deprecated, please use 'csi.storage.k8s.io/fstype' instead")
+			} else if param == AttributeEncrypted {
+				encrypted, err := strconv.ParseBool(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid value %q for param %q", value, param)
+				}
+				scParams.Encrypted = encrypted
+			} else if param == AttributeFsckOnStage {
+				fsckOnStage, err := strconv.ParseBool(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid value %q for param %q", value, param)
+				}
+				scParams.FsckOnStage = fsckOnStage
+			} else if param == AttributeMkfsOptions {
+				scParams.MkfsOptions = value
+			} else if param == AttributeDiskFormat {
+				diskFormat := strings.ToLower(value)
+				if _, ok := DiskFormatToProvisioningType[diskFormat]; !ok {
+					return nil, fmt.Errorf("invalid value %q for param %q", value, param)
+				}
+				scParams.DiskFormat = diskFormat
+			} else if param == AttributeIopsLimit {
+				if _, err := ParseStorageIOAllocation(value, ""); err != nil {
+					return nil, err
+				}
+				scParams.IopsLimit = value
+			} else if param == AttributeIopsShares {
+				if _, err := ParseStorageIOAllocation("", value); err != nil {
+					return nil, err
+				}
+				scParams.IopsShares = strings.ToLower(value)
 			} else {
 				return nil, fmt.Errorf("invalid param: %q and value: %q", param, value)
 			}
@@ -252,6 +283,36 @@ func ParseStorageClassParams(ctx context.Context, params map[string]string,
 				scParams.StoragePolicyName = value
 			} else if param == AttributeFsType {
 				log.Warnf("param 'fstype' is deprecated, please use 'csi.storage.k8s.io/fstype' instead")
+			} else if param == AttributeEncrypted {
+				encrypted, err := strconv.ParseBool(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid value %q for param %q", value, param)
+				}
+				scParams.Encrypted = encrypted
+			} else if param == AttributeFsckOnStage {
+				fsckOnStage, err := strconv.ParseBool(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid value %q for param %q", value, param)
+				}
+				scParams.FsckOnStage = fsckOnStage
+			} else if param == AttributeMkfsOptions {
+				scParams.MkfsOptions = value
+			} else if param == AttributeDiskFormat {
+				diskFormat := strings.ToLower(value)
+				if _, ok := DiskFormatToProvisioningType[diskFormat]; !ok {
+					return nil, fmt.Errorf("invalid value %q for param %q", value, param)
+				}
+				scParams.DiskFormat = diskFormat
+			} else if param == AttributeIopsLimit {
+				if _, err := ParseStorageIOAllocation(value, ""); err != nil {
+					return nil, err
+				}
+				scParams.IopsLimit = value
+			} else if param == AttributeIopsShares {
+				if _, err := ParseStorageIOAllocation("", value); err != nil {
+					return nil, err
+				}
+				scParams.IopsShares = strings.ToLower(value)
 			} else if param == CSIMigrationParams {
 				scParams.CSIMigration = value
 			} else {
@@ -285,6 +346,89 @@ func ParseStorageClassParams(ctx context.Context, params map[string]string,
 	return scParams, nil
 }
 
+// DiskFormatToProvisioningType maps the values of the diskformat StorageClass
+// parameter to the provisioning type of the backing disk.
+var DiskFormatToProvisioningType = map[string]string{
+	DiskFormatThin:             string(types.BaseConfigInfoDiskFileBackingInfoProvisioningTypeThin),
+	DiskFormatLazyZeroedThick:  string(types.BaseConfigInfoDiskFileBackingInfoProvisioningTypeLazyZeroedThick),
+	DiskFormatEagerZeroedThick: string(types.BaseConfigInfoDiskFileBackingInfoProvisioningTypeEagerZeroedThick),
+}
+
+// ParseStorageIOAllocation converts the iopslimit and iopsshares parameters of
+// a block volume into the Storage I/O Control settings of its virtual disk.
+// nil is returned if neither parameter is set.
+func ParseStorageIOAllocation(iopsLimit string, iopsShares string) (*types.StorageIOAllocationInfo, error) {
+	if iopsLimit == "" && iopsShares == "" {
+		return nil, nil
+	}
+	ioAllocation := &types.StorageIOAllocationInfo{}
+	if iopsLimit != "" {
+		limit, err := strconv.ParseInt(iopsLimit, 10, 64)
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("invalid value %q for param %q, it must be a positive integer",
+				iopsLimit, AttributeIopsLimit)
+		}
+		ioAllocation.Limit = &limit
+	}
+	if iopsShares != "" {
+		switch level := types.SharesLevel(strings.ToLower(iopsShares)); level {
+		case types.SharesLevelLow, types.SharesLevelNormal, types.SharesLevelHigh:
+			ioAllocation.Shares = &types.SharesInfo{Level: level}
+		default:
+			shares, err := strconv.ParseInt(iopsShares, 10, 32)
+			if err != nil || shares <= 0 {
+				return nil, fmt.Errorf("invalid value %q for param %q, it must be low, normal, high "+
+					"or a positive integer", iopsShares, AttributeIopsShares)
+			}
+			ioAllocation.Shares = &types.SharesInfo{Level: types.SharesLevelCustom, Shares: int32(shares)}
+		}
+	}
+	return ioAllocation, nil
+}
+
+// mkfsOptionValueRegex matches values accepted for mkfs options.
+var mkfsOptionValueRegex = regexp.MustCompile(`^[a-zA-Z0-9_=,.:^+-]+$`)
+
+// allowedMkfsOptions lists the mkfs options which can be set through the
+// mkfsoptions StorageClass parameter for each filesystem. Every option takes
+// a value.
+var allowedMkfsOptions = map[string]map[string]struct{}{
+	// -b block size, -i bytes per inode, -I inode size, -m reserved blocks
+	// percentage, -N number of inodes, -E extended options, -O features,
+	// -T usage type, -J journal options.
+	Ext4FsType: {"-b": {}, "-i": {}, "-I": {}, "-m": {}, "-N": {}, "-E": {}, "-O": {}, "-T": {}, "-J": {}},
+	Ext3FsType: {"-b": {}, "-i": {}, "-I": {}, "-m": {}, "-N": {}, "-E": {}, "-O": {}, "-T": {}, "-J": {}},
+	// -b block size, -i inode options, -m metadata options like crc and
+	// reflink, -n naming options, -l log options, -d data options,
+	// -s sector size.
+	XFSType: {"-b": {}, "-i": {}, "-m": {}, "-n": {}, "-l": {}, "-d": {}, "-s": {}},
+}
+
+// ParseMkfsOptions validates the mkfsoptions StorageClass parameter for the
+// given filesystem type and returns the options as arguments for mkfs.
+func ParseMkfsOptions(fsType string, mkfsOptions string) ([]string, error) {
+	if fsType == "" {
+		fsType = Ext4FsType
+	}
+	allowed, ok := allowedMkfsOptions[strings.ToLower(fsType)]
+	if !ok {
+		return nil, fmt.Errorf("%s is not supported for fstype %q", AttributeMkfsOptions, fsType)
+	}
+	args := strings.Fields(mkfsOptions)
+	if len(args)%2 != 0 {
+		return nil, fmt.Errorf("invalid %s %q, every option requires a value", AttributeMkfsOptions, mkfsOptions)
+	}
+	for i := 0; i < len(args); i += 2 {
+		if _, ok := allowed[args[i]]; !ok {
+			return nil, fmt.Errorf("mkfs option %q is not supported for fstype %q", args[i], fsType)
+		}
+		if !mkfsOptionValueRegex.MatchString(args[i+1]) {
+			return nil, fmt.Errorf("invalid value %q for mkfs option %q", args[i+1], args[i])
+		}
+	}
+	return args, nil
+}
+
 // GetK8sCloudOperatorServicePort return the port to connect the
 // K8sCloudOperator gRPC service.
 // If environment variable POD_LISTENER_SERVICE_PORT is set and valid,
@@ -335,6 +479,36 @@ func ConvertVolumeHealthStatus(ctx context.Context, volID string, volHealthStatu
 	}
 }
 
+// GetVolumeCondition converts the CNS health status of a volume into a CSI VolumeCondition.
+func GetVolumeCondition(ctx context.Context, volID string, volHealthStatus string) *csi.VolumeCondition {
+	healthStatus, _ := ConvertVolumeHealthStatus(ctx, volID, volHealthStatus)
+	return GetVolumeConditionFromHealthStatus(volID, healthStatus)
+}
+
+// GetVolumeConditionFromHealthStatus converts the accessible/inaccessible
+// health status of a volume, as returned by ConvertVolumeHealthStatus, into a
+// CSI VolumeCondition.
+func GetVolumeConditionFromHealthStatus(volID string, healthStatus string) *csi.VolumeCondition {
+	switch healthStatus {
+	case VolHealthStatusInaccessible:
+		return &csi.VolumeCondition{
+			Abnormal: true,
+			Message:  fmt.Sprintf("volume %s is inaccessible", volID),
+		}
+	case VolHealthStatusAccessible:
+		return &csi.VolumeCondition{
+			Abnormal: false,
+			Message:  fmt.Sprintf("volume %s is accessible", volID),
+		}
+	default:
+		// Health status is not yet known to SPBM, do not report the volume as abnormal.
+		return &csi.VolumeCondition{
+			Abnormal: false,
+			Message:  fmt.Sprintf("health status of volume %s is unknown", volID),
+		}
+	}
+}
+
 // ParseCSISnapshotID parses the SnapshotID from CSI RPC such as DeleteSnapshot, CreateVolume from snapshot
 // into a pair of CNS VolumeID and CNS SnapshotID.
 func ParseCSISnapshotID(csiSnapshotID string) (string, string, error) {
@@ -356,6 +530,38 @@ func ParseCSISnapshotID(csiSnapshotID string) (string, string, error) {
 	return cnsVolumeID, cnsSnapshotID, nil
 }
 
+// IsFileVolumeID returns true if the given CNS VolumeID is the one of a file share volume.
+func IsFileVolumeID(volumeID string) bool {
+	return strings.HasPrefix(volumeID, FileVolumeIDPrefix)
+}
+
+// IsFileVolumeSnapshotID returns true if the given CSI SnapshotID is the one of a vSAN file share snapshot.
+func IsFileVolumeSnapshotID(csiSnapshotID string) bool {
+	return IsFileVolumeID(csiSnapshotID) && strings.Contains(csiSnapshotID, VSphereCSIFileSnapshotIdDelimiter)
+}
+
+// GetCSIFileSnapshotID returns the CSI SnapshotID of the vSAN file share snapshot with
+// the given name, taken of the given file volume.
+func GetCSIFileSnapshotID(fileVolumeID string, snapshotName string) string {
+	return fileVolumeID + VSphereCSIFileSnapshotIdDelimiter + snapshotName
+}
+
+// ParseCSIFileSnapshotID parses the SnapshotID of a vSAN file share snapshot from CSI RPC
+// into a pair of CNS file VolumeID and file share snapshot name.
+func ParseCSIFileSnapshotID(csiSnapshotID string) (string, string, error) {
+	if csiSnapshotID == "" {
+		return "", "", errors.New("csiSnapshotID from the input is empty")
+	}
+
+	// The expected format is the CNS file VolumeID and the file share snapshot name
+	// concatenated by the "@" sign. That is, a string of "file:<UUID>@<name>".
+	IDs := strings.Split(csiSnapshotID, VSphereCSIFileSnapshotIdDelimiter)
+	if len(IDs) != 2 || !IsFileVolumeID(IDs[0]) || IDs[0] == FileVolumeIDPrefix || IDs[1] == "" {
+		return "", "", fmt.Errorf("unexpected format in file csiSnapshotID: %v", csiSnapshotID)
+	}
+	return IDs[0], IDs[1], nil
+}
+
 // Contains check if item exist in list
 func Contains(list []string, item string) bool {
 	for _, x := range list {
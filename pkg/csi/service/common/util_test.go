@@ -20,10 +20,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/vmware/govmomi/vim25/types"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 )
@@ -364,6 +366,12 @@ func isStorageClassParamsEqual(expected *StorageClassParams, actual *StorageClas
 	if expected.StoragePolicyName != actual.StoragePolicyName {
 		return false
 	}
+	if expected.Encrypted != actual.Encrypted {
+		return false
+	}
+	if expected.DiskFormat != actual.DiskFormat {
+		return false
+	}
 	return true
 }
 
@@ -401,6 +409,139 @@ func TestParseStorageClassParamsWithValidParams(t *testing.T) {
 	}
 }
 
+func TestParseStorageClassParamsWithEncrypted(t *testing.T) {
+	params := map[string]string{
+		AttributeStoragePolicyName: "policy1",
+		AttributeEncrypted:         "true",
+	}
+	expectedScParams := &StorageClassParams{
+		StoragePolicyName: "policy1",
+		Encrypted:         true,
+	}
+	for _, csiMigrationFeatureState := range []bool{false, true} {
+		actualScParams, err := ParseStorageClassParams(ctx, params, csiMigrationFeatureState)
+		if err != nil {
+			t.Errorf("failed to parse params: %+v", params)
+			continue
+		}
+		if !isStorageClassParamsEqual(expectedScParams, actualScParams) {
+			t.Errorf("Expected: %+v\n Actual: %+v", expectedScParams, actualScParams)
+		}
+	}
+	params[AttributeEncrypted] = "yes please"
+	scParam, err := ParseStorageClassParams(ctx, params, false)
+	if err == nil {
+		t.Errorf("error expected but not received. scParam received from ParseStorageClassParams: %v", scParam)
+	}
+}
+
+func TestParseStorageClassParamsWithDiskFormat(t *testing.T) {
+	params := map[string]string{
+		AttributeStoragePolicyName: "policy1",
+		"DiskFormat":               "EagerZeroedThick",
+	}
+	expectedScParams := &StorageClassParams{
+		StoragePolicyName: "policy1",
+		DiskFormat:        DiskFormatEagerZeroedThick,
+	}
+	for _, csiMigrationFeatureState := range []bool{false, true} {
+		actualScParams, err := ParseStorageClassParams(ctx, params, csiMigrationFeatureState)
+		if err != nil {
+			t.Errorf("failed to parse params: %+v", params)
+			continue
+		}
+		if !isStorageClassParamsEqual(expectedScParams, actualScParams) {
+			t.Errorf("Expected: %+v\n Actual: %+v", expectedScParams, actualScParams)
+		}
+	}
+	params["DiskFormat"] = "zeroedthick"
+	scParam, err := ParseStorageClassParams(ctx, params, false)
+	if err == nil {
+		t.Errorf("error expected but not received. scParam received from ParseStorageClassParams: %v", scParam)
+	}
+}
+
+func TestParseStorageIOAllocation(t *testing.T) {
+	tests := []struct {
+		iopsLimit  string
+		iopsShares string
+		limit      int64
+		shares     *types.SharesInfo
+		expectErr  bool
+	}{
+		{iopsLimit: "1000", limit: 1000},
+		{iopsShares: "High", shares: &types.SharesInfo{Level: types.SharesLevelHigh}},
+		{iopsLimit: "200", iopsShares: "1500", limit: 200,
+			shares: &types.SharesInfo{Level: types.SharesLevelCustom, Shares: 1500}},
+		{iopsLimit: "0", expectErr: true},
+		{iopsLimit: "unlimited", expectErr: true},
+		{iopsShares: "custom", expectErr: true},
+		{iopsShares: "-5", expectErr: true},
+	}
+	for _, test := range tests {
+		actual, err := ParseStorageIOAllocation(test.iopsLimit, test.iopsShares)
+		if test.expectErr {
+			if err == nil {
+				t.Errorf("expected error for iopslimit %q and iopsshares %q", test.iopsLimit, test.iopsShares)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for iopslimit %q and iopsshares %q: %v", test.iopsLimit,
+				test.iopsShares, err)
+			continue
+		}
+		if test.limit != 0 && (actual.Limit == nil || *actual.Limit != test.limit) {
+			t.Errorf("expected limit %d for iopslimit %q, got %+v", test.limit, test.iopsLimit, actual.Limit)
+		}
+		if !reflect.DeepEqual(test.shares, actual.Shares) {
+			t.Errorf("expected shares %+v for iopsshares %q, got %+v", test.shares, test.iopsShares, actual.Shares)
+		}
+	}
+	if ioAllocation, err := ParseStorageIOAllocation("", ""); err != nil || ioAllocation != nil {
+		t.Errorf("expected no IO allocation without parameters, got %+v, %v", ioAllocation, err)
+	}
+}
+
+func TestParseMkfsOptions(t *testing.T) {
+	tests := []struct {
+		fsType      string
+		mkfsOptions string
+		expected    []string
+		expectErr   bool
+	}{
+		{fsType: "", mkfsOptions: "-i 8192 -m 1", expected: []string{"-i", "8192", "-m", "1"}},
+		{fsType: "ext4", mkfsOptions: " -b 4096  -E lazy_itable_init=0,nodiscard ",
+			expected: []string{"-b", "4096", "-E", "lazy_itable_init=0,nodiscard"}},
+		{fsType: "xfs", mkfsOptions: "-m reflink=1,crc=1", expected: []string{"-m", "reflink=1,crc=1"}},
+		// Missing value.
+		{fsType: "ext4", mkfsOptions: "-m", expectErr: true},
+		// Option not in the allowed list.
+		{fsType: "ext4", mkfsOptions: "-F 1", expectErr: true},
+		// -I is not an xfs option.
+		{fsType: "xfs", mkfsOptions: "-I 512", expectErr: true},
+		// Invalid characters in the value.
+		{fsType: "ext4", mkfsOptions: "-O $(reboot)", expectErr: true},
+		{fsType: "ntfs", mkfsOptions: "-b 4096", expectErr: true},
+	}
+	for _, test := range tests {
+		actual, err := ParseMkfsOptions(test.fsType, test.mkfsOptions)
+		if test.expectErr {
+			if err == nil {
+				t.Errorf("expected error for fstype %q and options %q", test.fsType, test.mkfsOptions)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for fstype %q and options %q: %v", test.fsType, test.mkfsOptions, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("expected %v, got %v", test.expected, actual)
+		}
+	}
+}
+
 func TestParseStorageClassParamsWithMigrationEnabledNagative(t *testing.T) {
 	csiMigrationFeatureState := true
 	params := map[string]string{
@@ -536,3 +677,57 @@ func TestParseCSISnapshotID(t *testing.T) {
 		})
 	}
 }
+
+func TestParseCSIFileSnapshotID(t *testing.T) {
+	sampleFileVolumeID := FileVolumeIDPrefix + uuid.New().String()
+	sampleSnapshotName := "snapshot-" + uuid.New().String()
+	tests := []struct {
+		name                 string
+		csiSnapshotID        string
+		expectedFileVolumeID string
+		expectedSnapshotName string
+		expectErr            bool
+	}{
+		{
+			name:                 "ExpectedCSIFileSnapshotID",
+			csiSnapshotID:        GetCSIFileSnapshotID(sampleFileVolumeID, sampleSnapshotName),
+			expectedFileVolumeID: sampleFileVolumeID,
+			expectedSnapshotName: sampleSnapshotName,
+		},
+		{
+			name:          "EmptyCSIFileSnapshotID",
+			csiSnapshotID: "",
+			expectErr:     true,
+		},
+		{
+			name:          "BlockCSISnapshotID",
+			csiSnapshotID: uuid.New().String() + VSphereCSISnapshotIdDelimiter + uuid.New().String(),
+			expectErr:     true,
+		},
+		{
+			name:          "MissingSnapshotName",
+			csiSnapshotID: sampleFileVolumeID + VSphereCSIFileSnapshotIdDelimiter,
+			expectErr:     true,
+		},
+		{
+			name:          "MissingFileVolumeID",
+			csiSnapshotID: FileVolumeIDPrefix + VSphereCSIFileSnapshotIdDelimiter + sampleSnapshotName,
+			expectErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileVolumeID, snapshotName, err := ParseCSIFileSnapshotID(tt.csiSnapshotID)
+			assert.Equal(t, tt.expectErr, err != nil)
+			assert.Equal(t, tt.expectedFileVolumeID, fileVolumeID)
+			assert.Equal(t, tt.expectedSnapshotName, snapshotName)
+		})
+	}
+}
+
+func TestIsFileVolumeSnapshotID(t *testing.T) {
+	fileVolumeID := FileVolumeIDPrefix + uuid.New().String()
+	assert.True(t, IsFileVolumeSnapshotID(GetCSIFileSnapshotID(fileVolumeID, "snapshot-1")))
+	assert.False(t, IsFileVolumeSnapshotID(fileVolumeID))
+	assert.False(t, IsFileVolumeSnapshotID(uuid.New().String()+VSphereCSISnapshotIdDelimiter+uuid.New().String()))
+}
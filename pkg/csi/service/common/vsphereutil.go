@@ -203,7 +203,7 @@ func CreateBlockVolumeUtil(ctx context.Context, clusterFlavor cnstypes.CnsCluste
 		clusterID = manager.CnsConfig.Global.SupervisorID
 	}
 	containerCluster := vsphere.GetContainerCluster(clusterID,
-		manager.CnsConfig.VirtualCenter[vc.Config.Host].User, clusterFlavor,
+		vc.Config.Username, clusterFlavor,
 		manager.CnsConfig.Global.ClusterDistribution)
 	containerClusterArray = append(containerClusterArray, containerCluster)
 	createSpec := &cnstypes.CnsVolumeCreateSpec{
@@ -284,9 +284,24 @@ func CreateBlockVolumeUtil(ctx context.Context, clusterFlavor cnstypes.CnsCluste
 			}
 		}
 		if !foundCompatibleDatastore {
-			return nil, csifault.CSIInternalFault, logger.LogNewErrorf(log,
-				"failed to get the compatible datastore for create volume from snapshot %s with error: %+v",
-				spec.ContentSourceSnapshotID, err)
+			if !spec.AllowRestoreRelocation {
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorf(log,
+					"failed to get the compatible datastore for create volume from snapshot %s with error: %+v",
+					spec.ContentSourceSnapshotID, err)
+			}
+			// Restore the volume on the datastore of the snapshot. The caller relocates
+			// it to one of the candidate datastores and applies the storage policy.
+			snapshotDatastores, err := getDatastoreInfoObjList(ctx, vc, cnsVolume.DatastoreUrl)
+			if err != nil {
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorf(log,
+					"failed to find datastore %q of snapshot %s with error: %+v",
+					cnsVolume.DatastoreUrl, spec.ContentSourceSnapshotID, err)
+			}
+			log.Infof("Datastore %q of snapshot %s is not among the candidate datastores. "+
+				"Restoring the volume on it to relocate it afterwards.", cnsVolume.DatastoreUrl,
+				spec.ContentSourceSnapshotID)
+			compatibleDatastore = snapshotDatastores[0].Datastore.Reference()
+			createSpec.Profile = nil
 		}
 
 		// overwrite the datatstores field in create spec with the compatible datastore
@@ -296,6 +311,32 @@ func CreateBlockVolumeUtil(ctx context.Context, clusterFlavor cnstypes.CnsCluste
 		createSpec.Datastores = []vim25types.ManagedObjectReference{compatibleDatastore}
 	}
 
+	// Handle the case of CreateVolume from an existing volume by checking if
+	// the ContentSourceVolumeID is available in CreateVolumeSpec.
+	if spec.ContentSourceVolumeID != "" {
+		targetDatastore, err := getCloneTargetDatastore(ctx, vc, manager.VolumeManager, spec.ContentSourceVolumeID,
+			spec.StoragePolicyID, datastoreInfoList)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, err
+		}
+		createSpec.Datastores = []vim25types.ManagedObjectReference{targetDatastore.Reference()}
+		log.Debugf("vSphere CSI driver cloning volume %s to volume %s with create spec %+v",
+			spec.ContentSourceVolumeID, spec.Name, spew.Sdump(createSpec))
+		volumeInfo, faultType, err := manager.VolumeManager.CloneVolume(ctx, spec.ContentSourceVolumeID,
+			createSpec, extraParams)
+		if err != nil {
+			log.Errorf("failed to clone volume %s to %s with error %+v faultType %q",
+				spec.ContentSourceVolumeID, spec.Name, err, faultType)
+			return nil, faultType, err
+		}
+		return volumeInfo, "", nil
+	}
+
+	if spec.ScParams.DiskFormat != "" {
+		return createVolumeWithDiskFormat(ctx, vc, manager.VolumeManager, spec, spec.StoragePolicyID, createSpec,
+			datastoreInfoList, extraParams)
+	}
+
 	log.Debugf("vSphere CSI driver creating volume %s with create spec %+v", spec.Name, spew.Sdump(createSpec))
 	volumeInfo, faultType, err := manager.VolumeManager.CreateVolume(ctx, createSpec, extraParams)
 	if err != nil {
@@ -361,7 +402,7 @@ func CreateBlockVolumeUtilForMultiVC(ctx context.Context, reqParams interface{})
 	var containerClusterArray []cnstypes.CnsContainerCluster
 	clusterID := params.CNSConfig.Global.ClusterID
 	containerCluster := vsphere.GetContainerCluster(clusterID,
-		params.CNSConfig.VirtualCenter[params.Vcenter.Config.Host].User, params.ClusterFlavor,
+		params.Vcenter.Config.Username, params.ClusterFlavor,
 		params.CNSConfig.Global.ClusterDistribution)
 	containerClusterArray = append(containerClusterArray, containerCluster)
 	createSpec := &cnstypes.CnsVolumeCreateSpec{
@@ -410,9 +451,17 @@ func CreateBlockVolumeUtilForMultiVC(ctx context.Context, reqParams interface{})
 			}
 		}
 		if !isSharedDatastoreURL {
-			return nil, csifault.CSIInternalFault, logger.LogNewErrorf(log,
-				"failed to get the compatible shared datastore for create volume from snapshot %q in vCenter %q",
+			if !params.Spec.AllowRestoreRelocation {
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorf(log,
+					"failed to get the compatible shared datastore for create volume from snapshot %q in vCenter %q",
+					params.Spec.ContentSourceSnapshotID, params.Vcenter.Config.Host)
+			}
+			// Restore the volume on the datastore of the snapshot. The caller relocates
+			// it to one of the shared datastores and applies the storage policy.
+			log.Infof("Datastore %q of snapshot %q is not among the shared datastores in vCenter %q. "+
+				"Restoring the volume on it to relocate it afterwards.", params.SnapshotDatastoreURL,
 				params.Spec.ContentSourceSnapshotID, params.Vcenter.Config.Host)
+			createSpec.Profile = nil
 		}
 		// Check if DatastoreURL specified in the StorageClass is present in any one of the datacenters.
 		datastoreInfoObjList, err = getDatastoreInfoObjList(ctx, params.Vcenter, params.SnapshotDatastoreURL)
@@ -434,6 +483,32 @@ func CreateBlockVolumeUtilForMultiVC(ctx context.Context, reqParams interface{})
 		}
 	}
 
+	// Handle the case of CreateVolume from an existing volume by checking if
+	// the ContentSourceVolumeID is available in CreateVolumeSpec.
+	if params.Spec.ContentSourceVolumeID != "" {
+		targetDatastore, err := getCloneTargetDatastore(ctx, params.Vcenter, params.VolumeManager,
+			params.Spec.ContentSourceVolumeID, params.StoragePolicyID, params.SharedDatastores)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, err
+		}
+		createSpec.Datastores = []vim25types.ManagedObjectReference{targetDatastore.Reference()}
+		log.Debugf("vSphere CSI driver cloning volume %s to volume %s with create spec %+v",
+			params.Spec.ContentSourceVolumeID, params.Spec.Name, spew.Sdump(createSpec))
+		volumeInfo, faultType, err := params.VolumeManager.CloneVolume(ctx, params.Spec.ContentSourceVolumeID,
+			createSpec, nil)
+		if err != nil {
+			log.Errorf("failed to clone volume %s to %s on vCenter %q with error %+v faultType %q",
+				params.Spec.ContentSourceVolumeID, params.Spec.Name, params.Vcenter.Config.Host, err, faultType)
+			return nil, faultType, err
+		}
+		return volumeInfo, "", nil
+	}
+
+	if params.Spec.ScParams.DiskFormat != "" {
+		return createVolumeWithDiskFormat(ctx, params.Vcenter, params.VolumeManager, params.Spec,
+			params.StoragePolicyID, createSpec, params.SharedDatastores, nil)
+	}
+
 	log.Debugf("vSphere CSI driver creating volume %s with create spec %+v", params.Spec.Name, spew.Sdump(createSpec))
 	volumeInfo, faultType, err := params.VolumeManager.CreateVolume(ctx, createSpec, nil)
 	if err != nil {
@@ -522,7 +597,7 @@ func CreateFileVolumeUtil(ctx context.Context, clusterFlavor cnstypes.CnsCluster
 	}
 	var containerClusterArray []cnstypes.CnsContainerCluster
 	containerCluster := vsphere.GetContainerCluster(clusterID,
-		cnsConfig.VirtualCenter[vc.Config.Host].User, clusterFlavor,
+		vc.Config.Username, clusterFlavor,
 		cnsConfig.Global.ClusterDistribution)
 	containerClusterArray = append(containerClusterArray, containerCluster)
 	createSpec := &cnstypes.CnsVolumeCreateSpec{
@@ -1044,6 +1119,30 @@ func CreateSnapshotUtil(ctx context.Context, volumeManager cnsvolume.Manager, vo
 	return csiSnapshotID, cnsSnapshotInfo, nil
 }
 
+// CreateGroupSnapshotUtil is the helper function to create the CNS snapshots
+// of the volumes of a volume group snapshot. The CSI snapshot IDs are returned
+// in the order of the CNS snapshots.
+func CreateGroupSnapshotUtil(ctx context.Context, volumeManager cnsvolume.Manager, groupSnapshotName string,
+	volumeIDs []string) ([]string, []*cnsvolume.CnsSnapshotInfo, error) {
+	log := logger.GetLogger(ctx)
+
+	log.Debugf("vSphere CSI driver is creating group snapshot %q on volumes: %v", groupSnapshotName, volumeIDs)
+	cnsSnapshotInfos, err := volumeManager.CreateGroupSnapshot(ctx, groupSnapshotName, volumeIDs)
+	if err != nil {
+		log.Errorf("failed to create group snapshot %q on volumes %v with error %+v",
+			groupSnapshotName, volumeIDs, err)
+		return nil, nil, err
+	}
+	csiSnapshotIDs := make([]string, 0, len(cnsSnapshotInfos))
+	for _, cnsSnapshotInfo := range cnsSnapshotInfos {
+		csiSnapshotIDs = append(csiSnapshotIDs,
+			cnsSnapshotInfo.SourceVolumeID+VSphereCSISnapshotIdDelimiter+cnsSnapshotInfo.SnapshotID)
+	}
+	log.Debugf("Successfully created group snapshot %q with snapshots: %v", groupSnapshotName, csiSnapshotIDs)
+
+	return csiSnapshotIDs, cnsSnapshotInfos, nil
+}
+
 // DeleteSnapshotUtil is the helper function to delete CNS snapshot for given snapshotId
 func DeleteSnapshotUtil(ctx context.Context, volumeManager cnsvolume.Manager, csiSnapshotID string,
 	extraParams interface{}) (*cnsvolume.CnsSnapshotInfo, error) {
@@ -1065,6 +1164,129 @@ func DeleteSnapshotUtil(ctx context.Context, volumeManager cnsvolume.Manager, cs
 	return cnsSnapshotInfo, nil
 }
 
+// CreateFileSnapshotUtil is the helper function to create a vSAN file share snapshot with
+// the given name of the given file volume. An existing snapshot with the same name is
+// returned as is, so that retried CreateSnapshot requests are idempotent.
+func CreateFileSnapshotUtil(ctx context.Context, vc *vsphere.VirtualCenter, cluster vim25types.ManagedObjectReference,
+	fileVolumeID string, snapshotName string) (string, *vsphere.VsanFileShareSnapshot, error) {
+	log := logger.GetLogger(ctx)
+	shareUUID := strings.TrimPrefix(fileVolumeID, FileVolumeIDPrefix)
+	csiSnapshotID := GetCSIFileSnapshotID(fileVolumeID, snapshotName)
+
+	snapshot, err := queryFileShareSnapshot(ctx, vc, cluster, shareUUID, snapshotName)
+	if err != nil {
+		return "", nil, err
+	}
+	if snapshot != nil {
+		log.Infof("File share snapshot %q of volume %q already exists", snapshotName, fileVolumeID)
+		return csiSnapshotID, snapshot, nil
+	}
+
+	log.Debugf("vSphere CSI driver is creating file share snapshot %q on volume: %q", snapshotName, fileVolumeID)
+	task, err := vc.CreateFileShareSnapshot(ctx, cluster, shareUUID, snapshotName)
+	if err == nil {
+		_, err = task.WaitForResult(ctx)
+	}
+	if err != nil {
+		return "", nil, logger.LogNewErrorf(log, "failed to create file share snapshot %q on volume %q with error %+v",
+			snapshotName, fileVolumeID, err)
+	}
+	snapshot, err = queryFileShareSnapshot(ctx, vc, cluster, shareUUID, snapshotName)
+	if err != nil {
+		return "", nil, err
+	}
+	if snapshot == nil {
+		return "", nil, logger.LogNewErrorf(log, "file share snapshot %q of volume %q not found after creation",
+			snapshotName, fileVolumeID)
+	}
+	log.Debugf("Successfully created file share snapshot %q on volume: %q", snapshotName, fileVolumeID)
+	return csiSnapshotID, snapshot, nil
+}
+
+// DeleteFileSnapshotUtil is the helper function to delete the vSAN file share snapshot for
+// the given file snapshot ID. Deleting a snapshot which no longer exists succeeds.
+func DeleteFileSnapshotUtil(ctx context.Context, vc *vsphere.VirtualCenter, cluster vim25types.ManagedObjectReference,
+	csiSnapshotID string) error {
+	log := logger.GetLogger(ctx)
+	fileVolumeID, snapshotName, err := ParseCSIFileSnapshotID(csiSnapshotID)
+	if err != nil {
+		return err
+	}
+	shareUUID := strings.TrimPrefix(fileVolumeID, FileVolumeIDPrefix)
+
+	snapshot, err := queryFileShareSnapshot(ctx, vc, cluster, shareUUID, snapshotName)
+	if err != nil {
+		return err
+	}
+	if snapshot == nil {
+		log.Infof("File share snapshot %q of volume %q not found. Assuming it is already deleted.",
+			snapshotName, fileVolumeID)
+		return nil
+	}
+
+	log.Debugf("vSphere CSI driver is deleting file share snapshot %q on volume: %q", snapshotName, fileVolumeID)
+	task, err := vc.RemoveFileShareSnapshot(ctx, cluster, shareUUID, snapshotName)
+	if err == nil {
+		_, err = task.WaitForResult(ctx)
+	}
+	if err != nil {
+		return logger.LogNewErrorf(log, "failed to delete file share snapshot %q on volume %q with error %+v",
+			snapshotName, fileVolumeID, err)
+	}
+	log.Debugf("Successfully deleted file share snapshot %q on volume %q", snapshotName, fileVolumeID)
+	return nil
+}
+
+// ListFileSnapshotsUtil is the helper function to list the vSAN file share snapshots of the
+// given file volume, or only the one with the given name when snapshotName is set. The size
+// of the snapshots is reported as the capacity of the file volume.
+func ListFileSnapshotsUtil(ctx context.Context, vc *vsphere.VirtualCenter, cluster vim25types.ManagedObjectReference,
+	fileVolumeID string, snapshotName string, volumeSizeInMB int64) ([]*csi.Snapshot, error) {
+	log := logger.GetLogger(ctx)
+	shareUUID := strings.TrimPrefix(fileVolumeID, FileVolumeIDPrefix)
+	var snapshotNames []string
+	if snapshotName != "" {
+		snapshotNames = []string{snapshotName}
+	}
+	snapshots, err := vc.QueryFileShareSnapshots(ctx, cluster, shareUUID, snapshotNames)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to query file share snapshots of volume %q with error %+v",
+			fileVolumeID, err)
+	}
+	csiSnapshots := make([]*csi.Snapshot, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		csiSnapshot := &csi.Snapshot{
+			SnapshotId:     GetCSIFileSnapshotID(fileVolumeID, snapshot.Config.Name),
+			SourceVolumeId: fileVolumeID,
+			SizeBytes:      volumeSizeInMB * MbInBytes,
+			ReadyToUse:     true,
+		}
+		if snapshot.CreationTime != nil {
+			csiSnapshot.CreationTime = timestamppb.New(*snapshot.CreationTime)
+		}
+		csiSnapshots = append(csiSnapshots, csiSnapshot)
+	}
+	return csiSnapshots, nil
+}
+
+// queryFileShareSnapshot returns the vSAN file share snapshot with the given name,
+// or nil if the file share has no such snapshot.
+func queryFileShareSnapshot(ctx context.Context, vc *vsphere.VirtualCenter, cluster vim25types.ManagedObjectReference,
+	shareUUID string, snapshotName string) (*vsphere.VsanFileShareSnapshot, error) {
+	log := logger.GetLogger(ctx)
+	snapshots, err := vc.QueryFileShareSnapshots(ctx, cluster, shareUUID, []string{snapshotName})
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to query file share snapshot %q of share %q with error %+v",
+			snapshotName, shareUUID, err)
+	}
+	for i := range snapshots {
+		if snapshots[i].Config.Name == snapshotName {
+			return &snapshots[i], nil
+		}
+	}
+	return nil, nil
+}
+
 // GetCnsVolumeType is the helper function that determines the volume type based on the volume-id
 func GetCnsVolumeType(ctx context.Context, volumeManager cnsvolume.Manager, volumeId string) (string, error) {
 	log := logger.GetLogger(ctx)
@@ -1151,6 +1373,80 @@ func GetNodeVMsWithAccessToDatastore(ctx context.Context, vc *vsphere.VirtualCen
 	return accessibleNodes, nil
 }
 
+// ValidateCloneSourceVolume checks that the source volume of a clone request
+// is an existing block volume which is not larger than the requested size,
+// and returns the details of the source volume.
+func ValidateCloneSourceVolume(ctx context.Context, volManager cnsvolume.Manager, sourceVolumeID string,
+	volSizeBytes int64) (*utils.CnsVolumeDetails, string, error) {
+	log := logger.GetLogger(ctx)
+	volumeIds := []cnstypes.CnsVolumeId{{Id: sourceVolumeID}}
+	cnsVolumeDetailsMap, err := utils.QueryVolumeDetailsUtil(ctx, volManager, volumeIds)
+	if err != nil {
+		return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to retrieve the source volume: %s details. err: %+v", sourceVolumeID, err)
+	}
+	sourceVolume, ok := cnsVolumeDetailsMap[sourceVolumeID]
+	if !ok {
+		return nil, csifault.CSINotFoundFault, logger.LogNewErrorCodef(log, codes.NotFound,
+			"source volume: %s not found", sourceVolumeID)
+	}
+	if sourceVolume.VolumeType != BlockVolumeType {
+		return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"cloning is not supported for volume: %s of type %s", sourceVolumeID, sourceVolume.VolumeType)
+	}
+	sourceVolumeSizeInBytes := sourceVolume.SizeInMB * MbInBytes
+	if volSizeBytes < sourceVolumeSizeInBytes {
+		return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"requested volume size: %d must not be less than the source volume size: %d",
+			volSizeBytes, sourceVolumeSizeInBytes)
+	}
+	return sourceVolume, "", nil
+}
+
+// getCloneTargetDatastore selects the datastore to place a clone of the given
+// source volume on. Candidates not compatible with the storage policy are
+// skipped. The datastore of the source volume is preferred when it is a
+// candidate, otherwise the candidate with the most free space is selected.
+func getCloneTargetDatastore(ctx context.Context, vc *vsphere.VirtualCenter, volManager cnsvolume.Manager,
+	sourceVolumeID string, storagePolicyID string, candidates []*vsphere.DatastoreInfo) (
+	*vsphere.DatastoreInfo, error) {
+	log := logger.GetLogger(ctx)
+	if storagePolicyID != "" {
+		var err error
+		candidates, err = GetStoragePolicyCompatibleDatastores(ctx, vc, storagePolicyID, candidates)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, logger.LogNewErrorf(log,
+			"no compatible datastore found to clone volume %q", sourceVolumeID)
+	}
+
+	querySelection := cnstypes.CnsQuerySelection{
+		Names: []string{string(cnstypes.QuerySelectionNameTypeDataStoreUrl)},
+	}
+	sourceVolume, err := QueryVolumeByID(ctx, volManager, sourceVolumeID, &querySelection)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to query datastore for the source volume %q with error %+v",
+			sourceVolumeID, err)
+	}
+	var targetDatastore *vsphere.DatastoreInfo
+	for _, ds := range candidates {
+		if ds.Info.Url == sourceVolume.DatastoreUrl {
+			log.Infof("Datastore %q of source volume %q is selected to place the clone", ds.Info.Url,
+				sourceVolumeID)
+			return ds, nil
+		}
+		if targetDatastore == nil || ds.Info.FreeSpace > targetDatastore.Info.FreeSpace {
+			targetDatastore = ds
+		}
+	}
+	log.Infof("Datastore %q with the most free space is selected to place the clone of volume %q",
+		targetDatastore.Info.Url, sourceVolumeID)
+	return targetDatastore, nil
+}
+
 // isDataStoreCompatible validates if datastore is accessible from all nodes.
 func isDataStoreCompatible(ctx context.Context, vc *vsphere.VirtualCenter, spec *CreateVolumeSpec,
 	datastores []vim25types.ManagedObjectReference, datastoreObj *vsphere.Datastore) (string, error) {
@@ -1198,3 +1494,288 @@ func isDataStoreCompatible(ctx context.Context, vc *vsphere.VirtualCenter, spec
 	}
 	return "", nil
 }
+
+// GetStoragePolicyCompatibleDatastores returns the datastores from candidates
+// which are compatible with the given storage policy.
+func GetStoragePolicyCompatibleDatastores(ctx context.Context, vc *vsphere.VirtualCenter, storagePolicyID string,
+	candidates []*vsphere.DatastoreInfo) ([]*vsphere.DatastoreInfo, error) {
+	log := logger.GetLogger(ctx)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	compat, err := vc.PbmCheckCompatibility(ctx, getDatastoreMoRefs(candidates), storagePolicyID)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to find datastore compatibility "+
+			"with storage policy ID %q. Error: %+v", storagePolicyID, err)
+	}
+	compatibleDsMoids := make(map[string]struct{})
+	for _, ds := range compat.CompatibleDatastores() {
+		compatibleDsMoids[ds.HubId] = struct{}{}
+	}
+	var compatibleDatastores []*vsphere.DatastoreInfo
+	for _, ds := range candidates {
+		if _, exists := compatibleDsMoids[ds.Reference().Value]; exists {
+			compatibleDatastores = append(compatibleDatastores, ds)
+		}
+	}
+	return compatibleDatastores, nil
+}
+
+// createVolumeWithDiskFormat creates a block volume whose backing disk uses the
+// provisioning type requested by the diskformat StorageClass parameter. The
+// volume is placed on the datastore with the most free space among candidates
+// which are compatible with the storage policy of the volume.
+func createVolumeWithDiskFormat(ctx context.Context, vc *vsphere.VirtualCenter, volumeManager cnsvolume.Manager,
+	spec *CreateVolumeSpec, storagePolicyID string, createSpec *cnstypes.CnsVolumeCreateSpec,
+	candidates []*vsphere.DatastoreInfo, extraParams interface{}) (*cnsvolume.CnsVolumeInfo, string, error) {
+	log := logger.GetLogger(ctx)
+	provisioningType, ok := DiskFormatToProvisioningType[spec.ScParams.DiskFormat]
+	if !ok {
+		return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorf(log,
+			"invalid value %q for param %q", spec.ScParams.DiskFormat, AttributeDiskFormat)
+	}
+	if storagePolicyID != "" {
+		var err error
+		candidates, err = GetStoragePolicyCompatibleDatastores(ctx, vc, storagePolicyID, candidates)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, err
+		}
+	}
+	var targetDatastore *vsphere.DatastoreInfo
+	for _, ds := range candidates {
+		if targetDatastore == nil || ds.Info.FreeSpace > targetDatastore.Info.FreeSpace {
+			targetDatastore = ds
+		}
+	}
+	if targetDatastore == nil {
+		return nil, csifault.CSIInvalidStoragePolicyConfigurationFault, logger.LogNewErrorf(log,
+			"no compatible datastore found to create volume %q with %s %q", spec.Name,
+			AttributeDiskFormat, spec.ScParams.DiskFormat)
+	}
+	log.Infof("Datastore %q with the most free space is selected to create volume %q with %s %q",
+		targetDatastore.Info.Url, spec.Name, AttributeDiskFormat, spec.ScParams.DiskFormat)
+	createSpec.Datastores = []vim25types.ManagedObjectReference{targetDatastore.Reference()}
+	log.Debugf("vSphere CSI driver creating volume %s with provisioning type %q and create spec %+v",
+		spec.Name, provisioningType, spew.Sdump(createSpec))
+	volumeInfo, faultType, err := volumeManager.CreateVolumeWithProvisioningType(ctx, createSpec,
+		provisioningType, extraParams)
+	if err != nil {
+		log.Errorf("failed to create disk %s with provisioning type %q with error %+v faultType %q",
+			spec.Name, provisioningType, err, faultType)
+		return nil, faultType, err
+	}
+	return volumeInfo, "", nil
+}
+
+// ModifyVolumeStoragePolicyUtil is the helper function to change the storage
+// policy of a block volume. The policy is applied in place when the current
+// datastore of the volume is compatible with it. Otherwise the volume is
+// relocated to the compatible datastore with the most free space among the
+// datastores shared by all the node VMs which can access the current datastore.
+func ModifyVolumeStoragePolicyUtil(ctx context.Context, vc *vsphere.VirtualCenter, volManager cnsvolume.Manager,
+	volumeID string, storagePolicyName string, allNodeVMs []*vsphere.VirtualMachine) (string, error) {
+	log := logger.GetLogger(ctx)
+	querySelection := cnstypes.CnsQuerySelection{
+		Names: []string{
+			string(cnstypes.QuerySelectionNameTypeVolumeType),
+			string(cnstypes.QuerySelectionNameTypeDataStoreUrl),
+			string(cnstypes.QuerySelectionNameTypePolicyId),
+		},
+	}
+	volume, err := QueryVolumeByID(ctx, volManager, volumeID, &querySelection)
+	if err != nil {
+		if err == ErrNotFound {
+			return csifault.CSINotFoundFault, logger.LogNewErrorCodef(log, codes.NotFound,
+				"volume %q not found", volumeID)
+		}
+		return csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to query volume %q. Error: %+v", volumeID, err)
+	}
+	if volume.VolumeType != BlockVolumeType {
+		return csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"storage policy can only be modified for block volumes, volume %q is of type %q",
+			volumeID, volume.VolumeType)
+	}
+	storagePolicyID, err := vc.GetStoragePolicyIDByName(ctx, storagePolicyName)
+	if err != nil {
+		return csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"failed to get policy ID for storage policy name %q. Error: %+v", storagePolicyName, err)
+	}
+	if volume.StoragePolicyId == storagePolicyID {
+		log.Infof("Volume %q already has storage policy %q", volumeID, storagePolicyName)
+		return "", nil
+	}
+
+	// Check if the current datastore of the volume is compatible with the new policy.
+	currentDatastores, err := getDatastoreInfoObjList(ctx, vc, volume.DatastoreUrl)
+	if err != nil {
+		return csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to find datastore %q of volume %q. Error: %+v", volume.DatastoreUrl, volumeID, err)
+	}
+	compatibleDatastores, err := GetStoragePolicyCompatibleDatastores(ctx, vc, storagePolicyID,
+		currentDatastores)
+	if err != nil {
+		return csifault.CSIInternalFault, logger.LogNewErrorCode(log, codes.Internal, err.Error())
+	}
+	var targetDatastore *vim25types.ManagedObjectReference
+	if len(compatibleDatastores) == 0 {
+		log.Infof("Datastore %q of volume %q is not compatible with storage policy %q. "+
+			"Looking for a compatible datastore to relocate the volume.",
+			volume.DatastoreUrl, volumeID, storagePolicyName)
+		// The volume can only be relocated to datastores which are accessible
+		// from all the nodes having access to the current datastore.
+		accessibleNodes, err := GetNodeVMsWithAccessToDatastore(ctx, vc, volume.DatastoreUrl, allNodeVMs)
+		if err != nil {
+			return csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to find nodes with access to datastore %q. Error: %+v", volume.DatastoreUrl, err)
+		}
+		accessibleNodeMoIDs := make(map[string]struct{})
+		for _, vm := range accessibleNodes {
+			accessibleNodeMoIDs[vm.Reference().Value] = struct{}{}
+		}
+		var nodeVMs []*vsphere.VirtualMachine
+		for _, nodeVM := range allNodeVMs {
+			if _, exists := accessibleNodeMoIDs[nodeVM.Reference().Value]; exists {
+				nodeVMs = append(nodeVMs, nodeVM)
+			}
+		}
+		sharedDatastores, err := vsphere.GetSharedDatastoresForVMs(ctx, nodeVMs)
+		if err != nil {
+			return csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to get shared datastores for volume %q. Error: %+v", volumeID, err)
+		}
+		compatibleDatastores, err = GetStoragePolicyCompatibleDatastores(ctx, vc, storagePolicyID,
+			sharedDatastores)
+		if err != nil {
+			return csifault.CSIInternalFault, logger.LogNewErrorCode(log, codes.Internal, err.Error())
+		}
+		if len(compatibleDatastores) == 0 {
+			return csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"no datastore compatible with storage policy %q is accessible from the nodes of volume %q",
+				storagePolicyName, volumeID)
+		}
+		selected := getDatastoreWithMostFreeSpace(compatibleDatastores)
+		log.Infof("Datastore %q is selected to relocate volume %q", selected.Info.Url, volumeID)
+		dsMoRef := selected.Reference()
+		targetDatastore = &dsMoRef
+	}
+	faultType, err := volManager.UpdateVolumeStoragePolicy(ctx, volumeID, storagePolicyID, targetDatastore)
+	if err != nil {
+		return faultType, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to update storage policy of volume %q to %q. Error: %+v", volumeID, storagePolicyName, err)
+	}
+	log.Infof("Successfully updated storage policy of volume %q to %q", volumeID, storagePolicyName)
+	return "", nil
+}
+
+// RelocateRestoredVolumeUtil is the helper function to move a block volume
+// restored from a snapshot to the datastores requested for it. Nothing is done
+// when the volume is already on one of the candidate datastores, which are
+// further restricted to the DatastoreURL StorageClass parameter if it is set.
+// Otherwise the volume is relocated to the candidate datastore compatible with
+// the storage policy which has the most free space, and the policy is applied
+// along with the relocation. The relocate task is recorded in the
+// CnsVolumeOperationRequest by UpdateVolumeStoragePolicy, so the CreateVolume
+// request named volumeName resumes a pending relocation when it is retried.
+// The URL of the datastore the volume ends up on is returned.
+//
+// The volume may have been restored without a storage policy, so it is deleted
+// when it cannot be relocated, along with the details of the CreateVolume
+// request so that a retry restores the snapshot again.
+func RelocateRestoredVolumeUtil(ctx context.Context, vc *vsphere.VirtualCenter, volManager cnsvolume.Manager,
+	volumeName string, volumeID string, scParams *StorageClassParams,
+	candidates []*vsphere.DatastoreInfo) (string, string, error) {
+	log := logger.GetLogger(ctx)
+	datastoreURL, faultType, err := relocateRestoredVolume(ctx, vc, volManager, volumeID, scParams, candidates)
+	if err == nil {
+		return datastoreURL, "", nil
+	}
+	// The volume cannot be deleted while a relocate task which timed out is
+	// still running on it, in which case the retry resumes the relocation.
+	if _, deleteErr := volManager.DeleteVolume(ctx, volumeID, true); deleteErr != nil {
+		log.Warnf("failed to delete volume %q which could not be relocated. Error: %+v", volumeID, deleteErr)
+		return "", faultType, err
+	}
+	log.Infof("Deleted volume %q which could not be relocated", volumeID)
+	if operationStore := volManager.GetOperationStore(); operationStore != nil {
+		if deleteErr := operationStore.DeleteRequestDetails(ctx, volumeName); deleteErr != nil {
+			log.Warnf("failed to delete CreateVolume details of volume %q. Error: %+v", volumeName, deleteErr)
+		}
+	}
+	return "", faultType, err
+}
+
+// relocateRestoredVolume moves the restored volume as described by
+// RelocateRestoredVolumeUtil.
+func relocateRestoredVolume(ctx context.Context, vc *vsphere.VirtualCenter, volManager cnsvolume.Manager,
+	volumeID string, scParams *StorageClassParams, candidates []*vsphere.DatastoreInfo) (string, string, error) {
+	log := logger.GetLogger(ctx)
+	querySelection := cnstypes.CnsQuerySelection{
+		Names: []string{string(cnstypes.QuerySelectionNameTypeDataStoreUrl)},
+	}
+	volume, err := QueryVolumeByID(ctx, volManager, volumeID, &querySelection)
+	if err != nil {
+		return "", csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to query datastore of volume %q. Error: %+v", volumeID, err)
+	}
+	var targets []*vsphere.DatastoreInfo
+	for _, ds := range candidates {
+		if scParams.DatastoreURL != "" &&
+			strings.TrimSpace(ds.Info.Url) != strings.TrimSpace(scParams.DatastoreURL) {
+			continue
+		}
+		if strings.TrimSpace(ds.Info.Url) == strings.TrimSpace(volume.DatastoreUrl) {
+			log.Debugf("Volume %q is already on the requested datastore %q", volumeID, volume.DatastoreUrl)
+			return volume.DatastoreUrl, "", nil
+		}
+		targets = append(targets, ds)
+	}
+	var storagePolicyID string
+	if scParams.StoragePolicyName != "" {
+		storagePolicyID, err = vc.GetStoragePolicyIDByName(ctx, scParams.StoragePolicyName)
+		if err != nil {
+			return "", csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"failed to get policy ID for storage policy name %q. Error: %+v", scParams.StoragePolicyName, err)
+		}
+		targets, err = GetStoragePolicyCompatibleDatastores(ctx, vc, storagePolicyID, targets)
+		if err != nil {
+			return "", csifault.CSIInternalFault, logger.LogNewErrorCode(log, codes.Internal, err.Error())
+		}
+	}
+	if len(targets) == 0 {
+		return "", csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"no datastore to relocate volume %q restored on datastore %q is compatible with "+
+				"storage policy %q and the accessibility requirements", volumeID, volume.DatastoreUrl,
+			scParams.StoragePolicyName)
+	}
+	selected := getDatastoreWithMostFreeSpace(targets)
+	log.Infof("Relocating volume %q restored on datastore %q to datastore %q", volumeID,
+		volume.DatastoreUrl, selected.Info.Url)
+	dsMoRef := selected.Reference()
+	faultType, err := volManager.UpdateVolumeStoragePolicy(ctx, volumeID, storagePolicyID, &dsMoRef)
+	if err != nil {
+		return "", faultType, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to relocate volume %q to datastore %q. Error: %+v", volumeID, selected.Info.Url, err)
+	}
+	// A relocate task resumed from an earlier request may have had another
+	// target, so the datastore of the volume is queried again.
+	volume, err = QueryVolumeByID(ctx, volManager, volumeID, &querySelection)
+	if err != nil {
+		return "", csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to query datastore of relocated volume %q. Error: %+v", volumeID, err)
+	}
+	log.Infof("Successfully relocated volume %q to datastore %q", volumeID, volume.DatastoreUrl)
+	return volume.DatastoreUrl, "", nil
+}
+
+// getDatastoreWithMostFreeSpace returns the datastore with the most free space
+// among the given non-empty list of datastores.
+func getDatastoreWithMostFreeSpace(datastores []*vsphere.DatastoreInfo) *vsphere.DatastoreInfo {
+	selected := datastores[0]
+	for _, ds := range datastores {
+		if ds.Info.FreeSpace > selected.Info.FreeSpace {
+			selected = ds
+		}
+	}
+	return selected
+}
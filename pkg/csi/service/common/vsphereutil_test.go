@@ -2,14 +2,24 @@ package common
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/agiledragon/gomonkey/v2"
 	"github.com/stretchr/testify/assert"
 	cnstypes "github.com/vmware/govmomi/cns/types"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/pbm"
+	pbmtypes "github.com/vmware/govmomi/pbm/types"
 	"github.com/vmware/govmomi/vim25/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	cnsvolume "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/utils"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsvolumeoperationrequest"
 )
 
 func TestQueryVolumeSnapshotsByVolumeIDWithQuerySnapshotsCnsVolumeNotFoundFault(t *testing.T) {
@@ -151,3 +161,183 @@ func TestQueryAllVolumeSnapshotsWithQuerySnapshotsUnexpectedFault(t *testing.T)
 	_, _, err := QueryAllVolumeSnapshots(context.TODO(), nil, "", 100)
 	assert.Error(t, err)
 }
+
+func TestGetDatastoreWithMostFreeSpace(t *testing.T) {
+	datastores := []*vsphere.DatastoreInfo{
+		{Info: &types.DatastoreInfo{Url: "ds:///vmfs/volumes/ds-1/", FreeSpace: 10}},
+		{Info: &types.DatastoreInfo{Url: "ds:///vmfs/volumes/ds-2/", FreeSpace: 30}},
+		{Info: &types.DatastoreInfo{Url: "ds:///vmfs/volumes/ds-3/", FreeSpace: 20}},
+	}
+	assert.Equal(t, "ds:///vmfs/volumes/ds-2/", getDatastoreWithMostFreeSpace(datastores).Info.Url)
+	assert.Equal(t, "ds:///vmfs/volumes/ds-1/", getDatastoreWithMostFreeSpace(datastores[:1]).Info.Url)
+}
+
+const (
+	restoredVolumeName   = "pvc-restored"
+	restoredVolumeID     = "restored-volume-id"
+	snapshotDatastoreURL = "ds:///vmfs/volumes/snapshot-ds/"
+)
+
+// fakeRelocationManager tracks the datastore of the restored volume as it is
+// relocated or deleted. Other Manager methods are not implemented.
+type fakeRelocationManager struct {
+	cnsvolume.Manager
+	operationStore  *fakeRelocationOperationStore
+	datastoreURLs   map[string]string
+	datastoreURL    string
+	relocateErr     error
+	deleteErr       error
+	relocatedTo     *types.ManagedObjectReference
+	storagePolicyID string
+	deleted         bool
+}
+
+func (m *fakeRelocationManager) UpdateVolumeStoragePolicy(_ context.Context, _ string, storagePolicyID string,
+	datastore *types.ManagedObjectReference) (string, error) {
+	if m.relocateErr != nil {
+		return "", m.relocateErr
+	}
+	m.relocatedTo = datastore
+	m.storagePolicyID = storagePolicyID
+	m.datastoreURL = m.datastoreURLs[datastore.Value]
+	return "", nil
+}
+
+func (m *fakeRelocationManager) DeleteVolume(_ context.Context, _ string, _ bool) (string, error) {
+	if m.deleteErr != nil {
+		return "", m.deleteErr
+	}
+	m.deleted = true
+	return "", nil
+}
+
+func (m *fakeRelocationManager) GetOperationStore() cnsvolumeoperationrequest.VolumeOperationRequest {
+	return m.operationStore
+}
+
+// fakeRelocationOperationStore records the names of the deleted details.
+// Other VolumeOperationRequest methods are not implemented.
+type fakeRelocationOperationStore struct {
+	cnsvolumeoperationrequest.VolumeOperationRequest
+	deletedNames []string
+}
+
+func (s *fakeRelocationOperationStore) DeleteRequestDetails(_ context.Context, name string) error {
+	s.deletedNames = append(s.deletedNames, name)
+	return nil
+}
+
+// newRestoreRelocationTest returns candidate datastores ds-1, ds-2 and ds-3
+// with 10, 30 and 20 bytes of free space, of which only those in
+// compatibleMoids are compatible with the "gold" storage policy, and a volume
+// manager with the restored volume on the datastore of the snapshot.
+func newRestoreRelocationTest(compatibleMoids ...string) (*gomonkey.Patches, *fakeRelocationManager,
+	[]*vsphere.DatastoreInfo) {
+	volManager := &fakeRelocationManager{
+		operationStore: &fakeRelocationOperationStore{},
+		datastoreURLs:  make(map[string]string),
+		datastoreURL:   snapshotDatastoreURL,
+	}
+	var candidates []*vsphere.DatastoreInfo
+	for i, freeSpace := range []int64{10, 30, 20} {
+		moid := fmt.Sprintf("ds-%d", i+1)
+		candidates = append(candidates, &vsphere.DatastoreInfo{
+			Datastore: &vsphere.Datastore{
+				Datastore: object.NewDatastore(nil, types.ManagedObjectReference{Type: "Datastore", Value: moid}),
+			},
+			Info: &types.DatastoreInfo{Url: "ds:///vmfs/volumes/" + moid + "/", FreeSpace: freeSpace},
+		})
+		volManager.datastoreURLs[moid] = candidates[i].Info.Url
+	}
+	patches := gomonkey.ApplyFunc(utils.QueryVolumeUtil, func(_ context.Context, _ cnsvolume.Manager,
+		_ cnstypes.CnsQueryFilter, _ *cnstypes.CnsQuerySelection, _ bool) (*cnstypes.CnsQueryResult, error) {
+		return &cnstypes.CnsQueryResult{
+			Volumes: []cnstypes.CnsVolume{{
+				VolumeId:     cnstypes.CnsVolumeId{Id: restoredVolumeID},
+				DatastoreUrl: volManager.datastoreURL,
+			}},
+		}, nil
+	})
+	patches.ApplyMethod(reflect.TypeOf(&vsphere.VirtualCenter{}), "GetStoragePolicyIDByName",
+		func(_ *vsphere.VirtualCenter, _ context.Context, storagePolicyName string) (string, error) {
+			return storagePolicyName + "-id", nil
+		})
+	patches.ApplyMethod(reflect.TypeOf(&vsphere.VirtualCenter{}), "PbmCheckCompatibility",
+		func(_ *vsphere.VirtualCenter, _ context.Context, _ []types.ManagedObjectReference,
+			_ string) (pbm.PlacementCompatibilityResult, error) {
+			var result pbm.PlacementCompatibilityResult
+			for _, moid := range compatibleMoids {
+				result = append(result, pbmtypes.PbmPlacementCompatibilityResult{
+					Hub: pbmtypes.PbmPlacementHub{HubType: "Datastore", HubId: moid},
+				})
+			}
+			return result, nil
+		})
+	return patches, volManager, candidates
+}
+
+func TestRelocateRestoredVolume(t *testing.T) {
+	patches, volManager, candidates := newRestoreRelocationTest("ds-1", "ds-3")
+	defer patches.Reset()
+
+	// The compatible datastore with the most free space is selected.
+	datastoreURL, _, err := RelocateRestoredVolumeUtil(context.TODO(), &vsphere.VirtualCenter{}, volManager,
+		restoredVolumeName, restoredVolumeID, &StorageClassParams{StoragePolicyName: "gold"}, candidates)
+	assert.NoError(t, err)
+	assert.Equal(t, "ds:///vmfs/volumes/ds-3/", datastoreURL)
+	assert.Equal(t, "ds-3", volManager.relocatedTo.Value)
+	assert.Equal(t, "gold-id", volManager.storagePolicyID)
+	assert.False(t, volManager.deleted)
+}
+
+func TestRelocateRestoredVolumeAlreadyOnTarget(t *testing.T) {
+	patches, volManager, candidates := newRestoreRelocationTest("ds-1", "ds-3")
+	defer patches.Reset()
+	volManager.datastoreURL = candidates[1].Info.Url
+
+	datastoreURL, _, err := RelocateRestoredVolumeUtil(context.TODO(), &vsphere.VirtualCenter{}, volManager,
+		restoredVolumeName, restoredVolumeID, &StorageClassParams{StoragePolicyName: "gold"}, candidates)
+	assert.NoError(t, err)
+	assert.Equal(t, candidates[1].Info.Url, datastoreURL)
+	assert.Nil(t, volManager.relocatedTo)
+}
+
+func TestRelocateRestoredVolumeWithDatastoreURL(t *testing.T) {
+	patches, volManager, candidates := newRestoreRelocationTest()
+	defer patches.Reset()
+	// The volume is on a candidate datastore other than the requested one.
+	volManager.datastoreURL = candidates[1].Info.Url
+
+	datastoreURL, _, err := RelocateRestoredVolumeUtil(context.TODO(), &vsphere.VirtualCenter{}, volManager,
+		restoredVolumeName, restoredVolumeID, &StorageClassParams{DatastoreURL: candidates[0].Info.Url}, candidates)
+	assert.NoError(t, err)
+	assert.Equal(t, candidates[0].Info.Url, datastoreURL)
+	assert.Equal(t, "ds-1", volManager.relocatedTo.Value)
+	assert.Empty(t, volManager.storagePolicyID)
+}
+
+func TestRelocateRestoredVolumeWithIncompatiblePolicy(t *testing.T) {
+	patches, volManager, candidates := newRestoreRelocationTest()
+	defer patches.Reset()
+
+	_, _, err := RelocateRestoredVolumeUtil(context.TODO(), &vsphere.VirtualCenter{}, volManager,
+		restoredVolumeName, restoredVolumeID, &StorageClassParams{StoragePolicyName: "gold"}, candidates)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Nil(t, volManager.relocatedTo)
+	// The volume restored without the policy is not left behind.
+	assert.True(t, volManager.deleted)
+	assert.Equal(t, []string{restoredVolumeName}, volManager.operationStore.deletedNames)
+}
+
+func TestRelocateRestoredVolumeFailureWithUndeletableVolume(t *testing.T) {
+	patches, volManager, candidates := newRestoreRelocationTest("ds-1")
+	defer patches.Reset()
+	volManager.relocateErr = errors.New("relocate task timed out")
+	volManager.deleteErr = errors.New("volume is busy")
+
+	_, _, err := RelocateRestoredVolumeUtil(context.TODO(), &vsphere.VirtualCenter{}, volManager,
+		restoredVolumeName, restoredVolumeID, &StorageClassParams{StoragePolicyName: "gold"}, candidates)
+	assert.Equal(t, codes.Internal, status.Code(err))
+	// The details of the request are kept for the retry to resume the relocation.
+	assert.Empty(t, volManager.operationStore.deletedNames)
+}
@@ -61,6 +61,9 @@ type Driver interface {
 }
 
 type vsphereCSIDriver struct {
+	csi.UnimplementedIdentityServer
+	csi.UnimplementedNodeServer
+
 	mode    string
 	cnscs   csitypes.CnsController
 	osUtils *osutils.OsUtils
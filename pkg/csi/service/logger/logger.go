@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"google.golang.org/grpc/codes"
@@ -24,6 +25,8 @@ const (
 	EnvLoggerLevel = "LOGGER_LEVEL"
 	// LogCtxIDKey holds the TraceId for log.
 	LogCtxIDKey = "TraceId"
+	// LogCtxOtelTraceIDKey holds the OpenTelemetry trace ID for log.
+	LogCtxOtelTraceIDKey = "OtelTraceId"
 )
 
 var defaultLogLevel LogLevel
@@ -57,9 +60,13 @@ func GetLogger(ctx context.Context) *zap.SugaredLogger {
 }
 
 // NewContextWithLogger returns a new child context with context UUID set
-// using key CtxId.
+// using key CtxId. If ctx is part of a trace, the trace ID is logged too.
 func NewContextWithLogger(ctx context.Context) context.Context {
-	newCtx := withFields(ctx, zap.String(LogCtxIDKey, uuid.New().String()))
+	fields := []zapcore.Field{zap.String(LogCtxIDKey, uuid.New().String())}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		fields = append(fields, zap.String(LogCtxOtelTraceIDKey, spanContext.TraceID().String()))
+	}
+	newCtx := withFields(ctx, fields...)
 	return newCtx
 }
 
@@ -20,11 +20,18 @@ import (
 	"context"
 	"os"
 	"strconv"
+	"sync"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	cnstypes "github.com/vmware/govmomi/cns/types"
 	"github.com/vmware/govmomi/units"
 	"google.golang.org/grpc/codes"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
 
 	cnsconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
@@ -32,6 +39,8 @@ import (
 	commoncotypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco/types"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/osutils"
+	csitypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/types"
+	k8s "sigs.k8s.io/vsphere-csi-driver/v3/pkg/kubernetes"
 )
 
 const (
@@ -52,7 +61,9 @@ func (driver *vsphereCSIDriver) NodeStageVolume(
 	*csi.NodeStageVolumeResponse, error) {
 	ctx = logger.NewContextWithLogger(ctx)
 	log := logger.GetLogger(ctx)
-	log.Infof("NodeStageVolume: called with args %+v", *req)
+	log.Infof("NodeStageVolume: called with args {VolumeId:%s PublishContext:%v StagingTargetPath:%s "+
+		"VolumeCapability:%v VolumeContext:%v}", req.GetVolumeId(), req.GetPublishContext(),
+		req.GetStagingTargetPath(), req.GetVolumeCapability(), req.GetVolumeContext())
 
 	volumeID := req.GetVolumeId()
 	volCap := req.GetVolumeCapability()
@@ -85,6 +96,41 @@ func (driver *vsphereCSIDriver) NodeStageVolume(
 		// Retrieve accessmode - RO/RW.
 		Ro: common.IsVolumeReadOnly(req.GetVolumeCapability()),
 	}
+	if encrypted, ok := req.GetVolumeContext()[common.AttributeEncrypted]; ok {
+		params.Encrypted, err = strconv.ParseBool(encrypted)
+		if err != nil {
+			return nil, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"NodeStageVolume failed: invalid value %q for volume context attribute %q",
+				encrypted, common.AttributeEncrypted)
+		}
+	}
+	if params.Encrypted {
+		if !commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.NodeVolumeEncryption) {
+			return nil, logger.LogNewErrorCodef(log, codes.FailedPrecondition,
+				"NodeStageVolume failed: volume %q is encrypted but volume encryption is not enabled", volumeID)
+		}
+		if volCap.GetBlock() != nil {
+			return nil, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"NodeStageVolume failed: encryption is not supported for raw block volume %q", volumeID)
+		}
+		if req.GetSecrets()[common.LuksPassphraseKey] == "" {
+			return nil, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"NodeStageVolume failed: key %q not found in node stage secrets of encrypted volume %q",
+				common.LuksPassphraseKey, volumeID)
+		}
+	}
+	if fsckOnStage, ok := req.GetVolumeContext()[common.AttributeFsckOnStage]; ok {
+		params.FsckOnStage, err = strconv.ParseBool(fsckOnStage)
+		if err != nil {
+			return nil, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"NodeStageVolume failed: invalid value %q for volume context attribute %q",
+				fsckOnStage, common.AttributeFsckOnStage)
+		}
+		if params.FsckOnStage && !commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.FsckOnStage) {
+			log.Warnf("NodeStageVolume: filesystem check is not enabled, skipping it for volume %q", volumeID)
+			params.FsckOnStage = false
+		}
+	}
 	// TODO: Verify if volume exists and return a NotFound error in negative
 	// scenario.
 
@@ -98,13 +144,30 @@ func (driver *vsphereCSIDriver) NodeStageVolume(
 			return nil, err
 		}
 
+		if mkfsOptions := req.GetVolumeContext()[common.AttributeMkfsOptions]; mkfsOptions != "" {
+			params.MkfsOptions, err = common.ParseMkfsOptions(params.FsType, mkfsOptions)
+			if err != nil {
+				return nil, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+					"NodeStageVolume failed: invalid volume context attribute %q. Err: %v",
+					common.AttributeMkfsOptions, err)
+			}
+		}
+
 		// Check that staging path is created by CO and is a directory.
 		params.StagingTarget = req.GetStagingTargetPath()
 		if _, err = driver.osUtils.VerifyTargetDir(ctx, params.StagingTarget, true); err != nil {
 			return nil, err
 		}
 	}
-	return driver.osUtils.NodeStageBlockVolume(ctx, req, params)
+	if !params.FsckOnStage {
+		return driver.osUtils.NodeStageBlockVolume(ctx, req, params)
+	}
+	params.FsckResult = &osutils.FsckResult{}
+	resp, err := driver.osUtils.NodeStageBlockVolume(ctx, req, params)
+	if params.FsckResult.Performed {
+		recordFsckEvent(ctx, volumeID, params.FsckResult)
+	}
+	return resp, err
 }
 
 func (driver *vsphereCSIDriver) NodeUnstageVolume(
@@ -113,7 +176,7 @@ func (driver *vsphereCSIDriver) NodeUnstageVolume(
 	*csi.NodeUnstageVolumeResponse, error) {
 	ctx = logger.NewContextWithLogger(ctx)
 	log := logger.GetLogger(ctx)
-	log.Infof("NodeUnstageVolume: called with args %+v", *req)
+	log.Infof("NodeUnstageVolume: called with args %+v", req)
 
 	// Validate arguments
 	volumeID := req.GetVolumeId()
@@ -149,6 +212,10 @@ func (driver *vsphereCSIDriver) NodeUnstageVolume(
 
 	if !targetFound {
 		log.Infof("NodeUnstageVolume: Target path %q is not mounted. Skipping unstage.", stagingTarget)
+		if err := driver.osUtils.CloseLuksDevice(ctx, volumeID); err != nil {
+			return nil, logger.LogNewErrorCodef(log, codes.Internal,
+				"NodeUnstageVolume failed: %v", err)
+		}
 		return &csi.NodeUnstageVolumeResponse{}, nil
 	}
 
@@ -160,6 +227,10 @@ func (driver *vsphereCSIDriver) NodeUnstageVolume(
 	// This will take care of idempotent requests.
 	if !dirExists {
 		log.Infof("NodeUnstageVolume: Target path %q does not exist. Assuming unstage is complete.", stagingTarget)
+		if err := driver.osUtils.CloseLuksDevice(ctx, volID); err != nil {
+			return nil, logger.LogNewErrorCodef(log, codes.Internal,
+				"NodeUnstageVolume failed: %v", err)
+		}
 		return &csi.NodeUnstageVolumeResponse{}, nil
 	}
 
@@ -167,6 +238,11 @@ func (driver *vsphereCSIDriver) NodeUnstageVolume(
 		return nil, logger.LogNewErrorCodef(log, codes.Internal,
 			"NodeUnstageVolume failed: %v\nUnStage arguments: %s\n", err, stagingTarget)
 	}
+	// Close the LUKS mapping of an encrypted volume once it is unmounted.
+	if err := driver.osUtils.CloseLuksDevice(ctx, volID); err != nil {
+		return nil, logger.LogNewErrorCodef(log, codes.Internal,
+			"NodeUnstageVolume failed: %v", err)
+	}
 
 	log.Infof("NodeUnstageVolume successful for target %q for volume %q", stagingTarget, volID)
 	return &csi.NodeUnstageVolumeResponse{}, nil
@@ -178,7 +254,7 @@ func (driver *vsphereCSIDriver) NodePublishVolume(
 	*csi.NodePublishVolumeResponse, error) {
 	ctx = logger.NewContextWithLogger(ctx)
 	log := logger.GetLogger(ctx)
-	log.Infof("NodePublishVolume: called with args %+v", *req)
+	log.Infof("NodePublishVolume: called with args %+v", req)
 	var err error
 	volumeID := req.GetVolumeId()
 	if len(volumeID) == 0 {
@@ -246,7 +322,7 @@ func (driver *vsphereCSIDriver) NodeUnpublishVolume(
 	*csi.NodeUnpublishVolumeResponse, error) {
 	ctx = logger.NewContextWithLogger(ctx)
 	log := logger.GetLogger(ctx)
-	log.Infof("NodeUnpublishVolume: called with args %+v", *req)
+	log.Infof("NodeUnpublishVolume: called with args %+v", req)
 
 	volID := req.GetVolumeId()
 	target := req.GetTargetPath()
@@ -276,7 +352,7 @@ func (driver *vsphereCSIDriver) NodeGetVolumeStats(
 	*csi.NodeGetVolumeStatsResponse, error) {
 	ctx = logger.NewContextWithLogger(ctx)
 	log := logger.GetLogger(ctx)
-	log.Infof("NodeGetVolumeStats: called with args %+v", *req)
+	log.Infof("NodeGetVolumeStats: called with args %+v", req)
 
 	var err error
 	targetPath := req.GetVolumePath()
@@ -337,7 +413,7 @@ func (driver *vsphereCSIDriver) NodeGetCapabilities(
 	req *csi.NodeGetCapabilitiesRequest) (
 	*csi.NodeGetCapabilitiesResponse, error) {
 
-	return &csi.NodeGetCapabilitiesResponse{
+	nodeCaps := &csi.NodeGetCapabilitiesResponse{
 		Capabilities: []*csi.NodeServiceCapability{
 			{
 				Type: &csi.NodeServiceCapability_Rpc{
@@ -361,7 +437,17 @@ func (driver *vsphereCSIDriver) NodeGetCapabilities(
 				},
 			},
 		},
-	}, nil
+	}
+	if commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.NodeVolumeCondition) {
+		nodeCaps.Capabilities = append(nodeCaps.Capabilities, &csi.NodeServiceCapability{
+			Type: &csi.NodeServiceCapability_Rpc{
+				Rpc: &csi.NodeServiceCapability_RPC{
+					Type: csi.NodeServiceCapability_RPC_VOLUME_CONDITION,
+				},
+			},
+		})
+	}
+	return nodeCaps, nil
 }
 
 // NodeGetInfo RPC returns the NodeGetInfoResponse with mandatory fields
@@ -378,7 +464,7 @@ func (driver *vsphereCSIDriver) NodeGetInfo(
 	*csi.NodeGetInfoResponse, error) {
 	ctx = logger.NewContextWithLogger(ctx)
 	log := logger.GetLogger(ctx)
-	log.Infof("NodeGetInfo: called with args %+v", *req)
+	log.Infof("NodeGetInfo: called with args %+v", req)
 
 	driver.osUtils.ShouldContinue(ctx)
 
@@ -514,7 +600,9 @@ func (driver *vsphereCSIDriver) NodeExpandVolume(
 	*csi.NodeExpandVolumeResponse, error) {
 	ctx = logger.NewContextWithLogger(ctx)
 	log := logger.GetLogger(ctx)
-	log.Infof("NodeExpandVolume: called with args %+v", *req)
+	log.Infof("NodeExpandVolume: called with args {VolumeId:%s VolumePath:%s CapacityRange:%v "+
+		"StagingTargetPath:%s VolumeCapability:%v}", req.GetVolumeId(), req.GetVolumePath(),
+		req.GetCapacityRange(), req.GetStagingTargetPath(), req.GetVolumeCapability())
 
 	volumeID := req.GetVolumeId()
 	if len(volumeID) == 0 {
@@ -552,12 +640,31 @@ func (driver *vsphereCSIDriver) NodeExpandVolume(
 	}
 	log.Debugf("NodeExpandVolume: staging target path %s, getDevFromMount %+v", volumePath, *dev)
 
+	// For an encrypted volume the filesystem lives on the LUKS mapping, while
+	// the rescan has to happen on the backing disk.
+	diskDev := dev
+	fsSizeBytes := reqVolSizeBytes
+	isLuks := driver.osUtils.IsLuksMapping(dev)
+	if isLuks {
+		luksStatus, err := driver.osUtils.GetLuksDeviceStatus(ctx, dev)
+		if err != nil {
+			return nil, logger.LogNewErrorCodef(log, codes.Internal,
+				"error getting LUKS status of volume %q: %v", volumeID, err)
+		}
+		diskDev, err = driver.osUtils.GetDevice(ctx, luksStatus.BackingDevice)
+		if err != nil || diskDev == nil {
+			return nil, logger.LogNewErrorCodef(log, codes.Internal,
+				"error getting backing device %q of volume %q: %v", luksStatus.BackingDevice, volumeID, err)
+		}
+		fsSizeBytes -= luksStatus.OffsetBytes
+	}
+
 	if commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.OnlineVolumeExtend) {
 		// Fetch the current block size.
-		currentBlockSizeBytes, err := driver.osUtils.GetBlockSizeBytes(ctx, dev.RealDev)
+		currentBlockSizeBytes, err := driver.osUtils.GetBlockSizeBytes(ctx, diskDev.RealDev)
 		if err != nil {
 			return nil, logger.LogNewErrorCodef(log, codes.Internal,
-				"error when getting size of block volume at path %s: %v", dev.RealDev, err)
+				"error when getting size of block volume at path %s: %v", diskDev.RealDev, err)
 		}
 		// Check if a rescan is required.
 		if currentBlockSizeBytes < reqVolSizeBytes {
@@ -565,13 +672,21 @@ func (driver *vsphereCSIDriver) NodeExpandVolume(
 			// rescan the device on the guest OS in order to see the modified size
 			// on the Guest OS.
 			// Refer to https://kb.vmware.com/s/article/1006371
-			err = driver.osUtils.RescanDevice(ctx, dev)
+			err = driver.osUtils.RescanDevice(ctx, diskDev)
 			if err != nil {
 				return nil, logger.LogNewErrorCode(log, codes.Internal, err.Error())
 			}
 		}
 	}
 
+	if isLuks {
+		err = driver.osUtils.ResizeLuksDevice(ctx, dev, req.GetSecrets()[common.LuksPassphraseKey])
+		if err != nil {
+			return nil, logger.LogNewErrorCodef(log, codes.Internal,
+				"error when resizing LUKS device of volume %q: %v", volumeID, err)
+		}
+	}
+
 	// Check the volume capability and handle accordingly.
 	// NOTE: VolumeCapability is optional field, if specified, use it for validation.
 	//       Otherwise, use volume_path to determine access_type and handle accordingly.
@@ -604,7 +719,7 @@ func (driver *vsphereCSIDriver) NodeExpandVolume(
 	}
 
 	// Resize file system.
-	if err = driver.osUtils.ResizeVolume(ctx, dev.RealDev, volumePath, reqVolSizeBytes); err != nil {
+	if err = driver.osUtils.ResizeVolume(ctx, dev.RealDev, volumePath, fsSizeBytes); err != nil {
 		return nil, logger.LogNewErrorCodef(log, codes.Internal,
 			"error when resizing filesystem on volume %q on node: %v", volumeID, err)
 	}
@@ -616,3 +731,68 @@ func (driver *vsphereCSIDriver) NodeExpandVolume(
 		CapacityBytes: int64(units.FileSize(reqVolSizeMB * common.MbInBytes)),
 	}, nil
 }
+
+// recordFsckEvent records the outcome of the filesystem check of a volume as
+// an event on its PersistentVolume. Failures are only logged since they must
+// not fail staging of the volume.
+func recordFsckEvent(ctx context.Context, volumeID string, result *osutils.FsckResult) {
+	log := logger.GetLogger(ctx)
+	recorder, k8sClient, err := getNodeEventRecorder(ctx)
+	if err != nil {
+		log.Warnf("failed to create event recorder, filesystem check result of volume %q is not recorded. "+
+			"Err: %v", volumeID, err)
+		return
+	}
+	pvList, err := k8sClient.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		log.Warnf("failed to list PVs, filesystem check result of volume %q is not recorded. Err: %v",
+			volumeID, err)
+		return
+	}
+	for i := range pvList.Items {
+		pv := &pvList.Items[i]
+		if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != csitypes.Name || pv.Spec.CSI.VolumeHandle != volumeID {
+			continue
+		}
+		switch {
+		case result.Failed:
+			recorder.Event(pv, v1.EventTypeWarning, "FilesystemCheckFailed", result.Message)
+		case result.Repaired:
+			recorder.Event(pv, v1.EventTypeNormal, "FilesystemRepaired", result.Message)
+		default:
+			recorder.Event(pv, v1.EventTypeNormal, "FilesystemCheckPassed", result.Message)
+		}
+		return
+	}
+	log.Warnf("PV not found for volume %q, filesystem check result is not recorded", volumeID)
+}
+
+var (
+	nodeEventRecorder     record.EventRecorder
+	nodeEventK8sClient    clientset.Interface
+	nodeEventRecorderLock sync.Mutex
+)
+
+// getNodeEventRecorder returns the event recorder of the node plugin along
+// with its kubernetes client, creating them on first use.
+func getNodeEventRecorder(ctx context.Context) (record.EventRecorder, clientset.Interface, error) {
+	nodeEventRecorderLock.Lock()
+	defer nodeEventRecorderLock.Unlock()
+	if nodeEventRecorder != nil {
+		return nodeEventRecorder, nodeEventK8sClient, nil
+	}
+	k8sClient, err := k8s.NewClient(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(
+		&typedcorev1.EventSinkImpl{
+			Interface: k8sClient.CoreV1().Events(""),
+		},
+	)
+	nodeEventRecorder = eventBroadcaster.NewRecorder(scheme.Scheme,
+		v1.EventSource{Component: csitypes.Name, Host: os.Getenv("NODE_NAME")})
+	nodeEventK8sClient = k8sClient
+	return nodeEventRecorder, nodeEventK8sClient, nil
+}
@@ -0,0 +1,241 @@
+//go:build darwin || linux
+// +build darwin linux
+
+/*
+Copyright 2024 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osutils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	utilexec "k8s.io/utils/exec"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+)
+
+const (
+	cryptsetupCmd = "cryptsetup"
+	devMapperDir  = "/dev/mapper"
+	// luksMapperPrefix is the prefix of the device mapper name used for
+	// volumes opened by the driver.
+	luksMapperPrefix = "luks-"
+	// maxLuksMapperNameLen keeps the mapper name well below the device mapper
+	// limit of 127 characters.
+	maxLuksMapperNameLen = 64
+	// luksSectorSize is the unit of the offset reported by "cryptsetup status".
+	luksSectorSize = 512
+)
+
+var luksMapperNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// getLuksMapperName returns the device mapper name used for the given volume.
+func getLuksMapperName(volumeID string) string {
+	name := luksMapperPrefix + volumeID
+	if len(name) <= maxLuksMapperNameLen && luksMapperNameRegex.MatchString(volumeID) {
+		return name
+	}
+	// Volume IDs of migrated volumes contain characters which are not valid
+	// in a mapper name, use a hash of the volume ID for those.
+	sum := sha256.Sum256([]byte(volumeID))
+	return luksMapperPrefix + hex.EncodeToString(sum[:])[:maxLuksMapperNameLen-len(luksMapperPrefix)]
+}
+
+// runCryptsetup runs cryptsetup with the given args. If passphrase is not
+// empty, it is passed to cryptsetup over stdin.
+func (osUtils *OsUtils) runCryptsetup(passphrase string, args ...string) ([]byte, error) {
+	if passphrase != "" {
+		args = append(args, "--key-file", "-")
+	}
+	cmd := osUtils.Mounter.Exec.Command(cryptsetupCmd, args...)
+	if passphrase != "" {
+		cmd.SetStdin(strings.NewReader(passphrase))
+	}
+	return cmd.CombinedOutput()
+}
+
+// isLuks returns true if devicePath holds a LUKS header.
+func (osUtils *OsUtils) isLuks(devicePath string) (bool, error) {
+	out, err := osUtils.runCryptsetup("", "isLuks", devicePath)
+	if err == nil {
+		return true, nil
+	}
+	var exitErr utilexec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitStatus() == 1 {
+		return false, nil
+	}
+	return false, fmt.Errorf("cryptsetup isLuks failed for %q: %v, output: %q", devicePath, err, string(out))
+}
+
+// OpenLuksDevice opens the LUKS mapping for the volume on devicePath and
+// returns the mapped device. A device without a LUKS header is formatted
+// first, unless it already holds a filesystem.
+func (osUtils *OsUtils) OpenLuksDevice(ctx context.Context, devicePath string, volumeID string,
+	passphrase string) (*Device, error) {
+	log := logger.GetLogger(ctx)
+	mapperPath := filepath.Join(devMapperDir, getLuksMapperName(volumeID))
+	dev, err := osUtils.GetDevice(ctx, mapperPath)
+	if err != nil {
+		return nil, logger.LogNewErrorCodef(log, codes.Internal,
+			"error getting LUKS device %q for volume %q: %v", mapperPath, volumeID, err)
+	}
+	if dev != nil {
+		log.Infof("OpenLuksDevice: LUKS device %q for volume %q is already open", mapperPath, volumeID)
+		return dev, nil
+	}
+
+	isLuks, err := osUtils.isLuks(devicePath)
+	if err != nil {
+		return nil, logger.LogNewErrorCode(log, codes.Internal, err.Error())
+	}
+	if !isLuks {
+		// Refuse to format a device which already contains data.
+		format, err := osUtils.Mounter.GetDiskFormat(devicePath)
+		if err != nil {
+			return nil, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to determine format of device %q for volume %q: %v", devicePath, volumeID, err)
+		}
+		if format != "" {
+			return nil, logger.LogNewErrorCodef(log, codes.FailedPrecondition,
+				"device %q for volume %q is not encrypted and already contains a %q filesystem",
+				devicePath, volumeID, format)
+		}
+		log.Infof("OpenLuksDevice: formatting device %q for volume %q with LUKS", devicePath, volumeID)
+		out, err := osUtils.runCryptsetup(passphrase, "luksFormat", "--type", "luks2", "--batch-mode", devicePath)
+		if err != nil {
+			return nil, logger.LogNewErrorCodef(log, codes.Internal,
+				"cryptsetup luksFormat failed for device %q: %v, output: %q", devicePath, err, string(out))
+		}
+	}
+
+	log.Infof("OpenLuksDevice: opening device %q for volume %q at %q", devicePath, volumeID, mapperPath)
+	out, err := osUtils.runCryptsetup(passphrase, "luksOpen", devicePath, getLuksMapperName(volumeID))
+	if err != nil {
+		return nil, logger.LogNewErrorCodef(log, codes.Internal,
+			"cryptsetup luksOpen failed for device %q: %v, output: %q", devicePath, err, string(out))
+	}
+	dev, err = osUtils.GetDevice(ctx, mapperPath)
+	if err != nil || dev == nil {
+		return nil, logger.LogNewErrorCodef(log, codes.Internal,
+			"error getting LUKS device %q for volume %q: %v", mapperPath, volumeID, err)
+	}
+	return dev, nil
+}
+
+// CloseLuksDevice closes the LUKS mapping for the volume if it is open.
+func (osUtils *OsUtils) CloseLuksDevice(ctx context.Context, volumeID string) error {
+	log := logger.GetLogger(ctx)
+	mapperName := getLuksMapperName(volumeID)
+	if _, err := os.Stat(filepath.Join(devMapperDir, mapperName)); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	log.Infof("CloseLuksDevice: closing LUKS device %q for volume %q", mapperName, volumeID)
+	out, err := osUtils.runCryptsetup("", "luksClose", mapperName)
+	if err != nil {
+		return fmt.Errorf("cryptsetup luksClose failed for %q: %v, output: %q", mapperName, err, string(out))
+	}
+	return nil
+}
+
+// IsLuksMapping returns true if dev is a device mapper device backed by LUKS.
+func (osUtils *OsUtils) IsLuksMapping(dev *Device) bool {
+	uuid, err := os.ReadFile(filepath.Join("/sys/block", filepath.Base(dev.RealDev), "dm", "uuid"))
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(string(uuid), "CRYPT-LUKS")
+}
+
+// getDmName returns the device mapper name of dev.
+func getDmName(dev *Device) (string, error) {
+	name, err := os.ReadFile(filepath.Join("/sys/block", filepath.Base(dev.RealDev), "dm", "name"))
+	if err != nil {
+		return "", fmt.Errorf("failed to get device mapper name of %q: %v", dev.RealDev, err)
+	}
+	return strings.TrimSpace(string(name)), nil
+}
+
+// GetLuksDeviceStatus returns the backing device and payload offset of the
+// LUKS mapping dev.
+func (osUtils *OsUtils) GetLuksDeviceStatus(ctx context.Context, dev *Device) (*LuksDeviceStatus, error) {
+	log := logger.GetLogger(ctx)
+	name, err := getDmName(dev)
+	if err != nil {
+		return nil, err
+	}
+	out, err := osUtils.runCryptsetup("", "status", name)
+	if err != nil {
+		return nil, fmt.Errorf("cryptsetup status failed for %q: %v, output: %q", dev.RealDev, err, string(out))
+	}
+	log.Debugf("GetLuksDeviceStatus: cryptsetup status for %q: %s", dev.RealDev, string(out))
+	return parseLuksStatus(string(out))
+}
+
+// parseLuksStatus parses the output of "cryptsetup status".
+func parseLuksStatus(out string) (*LuksDeviceStatus, error) {
+	status := &LuksDeviceStatus{}
+	for _, line := range strings.Split(out, "\n") {
+		key, value, found := strings.Cut(strings.TrimSpace(line), ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "device":
+			status.BackingDevice = value
+		case "offset":
+			fields := strings.Fields(value)
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("empty LUKS offset in cryptsetup status output %q", out)
+			}
+			sectors, err := strconv.ParseInt(fields[0], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse LUKS offset %q: %v", value, err)
+			}
+			status.OffsetBytes = sectors * luksSectorSize
+		}
+	}
+	if status.BackingDevice == "" {
+		return nil, fmt.Errorf("backing device not found in cryptsetup status output %q", out)
+	}
+	return status, nil
+}
+
+// ResizeLuksDevice grows the LUKS mapping dev to the size of its backing
+// device.
+func (osUtils *OsUtils) ResizeLuksDevice(ctx context.Context, dev *Device, passphrase string) error {
+	log := logger.GetLogger(ctx)
+	name, err := getDmName(dev)
+	if err != nil {
+		return err
+	}
+	log.Infof("ResizeLuksDevice: resizing LUKS device %q", name)
+	out, err := osUtils.runCryptsetup(passphrase, "resize", name)
+	if err != nil {
+		return fmt.Errorf("cryptsetup resize failed for %q: %v, output: %q", name, err, string(out))
+	}
+	return nil
+}
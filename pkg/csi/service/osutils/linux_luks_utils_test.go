@@ -0,0 +1,132 @@
+//go:build darwin || linux
+// +build darwin linux
+
+package osutils
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/mount-utils"
+	utilexec "k8s.io/utils/exec"
+)
+
+func TestGetLuksMapperName(t *testing.T) {
+	volumeID := "6c4c8a3f-3a1b-4f0e-9b6a-2f6f6e3c1c11"
+	if name := getLuksMapperName(volumeID); name != "luks-"+volumeID {
+		t.Errorf("unexpected mapper name %q for volume %q", name, volumeID)
+	}
+	migratedVolumeID := "[vsanDatastore] 5137595f-7ce3-e95a-5c03-06d835dea807/e2e-vmdk-1641374604660540311.vmdk"
+	name := getLuksMapperName(migratedVolumeID)
+	if !strings.HasPrefix(name, luksMapperPrefix) || len(name) > maxLuksMapperNameLen ||
+		!luksMapperNameRegex.MatchString(name) {
+		t.Errorf("invalid mapper name %q for volume %q", name, migratedVolumeID)
+	}
+	if name != getLuksMapperName(migratedVolumeID) {
+		t.Errorf("mapper name for volume %q is not stable", migratedVolumeID)
+	}
+}
+
+func TestParseLuksStatus(t *testing.T) {
+	out := `/dev/mapper/luks-6c4c8a3f is active and is in use.
+  type:    LUKS2
+  cipher:  aes-xts-plain64
+  keysize: 512 bits
+  key location: keyring
+  device:  /dev/sdb
+  sector size:  512
+  offset:  32768 sectors
+  size:    2064384 sectors
+  mode:    read/write
+`
+	status, err := parseLuksStatus(out)
+	if err != nil {
+		t.Fatalf("failed to parse cryptsetup status: %v", err)
+	}
+	if status.BackingDevice != "/dev/sdb" {
+		t.Errorf("expected backing device /dev/sdb, got %q", status.BackingDevice)
+	}
+	if status.OffsetBytes != 32768*512 {
+		t.Errorf("expected offset %d, got %d", 32768*512, status.OffsetBytes)
+	}
+	if _, err := parseLuksStatus("luks-6c4c8a3f is inactive.\n"); err == nil {
+		t.Errorf("expected error for inactive device")
+	}
+}
+
+// TestLuksDeviceLifecycle formats, opens and closes a LUKS device on a loop
+// device. It requires root privileges along with cryptsetup and losetup.
+func TestLuksDeviceLifecycle(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("test requires root privileges")
+	}
+	for _, cmd := range []string{cryptsetupCmd, "losetup"} {
+		if _, err := exec.LookPath(cmd); err != nil {
+			t.Skipf("test requires %s", cmd)
+		}
+	}
+	ctx := context.Background()
+	backingFile := filepath.Join(t.TempDir(), "disk.img")
+	if err := os.WriteFile(backingFile, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(backingFile, 64*1024*1024); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command("losetup", "--find", "--show", backingFile).CombinedOutput()
+	if err != nil {
+		t.Skipf("failed to set up loop device: %v, output: %q", err, string(out))
+	}
+	loopDev := strings.TrimSpace(string(out))
+	defer func() {
+		_ = exec.Command("losetup", "--detach", loopDev).Run()
+	}()
+
+	osUtils := &OsUtils{
+		Mounter: &mount.SafeFormatAndMount{
+			Interface: mount.New(""),
+			Exec:      utilexec.New(),
+		},
+	}
+	volumeID := "luks-lifecycle-test"
+	passphrase := "passphrase"
+	dev, err := osUtils.OpenLuksDevice(ctx, loopDev, volumeID, passphrase)
+	if err != nil {
+		t.Fatalf("failed to open LUKS device: %v", err)
+	}
+	defer func() {
+		_ = osUtils.CloseLuksDevice(ctx, volumeID)
+	}()
+	if !osUtils.IsLuksMapping(dev) {
+		t.Errorf("device %+v is not reported as a LUKS mapping", *dev)
+	}
+	// Opening an open device is a no-op.
+	if _, err := osUtils.OpenLuksDevice(ctx, loopDev, volumeID, passphrase); err != nil {
+		t.Errorf("failed to open already open LUKS device: %v", err)
+	}
+	status, err := osUtils.GetLuksDeviceStatus(ctx, dev)
+	if err != nil {
+		t.Fatalf("failed to get LUKS device status: %v", err)
+	}
+	if status.BackingDevice != loopDev || status.OffsetBytes <= 0 {
+		t.Errorf("unexpected LUKS device status %+v", *status)
+	}
+	if err := osUtils.ResizeLuksDevice(ctx, dev, passphrase); err != nil {
+		t.Errorf("failed to resize LUKS device: %v", err)
+	}
+	if err := osUtils.CloseLuksDevice(ctx, volumeID); err != nil {
+		t.Fatalf("failed to close LUKS device: %v", err)
+	}
+	// Closing a closed device is a no-op.
+	if err := osUtils.CloseLuksDevice(ctx, volumeID); err != nil {
+		t.Errorf("failed to close already closed LUKS device: %v", err)
+	}
+	// The device keeps its LUKS header and can be opened again.
+	if _, err := osUtils.OpenLuksDevice(ctx, loopDev, volumeID, passphrase); err != nil {
+		t.Errorf("failed to reopen LUKS device: %v", err)
+	}
+}
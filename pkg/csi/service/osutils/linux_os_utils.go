@@ -35,6 +35,7 @@ import (
 	k8svol "k8s.io/kubernetes/pkg/volume"
 	"k8s.io/kubernetes/pkg/volume/util/fs"
 	"k8s.io/mount-utils"
+	utilexec "k8s.io/utils/exec"
 
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/mounter"
 
@@ -47,6 +48,10 @@ const (
 	blockPrefix = "wwn-0x"
 	dmiDir      = "/sys/class/dmi"
 	UUIDPrefix  = "VMware-"
+
+	procMountInfoPath = "/proc/self/mountinfo"
+	sysClassBlockDir  = "/sys/class/block"
+	sysDevBlockDir    = "/sys/dev/block"
 )
 
 // defaultFileMountOptions are the mount flag options used by default while publishing a file volume.
@@ -106,10 +111,25 @@ func (osUtils *OsUtils) NodeStageBlockVolume(
 		return &csi.NodeStageVolumeResponse{}, nil
 	}
 
+	// Open the LUKS mapping of an encrypted volume and stage the mapped device.
+	if params.Encrypted {
+		dev, err = osUtils.OpenLuksDevice(ctx, dev.RealDev, params.VolID,
+			req.GetSecrets()[common.LuksPassphraseKey])
+		if err != nil {
+			return nil, err
+		}
+		log.Debugf("nodeStageBlockVolume: LUKS device %+v", *dev)
+	}
+
 	// Mount Volume.
 	// Fetch dev mounts to check if the device is already staged.
 	log.Debugf("nodeStageBlockVolume: Fetching device mounts")
 	mnts, err := gofsutil.GetDevMounts(ctx, dev.RealDev)
+	if err == nil && len(mnts) == 0 && params.Encrypted {
+		// Device mapper devices are listed in the mount table by their
+		// /dev/mapper path.
+		mnts, err = gofsutil.GetDevMounts(ctx, dev.FullPath)
+	}
 	if err != nil {
 		return nil, logger.LogNewErrorCodef(log, codes.Internal,
 			"could not reliably determine existing mount status. Parameters: %v err: %v", params, err)
@@ -130,10 +150,20 @@ func (osUtils *OsUtils) NodeStageBlockVolume(
 			log.Infof("nodeStageBlockVolume: Device mounted successfully at %q", params.StagingTarget)
 			return &csi.NodeStageVolumeResponse{}, nil
 		}
+		if params.FsckOnStage {
+			if err := osUtils.CheckFilesystem(ctx, dev.FullPath, params.FsckResult); err != nil {
+				return nil, err
+			}
+		}
 		// Format and mount the device.
 		log.Debugf("nodeStageBlockVolume: Format and mount the device %q at %q with mount flags %v",
 			dev.FullPath, params.StagingTarget, params.MntFlags)
-		err := gofsutil.FormatAndMount(ctx, dev.FullPath, params.StagingTarget, params.FsType, params.MntFlags...)
+		if len(params.MkfsOptions) > 0 {
+			if err := osUtils.formatWithMkfsOptions(ctx, dev.FullPath, params.FsType, params.MkfsOptions); err != nil {
+				return nil, err
+			}
+		}
+		err = gofsutil.FormatAndMount(ctx, dev.FullPath, params.StagingTarget, params.FsType, params.MntFlags...)
 		if err != nil {
 			return nil, logger.LogNewErrorCodef(log, codes.Internal,
 				"error in formating and mounting volume. Parameters: %v err: %v", params, err)
@@ -168,6 +198,125 @@ func (osUtils *OsUtils) NodeStageBlockVolume(
 	return &csi.NodeStageVolumeResponse{}, nil
 }
 
+// formatWithMkfsOptions formats the device with the given mkfs options if it
+// is not formatted yet. The options are passed after the flags gofsutil uses
+// by default, so that they take precedence over the mkfs defaults.
+func (osUtils *OsUtils) formatWithMkfsOptions(ctx context.Context, devicePath string, fsType string,
+	mkfsOptions []string) error {
+	log := logger.GetLogger(ctx)
+	format, err := osUtils.Mounter.GetDiskFormat(devicePath)
+	if err != nil {
+		return logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to determine filesystem on device %q: %v", devicePath, err)
+	}
+	if format != "" {
+		log.Infof("formatWithMkfsOptions: device %q is already formatted as %q, skipping mkfs", devicePath, format)
+		return nil
+	}
+	if fsType == "" {
+		fsType = common.Ext4FsType
+	}
+	var args []string
+	if fsType == common.Ext4FsType || fsType == common.Ext3FsType {
+		args = append(args, "-F")
+	}
+	args = append(args, mkfsOptions...)
+	args = append(args, devicePath)
+	log.Infof("formatWithMkfsOptions: running mkfs.%s %v", fsType, args)
+	out, err := osUtils.Mounter.Exec.Command("mkfs."+fsType, args...).CombinedOutput()
+	if err != nil {
+		return logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to format device %q as %q with options %v: %v. Output: %s",
+			devicePath, fsType, mkfsOptions, err, string(out))
+	}
+	return nil
+}
+
+// CheckFilesystem checks the filesystem on devicePath before it is mounted.
+// Errors on ext filesystems are repaired automatically with "fsck -a", xfs
+// filesystems are only checked since their log is replayed on mount. A
+// FailedPrecondition error is returned if the filesystem has to be repaired
+// manually. The outcome of the check is recorded in result if it is not nil.
+func (osUtils *OsUtils) CheckFilesystem(ctx context.Context, devicePath string, result *FsckResult) error {
+	log := logger.GetLogger(ctx)
+	if result == nil {
+		result = &FsckResult{}
+	}
+	format, err := osUtils.Mounter.GetDiskFormat(devicePath)
+	if err != nil {
+		return logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to determine filesystem on device %q: %v", devicePath, err)
+	}
+	var args []string
+	switch format {
+	case "":
+		log.Infof("CheckFilesystem: device %q is not formatted, skipping filesystem check", devicePath)
+		return nil
+	case "ext2", common.Ext3FsType, common.Ext4FsType:
+		args = []string{"fsck", "-a", devicePath}
+	case common.XFSType:
+		args = []string{"xfs_repair", "-n", devicePath}
+	default:
+		log.Infof("CheckFilesystem: filesystem check is not supported for %q on device %q, skipping",
+			format, devicePath)
+		return nil
+	}
+
+	log.Infof("CheckFilesystem: running %v", args)
+	out, err := osUtils.Mounter.Exec.Command(args[0], args[1:]...).CombinedOutput()
+	result.Performed = true
+	exitCode := 0
+	if err != nil {
+		var exitErr utilexec.ExitError
+		if !errors.As(err, &exitErr) {
+			result.Failed = true
+			result.Message = fmt.Sprintf("failed to run %s on device %q: %v", args[0], devicePath, err)
+			return logger.LogNewErrorCode(log, codes.Internal, result.Message)
+		}
+		exitCode = exitErr.ExitStatus()
+	}
+	log.Debugf("CheckFilesystem: %s exited with code %d, output: %s", args[0], exitCode, string(out))
+
+	if format == common.XFSType {
+		switch exitCode {
+		case 0:
+			result.Message = fmt.Sprintf("xfs filesystem on device %q is clean", devicePath)
+		case 2:
+			// The log is dirty, it gets replayed when the filesystem is mounted.
+			result.Repaired = true
+			result.Message = fmt.Sprintf("xfs filesystem on device %q has a dirty log which is replayed on mount",
+				devicePath)
+		default:
+			result.Failed = true
+			result.Message = fmt.Sprintf("xfs filesystem on device %q is corrupted and needs to be repaired "+
+				"manually with xfs_repair: %s", devicePath, strings.TrimSpace(string(out)))
+			return logger.LogNewErrorCode(log, codes.FailedPrecondition, result.Message)
+		}
+	} else {
+		// Exit codes of fsck are a bit mask, refer to fsck(8).
+		switch {
+		case exitCode == 0:
+			result.Message = fmt.Sprintf("%s filesystem on device %q is clean", format, devicePath)
+		case exitCode&^3 == 0:
+			result.Repaired = true
+			result.Message = fmt.Sprintf("errors in %s filesystem on device %q were repaired: %s",
+				format, devicePath, strings.TrimSpace(string(out)))
+		case exitCode&4 != 0:
+			result.Failed = true
+			result.Message = fmt.Sprintf("%s filesystem on device %q has errors which need to be repaired "+
+				"manually with fsck: %s", format, devicePath, strings.TrimSpace(string(out)))
+			return logger.LogNewErrorCode(log, codes.FailedPrecondition, result.Message)
+		default:
+			result.Failed = true
+			result.Message = fmt.Sprintf("fsck failed on device %q with exit code %d: %s",
+				devicePath, exitCode, strings.TrimSpace(string(out)))
+			return logger.LogNewErrorCode(log, codes.Internal, result.Message)
+		}
+	}
+	log.Infof("CheckFilesystem: %s", result.Message)
+	return nil
+}
+
 // CleanupStagePath will unmount the volume from node and remove the stage directory
 func (osUtils *OsUtils) CleanupStagePath(ctx context.Context, stagingTarget string, volID string) error {
 	log := logger.GetLogger(ctx)
@@ -363,6 +512,125 @@ func (osUtils *OsUtils) GetMetrics(ctx context.Context, path string) (*k8svol.Me
 	return metrics, nil
 }
 
+// GetVolumeCondition checks the health of the volume published at volumePath
+// on this node. The volume is reported abnormal if the mount point is stale,
+// its backing device is gone or offline, or its filesystem was remounted
+// read-only.
+func (osUtils *OsUtils) GetVolumeCondition(ctx context.Context, volumePath string) (*csi.VolumeCondition, error) {
+	log := logger.GetLogger(ctx)
+	if _, err := os.Stat(volumePath); err != nil {
+		if errors.Is(err, syscall.ESTALE) || mount.IsCorruptedMnt(err) {
+			return &csi.VolumeCondition{
+				Abnormal: true,
+				Message:  fmt.Sprintf("mount point %q is stale: %v", volumePath, err),
+			}, nil
+		}
+		if os.IsNotExist(err) {
+			return &csi.VolumeCondition{
+				Abnormal: true,
+				Message:  fmt.Sprintf("volume path %q does not exist", volumePath),
+			}, nil
+		}
+		return nil, fmt.Errorf("failed to stat volume path %q: %v", volumePath, err)
+	}
+
+	mntInfos, err := mount.ParseMountInfo(procMountInfoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mount info: %v", err)
+	}
+	var mntInfo *mount.MountInfo
+	for i := range mntInfos {
+		// The last entry for the path is the one that is visible.
+		if unescape(ctx, mntInfos[i].MountPoint) == volumePath {
+			mntInfo = &mntInfos[i]
+		}
+	}
+	if mntInfo == nil {
+		return &csi.VolumeCondition{
+			Abnormal: true,
+			Message:  fmt.Sprintf("volume path %q is not mounted", volumePath),
+		}, nil
+	}
+	log.Debugf("GetVolumeCondition: mount info for volume path %q: %+v", volumePath, *mntInfo)
+
+	// Find the block device backing the mount. Raw block volumes are bind
+	// mounts of the device node from devtmpfs, file volumes have no device.
+	var sysBlockPath string
+	if mntInfo.FsType == "devtmpfs" {
+		sysBlockPath = filepath.Join(sysClassBlockDir, filepath.Base(mntInfo.Root))
+	} else if mntInfo.Major != 0 {
+		sysBlockPath = filepath.Join(sysDevBlockDir, fmt.Sprintf("%d:%d", mntInfo.Major, mntInfo.Minor))
+	}
+	if sysBlockPath != "" {
+		if msg := getBlockDeviceCondition(ctx, sysBlockPath); msg != "" {
+			return &csi.VolumeCondition{Abnormal: true, Message: msg}, nil
+		}
+	}
+
+	if isRemountedReadOnly(*mntInfo) {
+		return &csi.VolumeCondition{
+			Abnormal: true,
+			Message: fmt.Sprintf("filesystem at %q was remounted read-only, possibly after an I/O error",
+				volumePath),
+		}, nil
+	}
+	return &csi.VolumeCondition{
+		Abnormal: false,
+		Message:  fmt.Sprintf("volume at %q is healthy", volumePath),
+	}, nil
+}
+
+// getBlockDeviceCondition returns a message describing the problem with the
+// block device at sysBlockPath, or an empty string if the device is healthy.
+func getBlockDeviceCondition(ctx context.Context, sysBlockPath string) string {
+	log := logger.GetLogger(ctx)
+	sysDevPath, err := filepath.EvalSymlinks(sysBlockPath)
+	if err != nil {
+		return fmt.Sprintf("backing device %q of the volume is missing", filepath.Base(sysBlockPath))
+	}
+	disks := []string{filepath.Base(sysDevPath)}
+	// Check the disks underneath device mapper devices, e.g. LUKS mappings.
+	if slaves, err := os.ReadDir(filepath.Join(sysDevPath, "slaves")); err == nil && len(slaves) > 0 {
+		disks = disks[:0]
+		for _, slave := range slaves {
+			disks = append(disks, slave.Name())
+		}
+	}
+	byIDPaths, err := os.ReadDir(devDiskID)
+	if err != nil {
+		return fmt.Sprintf("failed to read %s: %v", devDiskID, err)
+	}
+	for _, disk := range disks {
+		state, err := os.ReadFile(filepath.Join(sysClassBlockDir, disk, "device", "state"))
+		if err == nil && strings.TrimSpace(string(state)) != "running" {
+			return fmt.Sprintf("backing device /dev/%s of the volume is in state %q",
+				disk, strings.TrimSpace(string(state)))
+		}
+		found := false
+		for _, byID := range byIDPaths {
+			if !strings.HasPrefix(byID.Name(), blockPrefix) {
+				continue
+			}
+			target, err := filepath.EvalSymlinks(filepath.Join(devDiskID, byID.Name()))
+			if err == nil && target == filepath.Join("/dev", disk) {
+				log.Debugf("getBlockDeviceCondition: found %s for device /dev/%s", byID.Name(), disk)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Sprintf("no %s entry found for backing device /dev/%s of the volume", devDiskID, disk)
+		}
+	}
+	return ""
+}
+
+// isRemountedReadOnly returns true if the filesystem of a read-write mount
+// has been switched to read-only, which ext4 does on I/O errors.
+func isRemountedReadOnly(mntInfo mount.MountInfo) bool {
+	return common.Contains(mntInfo.MountOptions, "rw") && common.Contains(mntInfo.SuperOptions, "ro")
+}
+
 // GetBlockSizeBytes returns the Block size in bytes
 func (osUtils *OsUtils) GetBlockSizeBytes(ctx context.Context, devicePath string) (int64, error) {
 	cmdArgs := []string{"--getsize64", devicePath}
@@ -5,8 +5,16 @@ package osutils
 
 import (
 	"context"
+	"path/filepath"
+	"reflect"
 	"strconv"
 	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/mount-utils"
+	utilexec "k8s.io/utils/exec"
+	testingexec "k8s.io/utils/exec/testing"
 )
 
 func TestUnescape(t *testing.T) {
@@ -52,3 +60,152 @@ func TestUnescape(t *testing.T) {
 		})
 	}
 }
+
+func TestIsRemountedReadOnly(t *testing.T) {
+	tests := []struct {
+		mountOptions, superOptions []string
+		expected                   bool
+	}{
+		{[]string{"rw", "relatime"}, []string{"rw", "errors=remount-ro"}, false},
+		// ext4 switched the filesystem to read-only after an I/O error.
+		{[]string{"rw", "relatime"}, []string{"ro", "errors=remount-ro"}, true},
+		// Volume published in read-only mode.
+		{[]string{"ro", "relatime"}, []string{"ro"}, false},
+	}
+	for i, test := range tests {
+		mntInfo := mount.MountInfo{MountOptions: test.mountOptions, SuperOptions: test.superOptions}
+		if actual := isRemountedReadOnly(mntInfo); actual != test.expected {
+			t.Errorf("test %d: expected %v, got %v", i, test.expected, actual)
+		}
+	}
+}
+
+func TestGetVolumeConditionForUnmountedPath(t *testing.T) {
+	ctx := context.Background()
+	osUtils := &OsUtils{}
+	dir := t.TempDir()
+	for _, path := range []string{dir, filepath.Join(dir, "missing")} {
+		volCondition, err := osUtils.GetVolumeCondition(ctx, path)
+		if err != nil {
+			t.Fatalf("failed to get volume condition for %q: %v", path, err)
+		}
+		if !volCondition.Abnormal {
+			t.Errorf("expected volume at %q to be reported abnormal, got %+v", path, volCondition)
+		}
+	}
+}
+
+func TestCheckFilesystem(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      string
+		exitCode    int
+		performed   bool
+		repaired    bool
+		expectedErr codes.Code
+	}{
+		{name: "unformatted", format: "", performed: false, expectedErr: codes.OK},
+		{name: "ext4 clean", format: "ext4", exitCode: 0, performed: true, expectedErr: codes.OK},
+		{name: "ext4 repaired", format: "ext4", exitCode: 1, performed: true, repaired: true, expectedErr: codes.OK},
+		{name: "ext4 uncorrected", format: "ext4", exitCode: 4, performed: true, expectedErr: codes.FailedPrecondition},
+		{name: "ext4 operational error", format: "ext4", exitCode: 8, performed: true, expectedErr: codes.Internal},
+		{name: "xfs clean", format: "xfs", exitCode: 0, performed: true, expectedErr: codes.OK},
+		{name: "xfs dirty log", format: "xfs", exitCode: 2, performed: true, repaired: true, expectedErr: codes.OK},
+		{name: "xfs corrupted", format: "xfs", exitCode: 1, performed: true, expectedErr: codes.FailedPrecondition},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			blkidOutput := ""
+			blkidErr := error(&testingexec.FakeExitError{Status: 2})
+			if test.format != "" {
+				blkidOutput = "DEVNAME=/dev/sdb\nTYPE=" + test.format + "\n"
+				blkidErr = nil
+			}
+			var checkErr error
+			if test.exitCode != 0 {
+				checkErr = &testingexec.FakeExitError{Status: test.exitCode}
+			}
+			fakeExec := &testingexec.FakeExec{
+				CommandScript: []testingexec.FakeCommandAction{
+					func(cmd string, args ...string) utilexec.Cmd {
+						return &testingexec.FakeCmd{CombinedOutputScript: []testingexec.FakeAction{
+							func() ([]byte, []byte, error) { return []byte(blkidOutput), nil, blkidErr },
+						}}
+					},
+					func(cmd string, args ...string) utilexec.Cmd {
+						return &testingexec.FakeCmd{CombinedOutputScript: []testingexec.FakeAction{
+							func() ([]byte, []byte, error) { return nil, nil, checkErr },
+						}}
+					},
+				},
+			}
+			osUtils := &OsUtils{Mounter: &mount.SafeFormatAndMount{Exec: fakeExec}}
+			result := &FsckResult{}
+			err := osUtils.CheckFilesystem(context.Background(), "/dev/sdb", result)
+			if status.Code(err) != test.expectedErr {
+				t.Errorf("expected error code %v, got %v", test.expectedErr, err)
+			}
+			if result.Performed != test.performed || result.Repaired != test.repaired {
+				t.Errorf("unexpected result %+v", *result)
+			}
+		})
+	}
+}
+
+func TestFormatWithMkfsOptions(t *testing.T) {
+	tests := []struct {
+		name         string
+		format       string
+		fsType       string
+		expectedCmd  string
+		expectedArgs []string
+	}{
+		{name: "ext4", fsType: "ext4", expectedCmd: "mkfs.ext4",
+			expectedArgs: []string{"-F", "-i", "8192", "-m", "1", "/dev/sdb"}},
+		{name: "default fstype", expectedCmd: "mkfs.ext4",
+			expectedArgs: []string{"-F", "-i", "8192", "-m", "1", "/dev/sdb"}},
+		{name: "xfs", fsType: "xfs", expectedCmd: "mkfs.xfs",
+			expectedArgs: []string{"-i", "8192", "-m", "1", "/dev/sdb"}},
+		{name: "already formatted", format: "ext4", fsType: "ext4"},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			blkidOutput := ""
+			blkidErr := error(&testingexec.FakeExitError{Status: 2})
+			if test.format != "" {
+				blkidOutput = "DEVNAME=/dev/sdb\nTYPE=" + test.format + "\n"
+				blkidErr = nil
+			}
+			var mkfsCmd string
+			var mkfsArgs []string
+			fakeExec := &testingexec.FakeExec{
+				CommandScript: []testingexec.FakeCommandAction{
+					func(cmd string, args ...string) utilexec.Cmd {
+						return &testingexec.FakeCmd{CombinedOutputScript: []testingexec.FakeAction{
+							func() ([]byte, []byte, error) { return []byte(blkidOutput), nil, blkidErr },
+						}}
+					},
+					func(cmd string, args ...string) utilexec.Cmd {
+						mkfsCmd, mkfsArgs = cmd, args
+						return &testingexec.FakeCmd{CombinedOutputScript: []testingexec.FakeAction{
+							func() ([]byte, []byte, error) { return nil, nil, nil },
+						}}
+					},
+				},
+			}
+			osUtils := &OsUtils{Mounter: &mount.SafeFormatAndMount{Exec: fakeExec}}
+			// The options are passed last so that the reserved blocks are not
+			// overridden by the defaults.
+			err := osUtils.formatWithMkfsOptions(context.Background(), "/dev/sdb", test.fsType,
+				[]string{"-i", "8192", "-m", "1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mkfsCmd != test.expectedCmd || !reflect.DeepEqual(mkfsArgs, test.expectedArgs) {
+				t.Errorf("expected %s %v, got %s %v", test.expectedCmd, test.expectedArgs, mkfsCmd, mkfsArgs)
+			}
+		})
+	}
+}
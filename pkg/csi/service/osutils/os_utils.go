@@ -41,6 +41,26 @@ type NodeStageParams struct {
 	MntFlags []string
 	// Read-only flag.
 	Ro bool
+	// Encrypted flag, set when the volume is encrypted with LUKS on the node.
+	Encrypted bool
+	// FsckOnStage flag, set when the filesystem should be checked before mounting.
+	FsckOnStage bool
+	// FsckResult is filled with the outcome of the filesystem check.
+	FsckResult *FsckResult
+	// MkfsOptions are passed to mkfs when the device is formatted.
+	MkfsOptions []string
+}
+
+// FsckResult holds the outcome of a filesystem check run before staging.
+type FsckResult struct {
+	// Performed is set when a filesystem check was run on the device.
+	Performed bool
+	// Repaired is set when errors were found and repaired.
+	Repaired bool
+	// Failed is set when the filesystem needs to be repaired manually.
+	Failed bool
+	// Message describes the outcome of the check.
+	Message string
 }
 
 // struct to hold params required for NodePublish operation
@@ -68,6 +88,14 @@ type Device struct {
 	RealDev  string // in windows it represents volumeID and in linux it represents device path
 }
 
+// LuksDeviceStatus holds details about an open LUKS mapping.
+type LuksDeviceStatus struct {
+	// BackingDevice is the path of the device holding the LUKS header.
+	BackingDevice string
+	// OffsetBytes is the size of the LUKS header preceding the payload.
+	OffsetBytes int64
+}
+
 // GetDiskID returns the diskID of the disk attached
 func (osUtils *OsUtils) GetDiskID(pubCtx map[string]string, log *zap.SugaredLogger) (string, error) {
 	var diskID string
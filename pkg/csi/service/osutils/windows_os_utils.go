@@ -69,6 +69,10 @@ func (osUtils *OsUtils) NodeStageBlockVolume(
 
 	log := logger.GetLogger(ctx)
 	log.Debug("start nodeStageBlockVolume")
+	if params.Encrypted {
+		return nil, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"volume %q is encrypted, volume encryption is not supported on windows nodes", params.VolID)
+	}
 
 	// For windows NodeStageVolumeRequest comes like {VolumeId:b03f0b6e-cf29-4b98-9411-5168682ace82
 	// PublishContext:map[diskUUID:6000c29a98d05e384a43f0ef189aaf5a type:vSphere CNS Block Volume]
@@ -514,3 +518,34 @@ func (osUtils *OsUtils) ShouldContinue(ctx context.Context) {
 func (osUtils *OsUtils) IsBlockDevice(ctx context.Context, volumePath string) (bool, error) {
 	return false, nil
 }
+
+// OpenLuksDevice is not supported on windows nodes
+func (osUtils *OsUtils) OpenLuksDevice(ctx context.Context, devicePath string, volumeID string,
+	passphrase string) (*Device, error) {
+	return nil, status.Error(codes.Unimplemented, "volume encryption is not supported on windows nodes")
+}
+
+// CloseLuksDevice is a no-op on windows nodes
+func (osUtils *OsUtils) CloseLuksDevice(ctx context.Context, volumeID string) error {
+	return nil
+}
+
+// IsLuksMapping always returns false on windows nodes
+func (osUtils *OsUtils) IsLuksMapping(dev *Device) bool {
+	return false
+}
+
+// GetLuksDeviceStatus is not supported on windows nodes
+func (osUtils *OsUtils) GetLuksDeviceStatus(ctx context.Context, dev *Device) (*LuksDeviceStatus, error) {
+	return nil, status.Error(codes.Unimplemented, "volume encryption is not supported on windows nodes")
+}
+
+// ResizeLuksDevice is not supported on windows nodes
+func (osUtils *OsUtils) ResizeLuksDevice(ctx context.Context, dev *Device, passphrase string) error {
+	return status.Error(codes.Unimplemented, "volume encryption is not supported on windows nodes")
+}
+
+// GetVolumeCondition is not supported on windows nodes, no condition is returned
+func (osUtils *OsUtils) GetVolumeCondition(ctx context.Context, volumePath string) (*csi.VolumeCondition, error) {
+	return nil, nil
+}
@@ -17,13 +17,19 @@ limitations under the License.
 package service
 
 import (
+	"context"
 	"net"
 	"os"
 	"strings"
 	"sync"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
 
 	csitypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/types"
@@ -113,7 +119,10 @@ func (s *nonBlockingGRPCServer) serve(endpoint string, ids csi.IdentityServer,
 		return logger.LogNewErrorf(log, "failed to listen: %v", err)
 	}
 
-	server := grpc.NewServer()
+	// Start a span for every CSI call, continuing the trace of the caller if
+	// the request carries one. Spans are only exported if tracing is enabled.
+	server := grpc.NewServer(grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.UnaryInterceptor(circuitBreakerInterceptor))
 	s.server = server
 
 	// Register the CSI services.
@@ -138,6 +147,14 @@ func (s *nonBlockingGRPCServer) serve(endpoint string, ids csi.IdentityServer,
 		}
 		csi.RegisterControllerServer(s.server, cs)
 		log.Info("controller service registered")
+		if gcs, ok := cs.(csi.GroupControllerServer); ok {
+			csi.RegisterGroupControllerServer(s.server, gcs)
+			log.Info("group controller service registered")
+		}
+		if sms, ok := cs.(csi.SnapshotMetadataServer); ok {
+			csi.RegisterSnapshotMetadataServer(s.server, sms)
+			log.Info("snapshot metadata service registered")
+		}
 	} else if strings.EqualFold(mode, "node") {
 		if ns == nil {
 			return logger.LogNewError(log, "node service required when running in node mode")
@@ -156,3 +173,15 @@ func (s *nonBlockingGRPCServer) serve(endpoint string, ids csi.IdentityServer,
 	}
 	return nil
 }
+
+// circuitBreakerInterceptor fails CSI calls which were rejected by the circuit
+// breaker of a vCenter with codes.Unavailable, so that the caller backs off
+// instead of treating the failure as an internal error.
+func circuitBreakerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if cnsvsphere.IsCircuitBreakerOpenError(err) {
+		return resp, status.Error(codes.Unavailable, status.Convert(err).Message())
+	}
+	return resp, err
+}
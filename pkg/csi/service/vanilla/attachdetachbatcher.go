@@ -0,0 +1,224 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vanilla
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	cnsvolume "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/prometheus"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+)
+
+// maxAttachDetachBatchSize is the maximum number of volumes attached to or
+// detached from a node VM with a single CNS call. A batch is sent as soon as
+// it is full.
+const maxAttachDetachBatchSize = 64
+
+// attachDetachBatcher coalesces the attach or detach requests targeting the
+// same node VM which are received within a window into a single CNS call, and
+// fans the result of each volume back to its request.
+type attachDetachBatcher struct {
+	window time.Duration
+	mutex  sync.Mutex
+	// batches maps the vCenter, node VM and operation to the batch collecting
+	// the volumes of the requests.
+	batches map[string]*attachDetachBatch
+}
+
+// attachDetachBatch holds the volumes to attach to or detach from a node VM.
+type attachDetachBatch struct {
+	volumeManager cnsvolume.Manager
+	vm            *cnsvsphere.VirtualMachine
+	attach        bool
+	volumeIDs     []string
+	// waiters maps the volume ID to the channels of the requests waiting for
+	// the result of the volume.
+	waiters map[string][]chan attachDetachBatchResult
+}
+
+// attachDetachBatchResult is the result of a volume in a batch. batchErr is
+// set if the CNS call failed as a whole, in which case the request falls back
+// to attaching or detaching its volume on its own.
+type attachDetachBatchResult struct {
+	cnsvolume.BatchAttachDetachResult
+	batchErr error
+}
+
+// newAttachDetachBatcher returns a batcher collecting the requests of a node
+// VM for window.
+func newAttachDetachBatcher(window time.Duration) *attachDetachBatcher {
+	return &attachDetachBatcher{
+		window:  window,
+		batches: make(map[string]*attachDetachBatch),
+	}
+}
+
+// attachVolume attaches the volume to nodevm and returns the disk UUID of the
+// volume. The volume is attached together with the other volumes of nodevm
+// requested within the batch window if the batch-attach-detach feature is
+// enabled.
+func (c *controller) attachVolume(ctx context.Context, volumeManager cnsvolume.Manager,
+	nodevm *cnsvsphere.VirtualMachine, volumeID string) (string, string, error) {
+	if c.attachDetachBatcher == nil ||
+		!commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.BatchAttachDetach) {
+		return common.AttachVolumeUtil(ctx, volumeManager, nodevm, volumeID, false)
+	}
+	result, err := c.attachDetachBatcher.submit(ctx, volumeManager, nodevm, volumeID, true)
+	if err != nil {
+		return "", "", err
+	}
+	if result.batchErr != nil {
+		log := logger.GetLogger(ctx)
+		log.Infof("Batched attach of volume %q failed with err: %v. Attaching it on its own",
+			volumeID, result.batchErr)
+		return common.AttachVolumeUtil(ctx, volumeManager, nodevm, volumeID, false)
+	}
+	return result.DiskUUID, result.FaultType, result.Err
+}
+
+// detachVolume detaches the volume from nodevm. The volume is detached
+// together with the other volumes of nodevm requested within the batch window
+// if the batch-attach-detach feature is enabled.
+func (c *controller) detachVolume(ctx context.Context, volumeManager cnsvolume.Manager,
+	nodevm *cnsvsphere.VirtualMachine, volumeID string) (string, error) {
+	if c.attachDetachBatcher == nil ||
+		!commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.BatchAttachDetach) {
+		return common.DetachVolumeUtil(ctx, volumeManager, nodevm, volumeID)
+	}
+	result, err := c.attachDetachBatcher.submit(ctx, volumeManager, nodevm, volumeID, false)
+	if err != nil {
+		return "", err
+	}
+	if result.batchErr != nil {
+		log := logger.GetLogger(ctx)
+		log.Infof("Batched detach of volume %q failed with err: %v. Detaching it on its own",
+			volumeID, result.batchErr)
+		return common.DetachVolumeUtil(ctx, volumeManager, nodevm, volumeID)
+	}
+	return result.FaultType, result.Err
+}
+
+// submit adds the volume to the batch of vm for the operation and waits for
+// the result of the volume. The batch is started by the first request and
+// sent once the window has passed or the batch is full.
+func (b *attachDetachBatcher) submit(ctx context.Context, volumeManager cnsvolume.Manager,
+	vm *cnsvsphere.VirtualMachine, volumeID string, attach bool) (attachDetachBatchResult, error) {
+	log := logger.GetLogger(ctx)
+	resultCh := make(chan attachDetachBatchResult, 1)
+	key := fmt.Sprintf("%s/%s/%t", vm.VirtualCenterHost, vm.Reference().Value, attach)
+
+	b.mutex.Lock()
+	batch, ok := b.batches[key]
+	if !ok {
+		batch = &attachDetachBatch{
+			volumeManager: volumeManager,
+			vm:            vm,
+			attach:        attach,
+			waiters:       make(map[string][]chan attachDetachBatchResult),
+		}
+		b.batches[key] = batch
+		time.AfterFunc(b.window, func() {
+			b.send(key, batch)
+		})
+	}
+	if _, ok := batch.waiters[volumeID]; !ok {
+		batch.volumeIDs = append(batch.volumeIDs, volumeID)
+	}
+	batch.waiters[volumeID] = append(batch.waiters[volumeID], resultCh)
+	full := len(batch.volumeIDs) >= maxAttachDetachBatchSize
+	b.mutex.Unlock()
+	if full {
+		go b.send(key, batch)
+	}
+	log.Debugf("Added volume %q to the batch of vm %q, attach: %t", volumeID, vm.String(), attach)
+
+	select {
+	case result := <-resultCh:
+		return result, nil
+	case <-ctx.Done():
+		return attachDetachBatchResult{}, ctx.Err()
+	}
+}
+
+// send sends the batch to CNS if it was not sent yet, and passes the result of
+// each volume to the requests waiting for it.
+func (b *attachDetachBatcher) send(key string, batch *attachDetachBatch) {
+	b.mutex.Lock()
+	if b.batches[key] != batch {
+		// The batch was already sent.
+		b.mutex.Unlock()
+		return
+	}
+	delete(b.batches, key)
+	b.mutex.Unlock()
+
+	// The batch is not bound to the context of any of its requests, as they
+	// may be cancelled while the other requests are still waiting.
+	ctx, log := logger.GetNewContextWithLogger()
+	opType := prometheus.PrometheusCnsBatchDetachVolumeOpType
+	if batch.attach {
+		opType = prometheus.PrometheusCnsBatchAttachVolumeOpType
+	}
+	prometheus.AttachDetachBatchSizeHistVec.WithLabelValues(opType).Observe(float64(len(batch.volumeIDs)))
+	log.Infof("Sending %s of volumes %v for vm %q", opType, batch.volumeIDs, batch.vm.String())
+
+	results := make(map[string]attachDetachBatchResult)
+	if len(batch.volumeIDs) == 1 {
+		// A single volume is attached or detached on its own.
+		result := attachDetachBatchResult{}
+		result.VolumeID = batch.volumeIDs[0]
+		if batch.attach {
+			result.DiskUUID, result.FaultType, result.Err = common.AttachVolumeUtil(ctx, batch.volumeManager,
+				batch.vm, result.VolumeID, false)
+		} else {
+			result.FaultType, result.Err = common.DetachVolumeUtil(ctx, batch.volumeManager, batch.vm,
+				result.VolumeID)
+		}
+		results[result.VolumeID] = result
+	} else {
+		var volumeResults []cnsvolume.BatchAttachDetachResult
+		var err error
+		if batch.attach {
+			volumeResults, _, err = batch.volumeManager.BatchAttachVolumes(ctx, batch.vm, batch.volumeIDs, false)
+		} else {
+			volumeResults, _, err = batch.volumeManager.BatchDetachVolumes(ctx, batch.vm, batch.volumeIDs)
+		}
+		if err != nil {
+			log.Errorf("%s of volumes %v for vm %q failed. err: %v", opType, batch.volumeIDs, batch.vm.String(), err)
+		}
+		for _, volumeResult := range volumeResults {
+			results[volumeResult.VolumeID] = attachDetachBatchResult{BatchAttachDetachResult: volumeResult}
+		}
+		for _, volumeID := range batch.volumeIDs {
+			if _, ok := results[volumeID]; !ok {
+				results[volumeID] = attachDetachBatchResult{batchErr: err}
+			}
+		}
+	}
+	for volumeID, waiters := range batch.waiters {
+		for _, resultCh := range waiters {
+			resultCh <- results[volumeID]
+		}
+	}
+}
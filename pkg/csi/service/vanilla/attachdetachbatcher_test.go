@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vanilla
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+
+	cnsvolume "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+)
+
+// fakeBatchVolumeManager records the batches of volumes attached and
+// detached. Detaching a batch fails as a whole.
+type fakeBatchVolumeManager struct {
+	cnsvolume.Manager
+	mutex   sync.Mutex
+	batches [][]string
+}
+
+func (m *fakeBatchVolumeManager) BatchAttachVolumes(ctx context.Context, vm *cnsvsphere.VirtualMachine,
+	volumeIDs []string, checkNVMeController bool) ([]cnsvolume.BatchAttachDetachResult, string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.batches = append(m.batches, volumeIDs)
+	results := make([]cnsvolume.BatchAttachDetachResult, len(volumeIDs))
+	for i, volumeID := range volumeIDs {
+		results[i] = cnsvolume.BatchAttachDetachResult{VolumeID: volumeID, DiskUUID: "disk-" + volumeID}
+	}
+	return results, "", nil
+}
+
+func (m *fakeBatchVolumeManager) BatchDetachVolumes(ctx context.Context, vm *cnsvsphere.VirtualMachine,
+	volumeIDs []string) ([]cnsvolume.BatchAttachDetachResult, string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.batches = append(m.batches, volumeIDs)
+	return nil, "", errors.New("task failed")
+}
+
+func TestAttachDetachBatcher(t *testing.T) {
+	ctx := context.Background()
+	volumeManager := &fakeBatchVolumeManager{}
+	vm := &cnsvsphere.VirtualMachine{
+		VirtualCenterHost: "vc-1",
+		VirtualMachine:    object.NewVirtualMachine(nil, types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"}),
+	}
+	batcher := newAttachDetachBatcher(100 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	for _, attach := range []bool{true, false} {
+		for i := 0; i < 3; i++ {
+			volumeID := fmt.Sprintf("volume-%d", i)
+			wg.Add(1)
+			go func(attach bool) {
+				defer wg.Done()
+				result, err := batcher.submit(ctx, volumeManager, vm, volumeID, attach)
+				if err != nil {
+					t.Errorf("unexpected error for volume %q: %v", volumeID, err)
+					return
+				}
+				if attach && (result.batchErr != nil || result.Err != nil || result.DiskUUID != "disk-"+volumeID) {
+					t.Errorf("unexpected attach result for volume %q: %+v", volumeID, result)
+				}
+				if !attach && result.batchErr == nil {
+					t.Errorf("expected batch error for the detach of volume %q", volumeID)
+				}
+			}(attach)
+		}
+	}
+	wg.Wait()
+
+	if len(volumeManager.batches) != 2 {
+		t.Fatalf("expected an attach and a detach batch, got %v", volumeManager.batches)
+	}
+	for _, batch := range volumeManager.batches {
+		if len(batch) != 3 {
+			t.Errorf("expected 3 volumes in batch, got %v", batch)
+		}
+	}
+}
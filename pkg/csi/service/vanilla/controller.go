@@ -35,7 +35,9 @@ import (
 	"github.com/vmware/govmomi/units"
 	"github.com/vmware/govmomi/vim25/types"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/migration"
@@ -69,6 +71,10 @@ type NodeManagerInterface interface {
 }
 
 type controller struct {
+	csi.UnimplementedControllerServer
+	csi.UnimplementedGroupControllerServer
+	csi.UnimplementedSnapshotMetadataServer
+
 	// Deprecated
 	// To be removed after multi vCenter support is added
 	manager  *common.Manager
@@ -79,6 +85,9 @@ type controller struct {
 	authMgr     common.AuthorizationService
 	authMgrs    map[string]*common.AuthManager
 	topologyMgr commoncotypes.ControllerTopologyService
+	// attachDetachBatcher coalesces the attach and detach requests of the
+	// same node VM.
+	attachDetachBatcher *attachDetachBatcher
 }
 
 var (
@@ -136,6 +145,8 @@ func (c *controller) Init(config *cnsconfig.Config, version string) error {
 		common.CnsMgrSuspendCreateVolume)
 	isTopologyAwareFileVolumeEnabled = commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx,
 		common.TopologyAwareFileVolume)
+	c.attachDetachBatcher = newAttachDetachBatcher(
+		time.Duration(config.Global.AttachDetachBatchWindowInMsec) * time.Millisecond)
 
 	vcManager := cnsvsphere.GetVirtualCenterManager(ctx)
 	if !multivCenterCSITopologyEnabled {
@@ -481,6 +492,43 @@ func (c *controller) ReloadConfiguration() error {
 	return nil
 }
 
+// getSharedDatastoresInVC returns the datastores in the given vCenter which are
+// accessible from the given topology segments, or from all the nodes in the
+// cluster if there are no topology segments, and which the authorization
+// service allows provisioning volumes on.
+func (c *controller) getSharedDatastoresInVC(ctx context.Context, vcenter *cnsvsphere.VirtualCenter,
+	vcHost string, topologySegmentsList []map[string]string, storagePolicyName string) (
+	[]*cnsvsphere.DatastoreInfo, error) {
+	var (
+		sharedDatastores []*cnsvsphere.DatastoreInfo
+		err              error
+	)
+	if len(topologySegmentsList) == 0 {
+		sharedDatastores, err = c.nodeMgr.GetSharedDatastoresInK8SCluster(ctx)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var storagePolicyID string
+		if storagePolicyName != "" {
+			storagePolicyID, err = vcenter.GetStoragePolicyIDByName(ctx, storagePolicyName)
+			if err != nil {
+				return nil, err
+			}
+		}
+		sharedDatastores, err = placementengine.GetSharedDatastores(ctx,
+			placementengine.VanillaSharedDatastoresParams{
+				Vcenter:              vcenter,
+				TopologySegmentsList: topologySegmentsList,
+				StoragePolicyID:      storagePolicyID,
+			})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.filterDatastores(ctx, sharedDatastores, vcHost)
+}
+
 func (c *controller) filterDatastores(ctx context.Context, sharedDatastores []*cnsvsphere.DatastoreInfo,
 	vcHost string) ([]*cnsvsphere.DatastoreInfo, error) {
 	log := logger.GetLogger(ctx)
@@ -523,12 +571,21 @@ func (c *controller) createBlockVolume(ctx context.Context, req *csi.CreateVolum
 
 	// Check if the feature states are enabled.
 	isBlockVolumeSnapshotEnabled := commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.BlockVolumeSnapshot)
+	isBlockVolumeCloneEnabled := commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.BlockVolumeClone)
 	csiMigrationFeatureState := commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.CSIMigration)
 
 	// Check if requested volume size and source snapshot size matches
 	volumeSource := req.GetVolumeContentSource()
-	var contentSourceSnapshotID string
-	if isBlockVolumeSnapshotEnabled && volumeSource != nil {
+	var contentSourceSnapshotID, contentSourceVolumeID string
+	if isBlockVolumeCloneEnabled && volumeSource.GetVolume() != nil {
+		// Check if requested volume size is not less than the source volume size.
+		contentSourceVolumeID = volumeSource.GetVolume().GetVolumeId()
+		_, faultType, err := common.ValidateCloneSourceVolume(ctx, c.manager.VolumeManager,
+			contentSourceVolumeID, volSizeBytes)
+		if err != nil {
+			return nil, faultType, err
+		}
+	} else if isBlockVolumeSnapshotEnabled && volumeSource != nil {
 		isCnsSnapshotSupported, err := c.manager.VcenterManager.IsCnsSnapshotSupported(ctx,
 			c.manager.VcenterConfig.Host)
 		if err != nil {
@@ -580,6 +637,15 @@ func (c *controller) createBlockVolume(ctx context.Context, req *csi.CreateVolum
 		return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
 			"parsing storage class parameters failed with error: %+v", err)
 	}
+	if err := validateNodeStageParams(ctx, scParams, req.GetVolumeCapabilities()); err != nil {
+		return nil, csifault.CSIInvalidArgumentFault, err
+	}
+	if err := validateDiskFormatParam(ctx, scParams, req.GetVolumeContentSource()); err != nil {
+		return nil, csifault.CSIInvalidArgumentFault, err
+	}
+	if err := validateIOAllocationParams(ctx, scParams); err != nil {
+		return nil, csifault.CSIInvalidArgumentFault, err
+	}
 
 	if csiMigrationFeatureState && scParams.CSIMigration == "true" {
 		if len(scParams.Datastore) != 0 {
@@ -629,12 +695,18 @@ func (c *controller) createBlockVolume(ctx context.Context, req *csi.CreateVolum
 		}
 	}
 
+	// A volume restored from a snapshot is relocated to the requested datastores
+	// when the datastore of the snapshot is not one of them.
+	relocateRestoredVolume := contentSourceSnapshotID != "" &&
+		commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.SnapshotRestoreRelocation)
 	var createVolumeSpec = common.CreateVolumeSpec{
 		CapacityMB:              volSizeMB,
 		Name:                    req.Name,
 		ScParams:                scParams,
 		VolumeType:              common.BlockVolumeType,
 		ContentSourceSnapshotID: contentSourceSnapshotID,
+		ContentSourceVolumeID:   contentSourceVolumeID,
+		AllowRestoreRelocation:  relocateRestoredVolume,
 	}
 
 	// Check if vCenter task for this volume is already registered as part of
@@ -726,7 +798,9 @@ func (c *controller) createBlockVolume(ctx context.Context, req *csi.CreateVolum
 	)
 	// Get accessibility.
 	topologyRequirement = req.GetAccessibilityRequirements()
-	if !volTaskAlreadyRegistered {
+	// The shared datastores are also needed to finish relocating a volume
+	// restored by an earlier request.
+	if !volTaskAlreadyRegistered || relocateRestoredVolume {
 		if topologyRequirement != nil {
 			// Check if topology domains have been provided in the vSphere CSI config secret.
 			// NOTE: We do not support kubernetes.io/hostname as a topology label.
@@ -779,16 +853,36 @@ func (c *controller) createBlockVolume(ctx context.Context, req *csi.CreateVolum
 				"failed to create volume. Error: %+v", err)
 		}
 
-		volumeInfo, faultType, err = common.CreateBlockVolumeUtil(ctx, cnstypes.CnsClusterFlavorVanilla,
-			c.manager, &createVolumeSpec, sharedDatastores, filterSuspendedDatastores, false, false, nil)
+		if !volTaskAlreadyRegistered {
+			volumeInfo, faultType, err = common.CreateBlockVolumeUtil(ctx, cnstypes.CnsClusterFlavorVanilla,
+				c.manager, &createVolumeSpec, sharedDatastores, filterSuspendedDatastores, false, false, nil)
+			if err != nil {
+				return nil, faultType, logger.LogNewErrorCodef(log, codes.Internal,
+					"failed to create volume. Error: %+v", err)
+			}
+		}
+	}
+	if relocateRestoredVolume {
+		volumeInfo.DatastoreURL, faultType, err = common.RelocateRestoredVolumeUtil(ctx, vcenter,
+			c.manager.VolumeManager, req.Name, volumeInfo.VolumeID.Id, scParams, sharedDatastores)
 		if err != nil {
-			return nil, faultType, logger.LogNewErrorCodef(log, codes.Internal,
-				"failed to create volume. Error: %+v", err)
+			// The details of a monitored CreateVolume task must not be stored
+			// again for a volume deleted after failing to be relocated.
+			volumeOperationDetails = nil
+			return nil, faultType, err
 		}
 	}
 
 	attributes := make(map[string]string)
 	attributes[common.AttributeDiskType] = common.DiskTypeBlockVolume
+	setNodeStageAttributes(attributes, scParams)
+	if scParams.DiskFormat != "" {
+		attributes[common.AttributeDiskFormat] = scParams.DiskFormat
+	}
+	if faultType, err := setIOAllocationParams(ctx, volumeInfo.VolumeID.Id, scParams,
+		attributes); err != nil {
+		return nil, faultType, err
+	}
 	if csiMigrationFeatureState && scParams.CSIMigration == "true" {
 		// In case if feature state switch is enabled after controller is
 		// deployed, we need to initialize the volumeMigrationService.
@@ -886,6 +980,16 @@ func (c *controller) createBlockVolume(ctx context.Context, req *csi.CreateVolum
 			},
 		}
 	}
+	// Set the Volume VolumeContentSource in the CreateVolumeResponse
+	if contentSourceVolumeID != "" {
+		resp.Volume.ContentSource = &csi.VolumeContentSource{
+			Type: &csi.VolumeContentSource_Volume{
+				Volume: &csi.VolumeContentSource_VolumeSource{
+					VolumeId: contentSourceVolumeID,
+				},
+			},
+		}
+	}
 	return resp, "", nil
 }
 
@@ -955,6 +1059,15 @@ func (c *controller) createBlockVolumeWithPlacementEngineForMultiVC(ctx context.
 		return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
 			"parsing storage class parameters failed with error: %+v", err)
 	}
+	if err := validateNodeStageParams(ctx, scParams, req.GetVolumeCapabilities()); err != nil {
+		return nil, csifault.CSIInvalidArgumentFault, err
+	}
+	if err := validateDiskFormatParam(ctx, scParams, req.GetVolumeContentSource()); err != nil {
+		return nil, csifault.CSIInvalidArgumentFault, err
+	}
+	if err := validateIOAllocationParams(ctx, scParams); err != nil {
+		return nil, csifault.CSIInvalidArgumentFault, err
+	}
 
 	if scParams.CSIMigration == "true" {
 		if len(c.managers.VcenterConfigs) > 1 {
@@ -1011,8 +1124,27 @@ func (c *controller) createBlockVolumeWithPlacementEngineForMultiVC(ctx context.
 
 	// Check if requested volume size and source snapshot size matches.
 	volumeSource := req.GetVolumeContentSource()
-	var contentSourceSnapshotID, snapshotDatastoreURL string
-	if volumeSource != nil {
+	var contentSourceSnapshotID, snapshotDatastoreURL, contentSourceVolumeID, sourceVolumeVCHost string
+	var relocateRestoredVolume bool
+	if volumeSource.GetVolume() != nil &&
+		commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.BlockVolumeClone) {
+		contentSourceVolumeID = volumeSource.GetVolume().GetVolumeId()
+		// Get VC, volumeManager for given volumeID. The clone is always created
+		// in the same vCenter as the source volume.
+		vCenterHost, volumeManager, err := getVCenterAndVolumeManagerForVolumeID(ctx, c, contentSourceVolumeID,
+			volumeInfoService)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to get vCenter/volume manager for volumeID: %q. Error: %+v", contentSourceVolumeID, err)
+		}
+		// Check if requested volume size is not less than the source volume size.
+		_, faultType, err := common.ValidateCloneSourceVolume(ctx, volumeManager, contentSourceVolumeID,
+			volSizeBytes)
+		if err != nil {
+			return nil, faultType, err
+		}
+		sourceVolumeVCHost = vCenterHost
+	} else if volumeSource != nil {
 		sourceSnapshot := volumeSource.GetSnapshot()
 		if sourceSnapshot == nil {
 			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCode(log, codes.InvalidArgument,
@@ -1061,9 +1193,13 @@ func (c *controller) createBlockVolumeWithPlacementEngineForMultiVC(ctx context.
 		}
 		// Store the datastoreURL of snapshot for future use.
 		snapshotDatastoreURL = cnsVolumeDetailsMap[cnsVolumeID].DatastoreUrl
+		// A volume restored from a snapshot is relocated to the requested datastores
+		// when the datastore of the snapshot is not one of them.
+		relocateRestoredVolume = commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx,
+			common.SnapshotRestoreRelocation)
 		// If DatastoreURL parameter is given in StorageClass, check if
 		// snapshot datastore URL is same as DatastoreURL.
-		if scParams.DatastoreURL != "" {
+		if scParams.DatastoreURL != "" && !relocateRestoredVolume {
 			if strings.TrimSpace(snapshotDatastoreURL) != strings.TrimSpace(scParams.DatastoreURL) {
 				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
 					"datastore URL %q given in storage class does not match the snapshot datastore URL %q.",
@@ -1078,6 +1214,8 @@ func (c *controller) createBlockVolumeWithPlacementEngineForMultiVC(ctx context.
 		ScParams:                scParams,
 		VolumeType:              common.BlockVolumeType,
 		ContentSourceSnapshotID: contentSourceSnapshotID,
+		ContentSourceVolumeID:   contentSourceVolumeID,
+		AllowRestoreRelocation:  relocateRestoredVolume,
 	}
 	// Check if vCenter task for this volume is already registered as part of
 	// improved idempotency CR.
@@ -1248,6 +1386,13 @@ func (c *controller) createBlockVolumeWithPlacementEngineForMultiVC(ctx context.
 		if topologyRequirement != nil {
 			var topologySegmentsList []map[string]string
 			for vcHost, topologySegmentsList = range vcTopologySegmentsMap {
+				if sourceVolumeVCHost != "" && vcHost != sourceVolumeVCHost {
+					errMsg := fmt.Sprintf("source volume %q does not belong to vCenter %q",
+						contentSourceVolumeID, vcHost)
+					log.Warn(errMsg)
+					combinedErrMssgs = append(combinedErrMssgs, errMsg)
+					continue
+				}
 				// Get VC instance.
 				vcenter, err = common.GetVCenterFromVCHost(ctx, c.managers.VcenterManager, vcHost)
 				if err != nil {
@@ -1344,6 +1489,10 @@ func (c *controller) createBlockVolumeWithPlacementEngineForMultiVC(ctx context.
 		} else {
 			// Get VC instance.
 			vcHost = c.managers.CnsConfig.Global.VCenterIP
+			if sourceVolumeVCHost != "" && vcHost != sourceVolumeVCHost {
+				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+					"source volume %q does not belong to vCenter %q", contentSourceVolumeID, vcHost)
+			}
 			vcenter, err = common.GetVCenterFromVCHost(ctx, c.managers.VcenterManager, vcHost)
 			if err != nil {
 				return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
@@ -1410,9 +1559,42 @@ func (c *controller) createBlockVolumeWithPlacementEngineForMultiVC(ctx context.
 		return nil, faultType, logger.LogNewErrorCodef(log, codes.Internal,
 			"failed to create volume. Errors encountered: %+v", combinedErrMssgs)
 	}
+	if relocateRestoredVolume {
+		if volumeMgr == nil {
+			volumeMgr, err = GetVolumeManagerFromVCHost(ctx, c.managers, vcHost)
+			if err != nil {
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorCode(log, codes.Internal, err.Error())
+			}
+		}
+		if sharedDatastores == nil {
+			// The volume was restored by an earlier request.
+			sharedDatastores, err = c.getSharedDatastoresInVC(ctx, vcenter, vcHost,
+				vcTopologySegmentsMap[vcHost], scParams.StoragePolicyName)
+			if err != nil {
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+					"failed to get shared datastores in vCenter %q. Error: %+v", vcHost, err)
+			}
+		}
+		volumeInfo.DatastoreURL, faultType, err = common.RelocateRestoredVolumeUtil(ctx, vcenter, volumeMgr,
+			req.Name, volumeInfo.VolumeID.Id, scParams, sharedDatastores)
+		if err != nil {
+			// The details of a monitored CreateVolume task must not be stored
+			// again for a volume deleted after failing to be relocated.
+			volumeOperationDetails = nil
+			return nil, faultType, err
+		}
+	}
 
 	attributes := make(map[string]string)
 	attributes[common.AttributeDiskType] = common.DiskTypeBlockVolume
+	setNodeStageAttributes(attributes, scParams)
+	if scParams.DiskFormat != "" {
+		attributes[common.AttributeDiskFormat] = scParams.DiskFormat
+	}
+	if faultType, err := setIOAllocationParams(ctx, volumeInfo.VolumeID.Id, scParams,
+		attributes); err != nil {
+		return nil, faultType, err
+	}
 
 	if scParams.CSIMigration == "true" {
 		volumePath, err := volumeMigrationService.GetVolumePath(ctx, volumeInfo.VolumeID.Id)
@@ -1509,6 +1691,16 @@ func (c *controller) createBlockVolumeWithPlacementEngineForMultiVC(ctx context.
 			},
 		}
 	}
+	// Set the Volume VolumeContentSource in the CreateVolumeResponse
+	if contentSourceVolumeID != "" {
+		resp.Volume.ContentSource = &csi.VolumeContentSource{
+			Type: &csi.VolumeContentSource_Volume{
+				Volume: &csi.VolumeContentSource_VolumeSource{
+					VolumeId: contentSourceVolumeID,
+				},
+			},
+		}
+	}
 	if len(c.managers.VcenterConfigs) > 1 {
 		// Create CNSVolumeInfo CR for the volume ID.
 		err = volumeInfoService.CreateVolumeInfo(ctx, volumeInfo.VolumeID.Id, vcHost)
@@ -1591,6 +1783,27 @@ func (c *controller) createFileVolume(ctx context.Context, req *csi.CreateVolume
 		return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
 			"parsing storage class parameters failed with error: %+v", err)
 	}
+	if scParams.Encrypted {
+		return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"parameter %q is not supported for file volumes", common.AttributeEncrypted)
+	}
+	if scParams.FsckOnStage {
+		return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"parameter %q is not supported for file volumes", common.AttributeFsckOnStage)
+	}
+	if scParams.MkfsOptions != "" {
+		return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"parameter %q is not supported for file volumes", common.AttributeMkfsOptions)
+	}
+	if scParams.DiskFormat != "" {
+		return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"parameter %q is not supported for file volumes", common.AttributeDiskFormat)
+	}
+	if scParams.IopsLimit != "" || scParams.IopsShares != "" {
+		return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"parameters %q and %q are not supported for file volumes", common.AttributeIopsLimit,
+			common.AttributeIopsShares)
+	}
 
 	var (
 		volTaskAlreadyRegistered bool
@@ -1954,7 +2167,7 @@ func (c *controller) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequ
 	volumeType := prometheus.PrometheusUnknownVolumeType
 	createVolumeInternal := func() (
 		*csi.CreateVolumeResponse, string, error) {
-		log.Infof("CreateVolume: called with args %+v", *req)
+		log.Infof("CreateVolume: called with args %+v", req)
 		// TODO: If the err is returned by invoking CNS API, then faultType should be
 		// populated by the underlying layer.
 		// If the request failed due to validate the request, "csi.fault.InvalidArgument" will be return.
@@ -1966,6 +2179,9 @@ func (c *controller) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequ
 			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
 				"volume capability not supported. Err: %+v", err)
 		}
+		if err := validateFileSnapshotRestore(ctx, req); err != nil {
+			return nil, csifault.CSIUnimplementedFault, err
+		}
 		if common.IsFileVolumeRequest(ctx, volumeCapabilities) {
 			// Error out if TopologyRequirement is provided during file volume provisioning
 			// as this is not supported yet.
@@ -2047,7 +2263,7 @@ func (c *controller) DeleteVolume(ctx context.Context, req *csi.DeleteVolumeRequ
 
 	deleteVolumeInternal := func() (
 		*csi.DeleteVolumeResponse, string, error) {
-		log.Infof("DeleteVolume: called with args: %+v", *req)
+		log.Infof("DeleteVolume: called with args: %+v", req)
 		// TODO: If the err is returned by invoking CNS API, then faultType should be
 		// populated by the underlying layer.
 		// If the request failed due to validate the request, "csi.fault.InvalidArgument" will be return.
@@ -2190,7 +2406,7 @@ func (c *controller) ControllerPublishVolume(ctx context.Context, req *csi.Contr
 
 	controllerPublishVolumeInternal := func() (
 		*csi.ControllerPublishVolumeResponse, string, error) {
-		log.Infof("ControllerPublishVolume: called with args %+v", *req)
+		log.Infof("ControllerPublishVolume: called with args %+v", req)
 		// TODO: If the err is returned by invoking CNS API, then faultType should be
 		// populated by the underlying layer.
 		// If the request failed due to validate the request, "csi.fault.InvalidArgument" will be return.
@@ -2201,7 +2417,7 @@ func (c *controller) ControllerPublishVolume(ctx context.Context, req *csi.Contr
 		if err != nil {
 
 			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.Internal,
-				"validation for PublishVolume Request: %+v has failed. Error: %v", *req, err)
+				"validation for PublishVolume Request: %+v has failed. Error: %v", req, err)
 		}
 		publishInfo := make(map[string]string)
 		_, volumeManager, err := getVCenterAndVolumeManagerForVolumeID(ctx, c, req.VolumeId, volumeInfoService)
@@ -2293,12 +2509,29 @@ func (c *controller) ControllerPublishVolume(ctx context.Context, req *csi.Contr
 			}
 			log.Debugf("Found VirtualMachine for node:%q.", req.NodeId)
 			// faultType is returned from manager.AttachVolume.
-			diskUUID, faultType, err := common.AttachVolumeUtil(ctx, volumeManager, nodevm, req.VolumeId,
-				false)
+			diskUUID, faultType, err := c.attachVolume(ctx, volumeManager, nodevm, req.VolumeId)
 			if err != nil {
 				return nil, faultType, logger.LogNewErrorCodef(log, codes.Internal,
 					"failed to attach disk: %+q with node: %q err %+v", req.VolumeId, req.NodeId, err)
 			}
+			if commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.IopsAllocation) {
+				// Storage I/O Control settings belong to the virtual disk device,
+				// apply them again every time the volume is attached.
+				ioAllocation, err := common.ParseStorageIOAllocation(req.VolumeContext[common.AttributeIopsLimit],
+					req.VolumeContext[common.AttributeIopsShares])
+				if err != nil {
+					return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+						"invalid IO allocation for volume %q. Error: %v", req.VolumeId, err)
+				}
+				if ioAllocation != nil {
+					faultType, err = volumeManager.ApplyVolumeIOAllocation(ctx, nodevm, req.VolumeId, ioAllocation)
+					if err != nil {
+						return nil, faultType, logger.LogNewErrorCodef(log, codes.Internal,
+							"failed to apply IO allocation for volume %q on node %q. Error: %v",
+							req.VolumeId, req.NodeId, err)
+					}
+				}
+			}
 			publishInfo[common.AttributeDiskType] = common.DiskTypeBlockVolume
 			publishInfo[common.AttributeFirstClassDiskUUID] = common.FormatDiskUUID(diskUUID)
 		}
@@ -2338,7 +2571,7 @@ func (c *controller) ControllerUnpublishVolume(ctx context.Context, req *csi.Con
 	controllerUnpublishVolumeInternal := func() (
 		*csi.ControllerUnpublishVolumeResponse, string, error) {
 		var faultType string
-		log.Infof("ControllerUnpublishVolume: called with args %+v", *req)
+		log.Infof("ControllerUnpublishVolume: called with args %+v", req)
 		// TODO: If the err is returned by invoking CNS API, then faultType should be
 		// populated by the underlying layer.
 		// If the request failed due to validate the request, "csi.fault.InvalidArgument" will be return.
@@ -2348,7 +2581,7 @@ func (c *controller) ControllerUnpublishVolume(ctx context.Context, req *csi.Con
 		err := validateVanillaControllerUnpublishVolumeRequest(ctx, req)
 		if err != nil {
 			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.Internal,
-				"validation for UnpublishVolume Request: %+v has failed. Error: %v", *req, err)
+				"validation for UnpublishVolume Request: %+v has failed. Error: %v", req, err)
 		}
 
 		_, volumeManager, err := getVCenterAndVolumeManagerForVolumeID(ctx, c, req.VolumeId, volumeInfoService)
@@ -2436,7 +2669,7 @@ func (c *controller) ControllerUnpublishVolume(ctx context.Context, req *csi.Con
 					"failed to find VirtualMachine for node:%q. Error: %v", req.NodeId, err)
 			}
 		}
-		faultType, err = common.DetachVolumeUtil(ctx, volumeManager, nodevm, req.VolumeId)
+		faultType, err = c.detachVolume(ctx, volumeManager, nodevm, req.VolumeId)
 		if err != nil {
 			return nil, faultType, logger.LogNewErrorCodef(log, codes.Internal,
 				"failed to detach disk: %+q from node: %q err %+v", req.VolumeId, req.NodeId, err)
@@ -2481,7 +2714,7 @@ func (c *controller) ControllerExpandVolume(ctx context.Context, req *csi.Contro
 			faultType      string
 		)
 
-		log.Infof("ControllerExpandVolume: called with args %+v", *req)
+		log.Infof("ControllerExpandVolume: called with args %+v", req)
 		// TODO: If the err is returned by invoking CNS API, then faultType should be
 		// populated by the underlying layer.
 		// If the request failed due to validate the request, "csi.fault.InvalidArgument" will be return.
@@ -2518,7 +2751,7 @@ func (c *controller) ControllerExpandVolume(ctx context.Context, req *csi.Contro
 		isOnlineExpansionEnabled := commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.OnlineVolumeExtend)
 		err = validateVanillaControllerExpandVolumeRequest(ctx, req, isOnlineExpansionEnabled, isOnlineExpansionSupported)
 		if err != nil {
-			msg := fmt.Sprintf("validation for ExpandVolume Request: %+v has failed. Error: %v", *req, err)
+			msg := fmt.Sprintf("validation for ExpandVolume Request: %+v has failed. Error: %v", req, err)
 			log.Error(msg)
 			return nil, csifault.CSIInternalFault, err
 		}
@@ -2603,7 +2836,7 @@ func (c *controller) ValidateVolumeCapabilities(ctx context.Context, req *csi.Va
 	*csi.ValidateVolumeCapabilitiesResponse, error) {
 	ctx = logger.NewContextWithLogger(ctx)
 	log := logger.GetLogger(ctx)
-	log.Infof("ControllerGetCapabilities: called with args %+v", *req)
+	log.Infof("ControllerGetCapabilities: called with args %+v", req)
 	volCaps := req.GetVolumeCapabilities()
 	var confirmed *csi.ValidateVolumeCapabilitiesResponse_Confirmed
 	if err := common.IsValidVolumeCapabilities(ctx, volCaps); err == nil {
@@ -2635,7 +2868,7 @@ func (c *controller) ListVolumes(ctx context.Context, req *csi.ListVolumesReques
 	}
 
 	listVolumesInternal := func() (*csi.ListVolumesResponse, string, error) {
-		log.Debugf("ListVolumes: called with args %+v", *req)
+		log.Debugf("ListVolumes: called with args %+v", req)
 
 		startingToken := 0
 		if req.StartingToken != "" {
@@ -2863,12 +3096,178 @@ func (c *controller) processQueryResultsListVolumes(ctx context.Context, startin
 	return entries, nextToken, volumeType, nil
 }
 
+// GetCapacity returns the largest usable free space among the shared datastores
+// compatible with the StorageClass parameters and the requested topology segment.
 func (c *controller) GetCapacity(ctx context.Context, req *csi.GetCapacityRequest) (
 	*csi.GetCapacityResponse, error) {
+	start := time.Now()
 	ctx = logger.NewContextWithLogger(ctx)
 	log := logger.GetLogger(ctx)
-	log.Infof("GetCapacity: called with args %+v", *req)
-	return nil, logger.LogNewErrorCode(log, codes.Unimplemented, "getCapacity")
+	volumeType := prometheus.PrometheusBlockVolumeType
+	if !commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.GetCapacity) {
+		return nil, logger.LogNewErrorCode(log, codes.Unimplemented, "getCapacity")
+	}
+
+	getCapacityInternal := func() (*csi.GetCapacityResponse, string, error) {
+		log.Infof("GetCapacity: called with args %+v", req)
+		volumeCapabilities := req.GetVolumeCapabilities()
+		if len(volumeCapabilities) != 0 {
+			if err := common.IsValidVolumeCapabilities(ctx, volumeCapabilities); err != nil {
+				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+					"volume capability not supported. Err: %+v", err)
+			}
+			if common.IsFileVolumeRequest(ctx, volumeCapabilities) {
+				volumeType = prometheus.PrometheusFileVolumeType
+				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCode(log, codes.InvalidArgument,
+					"GetCapacity is not supported for file volumes")
+			}
+		}
+		scParams, err := common.ParseStorageClassParams(ctx, getProvisioningParams(req.GetParameters()),
+			csiMigrationEnabled)
+		if err != nil {
+			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"parsing storage class parameters failed with error: %+v", err)
+		}
+
+		// Group the requested topology segment by the vCenter it belongs to.
+		var (
+			vcTopologySegmentsMap = make(map[string][]map[string]string)
+			defaultVCHost         string
+			cnsConfig             *cnsconfig.Config
+		)
+		if multivCenterCSITopologyEnabled {
+			defaultVCHost = c.managers.CnsConfig.Global.VCenterIP
+			cnsConfig = c.managers.CnsConfig
+		} else {
+			defaultVCHost = c.manager.VcenterConfig.Host
+			cnsConfig = c.manager.CnsConfig
+		}
+		accessibleTopology := req.GetAccessibleTopology()
+		if accessibleTopology != nil && len(accessibleTopology.GetSegments()) != 0 {
+			if cnsConfig.Labels.TopologyCategories == "" && cnsConfig.Labels.Zone == "" &&
+				cnsConfig.Labels.Region == "" {
+				return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCode(log, codes.InvalidArgument,
+					"topology category names not specified in the vsphere config secret")
+			}
+			if multivCenterCSITopologyEnabled && len(c.managers.VcenterConfigs) > 1 {
+				vcTopologySegmentsMap, err = common.GetAccessibilityRequirementsByVC(ctx,
+					&csi.TopologyRequirement{Preferred: []*csi.Topology{accessibleTopology}})
+				if err != nil {
+					return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+						"failed to get accessibility requirements by VC. Error: %+v", err)
+				}
+			} else {
+				vcTopologySegmentsMap[defaultVCHost] = []map[string]string{accessibleTopology.GetSegments()}
+			}
+		} else {
+			vcTopologySegmentsMap[defaultVCHost] = nil
+		}
+
+		var maxUsableFreeSpace int64
+		for vcHost, topologySegmentsList := range vcTopologySegmentsMap {
+			freeSpace, err := c.getMaxUsableFreeSpaceInVC(ctx, vcHost, topologySegmentsList, scParams)
+			if err != nil {
+				return nil, csifault.CSIInternalFault, err
+			}
+			if freeSpace > maxUsableFreeSpace {
+				maxUsableFreeSpace = freeSpace
+			}
+		}
+		log.Infof("GetCapacity: largest usable free space for parameters %+v and topology %+v is %d bytes",
+			req.GetParameters(), accessibleTopology, maxUsableFreeSpace)
+		return &csi.GetCapacityResponse{
+			AvailableCapacity: maxUsableFreeSpace,
+			MaximumVolumeSize: wrapperspb.Int64(maxUsableFreeSpace),
+		}, "", nil
+	}
+	resp, faultType, err := getCapacityInternal()
+	if err != nil {
+		if csifault.IsNonStorageFault(faultType) {
+			faultType = csifault.AddCsiNonStoragePrefix(ctx, faultType)
+		}
+		log.Errorf("Operation failed, reporting failure status to Prometheus."+
+			" Operation Type: %q, Volume Type: %q, Fault Type: %q",
+			prometheus.PrometheusGetCapacityOpType, volumeType, faultType)
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusGetCapacityOpType,
+			prometheus.PrometheusFailStatus, faultType).Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusGetCapacityOpType,
+			prometheus.PrometheusPassStatus, faultType).Observe(time.Since(start).Seconds())
+	}
+	return resp, err
+}
+
+// getMaxUsableFreeSpaceInVC finds the shared datastores in the given vCenter which are
+// accessible from the topology segments and compatible with the StorageClass parameters,
+// and returns the largest usable free space among them. When topologySegmentsList is empty,
+// datastores shared across all the nodes in the cluster are considered.
+func (c *controller) getMaxUsableFreeSpaceInVC(ctx context.Context, vcHost string,
+	topologySegmentsList []map[string]string, scParams *common.StorageClassParams) (int64, error) {
+	log := logger.GetLogger(ctx)
+	var (
+		vcenter          *cnsvsphere.VirtualCenter
+		sharedDatastores []*cnsvsphere.DatastoreInfo
+		storagePolicyID  string
+		err              error
+	)
+	vcenter, err = common.GetVCenterFromVCHost(ctx, getVCenterManagerForVCenter(ctx, c), vcHost)
+	if err != nil {
+		return 0, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to get vCenter instance for host %q. Error: %+v", vcHost, err)
+	}
+	if scParams.StoragePolicyName != "" {
+		storagePolicyID, err = vcenter.GetStoragePolicyIDByName(ctx, scParams.StoragePolicyName)
+		if err != nil {
+			return 0, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to get policy ID for storage policy name %q in vCenter %q. Error: %+v",
+				scParams.StoragePolicyName, vcHost, err)
+		}
+	}
+	if len(topologySegmentsList) != 0 {
+		// Policy compatibility is checked by the placement engine for every topology segment.
+		sharedDatastores, err = placementengine.GetSharedDatastores(ctx,
+			placementengine.VanillaSharedDatastoresParams{
+				Vcenter:              vcenter,
+				TopologySegmentsList: topologySegmentsList,
+				StoragePolicyID:      storagePolicyID,
+			})
+		if err != nil {
+			return 0, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to get shared datastores for topology segments %+v in vCenter %q. Error: %+v",
+				topologySegmentsList, vcHost, err)
+		}
+	} else {
+		sharedDatastores, err = c.nodeMgr.GetSharedDatastoresInK8SCluster(ctx)
+		if err != nil {
+			return 0, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to get shared datastores in kubernetes cluster. Error: %+v", err)
+		}
+		if storagePolicyID != "" {
+			sharedDatastores, err = common.GetStoragePolicyCompatibleDatastores(ctx, vcenter, storagePolicyID,
+				sharedDatastores)
+			if err != nil {
+				return 0, logger.LogNewErrorCodef(log, codes.Internal,
+					"failed to get datastores compatible with storage policy ID %q in vCenter %q. Error: %+v",
+					storagePolicyID, vcHost, err)
+			}
+		}
+	}
+	if len(sharedDatastores) == 0 {
+		log.Infof("No compatible shared datastores found in vCenter %q for topology segments %+v",
+			vcHost, topologySegmentsList)
+		return 0, nil
+	}
+	// Filter datastores based on user access.
+	sharedDatastores, err = c.filterDatastores(ctx, sharedDatastores, vcHost)
+	if err != nil {
+		if err == errAllDSFilteredOut {
+			log.Infof("authorization service filtered out all the compatible datastores in vCenter %q", vcHost)
+			return 0, nil
+		}
+		return 0, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to filter datastores based on authorisation check in vCenter %q. Error: %+v", vcHost, err)
+	}
+	return getMaxUsableFreeSpace(ctx, sharedDatastores, scParams.DatastoreURL), nil
 }
 
 // initVolumeMigrationService is a helper method to initialize
@@ -2907,11 +3306,68 @@ func initVolumeMigrationService(ctx context.Context, c *controller) error {
 	return nil
 }
 
+// getMigratedVolumeID returns the ID of the FCD registered for the VMDK path
+// of a migrated in-tree vSphere volume. The VMDK is registered as an FCD if
+// it is not yet and registerIfNotFound is set. Otherwise an error with code
+// NotFound is returned for a VMDK which is not registered.
+func getMigratedVolumeID(ctx context.Context, c *controller, volumePath string,
+	registerIfNotFound bool) (string, error) {
+	log := logger.GetLogger(ctx)
+	if !commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.CSIMigration) {
+		// Migration feature switch is disabled.
+		return "", logger.LogNewErrorCodef(log, codes.Internal,
+			"volume-migration feature switch is disabled. Cannot use volume with vmdk path :%q", volumePath)
+	}
+	// In case if feature state switch is enabled after controller is
+	// deployed, we need to initialize the volumeMigrationService.
+	if err := initVolumeMigrationService(ctx, c); err != nil {
+		// Error is already wrapped in CSI error code.
+		return "", err
+	}
+	volumeID, err := volumeMigrationService.GetVolumeID(ctx,
+		&migration.VolumeSpec{VolumePath: volumePath}, registerIfNotFound)
+	if errors.Is(err, migration.ErrVolumeIDNotFound) {
+		return "", logger.LogNewErrorCodef(log, codes.NotFound,
+			"VMDK path %q is not registered as a volume", volumePath)
+	}
+	if err != nil {
+		return "", logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to get VolumeID from volumeMigrationService for volumePath: %q", volumePath)
+	}
+	return volumeID, nil
+}
+
+// setMigratedSourceVolumeIDs replaces the source volume ID of the given
+// snapshots with the VMDK path for the snapshots of migrated in-tree vSphere
+// volumes, so that they match the volume handle of the PV.
+func setMigratedSourceVolumeIDs(ctx context.Context, snapshots []*csi.Snapshot) {
+	log := logger.GetLogger(ctx)
+	// volumeMigrationService is not initialized when CSI migration is disabled
+	// or when there is more than 1 vCenter.
+	if volumeMigrationService == nil {
+		return
+	}
+	for _, snapshot := range snapshots {
+		volumePath, err := volumeMigrationService.GetVolumePathFromMigrationServiceCache(ctx,
+			snapshot.SourceVolumeId)
+		if err != nil {
+			if err != common.ErrNotFound {
+				log.Warnf("failed to look up volumeID %q in migration service cache. Error: %v",
+					snapshot.SourceVolumeId, err)
+			}
+			continue
+		}
+		if volumePath != "" {
+			snapshot.SourceVolumeId = volumePath
+		}
+	}
+}
+
 func (c *controller) ControllerGetCapabilities(ctx context.Context, req *csi.ControllerGetCapabilitiesRequest) (
 	*csi.ControllerGetCapabilitiesResponse, error) {
 	ctx = logger.NewContextWithLogger(ctx)
 	log := logger.GetLogger(ctx)
-	log.Infof("ControllerGetCapabilities: called with args %+v", *req)
+	log.Infof("ControllerGetCapabilities: called with args %+v", req)
 
 	controllerCaps := []csi.ControllerServiceCapability_RPC_Type{
 		csi.ControllerServiceCapability_RPC_CREATE_DELETE_VOLUME,
@@ -2925,6 +3381,19 @@ func (c *controller) ControllerGetCapabilities(ctx context.Context, req *csi.Con
 		controllerCaps = append(controllerCaps, csi.ControllerServiceCapability_RPC_LIST_VOLUMES,
 			csi.ControllerServiceCapability_RPC_LIST_VOLUMES_PUBLISHED_NODES)
 	}
+	if commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.GetCapacity) {
+		controllerCaps = append(controllerCaps, csi.ControllerServiceCapability_RPC_GET_CAPACITY)
+	}
+	if commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.BlockVolumeClone) {
+		controllerCaps = append(controllerCaps, csi.ControllerServiceCapability_RPC_CLONE_VOLUME)
+	}
+	if commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.ControllerGetVolume) {
+		controllerCaps = append(controllerCaps, csi.ControllerServiceCapability_RPC_GET_VOLUME,
+			csi.ControllerServiceCapability_RPC_VOLUME_CONDITION)
+	}
+	if commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.ControllerModifyVolume) {
+		controllerCaps = append(controllerCaps, csi.ControllerServiceCapability_RPC_MODIFY_VOLUME)
+	}
 	var caps []*csi.ControllerServiceCapability
 	for _, cap := range controllerCaps {
 		c := &csi.ControllerServiceCapability{
@@ -2944,22 +3413,30 @@ func (c *controller) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshot
 	ctx = logger.NewContextWithLogger(ctx)
 	log := logger.GetLogger(ctx)
 	var (
-		vCenterHost                              string
-		vCenterManager                           cnsvsphere.VirtualCenterManager
-		volumeManager                            cnsvolume.Manager
-		err                                      error
-		maxSnapshotsPerBlockVolume               int
-		granularMaxSnapshotsPerBlockVolumeInVSAN int
-		granularMaxSnapshotsPerBlockVolumeInVVOL int
+		vCenterHost    string
+		vCenterManager cnsvsphere.VirtualCenterManager
+		volumeManager  cnsvolume.Manager
+		err            error
 	)
-	log.Infof("CreateSnapshot: called with args %+v", *req)
+	log.Infof("CreateSnapshot: called with args %+v", req)
 
 	isBlockVolumeSnapshotEnabled := commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.BlockVolumeSnapshot)
 	if !isBlockVolumeSnapshotEnabled {
 		return nil, logger.LogNewErrorCode(log, codes.Unimplemented, "createSnapshot")
 	}
+	if common.IsFileVolumeID(req.GetSourceVolumeId()) {
+		return c.createFileVolumeSnapshot(ctx, req)
+	}
 
 	volumeID := req.GetSourceVolumeId()
+	if strings.Contains(volumeID, ".vmdk") {
+		// The source volume is a migrated in-tree vSphere volume. Snapshot the
+		// FCD registered for its VMDK path.
+		volumeID, err = getMigratedVolumeID(ctx, c, volumeID, true)
+		if err != nil {
+			return nil, err
+		}
+	}
 	// Fetch vCenterHost, vCenterManager & volumeManager for given snapshot, based on VC configuration
 	vCenterManager = getVCenterManagerForVCenter(ctx, c)
 	vCenterHost, volumeManager, err = getVCenterAndVolumeManagerForVolumeID(ctx, c, volumeID, volumeInfoService)
@@ -2982,13 +3459,7 @@ func (c *controller) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshot
 		// Validate CreateSnapshotRequest
 		if err := validateVanillaCreateSnapshotRequestRequest(ctx, req); err != nil {
 			return nil, logger.LogNewErrorCodef(log, codes.Internal,
-				"validation for CreateSnapshot Request: %+v has failed. Error: %v", *req, err)
-		}
-
-		// Check if the source volume is migrated vSphere volume
-		if strings.Contains(volumeID, ".vmdk") {
-			return nil, logger.LogNewErrorCodef(log, codes.Unimplemented,
-				"cannot snapshot migrated vSphere volume. :%q", volumeID)
+				"validation for CreateSnapshot Request: %+v has failed. Error: %v", req, err)
 		}
 		volumeType = prometheus.PrometheusBlockVolumeType
 		// Query capacity in MB and datastore url for block volume snapshot
@@ -3008,55 +3479,8 @@ func (c *controller) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshot
 				"queried volume doesn't have the expected volume type. Expected VolumeType: %v. "+
 					"Queried VolumeType: %v", volumeType, cnsVolumeDetailsMap[volumeID].VolumeType)
 		}
-		// Check if snapshots number of this volume reaches the granular limit on VSAN/VVOL
-		if multivCenterCSITopologyEnabled {
-			maxSnapshotsPerBlockVolume = c.managers.CnsConfig.Snapshot.GlobalMaxSnapshotsPerBlockVolume
-			granularMaxSnapshotsPerBlockVolumeInVSAN =
-				c.managers.CnsConfig.Snapshot.GranularMaxSnapshotsPerBlockVolumeInVSAN
-			granularMaxSnapshotsPerBlockVolumeInVVOL =
-				c.managers.CnsConfig.Snapshot.GranularMaxSnapshotsPerBlockVolumeInVVOL
-		} else {
-			maxSnapshotsPerBlockVolume = c.manager.CnsConfig.Snapshot.GlobalMaxSnapshotsPerBlockVolume
-			granularMaxSnapshotsPerBlockVolumeInVSAN =
-				c.manager.CnsConfig.Snapshot.GranularMaxSnapshotsPerBlockVolumeInVSAN
-			granularMaxSnapshotsPerBlockVolumeInVVOL =
-				c.manager.CnsConfig.Snapshot.GranularMaxSnapshotsPerBlockVolumeInVVOL
-		}
-		log.Infof("The limit of the maximum number of snapshots per block volume is "+
-			"set to the global maximum (%v) by default.", maxSnapshotsPerBlockVolume)
-
-		if granularMaxSnapshotsPerBlockVolumeInVSAN > 0 || granularMaxSnapshotsPerBlockVolumeInVVOL > 0 {
-			var isGranularMaxEnabled bool
-			if strings.Contains(datastoreUrl, strings.ToLower(string(types.HostFileSystemVolumeFileSystemTypeVsan))) {
-				if granularMaxSnapshotsPerBlockVolumeInVSAN > 0 {
-					maxSnapshotsPerBlockVolume = granularMaxSnapshotsPerBlockVolumeInVSAN
-					isGranularMaxEnabled = true
-				}
-			} else if strings.Contains(datastoreUrl, strings.ToLower(string(types.HostFileSystemVolumeFileSystemTypeVVOL))) {
-				if granularMaxSnapshotsPerBlockVolumeInVVOL > 0 {
-					maxSnapshotsPerBlockVolume = granularMaxSnapshotsPerBlockVolumeInVVOL
-					isGranularMaxEnabled = true
-				}
-			}
-
-			if isGranularMaxEnabled {
-				log.Infof("The limit of the maximum number of snapshots per block volume on datastore %q is "+
-					"overridden by the granular maximum (%v).", datastoreUrl, maxSnapshotsPerBlockVolume)
-			}
-		}
-
-		// Check if snapshots number of this volume reaches the limit
-		snapshotList, _, err := common.QueryVolumeSnapshotsByVolumeID(ctx, volumeManager, volumeID,
-			common.QuerySnapshotLimit)
-		if err != nil {
-			return nil, logger.LogNewErrorCodef(log, codes.Internal,
-				"failed to query snapshots of volume %s for the limit check. Error: %v", volumeID, err)
-		}
-
-		if len(snapshotList) >= maxSnapshotsPerBlockVolume {
-			return nil, logger.LogNewErrorCodef(log, codes.FailedPrecondition,
-				"the number of snapshots on the source volume %s reaches the configured maximum (%v)",
-				volumeID, maxSnapshotsPerBlockVolume)
+		if err := c.checkSnapshotLimit(ctx, volumeManager, volumeID, datastoreUrl); err != nil {
+			return nil, err
 		}
 
 		// the returned snapshotID below is a combination of CNS VolumeID and CNS SnapshotID concatenated by the "+"
@@ -3074,7 +3498,7 @@ func (c *controller) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshot
 			Snapshot: &csi.Snapshot{
 				SizeBytes:      snapshotSizeInMB * common.MbInBytes,
 				SnapshotId:     snapshotID,
-				SourceVolumeId: volumeID,
+				SourceVolumeId: req.GetSourceVolumeId(),
 				CreationTime:   snapshotCreateTimeInProto,
 				ReadyToUse:     true,
 			},
@@ -3103,6 +3527,69 @@ func (c *controller) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshot
 	return resp, err
 }
 
+// checkSnapshotLimit returns an error if the number of snapshots of the block
+// volume on the datastore reaches the configured maximum.
+func (c *controller) checkSnapshotLimit(ctx context.Context, volumeManager cnsvolume.Manager,
+	volumeID string, datastoreUrl string) error {
+	log := logger.GetLogger(ctx)
+	var (
+		maxSnapshotsPerBlockVolume               int
+		granularMaxSnapshotsPerBlockVolumeInVSAN int
+		granularMaxSnapshotsPerBlockVolumeInVVOL int
+	)
+	// Check if snapshots number of this volume reaches the granular limit on VSAN/VVOL
+	if multivCenterCSITopologyEnabled {
+		maxSnapshotsPerBlockVolume = c.managers.CnsConfig.Snapshot.GlobalMaxSnapshotsPerBlockVolume
+		granularMaxSnapshotsPerBlockVolumeInVSAN =
+			c.managers.CnsConfig.Snapshot.GranularMaxSnapshotsPerBlockVolumeInVSAN
+		granularMaxSnapshotsPerBlockVolumeInVVOL =
+			c.managers.CnsConfig.Snapshot.GranularMaxSnapshotsPerBlockVolumeInVVOL
+	} else {
+		maxSnapshotsPerBlockVolume = c.manager.CnsConfig.Snapshot.GlobalMaxSnapshotsPerBlockVolume
+		granularMaxSnapshotsPerBlockVolumeInVSAN =
+			c.manager.CnsConfig.Snapshot.GranularMaxSnapshotsPerBlockVolumeInVSAN
+		granularMaxSnapshotsPerBlockVolumeInVVOL =
+			c.manager.CnsConfig.Snapshot.GranularMaxSnapshotsPerBlockVolumeInVVOL
+	}
+	log.Infof("The limit of the maximum number of snapshots per block volume is "+
+		"set to the global maximum (%v) by default.", maxSnapshotsPerBlockVolume)
+
+	if granularMaxSnapshotsPerBlockVolumeInVSAN > 0 || granularMaxSnapshotsPerBlockVolumeInVVOL > 0 {
+		var isGranularMaxEnabled bool
+		if strings.Contains(datastoreUrl, strings.ToLower(string(types.HostFileSystemVolumeFileSystemTypeVsan))) {
+			if granularMaxSnapshotsPerBlockVolumeInVSAN > 0 {
+				maxSnapshotsPerBlockVolume = granularMaxSnapshotsPerBlockVolumeInVSAN
+				isGranularMaxEnabled = true
+			}
+		} else if strings.Contains(datastoreUrl, strings.ToLower(string(types.HostFileSystemVolumeFileSystemTypeVVOL))) {
+			if granularMaxSnapshotsPerBlockVolumeInVVOL > 0 {
+				maxSnapshotsPerBlockVolume = granularMaxSnapshotsPerBlockVolumeInVVOL
+				isGranularMaxEnabled = true
+			}
+		}
+
+		if isGranularMaxEnabled {
+			log.Infof("The limit of the maximum number of snapshots per block volume on datastore %q is "+
+				"overridden by the granular maximum (%v).", datastoreUrl, maxSnapshotsPerBlockVolume)
+		}
+	}
+
+	// Check if snapshots number of this volume reaches the limit
+	snapshotList, _, err := common.QueryVolumeSnapshotsByVolumeID(ctx, volumeManager, volumeID,
+		common.QuerySnapshotLimit)
+	if err != nil {
+		return logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to query snapshots of volume %s for the limit check. Error: %v", volumeID, err)
+	}
+
+	if len(snapshotList) >= maxSnapshotsPerBlockVolume {
+		return logger.LogNewErrorCodef(log, codes.FailedPrecondition,
+			"the number of snapshots on the source volume %s reaches the configured maximum (%v)",
+			volumeID, maxSnapshotsPerBlockVolume)
+	}
+	return nil
+}
+
 func (c *controller) DeleteSnapshot(ctx context.Context, req *csi.DeleteSnapshotRequest) (
 	*csi.DeleteSnapshotResponse, error) {
 	ctx = logger.NewContextWithLogger(ctx)
@@ -3113,13 +3600,16 @@ func (c *controller) DeleteSnapshot(ctx context.Context, req *csi.DeleteSnapshot
 		volumeManager  cnsvolume.Manager
 		err            error
 	)
-	log.Infof("DeleteSnapshot: called with args %+v", *req)
+	log.Infof("DeleteSnapshot: called with args %+v", req)
 
 	isBlockVolumeSnapshotEnabled :=
 		commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.BlockVolumeSnapshot)
 	if !isBlockVolumeSnapshotEnabled {
 		return nil, logger.LogNewErrorCode(log, codes.Unimplemented, "deleteSnapshot")
 	}
+	if common.IsFileVolumeSnapshotID(req.SnapshotId) {
+		return c.deleteFileVolumeSnapshot(ctx, req)
+	}
 
 	volumeID, _, err := common.ParseCSISnapshotID(req.SnapshotId)
 	if err != nil {
@@ -3189,15 +3679,35 @@ func (c *controller) ListSnapshots(ctx context.Context, req *csi.ListSnapshotsRe
 			nextToken      string
 			err            error
 		)
-		log.Infof("ListSnapshots: called with args %+v", *req)
+		log.Infof("ListSnapshots: called with args %+v", req)
 		err = validateVanillaListSnapshotRequest(ctx, req)
 		if err != nil {
 			return nil, err
 		}
+		if common.IsFileVolumeSnapshotID(req.SnapshotId) ||
+			(req.SnapshotId == "" && common.IsFileVolumeID(req.SourceVolumeId)) {
+			volumeType = prometheus.PrometheusFileVolumeType
+			return c.listFileVolumeSnapshots(ctx, req)
+		}
 		maxEntries := common.QuerySnapshotLimit
 		if req.MaxEntries != 0 {
 			maxEntries = int64(req.MaxEntries)
 		}
+		sourceVolumeID := req.SourceVolumeId
+		if strings.Contains(sourceVolumeID, ".vmdk") {
+			// The source volume is a migrated in-tree vSphere volume. List the
+			// snapshots of the FCD registered for its VMDK path.
+			sourceVolumeID, err = getMigratedVolumeID(ctx, c, sourceVolumeID, false)
+			if status.Code(err) == codes.NotFound {
+				// The VMDK was never registered as an FCD, so it has no snapshots.
+				log.Infof("ListSnapshots: no snapshots of migrated volume %q as it is not registered",
+					req.SourceVolumeId)
+				return &csi.ListSnapshotsResponse{}, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
 		// Fetch vCenterHost, vCenterManager & volumeManager for given snapshot, based on input given and
 		// query snapshot records from respective VC
 		if req.SnapshotId != "" {
@@ -3225,18 +3735,18 @@ func (c *controller) ListSnapshots(ctx context.Context, req *csi.ListSnapshotsRe
 				return nil, logger.LogNewErrorCodef(log, codes.Unimplemented,
 					"VC %s version does not support snapshot operations", vCenterHost)
 			}
-			snapshots, nextToken, err = common.ListSnapshotsUtil(ctx, volManager, req.SourceVolumeId,
+			snapshots, nextToken, err = common.ListSnapshotsUtil(ctx, volManager, sourceVolumeID,
 				req.SnapshotId, req.StartingToken, maxEntries)
 			if err != nil {
 				return nil, logger.LogNewErrorCodef(log, codes.Internal, " failed to retrieve the snapshots, err: %+v", err)
 			}
-		} else if req.SourceVolumeId != "" {
+		} else if sourceVolumeID != "" {
 			// Fetch vCenterHost & volumeManager for source volume, based on VC configuration
 			vCenterManager = getVCenterManagerForVCenter(ctx, c)
-			vCenterHost, volManager, err = getVCenterAndVolumeManagerForVolumeID(ctx, c, req.SourceVolumeId, volumeInfoService)
+			vCenterHost, volManager, err = getVCenterAndVolumeManagerForVolumeID(ctx, c, sourceVolumeID, volumeInfoService)
 			if err != nil {
 				return nil, logger.LogNewErrorCodef(log, codes.Internal,
-					"failed to get vCenter/volume manager for volume Id: %q in VC %s. Error: %v", req.SourceVolumeId, vCenterHost, err)
+					"failed to get vCenter/volume manager for volume Id: %q in VC %s. Error: %v", sourceVolumeID, vCenterHost, err)
 			}
 			// Check for snapshot support
 			isCnsSnapshotSupported, err := vCenterManager.IsCnsSnapshotSupported(ctx, vCenterHost)
@@ -3248,7 +3758,7 @@ func (c *controller) ListSnapshots(ctx context.Context, req *csi.ListSnapshotsRe
 				return nil, logger.LogNewErrorCodef(log, codes.Unimplemented,
 					"VC %s version does not support snapshot operations", vCenterHost)
 			}
-			snapshots, nextToken, err = common.ListSnapshotsUtil(ctx, volManager, req.SourceVolumeId,
+			snapshots, nextToken, err = common.ListSnapshotsUtil(ctx, volManager, sourceVolumeID,
 				req.SnapshotId, req.StartingToken, maxEntries)
 			if err != nil {
 				return nil, logger.LogNewErrorCodef(log, codes.Internal, " failed to retrieve the snapshots, err: %+v", err)
@@ -3259,6 +3769,7 @@ func (c *controller) ListSnapshots(ctx context.Context, req *csi.ListSnapshotsRe
 				return nil, logger.LogNewErrorCodef(log, codes.Internal, " failed to retrieve the snapshots, err: %+v", err)
 			}
 		}
+		setMigratedSourceVolumeIDs(ctx, snapshots)
 		var entries []*csi.ListSnapshotsResponse_Entry
 		for _, snapshot := range snapshots {
 			entry := &csi.ListSnapshotsResponse_Entry{
@@ -3460,18 +3971,252 @@ func processQueryResultsListSnapshots(ctx context.Context, startingToken int,
 	return snapEntries, nextToken, nil
 }
 
+// ControllerGetVolume returns the capacity, accessible topology, published nodes
+// and the condition of the volume as reported by CNS.
 func (c *controller) ControllerGetVolume(ctx context.Context, req *csi.ControllerGetVolumeRequest) (
 	*csi.ControllerGetVolumeResponse, error) {
+	start := time.Now()
 	ctx = logger.NewContextWithLogger(ctx)
 	log := logger.GetLogger(ctx)
-	log.Infof("ControllerGetVolume: called with args %+v", *req)
-	return nil, logger.LogNewErrorCode(log, codes.Unimplemented, "controllerGetVolume")
+	log.Infof("ControllerGetVolume: called with args %+v", req)
+	volumeType := prometheus.PrometheusUnknownVolumeType
+	if !commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.ControllerGetVolume) {
+		return nil, logger.LogNewErrorCode(log, codes.Unimplemented, "controllerGetVolume")
+	}
+
+	controllerGetVolumeInternal := func() (*csi.ControllerGetVolumeResponse, string, error) {
+		var (
+			vCenterHost   string
+			volumeManager cnsvolume.Manager
+			err           error
+		)
+		if req.VolumeId == "" {
+			return nil, csifault.CSIInvalidArgumentFault, logger.LogNewErrorCode(log, codes.InvalidArgument,
+				"volume ID must be provided")
+		}
+		volumeID := req.VolumeId
+		if strings.Contains(req.VolumeId, ".vmdk") {
+			volumeType = prometheus.PrometheusBlockVolumeType
+			// In-tree volume support.
+			if !commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.CSIMigration) {
+				// Migration feature switch is disabled.
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+					"volume-migration feature switch is disabled. Cannot use volume with vmdk path :%q", req.VolumeId)
+			}
+			if err := initVolumeMigrationService(ctx, c); err != nil {
+				// Error is already wrapped in CSI error code.
+				return nil, csifault.CSIInternalFault, err
+			}
+			volumeID, err = volumeMigrationService.GetVolumeID(ctx,
+				&migration.VolumeSpec{VolumePath: req.VolumeId}, false)
+			if err != nil {
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+					"failed to get VolumeID from volumeMigrationService for volumePath: %q", req.VolumeId)
+			}
+		}
+		// Fetch vCenterHost & volumeManager for given volume, based on VC configuration
+		vCenterHost, volumeManager, err = getVCenterAndVolumeManagerForVolumeID(ctx, c, volumeID, volumeInfoService)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to get vCenter/volume manager for volume Id: %q. Error: %v", volumeID, err)
+		}
+
+		queryFilter := cnstypes.CnsQueryFilter{
+			VolumeIds: []cnstypes.CnsVolumeId{{Id: volumeID}},
+		}
+		querySelection := cnstypes.CnsQuerySelection{
+			Names: []string{
+				string(cnstypes.QuerySelectionNameTypeVolumeType),
+				string(cnstypes.QuerySelectionNameTypeBackingObjectDetails),
+				string(cnstypes.QuerySelectionNameTypeDataStoreUrl),
+				string(cnstypes.QuerySelectionNameTypeHealthStatus),
+			},
+		}
+		queryResult, err := utils.QueryVolumeUtil(ctx, volumeManager, queryFilter, &querySelection, true)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"queryVolumeUtil failed for volumeID: %s, err: %+v", volumeID, err)
+		}
+		if len(queryResult.Volumes) == 0 {
+			return nil, csifault.CSINotFoundFault, logger.LogNewErrorCodef(log, codes.NotFound,
+				"volume %q not found", volumeID)
+		}
+		cnsVolume := queryResult.Volumes[0]
+
+		var capacityInMb int64
+		if backingDetails, ok := cnsVolume.BackingObjectDetails.(cnstypes.BaseCnsBackingObjectDetails); ok {
+			capacityInMb = backingDetails.GetCnsBackingObjectDetails().CapacityInMb
+		}
+		resp := &csi.ControllerGetVolumeResponse{
+			Volume: &csi.Volume{
+				VolumeId:      req.VolumeId,
+				CapacityBytes: capacityInMb * common.MbInBytes,
+			},
+			Status: &csi.ControllerGetVolumeResponse_VolumeStatus{},
+		}
+
+		// Get all nodes from the vanilla K8s cluster from the node manager
+		allNodeVMs, err := c.nodeMgr.GetAllNodes(ctx)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to get nodes(node vms) in the vanilla cluster. Error: %v", err)
+		}
+
+		if cnsVolume.VolumeType == common.FileVolumeType {
+			volumeType = prometheus.PrometheusFileVolumeType
+			// Getting published nodes
+			publishedNodeIds := commonco.ContainerOrchestratorUtility.GetNodesForVolumes(ctx, []string{volumeID})
+			for _, nodeName := range publishedNodeIds[volumeID] {
+				nodeVMObj, err := c.nodeMgr.GetNodeVMByNameAndUpdateCache(ctx, nodeName)
+				if err != nil {
+					return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+						"failed to get node vm object from the node name %q, err: %v", nodeName, err)
+				}
+				resp.Status.PublishedNodeIds = append(resp.Status.PublishedNodeIds, nodeVMObj.UUID)
+			}
+		} else {
+			volumeType = prometheus.PrometheusBlockVolumeType
+			volumeIDToNodeUUIDMap, err := getBlockVolumeIDToNodeUUIDMap(ctx, c, allNodeVMs)
+			if err != nil {
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+					"get block volumeIDToNodeUUIDMap failed with err = %+v ", err)
+			}
+			if nodeUUID, ok := volumeIDToNodeUUIDMap[volumeID]; ok {
+				resp.Status.PublishedNodeIds = []string{nodeUUID}
+			}
+
+			// Populate the accessible topology only when topology domains are configured.
+			var cnsConfig *cnsconfig.Config
+			if multivCenterCSITopologyEnabled {
+				cnsConfig = c.managers.CnsConfig
+			} else {
+				cnsConfig = c.manager.CnsConfig
+			}
+			if cnsVolume.DatastoreUrl != "" && (cnsConfig.Labels.TopologyCategories != "" ||
+				cnsConfig.Labels.Zone != "" || cnsConfig.Labels.Region != "") {
+				vcenter, err := common.GetVCenterFromVCHost(ctx, getVCenterManagerForVCenter(ctx, c), vCenterHost)
+				if err != nil {
+					return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+						"failed to get vCenter instance for host %q. Error: %+v", vCenterHost, err)
+				}
+				datastoreAccessibleTopology, err := c.getAccessibleTopologiesForDatastore(ctx, vcenter, nil,
+					allNodeVMs, cnsVolume.DatastoreUrl)
+				if err != nil {
+					return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+						"failed to calculate accessible topologies for the datastore %q", cnsVolume.DatastoreUrl)
+				}
+				for _, topoSegments := range datastoreAccessibleTopology {
+					resp.Volume.AccessibleTopology = append(resp.Volume.AccessibleTopology,
+						&csi.Topology{Segments: topoSegments})
+				}
+			}
+		}
+		resp.Status.VolumeCondition = common.GetVolumeCondition(ctx, volumeID, cnsVolume.HealthStatus)
+		return resp, "", nil
+	}
+
+	resp, faultType, err := controllerGetVolumeInternal()
+	if err != nil {
+		if csifault.IsNonStorageFault(faultType) {
+			faultType = csifault.AddCsiNonStoragePrefix(ctx, faultType)
+		}
+		log.Errorf("Operation failed, reporting failure status to Prometheus."+
+			" Operation Type: %q, Volume Type: %q, Fault Type: %q",
+			prometheus.PrometheusControllerGetVolumeOpType, volumeType, faultType)
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusControllerGetVolumeOpType,
+			prometheus.PrometheusFailStatus, faultType).Observe(time.Since(start).Seconds())
+	} else {
+		log.Debugf("ControllerGetVolume response: %+v", resp)
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusControllerGetVolumeOpType,
+			prometheus.PrometheusPassStatus, faultType).Observe(time.Since(start).Seconds())
+	}
+	return resp, err
 }
 
+// ControllerModifyVolume changes the storage policy of a block volume as
+// requested through the mutable parameters of a VolumeAttributesClass.
 func (c *controller) ControllerModifyVolume(ctx context.Context, req *csi.ControllerModifyVolumeRequest) (
 	*csi.ControllerModifyVolumeResponse, error) {
+	start := time.Now()
 	ctx = logger.NewContextWithLogger(ctx)
 	log := logger.GetLogger(ctx)
-	log.Infof("ControllerModifyVolume: called with args %+v", *req)
-	return nil, logger.LogNewErrorCode(log, codes.Unimplemented, "ControllerModifyVolume")
+	log.Infof("ControllerModifyVolume: called with args %+v", req)
+	volumeType := prometheus.PrometheusBlockVolumeType
+	if !commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.ControllerModifyVolume) {
+		return nil, logger.LogNewErrorCode(log, codes.Unimplemented, "ControllerModifyVolume")
+	}
+
+	controllerModifyVolumeInternal := func() (*csi.ControllerModifyVolumeResponse, string, error) {
+		var (
+			vCenterHost   string
+			volumeManager cnsvolume.Manager
+			allNodeVMs    []*cnsvsphere.VirtualMachine
+		)
+		storagePolicyName, err := validateVanillaControllerModifyVolumeRequest(ctx, req)
+		if err != nil {
+			return nil, csifault.CSIInvalidArgumentFault, err
+		}
+		volumeID := req.VolumeId
+		if strings.Contains(req.VolumeId, ".vmdk") {
+			// In-tree volume support.
+			if !commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.CSIMigration) {
+				// Migration feature switch is disabled.
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+					"volume-migration feature switch is disabled. Cannot use volume with vmdk path :%q", req.VolumeId)
+			}
+			if err := initVolumeMigrationService(ctx, c); err != nil {
+				// Error is already wrapped in CSI error code.
+				return nil, csifault.CSIInternalFault, err
+			}
+			volumeID, err = volumeMigrationService.GetVolumeID(ctx,
+				&migration.VolumeSpec{VolumePath: req.VolumeId}, false)
+			if err != nil {
+				return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+					"failed to get VolumeID from volumeMigrationService for volumePath: %q", req.VolumeId)
+			}
+		}
+		// Fetch vCenterHost & volumeManager for given volume, based on VC configuration
+		vCenterHost, volumeManager, err = getVCenterAndVolumeManagerForVolumeID(ctx, c, volumeID, volumeInfoService)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to get vCenter/volume manager for volume Id: %q. Error: %v", volumeID, err)
+		}
+		vcenter, err := common.GetVCenterFromVCHost(ctx, getVCenterManagerForVCenter(ctx, c), vCenterHost)
+		if err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to get vCenter instance for host %q. Error: %+v", vCenterHost, err)
+		}
+		if multivCenterCSITopologyEnabled {
+			allNodeVMs, err = c.nodeMgr.GetAllNodesByVC(ctx, vCenterHost)
+		} else {
+			allNodeVMs, err = c.nodeMgr.GetAllNodes(ctx)
+		}
+		if err != nil {
+			return nil, csifault.CSIInternalFault, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to find VirtualMachines for the registered nodes in the cluster. Error: %v", err)
+		}
+		faultType, err := common.ModifyVolumeStoragePolicyUtil(ctx, vcenter, volumeManager, volumeID,
+			storagePolicyName, allNodeVMs)
+		if err != nil {
+			return nil, faultType, err
+		}
+		return &csi.ControllerModifyVolumeResponse{}, "", nil
+	}
+
+	resp, faultType, err := controllerModifyVolumeInternal()
+	if err != nil {
+		if csifault.IsNonStorageFault(faultType) {
+			faultType = csifault.AddCsiNonStoragePrefix(ctx, faultType)
+		}
+		log.Errorf("Operation failed, reporting failure status to Prometheus."+
+			" Operation Type: %q, Volume Type: %q, Fault Type: %q",
+			prometheus.PrometheusControllerModifyVolumeOpType, volumeType, faultType)
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusControllerModifyVolumeOpType,
+			prometheus.PrometheusFailStatus, faultType).Observe(time.Since(start).Seconds())
+	} else {
+		log.Infof("Volume %q modified successfully", req.VolumeId)
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusControllerModifyVolumeOpType,
+			prometheus.PrometheusPassStatus, faultType).Observe(time.Since(start).Seconds())
+	}
+	return resp, err
 }
@@ -33,8 +33,10 @@ import (
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/node"
 	cnsvolume "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	csifault "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/fault"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/prometheus"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsvolumeinfo"
 )
@@ -89,6 +91,32 @@ func validateVanillaControllerExpandVolumeRequest(ctx context.Context,
 	return common.IsOnlineExpansion(ctx, req.GetVolumeId(), nodes)
 }
 
+// validateVanillaControllerModifyVolumeRequest is the helper function to
+// validate ControllerModifyVolumeRequest for Vanilla CSI driver.
+// Function returns the storage policy name to apply to the volume if
+// validation succeeds, otherwise returns error.
+func validateVanillaControllerModifyVolumeRequest(ctx context.Context,
+	req *csi.ControllerModifyVolumeRequest) (string, error) {
+	log := logger.GetLogger(ctx)
+	if len(req.GetVolumeId()) == 0 {
+		return "", logger.LogNewErrorCode(log, codes.InvalidArgument,
+			"ControllerModifyVolume Volume ID must be provided")
+	}
+	var storagePolicyName string
+	for param, value := range req.GetMutableParameters() {
+		if strings.ToLower(param) != common.AttributeStoragePolicyName {
+			return "", logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"ControllerModifyVolume mutable parameter %q is not supported", param)
+		}
+		storagePolicyName = value
+	}
+	if storagePolicyName == "" {
+		return "", logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"ControllerModifyVolume mutable parameter %q must be provided", common.AttributeStoragePolicyName)
+	}
+	return storagePolicyName, nil
+}
+
 // validateVanillaCreateSnapshotRequestRequest is the helper function to
 // validate CreateSnapshotRequest for Vanilla CSI driver.
 // Function returns error if validation fails otherwise returns nil.
@@ -124,8 +152,9 @@ func validateVanillaListSnapshotRequest(ctx context.Context, req *csi.ListSnapsh
 	}
 	// validate snapshot-id conforms to vSphere CSI driver format if specified.
 	if req.SnapshotId != "" {
-		// check for the delimiter "+" in the snapshot-id.
-		check := strings.Contains(req.SnapshotId, common.VSphereCSISnapshotIdDelimiter)
+		// check for the delimiter "+" in the snapshot-id, or the file share snapshot format.
+		check := strings.Contains(req.SnapshotId, common.VSphereCSISnapshotIdDelimiter) ||
+			common.IsFileVolumeSnapshotID(req.SnapshotId)
 		if !check {
 			return logger.LogNewErrorCodef(log, codes.InvalidArgument,
 				"ListSnapshots SnapshotId: %s is incorrectly formatted for vSphere CSI driver",
@@ -295,3 +324,145 @@ func GetVolumeManagerFromVCHost(ctx context.Context, managers *common.Managers,
 	}
 	return volumeMgr, nil
 }
+
+// getProvisioningParams returns the StorageClass parameters without the
+// "csi.storage.k8s.io/" prefixed keys. The external-provisioner strips these
+// keys before invoking CreateVolume, but passes them as is to GetCapacity.
+func getProvisioningParams(params map[string]string) map[string]string {
+	provisioningParams := make(map[string]string)
+	for param, value := range params {
+		if strings.HasPrefix(strings.ToLower(param), common.CSIParameterPrefix) {
+			continue
+		}
+		provisioningParams[param] = value
+	}
+	return provisioningParams
+}
+
+// getMaxUsableFreeSpace returns the largest free space, in bytes, in which a single
+// volume can be provisioned among the given datastores. Free space of a datastore is
+// capped by the maximum virtual disk capacity it supports. If datastoreURL is set,
+// only the datastore with the matching URL is considered.
+func getMaxUsableFreeSpace(ctx context.Context, datastores []*vsphere.DatastoreInfo,
+	datastoreURL string) int64 {
+	log := logger.GetLogger(ctx)
+	var maxUsableFreeSpace int64
+	for _, ds := range datastores {
+		if ds.Info == nil {
+			continue
+		}
+		if datastoreURL != "" && strings.TrimSpace(ds.Info.Url) != strings.TrimSpace(datastoreURL) {
+			continue
+		}
+		usableFreeSpace := ds.Info.FreeSpace
+		if ds.Info.MaxVirtualDiskCapacity > 0 && ds.Info.MaxVirtualDiskCapacity < usableFreeSpace {
+			usableFreeSpace = ds.Info.MaxVirtualDiskCapacity
+		}
+		log.Debugf("Datastore %q has usable free space of %d bytes", ds.Info.Url, usableFreeSpace)
+		if usableFreeSpace > maxUsableFreeSpace {
+			maxUsableFreeSpace = usableFreeSpace
+		}
+	}
+	return maxUsableFreeSpace
+}
+
+// validateNodeStageParams validates the StorageClass parameters of a block
+// volume which are consumed by the node plugin while staging the volume.
+func validateNodeStageParams(ctx context.Context, scParams *common.StorageClassParams,
+	volCaps []*csi.VolumeCapability) error {
+	log := logger.GetLogger(ctx)
+	if scParams.Encrypted &&
+		!commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.NodeVolumeEncryption) {
+		return logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"parameter %q is not supported", common.AttributeEncrypted)
+	}
+	if scParams.FsckOnStage && !commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.FsckOnStage) {
+		return logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"parameter %q is not supported", common.AttributeFsckOnStage)
+	}
+	if scParams.MkfsOptions != "" {
+		if !commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.MkfsOptions) {
+			return logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"parameter %q is not supported", common.AttributeMkfsOptions)
+		}
+		for _, volCap := range volCaps {
+			if volCap.GetMount() == nil {
+				continue
+			}
+			if _, err := common.ParseMkfsOptions(volCap.GetMount().GetFsType(), scParams.MkfsOptions); err != nil {
+				return logger.LogNewErrorCodef(log, codes.InvalidArgument,
+					"invalid parameter %q. Error: %v", common.AttributeMkfsOptions, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateDiskFormatParam validates the diskformat StorageClass parameter of a
+// block volume. The provisioning type can only be chosen for new empty volumes,
+// volumes created from a snapshot or a clone inherit it from their source.
+func validateDiskFormatParam(ctx context.Context, scParams *common.StorageClassParams,
+	contentSource *csi.VolumeContentSource) error {
+	log := logger.GetLogger(ctx)
+	if scParams.DiskFormat == "" {
+		return nil
+	}
+	if !commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.DiskFormat) {
+		return logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"parameter %q is not supported", common.AttributeDiskFormat)
+	}
+	if contentSource != nil {
+		return logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"parameter %q is not supported for volumes created from a snapshot or a volume",
+			common.AttributeDiskFormat)
+	}
+	return nil
+}
+
+// validateIOAllocationParams validates the iopslimit and iopsshares
+// StorageClass parameters of a block volume.
+func validateIOAllocationParams(ctx context.Context, scParams *common.StorageClassParams) error {
+	log := logger.GetLogger(ctx)
+	if scParams.IopsLimit == "" && scParams.IopsShares == "" {
+		return nil
+	}
+	if !commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.IopsAllocation) {
+		return logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"parameters %q and %q are not supported", common.AttributeIopsLimit, common.AttributeIopsShares)
+	}
+	return nil
+}
+
+// setIOAllocationParams adds the iopslimit and iopsshares StorageClass
+// parameters of a new block volume to the volume attributes. A virtual disk
+// only has Storage I/O Control settings while it is attached to a VM, so
+// ControllerPublishVolume applies them from the volume context on every attach.
+func setIOAllocationParams(ctx context.Context, volumeID string, scParams *common.StorageClassParams,
+	attributes map[string]string) (string, error) {
+	log := logger.GetLogger(ctx)
+	if _, err := common.ParseStorageIOAllocation(scParams.IopsLimit, scParams.IopsShares); err != nil {
+		return csifault.CSIInvalidArgumentFault, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"invalid IO allocation parameters for volume %q. Error: %v", volumeID, err)
+	}
+	if scParams.IopsLimit != "" {
+		attributes[common.AttributeIopsLimit] = scParams.IopsLimit
+	}
+	if scParams.IopsShares != "" {
+		attributes[common.AttributeIopsShares] = scParams.IopsShares
+	}
+	return "", nil
+}
+
+// setNodeStageAttributes adds the StorageClass parameters consumed by the
+// node plugin while staging a block volume to the volume attributes.
+func setNodeStageAttributes(attributes map[string]string, scParams *common.StorageClassParams) {
+	if scParams.Encrypted {
+		attributes[common.AttributeEncrypted] = "true"
+	}
+	if scParams.FsckOnStage {
+		attributes[common.AttributeFsckOnStage] = "true"
+	}
+	if scParams.MkfsOptions != "" {
+		attributes[common.AttributeMkfsOptions] = scParams.MkfsOptions
+	}
+}
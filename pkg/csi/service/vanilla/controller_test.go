@@ -41,6 +41,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/migration"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/node"
 	cnsvolume "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
 	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
@@ -691,6 +692,59 @@ func TestCreateVolumeWithNoSharedDatastores(t *testing.T) {
 	}
 }
 
+func TestGetCapacity(t *testing.T) {
+	ct := getControllerTest(t)
+
+	capabilities := []*csi.VolumeCapability{
+		{
+			AccessMode: &csi.VolumeCapability_AccessMode{
+				Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+			},
+		},
+	}
+	// CSI prefixed parameters are passed as is by the external-provisioner and
+	// should be ignored.
+	params := map[string]string{
+		"csi.storage.k8s.io/fstype": "ext4",
+	}
+	resp, err := ct.controller.GetCapacity(ctx, &csi.GetCapacityRequest{
+		VolumeCapabilities: capabilities,
+		Parameters:         params,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.AvailableCapacity <= 0 {
+		t.Fatalf("expected available capacity to be greater than 0, got %d", resp.AvailableCapacity)
+	}
+	if resp.MaximumVolumeSize.GetValue() != resp.AvailableCapacity {
+		t.Fatalf("expected maximum volume size %d to match available capacity %d",
+			resp.MaximumVolumeSize.GetValue(), resp.AvailableCapacity)
+	}
+
+	// Datastore URL which doesn't match any shared datastore.
+	params[common.AttributeDatastoreURL] = "ds:///vmfs/volumes/non-existent/"
+	resp, err = ct.controller.GetCapacity(ctx, &csi.GetCapacityRequest{
+		VolumeCapabilities: capabilities,
+		Parameters:         params,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.AvailableCapacity != 0 {
+		t.Fatalf("expected available capacity to be 0 for non-existent datastore, got %d", resp.AvailableCapacity)
+	}
+
+	// Invalid StorageClass parameter.
+	_, err = ct.controller.GetCapacity(ctx, &csi.GetCapacityRequest{
+		VolumeCapabilities: capabilities,
+		Parameters:         map[string]string{"invalid-param": "value"},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument error for invalid parameter, got %v", err)
+	}
+}
+
 func TestExtendVolume(t *testing.T) {
 	ct := getControllerTest(t)
 
@@ -766,7 +820,7 @@ func TestExtendVolume(t *testing.T) {
 		},
 		VolumeCapability: capabilities[0],
 	}
-	t.Logf("ControllerExpandVolume will be called with req +%v", *reqExpand)
+	t.Logf("ControllerExpandVolume will be called with req +%v", reqExpand)
 	respExpand, err := ct.controller.ControllerExpandVolume(ctx, reqExpand)
 	if err != nil {
 		t.Fatal(err)
@@ -824,7 +878,7 @@ func TestMigratedExtendVolume(t *testing.T) {
 			RequiredBytes: 1024,
 		},
 	}
-	t.Logf("ControllerExpandVolume will be called with req +%v", *reqExpand)
+	t.Logf("ControllerExpandVolume will be called with req +%v", reqExpand)
 	_, err := ct.controller.ControllerExpandVolume(ctx, reqExpand)
 	if err != nil {
 		t.Logf("Expected error received. migrated volume with VMDK path can not be expanded")
@@ -913,7 +967,7 @@ func TestCompleteControllerFlow(t *testing.T) {
 		VolumeCapability: capabilities[0],
 		Readonly:         false,
 	}
-	t.Logf("ControllerPublishVolume will be called with req +%v", *reqControllerPublishVolume)
+	t.Logf("ControllerPublishVolume will be called with req +%v", reqControllerPublishVolume)
 	respControllerPublishVolume, err := ct.controller.ControllerPublishVolume(ctx, reqControllerPublishVolume)
 	if err != nil {
 		t.Fatal(err)
@@ -926,7 +980,7 @@ func TestCompleteControllerFlow(t *testing.T) {
 		VolumeId: volID,
 		NodeId:   NodeID,
 	}
-	t.Logf("ControllerUnpublishVolume will be called with req +%v", *reqControllerUnpublishVolume)
+	t.Logf("ControllerUnpublishVolume will be called with req +%v", reqControllerUnpublishVolume)
 	_, err = ct.controller.ControllerUnpublishVolume(ctx, reqControllerUnpublishVolume)
 	if err != nil {
 		t.Fatal(err)
@@ -1564,6 +1618,194 @@ func TestCreateVolumeFromSnapshot(t *testing.T) {
 	}
 }
 
+func TestCreateVolumeFromVolumeWithInvalidSource(t *testing.T) {
+	ct := getControllerTest(t)
+
+	params := make(map[string]string)
+	if v := os.Getenv("VSPHERE_DATASTORE_URL"); v != "" {
+		params[common.AttributeDatastoreURL] = v
+	}
+	capabilities := []*csi.VolumeCapability{
+		{
+			AccessMode: &csi.VolumeCapability_AccessMode{
+				Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+			},
+		},
+	}
+
+	// Create the source volume.
+	reqCreate := &csi.CreateVolumeRequest{
+		Name: testVolumeName + "-" + uuid.New().String(),
+		CapacityRange: &csi.CapacityRange{
+			RequiredBytes: 2 * common.GbInBytes,
+		},
+		Parameters:         params,
+		VolumeCapabilities: capabilities,
+	}
+	respCreate, err := ct.controller.CreateVolume(ctx, reqCreate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	volID := respCreate.Volume.VolumeId
+	defer func() {
+		_, err = ct.controller.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: volID})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Clone size smaller than the source volume size.
+	reqClone := &csi.CreateVolumeRequest{
+		Name: testVolumeName + "-" + uuid.New().String(),
+		CapacityRange: &csi.CapacityRange{
+			RequiredBytes: 1 * common.GbInBytes,
+		},
+		Parameters:         params,
+		VolumeCapabilities: capabilities,
+		VolumeContentSource: &csi.VolumeContentSource{
+			Type: &csi.VolumeContentSource_Volume{
+				Volume: &csi.VolumeContentSource_VolumeSource{
+					VolumeId: volID,
+				},
+			},
+		},
+	}
+	_, err = ct.controller.CreateVolume(ctx, reqClone)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument error for clone smaller than the source volume, got %v", err)
+	}
+
+	// Source volume which does not exist.
+	reqClone.Name = testVolumeName + "-" + uuid.New().String()
+	reqClone.CapacityRange.RequiredBytes = 2 * common.GbInBytes
+	reqClone.VolumeContentSource.GetVolume().VolumeId = uuid.New().String()
+	_, err = ct.controller.CreateVolume(ctx, reqClone)
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound error for non-existent source volume, got %v", err)
+	}
+}
+
+func TestControllerGetVolume(t *testing.T) {
+	ct := getControllerTest(t)
+
+	params := make(map[string]string)
+	if v := os.Getenv("VSPHERE_DATASTORE_URL"); v != "" {
+		params[common.AttributeDatastoreURL] = v
+	}
+	reqCreate := &csi.CreateVolumeRequest{
+		Name: testVolumeName + "-" + uuid.New().String(),
+		CapacityRange: &csi.CapacityRange{
+			RequiredBytes: 1 * common.GbInBytes,
+		},
+		Parameters: params,
+		VolumeCapabilities: []*csi.VolumeCapability{
+			{
+				AccessMode: &csi.VolumeCapability_AccessMode{
+					Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+				},
+			},
+		},
+	}
+	respCreate, err := ct.controller.CreateVolume(ctx, reqCreate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	volID := respCreate.Volume.VolumeId
+	defer func() {
+		_, err = ct.controller.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: volID})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	resp, err := ct.controller.ControllerGetVolume(ctx, &csi.ControllerGetVolumeRequest{VolumeId: volID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Volume.CapacityBytes != 1*common.GbInBytes {
+		t.Fatalf("expected capacity %d, got %d", 1*common.GbInBytes, resp.Volume.CapacityBytes)
+	}
+	if resp.Status.GetVolumeCondition() == nil {
+		t.Fatalf("expected volume condition to be set for volume %q", volID)
+	}
+
+	// Volume which does not exist.
+	_, err = ct.controller.ControllerGetVolume(ctx, &csi.ControllerGetVolumeRequest{VolumeId: uuid.New().String()})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound error for non-existent volume, got %v", err)
+	}
+}
+
+func TestControllerModifyVolume(t *testing.T) {
+	ct := getControllerTest(t)
+
+	params := make(map[string]string)
+	if v := os.Getenv("VSPHERE_DATASTORE_URL"); v != "" {
+		params[common.AttributeDatastoreURL] = v
+	}
+	// PBM simulator defaults.
+	params[common.AttributeStoragePolicyName] = "vSAN Default Storage Policy"
+	if v := os.Getenv("VSPHERE_STORAGE_POLICY_NAME"); v != "" {
+		params[common.AttributeStoragePolicyName] = v
+	}
+	reqCreate := &csi.CreateVolumeRequest{
+		Name: testVolumeName + "-" + uuid.New().String(),
+		CapacityRange: &csi.CapacityRange{
+			RequiredBytes: 1 * common.GbInBytes,
+		},
+		Parameters: params,
+		VolumeCapabilities: []*csi.VolumeCapability{
+			{
+				AccessMode: &csi.VolumeCapability_AccessMode{
+					Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+				},
+			},
+		},
+	}
+	respCreate, err := ct.controller.CreateVolume(ctx, reqCreate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	volID := respCreate.Volume.VolumeId
+	defer func() {
+		_, err = ct.controller.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: volID})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Unsupported mutable parameter.
+	_, err = ct.controller.ControllerModifyVolume(ctx, &csi.ControllerModifyVolumeRequest{
+		VolumeId:          volID,
+		MutableParameters: map[string]string{"iopslimit": "100"},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument error for unsupported mutable parameter, got %v", err)
+	}
+
+	// Storage policy of the volume is not changed, no CNS call is expected.
+	_, err = ct.controller.ControllerModifyVolume(ctx, &csi.ControllerModifyVolumeRequest{
+		VolumeId: volID,
+		MutableParameters: map[string]string{
+			common.AttributeStoragePolicyName: params[common.AttributeStoragePolicyName],
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Volume which does not exist.
+	_, err = ct.controller.ControllerModifyVolume(ctx, &csi.ControllerModifyVolumeRequest{
+		VolumeId: uuid.New().String(),
+		MutableParameters: map[string]string{
+			common.AttributeStoragePolicyName: params[common.AttributeStoragePolicyName],
+		},
+	})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound error for non-existent volume, got %v", err)
+	}
+}
+
 func TestListSnapshotsOnSpecificVolumeAndSnapshot(t *testing.T) {
 	ct := getControllerTest(t)
 
@@ -2553,3 +2795,284 @@ func TestCreateSnapshotWithCnsSnapshotCreatedFault(t *testing.T) {
 		t.Fatal("expected error was not received for create snapshot operation.")
 	}
 }
+
+func TestCreateVolumeGroupSnapshot(t *testing.T) {
+	ct := getControllerTest(t)
+
+	params := make(map[string]string)
+	if v := os.Getenv("VSPHERE_DATASTORE_URL"); v != "" {
+		params[common.AttributeDatastoreURL] = v
+	}
+	capabilities := []*csi.VolumeCapability{
+		{
+			AccessMode: &csi.VolumeCapability_AccessMode{
+				Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+			},
+		},
+	}
+
+	// Create the data and WAL volumes of the group.
+	var volIDs []string
+	for i := 0; i < 2; i++ {
+		reqCreate := &csi.CreateVolumeRequest{
+			Name: testVolumeName + "-" + uuid.New().String(),
+			CapacityRange: &csi.CapacityRange{
+				RequiredBytes: 1 * common.GbInBytes,
+			},
+			Parameters:         params,
+			VolumeCapabilities: capabilities,
+		}
+		respCreate, err := ct.controller.CreateVolume(ctx, reqCreate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		volID := respCreate.Volume.VolumeId
+		volIDs = append(volIDs, volID)
+		defer func() {
+			_, err := ct.controller.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: volID})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}()
+	}
+
+	reqCreateGroupSnapshot := &csi.CreateVolumeGroupSnapshotRequest{
+		Name:            "groupsnapshot-" + uuid.New().String(),
+		SourceVolumeIds: volIDs,
+	}
+	respCreateGroupSnapshot, err := ct.controller.CreateVolumeGroupSnapshot(ctx, reqCreateGroupSnapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groupSnapshot := respCreateGroupSnapshot.GroupSnapshot
+	if groupSnapshot.GroupSnapshotId != reqCreateGroupSnapshot.Name || len(groupSnapshot.Snapshots) != len(volIDs) {
+		t.Fatalf("unexpected group snapshot: %+v", groupSnapshot)
+	}
+	var snapIDs []string
+	for i, snapshot := range groupSnapshot.Snapshots {
+		volID, _, err := common.ParseCSISnapshotID(snapshot.SnapshotId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if volID != volIDs[i] || snapshot.SourceVolumeId != volIDs[i] ||
+			snapshot.GroupSnapshotId != groupSnapshot.GroupSnapshotId {
+			t.Fatalf("unexpected snapshot %+v for volume %q", snapshot, volIDs[i])
+		}
+		snapIDs = append(snapIDs, snapshot.SnapshotId)
+	}
+
+	// A retried request returns the same snapshots.
+	respCreateGroupSnapshot, err = ct.controller.CreateVolumeGroupSnapshot(ctx, reqCreateGroupSnapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, snapshot := range respCreateGroupSnapshot.GroupSnapshot.Snapshots {
+		if snapshot.SnapshotId != snapIDs[i] {
+			t.Fatalf("expected snapshot %q on retry, got %q", snapIDs[i], snapshot.SnapshotId)
+		}
+	}
+
+	respGetGroupSnapshot, err := ct.controller.GetVolumeGroupSnapshot(ctx, &csi.GetVolumeGroupSnapshotRequest{
+		GroupSnapshotId: groupSnapshot.GroupSnapshotId,
+		SnapshotIds:     snapIDs,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(respGetGroupSnapshot.GroupSnapshot.Snapshots) != len(snapIDs) {
+		t.Fatalf("unexpected group snapshot: %+v", respGetGroupSnapshot.GroupSnapshot)
+	}
+
+	_, err = ct.controller.DeleteVolumeGroupSnapshot(ctx, &csi.DeleteVolumeGroupSnapshotRequest{
+		GroupSnapshotId: groupSnapshot.GroupSnapshotId,
+		SnapshotIds:     snapIDs,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ct.operationStore.GetRequestDetails(ctx, groupSnapshot.GroupSnapshotId); err == nil {
+		t.Fatalf("CnsVolumeOperationRequest instance of group snapshot %q was not deleted",
+			groupSnapshot.GroupSnapshotId)
+	}
+
+	// None of the snapshots is kept if the snapshot of a volume fails.
+	_, err = ct.controller.CreateVolumeGroupSnapshot(ctx, &csi.CreateVolumeGroupSnapshotRequest{
+		Name:            "groupsnapshot-" + uuid.New().String(),
+		SourceVolumeIds: []string{volIDs[0], uuid.New().String()},
+	})
+	if err == nil {
+		t.Fatal("expected error when creating a group snapshot with an unknown volume")
+	}
+	snapshots, _, err := common.QueryVolumeSnapshotsByVolumeID(ctx, ct.controller.manager.VolumeManager,
+		volIDs[0], common.QuerySnapshotLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 0 {
+		t.Fatalf("expected no snapshots on volume %q, got %+v", volIDs[0], snapshots)
+	}
+}
+
+// fakeVolumeMigrationService maps the VMDK paths of migrated in-tree volumes
+// to volume IDs.
+type fakeVolumeMigrationService struct {
+	volumePathToVolumeID map[string]string
+}
+
+func (f *fakeVolumeMigrationService) GetVolumeID(ctx context.Context, volumeSpec *migration.VolumeSpec,
+	registerIfNotFound bool) (string, error) {
+	if volumeID, ok := f.volumePathToVolumeID[volumeSpec.VolumePath]; ok {
+		return volumeID, nil
+	}
+	return "", migration.ErrVolumeIDNotFound
+}
+
+func (f *fakeVolumeMigrationService) GetVolumePath(ctx context.Context, volumeID string) (string, error) {
+	return f.GetVolumePathFromMigrationServiceCache(ctx, volumeID)
+}
+
+func (f *fakeVolumeMigrationService) GetVolumePathFromMigrationServiceCache(ctx context.Context,
+	volumeID string) (string, error) {
+	for volumePath, id := range f.volumePathToVolumeID {
+		if id == volumeID {
+			return volumePath, nil
+		}
+	}
+	return "", common.ErrNotFound
+}
+
+func (f *fakeVolumeMigrationService) DeleteVolumeInfo(ctx context.Context, volumeID string) error {
+	return nil
+}
+
+func (f *fakeVolumeMigrationService) ProtectVolumeFromVMDeletion(ctx context.Context, volumeID string) error {
+	return nil
+}
+
+func TestCreateSnapshotOfMigratedVolume(t *testing.T) {
+	ct := getControllerTest(t)
+
+	params := make(map[string]string)
+	if v := os.Getenv("VSPHERE_DATASTORE_URL"); v != "" {
+		params[common.AttributeDatastoreURL] = v
+	}
+	capabilities := []*csi.VolumeCapability{
+		{
+			AccessMode: &csi.VolumeCapability_AccessMode{
+				Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+			},
+		},
+	}
+	reqCreate := &csi.CreateVolumeRequest{
+		Name: testVolumeName + "-" + uuid.New().String(),
+		CapacityRange: &csi.CapacityRange{
+			RequiredBytes: 1 * common.GbInBytes,
+		},
+		Parameters:         params,
+		VolumeCapabilities: capabilities,
+	}
+	respCreate, err := ct.controller.CreateVolume(ctx, reqCreate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	volID := respCreate.Volume.VolumeId
+	defer func() {
+		_, err = ct.controller.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: volID})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Register the volume as a migrated in-tree volume.
+	volumePath := "[vsanDatastore] kubevols/" + reqCreate.Name + ".vmdk"
+	originalVolumeMigrationService := volumeMigrationService
+	volumeMigrationService = &fakeVolumeMigrationService{
+		volumePathToVolumeID: map[string]string{volumePath: volID},
+	}
+	defer func() {
+		volumeMigrationService = originalVolumeMigrationService
+	}()
+
+	respCreateSnapshot, err := ct.controller.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{
+		SourceVolumeId: volumePath,
+		Name:           "snapshot-" + uuid.New().String(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapID := respCreateSnapshot.Snapshot.SnapshotId
+	if !strings.HasPrefix(snapID, volID+common.VSphereCSISnapshotIdDelimiter) {
+		t.Fatalf("snapshot ID %q is not of the FCD %q of migrated volume %q", snapID, volID, volumePath)
+	}
+	if respCreateSnapshot.Snapshot.SourceVolumeId != volumePath {
+		t.Fatalf("expected source volume ID %q, got %q", volumePath, respCreateSnapshot.Snapshot.SourceVolumeId)
+	}
+
+	respList, err := ct.controller.ListSnapshots(ctx, &csi.ListSnapshotsRequest{SourceVolumeId: volumePath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(respList.Entries) != 1 || respList.Entries[0].Snapshot.SnapshotId != snapID ||
+		respList.Entries[0].Snapshot.SourceVolumeId != volumePath {
+		t.Fatalf("unexpected snapshots listed for migrated volume %q: %+v", volumePath, respList.Entries)
+	}
+
+	// A VMDK which was never registered as an FCD has no snapshots.
+	unregisteredVolumePath := "[vsanDatastore] kubevols/" + uuid.New().String() + ".vmdk"
+	respList, err = ct.controller.ListSnapshots(ctx, &csi.ListSnapshotsRequest{SourceVolumeId: unregisteredVolumePath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(respList.Entries) != 0 {
+		t.Fatalf("expected no snapshots for unregistered VMDK %q, got %+v", unregisteredVolumePath, respList.Entries)
+	}
+
+	_, err = ct.controller.DeleteSnapshot(ctx, &csi.DeleteSnapshotRequest{SnapshotId: snapID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Group snapshots are taken of the FCD registered for the VMDK path too.
+	respCreateGroupSnapshot, err := ct.controller.CreateVolumeGroupSnapshot(ctx,
+		&csi.CreateVolumeGroupSnapshotRequest{
+			Name:            "groupsnapshot-" + uuid.New().String(),
+			SourceVolumeIds: []string{volumePath},
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	groupSnapshot := respCreateGroupSnapshot.GroupSnapshot
+	if len(groupSnapshot.Snapshots) != 1 ||
+		!strings.HasPrefix(groupSnapshot.Snapshots[0].SnapshotId, volID+common.VSphereCSISnapshotIdDelimiter) ||
+		groupSnapshot.Snapshots[0].SourceVolumeId != volumePath {
+		t.Fatalf("unexpected group snapshot of migrated volume %q: %+v", volumePath, groupSnapshot)
+	}
+	_, err = ct.controller.DeleteVolumeGroupSnapshot(ctx, &csi.DeleteVolumeGroupSnapshotRequest{
+		GroupSnapshotId: groupSnapshot.GroupSnapshotId,
+		SnapshotIds:     []string{groupSnapshot.Snapshots[0].SnapshotId},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileVolumeSnapshotOfUnknownVolume(t *testing.T) {
+	ct := getControllerTest(t)
+
+	fileVolumeID := common.FileVolumeIDPrefix + uuid.New().String()
+	snapshotID := common.GetCSIFileSnapshotID(fileVolumeID, "snapshot-"+uuid.New().String())
+
+	// Deleting a file share snapshot of a volume unknown to CNS succeeds.
+	_, err := ct.controller.DeleteSnapshot(ctx, &csi.DeleteSnapshotRequest{SnapshotId: snapshotID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	respList, err := ct.controller.ListSnapshots(ctx, &csi.ListSnapshotsRequest{SnapshotId: snapshotID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(respList.Entries) != 0 {
+		t.Fatalf("expected no snapshots, got %d", len(respList.Entries))
+	}
+}
@@ -0,0 +1,294 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vanilla
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	"github.com/vmware/govmomi/vim25/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/prometheus"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/utils"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+)
+
+// isFileVolumeSnapshotEnabled returns true if both the block-volume-snapshot
+// and the file-volume-snapshot features are enabled.
+func isFileVolumeSnapshotEnabled(ctx context.Context) bool {
+	return commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.BlockVolumeSnapshot) &&
+		commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.FileVolumeSnapshot)
+}
+
+// validateFileSnapshotRestore rejects requests to create a volume from a vSAN
+// file share snapshot. Neither CNS nor the vSAN file service can create a
+// volume from a file share snapshot: CnsSnapshotVolumeSource only takes FCD
+// snapshots, and the vSAN file service has no API to create a file share from
+// a snapshot. Restoring file share snapshots is therefore out of scope of the
+// file-volume-snapshot feature.
+func validateFileSnapshotRestore(ctx context.Context, req *csi.CreateVolumeRequest) error {
+	log := logger.GetLogger(ctx)
+	sourceSnapshot := req.GetVolumeContentSource().GetSnapshot()
+	if sourceSnapshot == nil || !common.IsFileVolumeSnapshotID(sourceSnapshot.GetSnapshotId()) {
+		return nil
+	}
+	return logger.LogNewErrorCodef(log, codes.Unimplemented,
+		"restoring file share snapshot %q to a new volume is not supported", sourceSnapshot.GetSnapshotId())
+}
+
+// fileShareLocation holds the vCenter and the vSAN cluster serving the file
+// share of a file volume, along with the CNS details of the volume.
+type fileShareLocation struct {
+	vcenter       *cnsvsphere.VirtualCenter
+	cluster       types.ManagedObjectReference
+	volumeDetails *utils.CnsVolumeDetails
+}
+
+// getFileShareLocation finds the vCenter and the vSAN file service enabled
+// cluster of the given file volume. A NotFound error is returned when CNS
+// does not know the volume.
+func (c *controller) getFileShareLocation(ctx context.Context, fileVolumeID string) (*fileShareLocation, error) {
+	log := logger.GetLogger(ctx)
+	vCenterHost, volumeManager, err := getVCenterAndVolumeManagerForVolumeID(ctx, c, fileVolumeID,
+		volumeInfoService)
+	if err != nil {
+		return nil, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to get vCenter/volume manager for volume Id: %q. Error: %v", fileVolumeID, err)
+	}
+	vcenter, err := common.GetVCenterFromVCHost(ctx, getVCenterManagerForVCenter(ctx, c), vCenterHost)
+	if err != nil {
+		return nil, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to get vCenter instance for host %q. Error: %+v", vCenterHost, err)
+	}
+	cnsVolumeDetailsMap, err := utils.QueryVolumeDetailsUtil(ctx, volumeManager,
+		[]cnstypes.CnsVolumeId{{Id: fileVolumeID}})
+	if err != nil {
+		return nil, logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to query details of volume %q. Error: %+v", fileVolumeID, err)
+	}
+	volumeDetails, ok := cnsVolumeDetailsMap[fileVolumeID]
+	if !ok {
+		return nil, logger.LogNewErrorCodef(log, codes.NotFound,
+			"cns query volume did not return the volume: %s", fileVolumeID)
+	}
+	if volumeDetails.VolumeType != common.FileVolumeType {
+		return nil, logger.LogNewErrorCodef(log, codes.FailedPrecondition,
+			"queried volume doesn't have the expected volume type. Expected VolumeType: %v. "+
+				"Queried VolumeType: %v", common.FileVolumeType, volumeDetails.VolumeType)
+	}
+
+	var fsEnabledClusterToDsInfoMap map[string][]*cnsvsphere.DatastoreInfo
+	if multivCenterCSITopologyEnabled {
+		fsEnabledClusterToDsInfoMap = c.authMgrs[vCenterHost].GetFsEnabledClusterToDsMap(ctx)
+	} else {
+		fsEnabledClusterToDsInfoMap = c.authMgr.GetFsEnabledClusterToDsMap(ctx)
+	}
+	for clusterID, datastores := range fsEnabledClusterToDsInfoMap {
+		for _, ds := range datastores {
+			if ds.Info.Url == volumeDetails.DatastoreUrl {
+				return &fileShareLocation{
+					vcenter: vcenter,
+					cluster: types.ManagedObjectReference{
+						Type:  "ClusterComputeResource",
+						Value: clusterID,
+					},
+					volumeDetails: volumeDetails,
+				}, nil
+			}
+		}
+	}
+	return nil, logger.LogNewErrorCodef(log, codes.FailedPrecondition,
+		"no vSAN file service enabled cluster found for datastore %q of volume %q",
+		volumeDetails.DatastoreUrl, fileVolumeID)
+}
+
+// createFileVolumeSnapshot takes a vSAN file share snapshot of the file volume
+// in the CreateSnapshotRequest. The snapshot is named after the request, and
+// its CSI snapshot ID is the file volume ID and that name concatenated by "@".
+func (c *controller) createFileVolumeSnapshot(ctx context.Context, req *csi.CreateSnapshotRequest) (
+	*csi.CreateSnapshotResponse, error) {
+	log := logger.GetLogger(ctx)
+	if !isFileVolumeSnapshotEnabled(ctx) {
+		return nil, logger.LogNewErrorCode(log, codes.Unimplemented,
+			"createSnapshot of file volumes is not supported")
+	}
+	volumeType := prometheus.PrometheusFileVolumeType
+	createSnapshotInternal := func() (*csi.CreateSnapshotResponse, error) {
+		if err := validateVanillaCreateSnapshotRequestRequest(ctx, req); err != nil {
+			return nil, logger.LogNewErrorCodef(log, codes.Internal,
+				"validation for CreateSnapshot Request: %+v has failed. Error: %v", req, err)
+		}
+		volumeID := req.GetSourceVolumeId()
+		location, err := c.getFileShareLocation(ctx, volumeID)
+		if err != nil {
+			return nil, err
+		}
+		snapshotID, snapshot, err := common.CreateFileSnapshotUtil(ctx, location.vcenter, location.cluster,
+			volumeID, req.Name)
+		if err != nil {
+			return nil, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to create snapshot on volume %q with error: %v", volumeID, err)
+		}
+		creationTime := time.Now()
+		if snapshot.CreationTime != nil {
+			creationTime = *snapshot.CreationTime
+		}
+		createSnapshotResponse := &csi.CreateSnapshotResponse{
+			Snapshot: &csi.Snapshot{
+				SizeBytes:      location.volumeDetails.SizeInMB * common.MbInBytes,
+				SnapshotId:     snapshotID,
+				SourceVolumeId: volumeID,
+				CreationTime:   timestamppb.New(creationTime),
+				ReadyToUse:     true,
+			},
+		}
+		log.Infof("CreateSnapshot succeeded for file share snapshot %s on volume %s. Response: %+v",
+			snapshotID, volumeID, createSnapshotResponse)
+		return createSnapshotResponse, nil
+	}
+
+	start := time.Now()
+	resp, err := createSnapshotInternal()
+	if err != nil {
+		log.Errorf("Operation failed, reporting failure status to Prometheus."+
+			" Operation Type: %q, Volume Type: %q, Fault Type: %q",
+			prometheus.PrometheusCreateSnapshotOpType, volumeType, "NotComputed")
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusCreateSnapshotOpType,
+			prometheus.PrometheusFailStatus, "NotComputed").Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusCreateSnapshotOpType,
+			prometheus.PrometheusPassStatus, "").Observe(time.Since(start).Seconds())
+	}
+	return resp, err
+}
+
+// deleteFileVolumeSnapshot removes the vSAN file share snapshot in the
+// DeleteSnapshotRequest. Snapshots of file volumes unknown to CNS are
+// considered deleted.
+func (c *controller) deleteFileVolumeSnapshot(ctx context.Context, req *csi.DeleteSnapshotRequest) (
+	*csi.DeleteSnapshotResponse, error) {
+	log := logger.GetLogger(ctx)
+	if !isFileVolumeSnapshotEnabled(ctx) {
+		return nil, logger.LogNewErrorCode(log, codes.Unimplemented,
+			"deleteSnapshot of file volumes is not supported")
+	}
+	volumeType := prometheus.PrometheusFileVolumeType
+	deleteSnapshotInternal := func() (*csi.DeleteSnapshotResponse, error) {
+		csiSnapshotID := req.GetSnapshotId()
+		volumeID, _, err := common.ParseCSIFileSnapshotID(csiSnapshotID)
+		if err != nil {
+			return nil, logger.LogNewErrorCode(log, codes.InvalidArgument, err.Error())
+		}
+		location, err := c.getFileShareLocation(ctx, volumeID)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				log.Infof("DeleteSnapshot: volume %q of snapshot %q not found. Assuming the snapshot is "+
+					"already deleted.", volumeID, csiSnapshotID)
+				return &csi.DeleteSnapshotResponse{}, nil
+			}
+			return nil, err
+		}
+		err = common.DeleteFileSnapshotUtil(ctx, location.vcenter, location.cluster, csiSnapshotID)
+		if err != nil {
+			return nil, logger.LogNewErrorCodef(log, codes.Internal,
+				"Failed to delete snapshot %q. Error: %+v", csiSnapshotID, err)
+		}
+		log.Infof("DeleteSnapshot: successfully deleted file share snapshot %q", csiSnapshotID)
+		return &csi.DeleteSnapshotResponse{}, nil
+	}
+
+	start := time.Now()
+	resp, err := deleteSnapshotInternal()
+	if err != nil {
+		log.Errorf("Operation failed, reporting failure status to Prometheus."+
+			" Operation Type: %q, Volume Type: %q, Fault Type: %q",
+			prometheus.PrometheusDeleteSnapshotOpType, volumeType, "NotComputed")
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusDeleteSnapshotOpType,
+			prometheus.PrometheusFailStatus, "NotComputed").Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusDeleteSnapshotOpType,
+			prometheus.PrometheusPassStatus, "").Observe(time.Since(start).Seconds())
+	}
+	return resp, err
+}
+
+// listFileVolumeSnapshots lists the vSAN file share snapshots selected by the
+// SnapshotId or, when it is not set, the SourceVolumeId of the request.
+func (c *controller) listFileVolumeSnapshots(ctx context.Context, req *csi.ListSnapshotsRequest) (
+	*csi.ListSnapshotsResponse, error) {
+	log := logger.GetLogger(ctx)
+	if !isFileVolumeSnapshotEnabled(ctx) {
+		return nil, logger.LogNewErrorCode(log, codes.Unimplemented,
+			"listSnapshots of file volumes is not supported")
+	}
+	volumeID := req.SourceVolumeId
+	var snapshotName string
+	if req.SnapshotId != "" {
+		snapshotVolumeID, name, err := common.ParseCSIFileSnapshotID(req.SnapshotId)
+		if err != nil {
+			return nil, logger.LogNewErrorCode(log, codes.InvalidArgument, err.Error())
+		}
+		if volumeID != "" && volumeID != snapshotVolumeID {
+			// The snapshot is not one of the source volume.
+			return &csi.ListSnapshotsResponse{}, nil
+		}
+		volumeID, snapshotName = snapshotVolumeID, name
+	}
+	location, err := c.getFileShareLocation(ctx, volumeID)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return &csi.ListSnapshotsResponse{}, nil
+		}
+		return nil, err
+	}
+	snapshots, err := common.ListFileSnapshotsUtil(ctx, location.vcenter, location.cluster, volumeID,
+		snapshotName, location.volumeDetails.SizeInMB)
+	if err != nil {
+		return nil, logger.LogNewErrorCodef(log, codes.Internal, " failed to retrieve the snapshots, err: %+v", err)
+	}
+
+	startingToken := 0
+	if req.StartingToken != "" {
+		// The StartingToken is validated to be an integer.
+		startingToken, _ = strconv.Atoi(req.StartingToken)
+	}
+	var (
+		entries   []*csi.ListSnapshotsResponse_Entry
+		nextToken string
+	)
+	for i := startingToken; i < len(snapshots); i++ {
+		if req.MaxEntries != 0 && len(entries) == int(req.MaxEntries) {
+			nextToken = strconv.Itoa(i)
+			break
+		}
+		entries = append(entries, &csi.ListSnapshotsResponse_Entry{Snapshot: snapshots[i]})
+	}
+	log.Infof("ListSnapshot served %d file share snapshots, token for next set: %s", len(entries), nextToken)
+	return &csi.ListSnapshotsResponse{
+		Entries:   entries,
+		NextToken: nextToken,
+	}, nil
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vanilla
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	"github.com/vmware/govmomi/vim25/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	cnsvolume "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/utils"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+)
+
+var testFileServiceCluster = types.ManagedObjectReference{
+	Type:  "ClusterComputeResource",
+	Value: "domain-c1",
+}
+
+// patchFileShareLocation makes every file volume a file share of the
+// simulated vSAN file service, as the CNS simulator has no file volumes.
+func patchFileShareLocation(sizeInMB int64) *gomonkey.Patches {
+	const datastoreURL = "ds:///vmfs/volumes/vsan:file-service/"
+	patches := gomonkey.ApplyFunc(utils.QueryVolumeDetailsUtil,
+		func(_ context.Context, _ cnsvolume.Manager, volumeIds []cnstypes.CnsVolumeId) (
+			map[string]*utils.CnsVolumeDetails, error) {
+			volumeDetailsMap := make(map[string]*utils.CnsVolumeDetails)
+			for _, volumeID := range volumeIds {
+				volumeDetailsMap[volumeID.Id] = &utils.CnsVolumeDetails{
+					VolumeID:     volumeID.Id,
+					SizeInMB:     sizeInMB,
+					DatastoreUrl: datastoreURL,
+					VolumeType:   common.FileVolumeType,
+				}
+			}
+			return volumeDetailsMap, nil
+		})
+	patches.ApplyMethod(reflect.TypeOf(&FakeAuthManager{}), "GetFsEnabledClusterToDsMap",
+		func(_ *FakeAuthManager, _ context.Context) map[string][]*cnsvsphere.DatastoreInfo {
+			return map[string][]*cnsvsphere.DatastoreInfo{
+				testFileServiceCluster.Value: {{Info: &types.DatastoreInfo{Url: datastoreURL}}},
+			}
+		})
+	return patches
+}
+
+func TestVsanFileShareSnapshotBindings(t *testing.T) {
+	ct := getControllerTest(t)
+	shareUUID := uuid.New().String()
+
+	task, err := ct.vcenter.CreateFileShareSnapshot(ctx, testFileServiceCluster, shareUUID, "snapshot-1")
+	assert.NoError(t, err)
+	_, err = task.WaitForResult(ctx)
+	assert.NoError(t, err)
+
+	snapshots, err := ct.vcenter.QueryFileShareSnapshots(ctx, testFileServiceCluster, shareUUID, nil)
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 1)
+	assert.Equal(t, "snapshot-1", snapshots[0].Config.Name)
+	assert.Equal(t, shareUUID, snapshots[0].Config.ShareUuid)
+	assert.NotNil(t, snapshots[0].CreationTime)
+	snapshots, err = ct.vcenter.QueryFileShareSnapshots(ctx, testFileServiceCluster, shareUUID,
+		[]string{"snapshot-2"})
+	assert.NoError(t, err)
+	assert.Empty(t, snapshots)
+
+	// The fault of the task is returned when the snapshot already exists.
+	task, err = ct.vcenter.CreateFileShareSnapshot(ctx, testFileServiceCluster, shareUUID, "snapshot-1")
+	assert.NoError(t, err)
+	_, err = task.WaitForResult(ctx)
+	assert.Error(t, err)
+
+	task, err = ct.vcenter.RemoveFileShareSnapshot(ctx, testFileServiceCluster, shareUUID, "snapshot-1")
+	assert.NoError(t, err)
+	_, err = task.WaitForResult(ctx)
+	assert.NoError(t, err)
+	snapshots, err = ct.vcenter.QueryFileShareSnapshots(ctx, testFileServiceCluster, shareUUID, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, snapshots)
+}
+
+func TestFileVolumeSnapshotLifecycle(t *testing.T) {
+	ct := getControllerTest(t)
+	patches := patchFileShareLocation(1024)
+	defer patches.Reset()
+	fileVolumeID := common.FileVolumeIDPrefix + uuid.New().String()
+
+	var snapshotIDs []string
+	for _, name := range []string{"snapshot-1", "snapshot-2"} {
+		resp, err := ct.controller.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{
+			SourceVolumeId: fileVolumeID,
+			Name:           name,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, common.GetCSIFileSnapshotID(fileVolumeID, name), resp.Snapshot.SnapshotId)
+		assert.Equal(t, fileVolumeID, resp.Snapshot.SourceVolumeId)
+		assert.Equal(t, 1024*common.MbInBytes, resp.Snapshot.SizeBytes)
+		assert.True(t, resp.Snapshot.ReadyToUse)
+		snapshotIDs = append(snapshotIDs, resp.Snapshot.SnapshotId)
+	}
+	// A retried CreateSnapshot returns the existing snapshot.
+	resp, err := ct.controller.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{
+		SourceVolumeId: fileVolumeID,
+		Name:           "snapshot-1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, snapshotIDs[0], resp.Snapshot.SnapshotId)
+
+	// The snapshots of the volume are listed in pages.
+	respList, err := ct.controller.ListSnapshots(ctx, &csi.ListSnapshotsRequest{
+		SourceVolumeId: fileVolumeID,
+		MaxEntries:     1,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, respList.Entries, 1)
+	assert.Equal(t, snapshotIDs[0], respList.Entries[0].Snapshot.SnapshotId)
+	assert.Equal(t, "1", respList.NextToken)
+	respList, err = ct.controller.ListSnapshots(ctx, &csi.ListSnapshotsRequest{
+		SourceVolumeId: fileVolumeID,
+		StartingToken:  respList.NextToken,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, respList.Entries, 1)
+	assert.Equal(t, snapshotIDs[1], respList.Entries[0].Snapshot.SnapshotId)
+	assert.Empty(t, respList.NextToken)
+
+	respList, err = ct.controller.ListSnapshots(ctx, &csi.ListSnapshotsRequest{SnapshotId: snapshotIDs[1]})
+	assert.NoError(t, err)
+	assert.Len(t, respList.Entries, 1)
+	assert.Equal(t, fileVolumeID, respList.Entries[0].Snapshot.SourceVolumeId)
+	// The snapshot is not listed for another source volume.
+	respList, err = ct.controller.ListSnapshots(ctx, &csi.ListSnapshotsRequest{
+		SnapshotId:     snapshotIDs[1],
+		SourceVolumeId: common.FileVolumeIDPrefix + uuid.New().String(),
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, respList.Entries)
+
+	for _, snapshotID := range snapshotIDs {
+		_, err = ct.controller.DeleteSnapshot(ctx, &csi.DeleteSnapshotRequest{SnapshotId: snapshotID})
+		assert.NoError(t, err)
+	}
+	// Deleting a snapshot which is already deleted succeeds.
+	_, err = ct.controller.DeleteSnapshot(ctx, &csi.DeleteSnapshotRequest{SnapshotId: snapshotIDs[0]})
+	assert.NoError(t, err)
+	respList, err = ct.controller.ListSnapshots(ctx, &csi.ListSnapshotsRequest{SourceVolumeId: fileVolumeID})
+	assert.NoError(t, err)
+	assert.Empty(t, respList.Entries)
+}
+
+func TestCreateVolumeFromFileSnapshot(t *testing.T) {
+	ct := getControllerTest(t)
+	fileVolumeID := common.FileVolumeIDPrefix + uuid.New().String()
+	snapshotID := common.GetCSIFileSnapshotID(fileVolumeID, "snapshot-1")
+
+	// Restoring a file share snapshot is rejected for file and block volumes.
+	for _, mode := range []csi.VolumeCapability_AccessMode_Mode{
+		csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+	} {
+		_, err := ct.controller.CreateVolume(ctx, &csi.CreateVolumeRequest{
+			Name: testVolumeName + "-" + uuid.New().String(),
+			CapacityRange: &csi.CapacityRange{
+				RequiredBytes: 1 * common.GbInBytes,
+			},
+			VolumeCapabilities: []*csi.VolumeCapability{{
+				AccessMode: &csi.VolumeCapability_AccessMode{
+					Mode: mode,
+				},
+			}},
+			VolumeContentSource: &csi.VolumeContentSource{
+				Type: &csi.VolumeContentSource_Snapshot{
+					Snapshot: &csi.VolumeContentSource_SnapshotSource{
+						SnapshotId: snapshotID,
+					},
+				},
+			},
+		})
+		assert.Equal(t, codes.Unimplemented, status.Code(err), "access mode %v", mode)
+	}
+}
@@ -0,0 +1,388 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vanilla
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	cnsvolume "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/prometheus"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/utils"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+)
+
+// isVolumeGroupSnapshotEnabled returns true if both the block-volume-snapshot
+// and the volume-group-snapshot features are enabled.
+func isVolumeGroupSnapshotEnabled(ctx context.Context) bool {
+	return commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.BlockVolumeSnapshot) &&
+		commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.VolumeGroupSnapshot)
+}
+
+func (c *controller) GroupControllerGetCapabilities(ctx context.Context,
+	req *csi.GroupControllerGetCapabilitiesRequest) (*csi.GroupControllerGetCapabilitiesResponse, error) {
+	ctx = logger.NewContextWithLogger(ctx)
+	log := logger.GetLogger(ctx)
+	log.Infof("GroupControllerGetCapabilities: called with args %+v", req)
+
+	var caps []*csi.GroupControllerServiceCapability
+	if isVolumeGroupSnapshotEnabled(ctx) {
+		caps = append(caps, &csi.GroupControllerServiceCapability{
+			Type: &csi.GroupControllerServiceCapability_Rpc{
+				Rpc: &csi.GroupControllerServiceCapability_RPC{
+					Type: csi.GroupControllerServiceCapability_RPC_CREATE_DELETE_GET_VOLUME_GROUP_SNAPSHOT,
+				},
+			},
+		})
+	}
+	return &csi.GroupControllerGetCapabilitiesResponse{Capabilities: caps}, nil
+}
+
+// getVolumeManagerForVolumeIDs returns the vCenter and volume manager of the
+// volumes, which are expected to be on the same vCenter.
+func (c *controller) getVolumeManagerForVolumeIDs(ctx context.Context, volumeIDs []string) (
+	string, cnsvolume.Manager, error) {
+	log := logger.GetLogger(ctx)
+	var (
+		vCenterHost   string
+		volumeManager cnsvolume.Manager
+	)
+	for _, volumeID := range volumeIDs {
+		volumeVCenterHost, volumeVolumeManager, err := getVCenterAndVolumeManagerForVolumeID(ctx, c, volumeID,
+			volumeInfoService)
+		if err != nil {
+			return "", nil, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to get vCenter/volume manager for volume Id: %q. Error: %v", volumeID, err)
+		}
+		if volumeManager == nil {
+			vCenterHost, volumeManager = volumeVCenterHost, volumeVolumeManager
+		} else if volumeVCenterHost != vCenterHost {
+			return "", nil, logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"volumes of a group snapshot must be on the same vCenter, volume %q is on vCenter %q "+
+					"instead of %q", volumeID, volumeVCenterHost, vCenterHost)
+		}
+	}
+	return vCenterHost, volumeManager, nil
+}
+
+// getVolumeManagerForSnapshotIDs returns the volume manager of the CSI
+// snapshots of a group snapshot.
+func (c *controller) getVolumeManagerForSnapshotIDs(ctx context.Context, snapshotIDs []string) (
+	string, cnsvolume.Manager, error) {
+	log := logger.GetLogger(ctx)
+	var volumeIDs []string
+	for _, snapshotID := range snapshotIDs {
+		volumeID, _, err := common.ParseCSISnapshotID(snapshotID)
+		if err != nil {
+			return "", nil, logger.LogNewErrorCode(log, codes.InvalidArgument, err.Error())
+		}
+		volumeIDs = append(volumeIDs, volumeID)
+	}
+	return c.getVolumeManagerForVolumeIDs(ctx, volumeIDs)
+}
+
+// checkCnsSnapshotSupported returns an error if the vCenter does not support
+// CNS snapshots.
+func (c *controller) checkCnsSnapshotSupported(ctx context.Context, vCenterHost string) error {
+	log := logger.GetLogger(ctx)
+	isCnsSnapshotSupported, err := getVCenterManagerForVCenter(ctx, c).IsCnsSnapshotSupported(ctx, vCenterHost)
+	if err != nil {
+		return logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to check if cns snapshot is supported on VC due to error: %v", err)
+	}
+	if !isCnsSnapshotSupported {
+		return logger.LogNewErrorCode(log, codes.Unimplemented,
+			"VC version does not support snapshot operations")
+	}
+	return nil
+}
+
+// CreateVolumeGroupSnapshot creates the snapshots of the block volumes of the
+// group with a single CNS CreateSnapshots call, so that they are consistent
+// with each other. The name of the request is used as the group snapshot ID.
+func (c *controller) CreateVolumeGroupSnapshot(ctx context.Context, req *csi.CreateVolumeGroupSnapshotRequest) (
+	*csi.CreateVolumeGroupSnapshotResponse, error) {
+	ctx = logger.NewContextWithLogger(ctx)
+	log := logger.GetLogger(ctx)
+	log.Infof("CreateVolumeGroupSnapshot: called with args %+v", req)
+
+	if !isVolumeGroupSnapshotEnabled(ctx) {
+		return nil, logger.LogNewErrorCode(log, codes.Unimplemented, "createVolumeGroupSnapshot")
+	}
+	volumeType := prometheus.PrometheusUnknownVolumeType
+	createVolumeGroupSnapshotInternal := func() (*csi.CreateVolumeGroupSnapshotResponse, error) {
+		if len(req.Name) == 0 {
+			return nil, logger.LogNewErrorCode(log, codes.InvalidArgument, "Group snapshot name must be provided")
+		}
+		if len(req.SourceVolumeIds) == 0 {
+			return nil, logger.LogNewErrorCode(log, codes.InvalidArgument,
+				"CreateVolumeGroupSnapshot Source Volume IDs must be provided")
+		}
+		var volumeIDs []string
+		volumeIDSet := make(map[string]bool)
+		// migratedVolumePaths maps the FCD IDs of migrated in-tree vSphere
+		// volumes to their VMDK paths given in the request.
+		migratedVolumePaths := make(map[string]string)
+		for _, volumeID := range req.SourceVolumeIds {
+			if strings.Contains(volumeID, ".vmdk") {
+				// The source volume is a migrated in-tree vSphere volume.
+				// Snapshot the FCD registered for its VMDK path.
+				volumePath := volumeID
+				var err error
+				volumeID, err = getMigratedVolumeID(ctx, c, volumePath, true)
+				if err != nil {
+					return nil, err
+				}
+				migratedVolumePaths[volumeID] = volumePath
+			}
+			if !volumeIDSet[volumeID] {
+				volumeIDSet[volumeID] = true
+				volumeIDs = append(volumeIDs, volumeID)
+			}
+		}
+		vCenterHost, volumeManager, err := c.getVolumeManagerForVolumeIDs(ctx, volumeIDs)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.checkCnsSnapshotSupported(ctx, vCenterHost); err != nil {
+			return nil, err
+		}
+		volumeType = prometheus.PrometheusBlockVolumeType
+
+		// Query capacity in MB and datastore url for the block volume snapshots
+		var cnsVolumeIDs []cnstypes.CnsVolumeId
+		for _, volumeID := range volumeIDs {
+			cnsVolumeIDs = append(cnsVolumeIDs, cnstypes.CnsVolumeId{Id: volumeID})
+		}
+		cnsVolumeDetailsMap, err := utils.QueryVolumeDetailsUtil(ctx, volumeManager, cnsVolumeIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, volumeID := range volumeIDs {
+			volumeDetails, ok := cnsVolumeDetailsMap[volumeID]
+			if !ok {
+				return nil, logger.LogNewErrorCodef(log, codes.Internal,
+					"cns query volume did not return the volume: %s", volumeID)
+			}
+			if volumeDetails.VolumeType != common.BlockVolumeType {
+				return nil, logger.LogNewErrorCodef(log, codes.FailedPrecondition,
+					"queried volume %q doesn't have the expected volume type. Expected VolumeType: %v. "+
+						"Queried VolumeType: %v", volumeID, volumeType, volumeDetails.VolumeType)
+			}
+			if err := c.checkSnapshotLimit(ctx, volumeManager, volumeID, volumeDetails.DatastoreUrl); err != nil {
+				return nil, err
+			}
+		}
+
+		// The returned snapshot IDs are in the "<volume-id>+<snapshot-id>" format of CreateSnapshot.
+		snapshotIDs, cnsSnapshotInfos, err := common.CreateGroupSnapshotUtil(ctx, volumeManager, req.Name,
+			volumeIDs)
+		if err != nil {
+			return nil, logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to create group snapshot %q on volumes %v with error: %v", req.Name, volumeIDs, err)
+		}
+		groupSnapshot := &csi.VolumeGroupSnapshot{
+			GroupSnapshotId: req.Name,
+			ReadyToUse:      true,
+		}
+		var creationTime time.Time
+		for i, cnsSnapshotInfo := range cnsSnapshotInfos {
+			snapshotCreateTime := cnsSnapshotInfo.SnapshotLatestOperationCompleteTime
+			if snapshotCreateTime.After(creationTime) {
+				creationTime = snapshotCreateTime
+			}
+			sourceVolumeID := cnsSnapshotInfo.SourceVolumeID
+			if volumePath, ok := migratedVolumePaths[sourceVolumeID]; ok {
+				sourceVolumeID = volumePath
+			}
+			groupSnapshot.Snapshots = append(groupSnapshot.Snapshots, &csi.Snapshot{
+				SizeBytes:       cnsVolumeDetailsMap[cnsSnapshotInfo.SourceVolumeID].SizeInMB * common.MbInBytes,
+				SnapshotId:      snapshotIDs[i],
+				SourceVolumeId:  sourceVolumeID,
+				CreationTime:    timestamppb.New(snapshotCreateTime),
+				ReadyToUse:      true,
+				GroupSnapshotId: req.Name,
+			})
+		}
+		groupSnapshot.CreationTime = timestamppb.New(creationTime)
+
+		log.Infof("CreateVolumeGroupSnapshot succeeded for group snapshot %q on volumes %v with snapshots %v",
+			req.Name, volumeIDs, snapshotIDs)
+		return &csi.CreateVolumeGroupSnapshotResponse{GroupSnapshot: groupSnapshot}, nil
+	}
+
+	start := time.Now()
+	resp, err := createVolumeGroupSnapshotInternal()
+	if err != nil {
+		log.Errorf("Operation failed, reporting failure status to Prometheus."+
+			" Operation Type: %q, Volume Type: %q, Fault Type: %q",
+			prometheus.PrometheusCreateVolumeGroupSnapshotOpType, volumeType, "NotComputed")
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType,
+			prometheus.PrometheusCreateVolumeGroupSnapshotOpType, prometheus.PrometheusFailStatus,
+			"NotComputed").Observe(time.Since(start).Seconds())
+	} else {
+		log.Infof("Group snapshot %q created successfully.", req.Name)
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType,
+			prometheus.PrometheusCreateVolumeGroupSnapshotOpType, prometheus.PrometheusPassStatus,
+			"").Observe(time.Since(start).Seconds())
+	}
+	return resp, err
+}
+
+// DeleteVolumeGroupSnapshot deletes the snapshots of the group and the
+// CnsVolumeOperationRequest instance tracking the group.
+func (c *controller) DeleteVolumeGroupSnapshot(ctx context.Context, req *csi.DeleteVolumeGroupSnapshotRequest) (
+	*csi.DeleteVolumeGroupSnapshotResponse, error) {
+	ctx = logger.NewContextWithLogger(ctx)
+	log := logger.GetLogger(ctx)
+	log.Infof("DeleteVolumeGroupSnapshot: called with args %+v", req)
+
+	if !isVolumeGroupSnapshotEnabled(ctx) {
+		return nil, logger.LogNewErrorCode(log, codes.Unimplemented, "deleteVolumeGroupSnapshot")
+	}
+	deleteVolumeGroupSnapshotInternal := func() (*csi.DeleteVolumeGroupSnapshotResponse, error) {
+		if len(req.GroupSnapshotId) == 0 {
+			return nil, logger.LogNewErrorCode(log, codes.InvalidArgument, "Group snapshot ID must be provided")
+		}
+		if len(req.SnapshotIds) == 0 {
+			return nil, logger.LogNewErrorCode(log, codes.InvalidArgument,
+				"DeleteVolumeGroupSnapshot Snapshot IDs must be provided")
+		}
+		vCenterHost, volumeManager, err := c.getVolumeManagerForSnapshotIDs(ctx, req.SnapshotIds)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.checkCnsSnapshotSupported(ctx, vCenterHost); err != nil {
+			return nil, err
+		}
+		for _, csiSnapshotID := range req.SnapshotIds {
+			_, err := common.DeleteSnapshotUtil(ctx, volumeManager, csiSnapshotID, nil)
+			if err != nil {
+				return nil, logger.LogNewErrorCodef(log, codes.Internal,
+					"Failed to delete snapshot %q of group snapshot %q. Error: %+v",
+					csiSnapshotID, req.GroupSnapshotId, err)
+			}
+		}
+		if operationStore := volumeManager.GetOperationStore(); operationStore != nil {
+			if err := operationStore.DeleteRequestDetails(ctx, req.GroupSnapshotId); err != nil {
+				return nil, logger.LogNewErrorCodef(log, codes.Internal,
+					"failed to delete CnsVolumeOperationRequest instance of group snapshot %q. Error: %+v",
+					req.GroupSnapshotId, err)
+			}
+		}
+
+		log.Infof("DeleteVolumeGroupSnapshot: successfully deleted group snapshot %q", req.GroupSnapshotId)
+		return &csi.DeleteVolumeGroupSnapshotResponse{}, nil
+	}
+
+	volumeType := prometheus.PrometheusBlockVolumeType
+	start := time.Now()
+	resp, err := deleteVolumeGroupSnapshotInternal()
+	if err != nil {
+		log.Errorf("Operation failed, reporting failure status to Prometheus."+
+			" Operation Type: %q, Volume Type: %q, Fault Type: %q",
+			prometheus.PrometheusDeleteVolumeGroupSnapshotOpType, volumeType, "NotComputed")
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType,
+			prometheus.PrometheusDeleteVolumeGroupSnapshotOpType, prometheus.PrometheusFailStatus,
+			"NotComputed").Observe(time.Since(start).Seconds())
+	} else {
+		log.Infof("Group snapshot %q deleted successfully.", req.GroupSnapshotId)
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType,
+			prometheus.PrometheusDeleteVolumeGroupSnapshotOpType, prometheus.PrometheusPassStatus,
+			"").Observe(time.Since(start).Seconds())
+	}
+	return resp, err
+}
+
+// GetVolumeGroupSnapshot returns the snapshots of the group as queried from
+// CNS.
+func (c *controller) GetVolumeGroupSnapshot(ctx context.Context, req *csi.GetVolumeGroupSnapshotRequest) (
+	*csi.GetVolumeGroupSnapshotResponse, error) {
+	ctx = logger.NewContextWithLogger(ctx)
+	log := logger.GetLogger(ctx)
+	log.Infof("GetVolumeGroupSnapshot: called with args %+v", req)
+
+	if !isVolumeGroupSnapshotEnabled(ctx) {
+		return nil, logger.LogNewErrorCode(log, codes.Unimplemented, "getVolumeGroupSnapshot")
+	}
+	getVolumeGroupSnapshotInternal := func() (*csi.GetVolumeGroupSnapshotResponse, error) {
+		if len(req.GroupSnapshotId) == 0 {
+			return nil, logger.LogNewErrorCode(log, codes.InvalidArgument, "Group snapshot ID must be provided")
+		}
+		if len(req.SnapshotIds) == 0 {
+			return nil, logger.LogNewErrorCode(log, codes.InvalidArgument,
+				"GetVolumeGroupSnapshot Snapshot IDs must be provided")
+		}
+		vCenterHost, volumeManager, err := c.getVolumeManagerForSnapshotIDs(ctx, req.SnapshotIds)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.checkCnsSnapshotSupported(ctx, vCenterHost); err != nil {
+			return nil, err
+		}
+		groupSnapshot := &csi.VolumeGroupSnapshot{
+			GroupSnapshotId: req.GroupSnapshotId,
+			ReadyToUse:      true,
+		}
+		for _, csiSnapshotID := range req.SnapshotIds {
+			volumeID, snapshotID, err := common.ParseCSISnapshotID(csiSnapshotID)
+			if err != nil {
+				return nil, logger.LogNewErrorCode(log, codes.InvalidArgument, err.Error())
+			}
+			snapshots, err := common.QueryVolumeSnapshot(ctx, volumeManager, volumeID, snapshotID,
+				common.QuerySnapshotLimit)
+			if err != nil {
+				return nil, err
+			}
+			for _, snapshot := range snapshots {
+				snapshot.GroupSnapshotId = req.GroupSnapshotId
+				if groupSnapshot.CreationTime == nil ||
+					snapshot.CreationTime.AsTime().After(groupSnapshot.CreationTime.AsTime()) {
+					groupSnapshot.CreationTime = snapshot.CreationTime
+				}
+			}
+			groupSnapshot.Snapshots = append(groupSnapshot.Snapshots, snapshots...)
+		}
+		setMigratedSourceVolumeIDs(ctx, groupSnapshot.Snapshots)
+		return &csi.GetVolumeGroupSnapshotResponse{GroupSnapshot: groupSnapshot}, nil
+	}
+
+	volumeType := prometheus.PrometheusBlockVolumeType
+	start := time.Now()
+	resp, err := getVolumeGroupSnapshotInternal()
+	if err != nil {
+		log.Errorf("Operation failed, reporting failure status to Prometheus."+
+			" Operation Type: %q, Volume Type: %q, Fault Type: %q",
+			prometheus.PrometheusGetVolumeGroupSnapshotOpType, volumeType, "NotComputed")
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType,
+			prometheus.PrometheusGetVolumeGroupSnapshotOpType, prometheus.PrometheusFailStatus,
+			"NotComputed").Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType,
+			prometheus.PrometheusGetVolumeGroupSnapshotOpType, prometheus.PrometheusPassStatus,
+			"").Observe(time.Since(start).Seconds())
+	}
+	return resp, err
+}
@@ -0,0 +1,267 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vanilla
+
+import (
+	"context"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/vmware/govmomi/vim25/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/prometheus"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+)
+
+const (
+	// defaultSnapshotMetadataMaxResults is the number of block ranges sent in
+	// each response of the SnapshotMetadata service when the request does not
+	// set max_results.
+	defaultSnapshotMetadataMaxResults = 256
+	// allocatedAreasChangeID is the change ID for which the changed areas of a
+	// FCD snapshot are all of its allocated areas.
+	allocatedAreasChangeID = "*"
+)
+
+// isSnapshotMetadataServiceEnabled returns true if both the block-volume-snapshot
+// and the snapshot-metadata-service features are enabled.
+func isSnapshotMetadataServiceEnabled(ctx context.Context) bool {
+	return commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.BlockVolumeSnapshot) &&
+		commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.SnapshotMetadataService)
+}
+
+// changedBlockTracker is the part of the volume manager serving the changed
+// block tracking queries of FCD snapshots.
+type changedBlockTracker interface {
+	RetrieveVStorageObject(ctx context.Context, volumeID string) (*types.VStorageObject, error)
+	RetrieveSnapshotDetails(ctx context.Context, volumeID string, snapshotID string) (
+		*types.VStorageObjectSnapshotDetails, error)
+	QueryChangedDiskAreas(ctx context.Context, volumeID string, snapshotID string, startOffset int64,
+		changeID string) (*types.DiskChangeInfo, error)
+}
+
+// sendBlockMetadataFunc sends a response of the SnapshotMetadata service with
+// the given block ranges of a volume of the given capacity.
+type sendBlockMetadataFunc func(volumeCapacityBytes int64, blockMetadata []*csi.BlockMetadata) error
+
+// GetMetadataAllocated streams the allocated block ranges of a block volume
+// snapshot, as reported by the changed block tracking of its FCD.
+func (c *controller) GetMetadataAllocated(req *csi.GetMetadataAllocatedRequest,
+	stream csi.SnapshotMetadata_GetMetadataAllocatedServer) error {
+	start := time.Now()
+	ctx := logger.NewContextWithLogger(stream.Context())
+	log := logger.GetLogger(ctx)
+	// The request is not logged as is, as it may carry secrets.
+	log.Infof("GetMetadataAllocated: called for snapshot %q with starting offset %d and max results %d",
+		req.SnapshotId, req.StartingOffset, req.MaxResults)
+	volumeType := prometheus.PrometheusBlockVolumeType
+
+	getMetadataAllocatedInternal := func() error {
+		if !isSnapshotMetadataServiceEnabled(ctx) {
+			return logger.LogNewErrorCode(log, codes.Unimplemented, "getMetadataAllocated")
+		}
+		volumeID, snapshotID, err := common.ParseCSISnapshotID(req.SnapshotId)
+		if err != nil {
+			return logger.LogNewErrorCode(log, codes.InvalidArgument, err.Error())
+		}
+		_, volumeManager, err := getVCenterAndVolumeManagerForVolumeID(ctx, c, volumeID, volumeInfoService)
+		if err != nil {
+			return logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to get vCenter/volume manager for volume Id: %q. Error: %v", volumeID, err)
+		}
+		return streamAllocatedBlocks(ctx, volumeManager, volumeID, snapshotID, req.StartingOffset,
+			req.MaxResults, func(volumeCapacityBytes int64, blockMetadata []*csi.BlockMetadata) error {
+				return stream.Send(&csi.GetMetadataAllocatedResponse{
+					BlockMetadataType:   csi.BlockMetadataType_VARIABLE_LENGTH,
+					VolumeCapacityBytes: volumeCapacityBytes,
+					BlockMetadata:       blockMetadata,
+				})
+			})
+	}
+
+	err := getMetadataAllocatedInternal()
+	if err != nil {
+		log.Errorf("Operation failed, reporting failure status to Prometheus."+
+			" Operation Type: %q, Volume Type: %q, Fault Type: %q",
+			prometheus.PrometheusGetMetadataAllocatedOpType, volumeType, "NotComputed")
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusGetMetadataAllocatedOpType,
+			prometheus.PrometheusFailStatus, "NotComputed").Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusGetMetadataAllocatedOpType,
+			prometheus.PrometheusPassStatus, "").Observe(time.Since(start).Seconds())
+	}
+	return err
+}
+
+// GetMetadataDelta streams the block ranges which changed between two
+// snapshots of the same block volume, as reported by the changed block
+// tracking of its FCD.
+func (c *controller) GetMetadataDelta(req *csi.GetMetadataDeltaRequest,
+	stream csi.SnapshotMetadata_GetMetadataDeltaServer) error {
+	start := time.Now()
+	ctx := logger.NewContextWithLogger(stream.Context())
+	log := logger.GetLogger(ctx)
+	// The request is not logged as is, as it may carry secrets.
+	log.Infof("GetMetadataDelta: called for base snapshot %q and target snapshot %q with starting offset %d "+
+		"and max results %d", req.BaseSnapshotId, req.TargetSnapshotId, req.StartingOffset, req.MaxResults)
+	volumeType := prometheus.PrometheusBlockVolumeType
+
+	getMetadataDeltaInternal := func() error {
+		if !isSnapshotMetadataServiceEnabled(ctx) {
+			return logger.LogNewErrorCode(log, codes.Unimplemented, "getMetadataDelta")
+		}
+		volumeID, baseSnapshotID, err := common.ParseCSISnapshotID(req.BaseSnapshotId)
+		if err != nil {
+			return logger.LogNewErrorCode(log, codes.InvalidArgument, err.Error())
+		}
+		targetVolumeID, targetSnapshotID, err := common.ParseCSISnapshotID(req.TargetSnapshotId)
+		if err != nil {
+			return logger.LogNewErrorCode(log, codes.InvalidArgument, err.Error())
+		}
+		if targetVolumeID != volumeID {
+			return logger.LogNewErrorCodef(log, codes.InvalidArgument,
+				"base snapshot %q and target snapshot %q are not snapshots of the same volume",
+				req.BaseSnapshotId, req.TargetSnapshotId)
+		}
+		_, volumeManager, err := getVCenterAndVolumeManagerForVolumeID(ctx, c, volumeID, volumeInfoService)
+		if err != nil {
+			return logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to get vCenter/volume manager for volume Id: %q. Error: %v", volumeID, err)
+		}
+		return streamDeltaBlocks(ctx, volumeManager, volumeID, baseSnapshotID, targetSnapshotID,
+			req.StartingOffset, req.MaxResults, func(volumeCapacityBytes int64, blockMetadata []*csi.BlockMetadata) error {
+				return stream.Send(&csi.GetMetadataDeltaResponse{
+					BlockMetadataType:   csi.BlockMetadataType_VARIABLE_LENGTH,
+					VolumeCapacityBytes: volumeCapacityBytes,
+					BlockMetadata:       blockMetadata,
+				})
+			})
+	}
+
+	err := getMetadataDeltaInternal()
+	if err != nil {
+		log.Errorf("Operation failed, reporting failure status to Prometheus."+
+			" Operation Type: %q, Volume Type: %q, Fault Type: %q",
+			prometheus.PrometheusGetMetadataDeltaOpType, volumeType, "NotComputed")
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusGetMetadataDeltaOpType,
+			prometheus.PrometheusFailStatus, "NotComputed").Observe(time.Since(start).Seconds())
+	} else {
+		prometheus.CsiControlOpsHistVec.WithLabelValues(volumeType, prometheus.PrometheusGetMetadataDeltaOpType,
+			prometheus.PrometheusPassStatus, "").Observe(time.Since(start).Seconds())
+	}
+	return err
+}
+
+// streamAllocatedBlocks sends the allocated block ranges of the FCD snapshot,
+// starting at startingOffset.
+func streamAllocatedBlocks(ctx context.Context, tracker changedBlockTracker, volumeID string, snapshotID string,
+	startingOffset int64, maxResults int32, send sendBlockMetadataFunc) error {
+	return streamChangedBlocks(ctx, tracker, volumeID, snapshotID, allocatedAreasChangeID, startingOffset,
+		maxResults, send)
+}
+
+// streamDeltaBlocks sends the block ranges of the target FCD snapshot which
+// changed since the base snapshot, starting at startingOffset.
+func streamDeltaBlocks(ctx context.Context, tracker changedBlockTracker, volumeID string, baseSnapshotID string,
+	targetSnapshotID string, startingOffset int64, maxResults int32, send sendBlockMetadataFunc) error {
+	log := logger.GetLogger(ctx)
+	snapshotDetails, err := tracker.RetrieveSnapshotDetails(ctx, volumeID, baseSnapshotID)
+	if err != nil {
+		return logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to retrieve details of snapshot %q of volume %q. Error: %v", baseSnapshotID, volumeID, err)
+	}
+	if snapshotDetails.ChangedBlockTrackingId == "" {
+		return logger.LogNewErrorCodef(log, codes.FailedPrecondition,
+			"snapshot %q of volume %q has no changed block tracking ID. Changed block tracking may be "+
+				"disabled on the volume", baseSnapshotID, volumeID)
+	}
+	return streamChangedBlocks(ctx, tracker, volumeID, targetSnapshotID, snapshotDetails.ChangedBlockTrackingId,
+		startingOffset, maxResults, send)
+}
+
+// streamChangedBlocks walks the FCD snapshot from startingOffset with changed
+// disk area queries since changeID, and sends the changed areas in batches of
+// at most maxResults block ranges.
+func streamChangedBlocks(ctx context.Context, tracker changedBlockTracker, volumeID string, snapshotID string,
+	changeID string, startingOffset int64, maxResults int32, send sendBlockMetadataFunc) error {
+	log := logger.GetLogger(ctx)
+	if startingOffset < 0 {
+		return logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"starting offset %d cannot be negative", startingOffset)
+	}
+	if maxResults < 0 {
+		return logger.LogNewErrorCodef(log, codes.InvalidArgument,
+			"max results %d cannot be negative", maxResults)
+	}
+	if maxResults == 0 {
+		maxResults = defaultSnapshotMetadataMaxResults
+	}
+	vStorageObject, err := tracker.RetrieveVStorageObject(ctx, volumeID)
+	if err != nil {
+		return logger.LogNewErrorCodef(log, codes.Internal,
+			"failed to retrieve virtual disk of volume %q. Error: %v", volumeID, err)
+	}
+	volumeCapacityBytes := vStorageObject.Config.CapacityInMB * common.MbInBytes
+	if startingOffset >= volumeCapacityBytes {
+		return logger.LogNewErrorCodef(log, codes.OutOfRange,
+			"starting offset %d exceeds the capacity %d of volume %q", startingOffset, volumeCapacityBytes, volumeID)
+	}
+
+	blockMetadata := make([]*csi.BlockMetadata, 0, maxResults)
+	for offset := startingOffset; offset < volumeCapacityBytes; {
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		diskChangeInfo, err := tracker.QueryChangedDiskAreas(ctx, volumeID, snapshotID, offset, changeID)
+		if err != nil {
+			return logger.LogNewErrorCodef(log, codes.Internal,
+				"failed to query changed areas of snapshot %q of volume %q at offset %d. Error: %v",
+				snapshotID, volumeID, offset, err)
+		}
+		for _, area := range diskChangeInfo.ChangedArea {
+			// Skip the areas ending before the requested starting offset.
+			if area.Length <= 0 || area.Start+area.Length <= startingOffset {
+				continue
+			}
+			blockMetadata = append(blockMetadata, &csi.BlockMetadata{
+				ByteOffset: area.Start,
+				SizeBytes:  area.Length,
+			})
+			if len(blockMetadata) == int(maxResults) {
+				if err := send(volumeCapacityBytes, blockMetadata); err != nil {
+					return err
+				}
+				blockMetadata = make([]*csi.BlockMetadata, 0, maxResults)
+			}
+		}
+		nextOffset := diskChangeInfo.StartOffset + diskChangeInfo.Length
+		if nextOffset <= offset {
+			return logger.LogNewErrorCodef(log, codes.Internal,
+				"changed areas query of snapshot %q of volume %q made no progress at offset %d",
+				snapshotID, volumeID, offset)
+		}
+		offset = nextOffset
+	}
+	if len(blockMetadata) > 0 {
+		return send(volumeCapacityBytes, blockMetadata)
+	}
+	return nil
+}
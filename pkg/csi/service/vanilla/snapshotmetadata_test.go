@@ -0,0 +1,182 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vanilla
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware/govmomi/vim25/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+)
+
+// fakeChangedBlockTracker is an in-memory changedBlockTracker of a single FCD.
+type fakeChangedBlockTracker struct {
+	capacityInMB int64
+	// changeIDs maps the snapshot IDs to their changed block tracking IDs.
+	changeIDs map[string]string
+	// changedAreas maps the change IDs to the areas of the snapshots which
+	// changed since them.
+	changedAreas map[string][]types.DiskChangeExtent
+	// queryLength is the length of the disk area covered by each query.
+	queryLength int64
+	queries     int
+}
+
+func (f *fakeChangedBlockTracker) RetrieveVStorageObject(ctx context.Context, volumeID string) (
+	*types.VStorageObject, error) {
+	return &types.VStorageObject{
+		Config: types.VStorageObjectConfigInfo{
+			BaseConfigInfo: types.BaseConfigInfo{Id: types.ID{Id: volumeID}},
+			CapacityInMB:   f.capacityInMB,
+		},
+	}, nil
+}
+
+func (f *fakeChangedBlockTracker) RetrieveSnapshotDetails(ctx context.Context, volumeID string,
+	snapshotID string) (*types.VStorageObjectSnapshotDetails, error) {
+	changeID, ok := f.changeIDs[snapshotID]
+	if !ok {
+		return nil, fmt.Errorf("snapshot %q not found", snapshotID)
+	}
+	return &types.VStorageObjectSnapshotDetails{ChangedBlockTrackingId: changeID}, nil
+}
+
+func (f *fakeChangedBlockTracker) QueryChangedDiskAreas(ctx context.Context, volumeID string, snapshotID string,
+	startOffset int64, changeID string) (*types.DiskChangeInfo, error) {
+	f.queries++
+	length := f.queryLength
+	if capacity := f.capacityInMB * common.MbInBytes; startOffset+length > capacity {
+		length = capacity - startOffset
+	}
+	diskChangeInfo := &types.DiskChangeInfo{StartOffset: startOffset, Length: length}
+	for _, area := range f.changedAreas[changeID] {
+		if area.Start >= startOffset && area.Start < startOffset+length {
+			diskChangeInfo.ChangedArea = append(diskChangeInfo.ChangedArea, area)
+		}
+	}
+	return diskChangeInfo, nil
+}
+
+// blockMetadataCollector collects the responses sent by the SnapshotMetadata
+// service.
+type blockMetadataCollector struct {
+	volumeCapacityBytes int64
+	responses           [][]*csi.BlockMetadata
+}
+
+func (c *blockMetadataCollector) send(volumeCapacityBytes int64, blockMetadata []*csi.BlockMetadata) error {
+	c.volumeCapacityBytes = volumeCapacityBytes
+	c.responses = append(c.responses, blockMetadata)
+	return nil
+}
+
+func (c *blockMetadataCollector) blocks() [][2]int64 {
+	var blocks [][2]int64
+	for _, response := range c.responses {
+		for _, block := range response {
+			blocks = append(blocks, [2]int64{block.ByteOffset, block.SizeBytes})
+		}
+	}
+	return blocks
+}
+
+func newFakeChangedBlockTracker() *fakeChangedBlockTracker {
+	const kb = int64(1024)
+	return &fakeChangedBlockTracker{
+		capacityInMB: 1,
+		changeIDs: map[string]string{
+			"snap-1": "change-1",
+			"snap-2": "change-2",
+			"snap-3": "",
+		},
+		changedAreas: map[string][]types.DiskChangeExtent{
+			allocatedAreasChangeID: {
+				{Start: 0, Length: 64 * kb},
+				{Start: 128 * kb, Length: 64 * kb},
+				{Start: 300 * kb, Length: 4 * kb},
+				{Start: 512 * kb, Length: 128 * kb},
+				{Start: 1000 * kb, Length: 24 * kb},
+			},
+			"change-1": {
+				{Start: 128 * kb, Length: 4 * kb},
+				{Start: 768 * kb, Length: 8 * kb},
+			},
+		},
+		queryLength: 256 * kb,
+	}
+}
+
+func TestStreamAllocatedBlocks(t *testing.T) {
+	const kb = int64(1024)
+	testCtx := context.Background()
+	tracker := newFakeChangedBlockTracker()
+	collector := &blockMetadataCollector{}
+
+	err := streamAllocatedBlocks(testCtx, tracker, "vol-1", "snap-2", 0, 2, collector.send)
+	assert.NoError(t, err)
+	assert.Equal(t, common.MbInBytes, collector.volumeCapacityBytes)
+	// The whole disk is walked in 256 KiB queries.
+	assert.Equal(t, 4, tracker.queries)
+	// The 5 allocated areas are sent in batches of at most 2 block ranges.
+	assert.Len(t, collector.responses, 3)
+	assert.Equal(t, [][2]int64{
+		{0, 64 * kb}, {128 * kb, 64 * kb}, {300 * kb, 4 * kb}, {512 * kb, 128 * kb}, {1000 * kb, 24 * kb},
+	}, collector.blocks())
+}
+
+func TestStreamAllocatedBlocksFromStartingOffset(t *testing.T) {
+	const kb = int64(1024)
+	testCtx := context.Background()
+	tracker := newFakeChangedBlockTracker()
+	collector := &blockMetadataCollector{}
+
+	// Resuming an interrupted stream after the second block range.
+	err := streamAllocatedBlocks(testCtx, tracker, "vol-1", "snap-2", 192*kb, 0, collector.send)
+	assert.NoError(t, err)
+	assert.Len(t, collector.responses, 1)
+	assert.Equal(t, [][2]int64{{300 * kb, 4 * kb}, {512 * kb, 128 * kb}, {1000 * kb, 24 * kb}},
+		collector.blocks())
+
+	err = streamAllocatedBlocks(testCtx, tracker, "vol-1", "snap-2", common.MbInBytes, 0, collector.send)
+	assert.Equal(t, codes.OutOfRange, status.Code(err))
+}
+
+func TestStreamDeltaBlocks(t *testing.T) {
+	const kb = int64(1024)
+	testCtx := context.Background()
+	tracker := newFakeChangedBlockTracker()
+	collector := &blockMetadataCollector{}
+
+	err := streamDeltaBlocks(testCtx, tracker, "vol-1", "snap-1", "snap-2", 0, 0, collector.send)
+	assert.NoError(t, err)
+	assert.Equal(t, [][2]int64{{128 * kb, 4 * kb}, {768 * kb, 8 * kb}}, collector.blocks())
+
+	// The base snapshot has no changed block tracking ID.
+	err = streamDeltaBlocks(testCtx, tracker, "vol-1", "snap-3", "snap-2", 0, 0, collector.send)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// The base snapshot does not exist.
+	err = streamDeltaBlocks(testCtx, tracker, "vol-1", "snap-4", "snap-2", 0, 0, collector.send)
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
@@ -24,12 +24,48 @@ import (
 // created to trigger full sync on demand.
 const TriggerCsiFullSyncCRName = "csifullsync"
 
+// FullSyncReportMaxVolumeIDs is the maximum number of volume IDs listed for
+// each category of a FullSyncReport.
+const FullSyncReportMaxVolumeIDs = 10
+
 // TriggerCsiFullSyncSpec is the spec for TriggerCsiFullSync
 type TriggerCsiFullSyncSpec struct {
 	// TriggerSyncID gives an option to trigger full sync on demand.
 	// Initial value will be 0. In order to trigger a full sync, user
 	// has to set a number that is 1 greater than the previous one.
 	TriggerSyncID uint64 `json:"triggerSyncID"`
+
+	// DryRunSyncID gives an option to run a dry run full sync on demand.
+	// A dry run only computes the volumes which need to be created, updated
+	// or deleted in CNS, without changing them, and reports them in
+	// Status.DryRunReport. In order to run a dry run, user has to set a
+	// number that is 1 greater than the previous one.
+	DryRunSyncID uint64 `json:"dryRunSyncID,omitempty"`
+}
+
+// FullSyncVolumeSummary summarizes the volumes of a FullSyncReport category.
+type FullSyncVolumeSummary struct {
+	// Count is the number of volumes in the category.
+	Count int `json:"count"`
+
+	// VolumeIDs lists the IDs of the first FullSyncReportMaxVolumeIDs volumes
+	// in the category.
+	VolumeIDs []string `json:"volumeIDs,omitempty"`
+}
+
+// FullSyncReport contains the volumes a full sync would act on in CNS.
+type FullSyncReport struct {
+	// VolumesToCreate summarizes volumes present in kubernetes which are
+	// missing in CNS.
+	VolumesToCreate FullSyncVolumeSummary `json:"volumesToCreate"`
+
+	// VolumesToUpdate summarizes volumes whose metadata in CNS differs from
+	// kubernetes.
+	VolumesToUpdate FullSyncVolumeSummary `json:"volumesToUpdate"`
+
+	// VolumesToDelete summarizes volumes present in CNS which are missing in
+	// kubernetes.
+	VolumesToDelete FullSyncVolumeSummary `json:"volumesToDelete"`
 }
 
 // TriggerCsiFullSyncStatus contains the status for a TriggerCsiFullSync
@@ -58,6 +94,12 @@ type TriggerCsiFullSyncStatus struct {
 	// The last error encountered during CSI full sync operation, if any.
 	// Previous error will be cleared when a new full sync is in progress.
 	Error string `json:"error,omitempty"`
+
+	// LastDryRunSyncID indicates the last dry run sync Id.
+	LastDryRunSyncID uint64 `json:"lastDryRunSyncID,omitempty"`
+
+	// DryRunReport is the report of the last successful dry run full sync.
+	DryRunReport *FullSyncReport `json:"dryRunReport,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -22,13 +22,53 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FullSyncReport) DeepCopyInto(out *FullSyncReport) {
+	*out = *in
+	in.VolumesToCreate.DeepCopyInto(&out.VolumesToCreate)
+	in.VolumesToUpdate.DeepCopyInto(&out.VolumesToUpdate)
+	in.VolumesToDelete.DeepCopyInto(&out.VolumesToDelete)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FullSyncReport.
+func (in *FullSyncReport) DeepCopy() *FullSyncReport {
+	if in == nil {
+		return nil
+	}
+	out := new(FullSyncReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FullSyncVolumeSummary) DeepCopyInto(out *FullSyncVolumeSummary) {
+	*out = *in
+	if in.VolumeIDs != nil {
+		in, out := &in.VolumeIDs, &out.VolumeIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FullSyncVolumeSummary.
+func (in *FullSyncVolumeSummary) DeepCopy() *FullSyncVolumeSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(FullSyncVolumeSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TriggerCsiFullSync) DeepCopyInto(out *TriggerCsiFullSync) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -102,6 +142,27 @@ func (in *TriggerCsiFullSyncSpec) DeepCopy() *TriggerCsiFullSyncSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TriggerCsiFullSyncStatus) DeepCopyInto(out *TriggerCsiFullSyncStatus) {
 	*out = *in
+	if in.LastSuccessfulStartTimeStamp != nil {
+		in, out := &in.LastSuccessfulStartTimeStamp, &out.LastSuccessfulStartTimeStamp
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulEndTimeStamp != nil {
+		in, out := &in.LastSuccessfulEndTimeStamp, &out.LastSuccessfulEndTimeStamp
+		*out = (*in).DeepCopy()
+	}
+	if in.LastRunStartTimeStamp != nil {
+		in, out := &in.LastRunStartTimeStamp, &out.LastRunStartTimeStamp
+		*out = (*in).DeepCopy()
+	}
+	if in.LastRunEndTimeStamp != nil {
+		in, out := &in.LastRunEndTimeStamp, &out.LastRunEndTimeStamp
+		*out = (*in).DeepCopy()
+	}
+	if in.DryRunReport != nil {
+		in, out := &in.DryRunReport, &out.DryRunReport
+		*out = new(FullSyncReport)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
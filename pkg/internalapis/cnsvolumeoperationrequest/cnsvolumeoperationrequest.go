@@ -30,6 +30,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	csiconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/tracing"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
 	csitypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/types"
 	cnsvolumeoperationrequestconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsvolumeoperationrequest/config"
@@ -174,11 +175,18 @@ func (or *operationRequestStore) GetRequestDetails(
 		}
 	}
 
-	return CreateVolumeOperationRequestDetails(instance.Spec.Name, instance.Status.VolumeID, instance.Status.SnapshotID,
-			instance.Status.Capacity, quotaDetails, operationDetailsToReturn.TaskInvocationTimestamp,
-			operationDetailsToReturn.TaskID, operationDetailsToReturn.VCenterServer, operationDetailsToReturn.OpID,
-			operationDetailsToReturn.TaskStatus, operationDetailsToReturn.Error),
-		nil
+	details := CreateVolumeOperationRequestDetails(instance.Spec.Name, instance.Status.VolumeID,
+		instance.Status.SnapshotID, instance.Status.Capacity, quotaDetails,
+		operationDetailsToReturn.TaskInvocationTimestamp, operationDetailsToReturn.TaskID,
+		operationDetailsToReturn.VCenterServer, operationDetailsToReturn.OpID,
+		operationDetailsToReturn.TaskStatus, operationDetailsToReturn.Error)
+	for _, member := range instance.Status.GroupSnapshotMembers {
+		details.GroupSnapshotMembers = append(details.GroupSnapshotMembers, GroupSnapshotMember{
+			VolumeID:   member.VolumeID,
+			SnapshotID: member.SnapshotID,
+		})
+	}
+	return details, nil
 }
 
 // StoreRequestDetails persists the details of the operation taking
@@ -196,6 +204,10 @@ func (or *operationRequestStore) StoreRequestDetails(
 	log.Debugf("Storing CnsVolumeOperationRequest instance with spec %v", spew.Sdump(operationToStore))
 
 	operationDetailsToStore := convertToCnsVolumeOperationRequestDetails(*operationToStore.OperationDetails)
+	operationDetailsToStore.TraceID = tracing.TraceID(ctx)
+	if operationDetailsToStore.TaskID != "" {
+		tracing.SetTaskAttributes(ctx, operationDetailsToStore.TaskID, operationDetailsToStore.OpID)
+	}
 	instance := &cnsvolumeoprequestv1alpha1.CnsVolumeOperationRequest{}
 	instanceKey := client.ObjectKey{Name: operationToStore.Name, Namespace: csiNamespace}
 
@@ -215,6 +227,7 @@ func (or *operationRequestStore) StoreRequestDetails(
 				Status: cnsvolumeoprequestv1alpha1.CnsVolumeOperationRequestStatus{
 					VolumeID:              operationToStore.VolumeID,
 					SnapshotID:            operationToStore.SnapshotID,
+					GroupSnapshotMembers:  convertToCnsGroupSnapshotMembers(operationToStore.GroupSnapshotMembers),
 					Capacity:              operationToStore.Capacity,
 					FirstOperationDetails: *operationDetailsToStore,
 					LatestOperationDetails: []cnsvolumeoprequestv1alpha1.OperationDetails{
@@ -262,9 +275,11 @@ func (or *operationRequestStore) StoreRequestDetails(
 	// Create a deep copy since we modify the object.
 	updatedInstance := instance.DeepCopy()
 
-	// Modify VolumeID, SnapshotID and Capacity
+	// Modify VolumeID, SnapshotID, GroupSnapshotMembers and Capacity
 	updatedInstance.Status.VolumeID = operationToStore.VolumeID
 	updatedInstance.Status.SnapshotID = operationToStore.SnapshotID
+	updatedInstance.Status.GroupSnapshotMembers = convertToCnsGroupSnapshotMembers(
+		operationToStore.GroupSnapshotMembers)
 	updatedInstance.Status.Capacity = operationToStore.Capacity
 	if isPodVMOnStretchSupervisorFSSEnabled && operationToStore.QuotaDetails != nil {
 		updatedInstance.Status.StorageQuotaDetails = &cnsvolumeoprequestv1alpha1.QuotaDetails{
@@ -423,7 +438,9 @@ func (or *operationRequestStore) cleanupStaleInstances(cleanupInterval int, isBl
 					instanceMap[strings.TrimPrefix(vsc.Name, "snapcontent-")+"-"+*volumeHandle] = true
 				}
 				if vsc.Status != nil && vsc.Status.SnapshotHandle != nil {
-					// CnsVolumeOperation instance for DeleteSnapshot
+					// CnsVolumeOperation instance for DeleteSnapshot. The handles also
+					// identify the members of CnsVolumeOperation instances for
+					// CreateVolumeGroupSnapshot.
 					instanceMap[strings.Replace(*vsc.Status.SnapshotHandle, "+", "-", 1)] = true
 				}
 			}
@@ -448,6 +465,16 @@ func (or *operationRequestStore) cleanupStaleInstances(cleanupInterval int, isBl
 				trimmedName = strings.TrimPrefix(instance.Name, "snapshot-")
 			case blockVolumeSnapshotEnabled && strings.HasPrefix(instance.Name, "deletesnapshot"):
 				trimmedName = strings.TrimPrefix(instance.Name, "deletesnapshot-")
+			case blockVolumeSnapshotEnabled && strings.HasPrefix(instance.Name, "groupsnapshot"):
+				// Keep the instance of a volume group snapshot as long as any of
+				// its member snapshots exists.
+				for _, member := range instance.Status.GroupSnapshotMembers {
+					memberName := member.VolumeID + "-" + member.SnapshotID
+					if instanceMap[memberName] {
+						trimmedName = memberName
+						break
+					}
+				}
 			}
 			if _, ok := instanceMap[trimmedName]; !ok {
 				err = or.DeleteRequestDetails(ctx, instance.Name)
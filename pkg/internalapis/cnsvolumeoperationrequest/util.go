@@ -48,6 +48,16 @@ type VolumeOperationRequestDetails struct {
 	Capacity         int64
 	QuotaDetails     *QuotaDetails
 	OperationDetails *OperationDetails
+	// GroupSnapshotMembers are the snapshots of the volumes of a volume group
+	// snapshot.
+	GroupSnapshotMembers []GroupSnapshotMember
+}
+
+// GroupSnapshotMember stores the volume and backend snapshot IDs of a
+// snapshot in a volume group snapshot.
+type GroupSnapshotMember struct {
+	VolumeID   string
+	SnapshotID string
 }
 
 // QuotaDetails stores information required to interact with the custom
@@ -107,3 +117,18 @@ func convertToCnsVolumeOperationRequestDetails(
 		Error:                   details.Error,
 	}
 }
+
+// convertToCnsGroupSnapshotMembers converts the members of a volume group
+// snapshot to the GroupSnapshotMember type defined by the
+// CnsVolumeOperationRequest Custom Resource.
+func convertToCnsGroupSnapshotMembers(
+	members []GroupSnapshotMember) []cnsvolumeoprequestv1alpha1.GroupSnapshotMember {
+	var cnsMembers []cnsvolumeoprequestv1alpha1.GroupSnapshotMember
+	for _, member := range members {
+		cnsMembers = append(cnsMembers, cnsvolumeoprequestv1alpha1.GroupSnapshotMember{
+			VolumeID:   member.VolumeID,
+			SnapshotID: member.SnapshotID,
+		})
+	}
+	return cnsMembers
+}
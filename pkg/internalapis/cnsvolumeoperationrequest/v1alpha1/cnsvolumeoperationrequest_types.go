@@ -36,6 +36,9 @@ type CnsVolumeOperationRequestStatus struct {
 	// SnapshotID is the unique ID of the backend snapshot.
 	// Populated during successful CreateSnapshot calls.
 	SnapshotID string `json:"snapshotID,omitempty"`
+	// GroupSnapshotMembers are the volumes and backend snapshots of a volume group snapshot.
+	// Populated during successful CreateVolumeGroupSnapshot calls.
+	GroupSnapshotMembers []GroupSnapshotMember `json:"groupSnapshotMembers,omitempty"`
 	// Populated with the latest capacity on every successful ExtendVolume call for a volume.
 	Capacity int64 `json:"capacity,omitempty"`
 	// ErrorCount is the number of times this operation failed for this volume.
@@ -53,6 +56,15 @@ type CnsVolumeOperationRequestStatus struct {
 	// on the volume. Should have a maximum of 10 entries.
 	LatestOperationDetails []OperationDetails `json:"latestOperationDetails,omitempty"`
 }
+
+// GroupSnapshotMember stores a backend snapshot of a volume group snapshot.
+type GroupSnapshotMember struct {
+	// VolumeID is the unique ID of the backend volume.
+	VolumeID string `json:"volumeID"`
+	// SnapshotID is the unique ID of the backend snapshot of the volume.
+	SnapshotID string `json:"snapshotID"`
+}
+
 type QuotaDetails struct {
 	// Reserved keeps a track of the quantity that should be reserved in
 	// storage quota during a create volume/snapshot operation.
@@ -89,6 +101,9 @@ type OperationDetails struct {
 	// Error represents the error returned if the task fails on CNS.
 	// Defaults to empty string.
 	Error string `json:"error,omitempty"`
+	// TraceID is the ID of the trace of the request that invoked the task,
+	// if tracing is enabled.
+	TraceID string `json:"traceId,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -101,6 +101,11 @@ func (in *CnsVolumeOperationRequestSpec) DeepCopy() *CnsVolumeOperationRequestSp
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CnsVolumeOperationRequestStatus) DeepCopyInto(out *CnsVolumeOperationRequestStatus) {
 	*out = *in
+	if in.GroupSnapshotMembers != nil {
+		in, out := &in.GroupSnapshotMembers, &out.GroupSnapshotMembers
+		*out = make([]GroupSnapshotMember, len(*in))
+		copy(*out, *in)
+	}
 	in.FirstOperationDetails.DeepCopyInto(&out.FirstOperationDetails)
 	if in.LatestOperationDetails != nil {
 		in, out := &in.LatestOperationDetails, &out.LatestOperationDetails
@@ -121,6 +126,21 @@ func (in *CnsVolumeOperationRequestStatus) DeepCopy() *CnsVolumeOperationRequest
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GroupSnapshotMember) DeepCopyInto(out *GroupSnapshotMember) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupSnapshotMember.
+func (in *GroupSnapshotMember) DeepCopy() *GroupSnapshotMember {
+	if in == nil {
+		return nil
+	}
+	out := new(GroupSnapshotMember)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OperationDetails) DeepCopyInto(out *OperationDetails) {
 	*out = *in
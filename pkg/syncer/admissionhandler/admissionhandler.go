@@ -61,6 +61,7 @@ var (
 	featureGateVolumeHealthEnabled            bool
 	featureGateTopologyAwareFileVolumeEnabled bool
 	featureGateStorageQuotaM2Enabled          bool
+	featureGateDiskFormatEnabled              bool
 )
 
 // watchConfigChange watches on the webhook configuration directory for changes
@@ -161,8 +162,9 @@ func StartWebhookServer(ctx context.Context) error {
 		featureGateBlockVolumeSnapshotEnabled = containerOrchestratorUtility.IsFSSEnabled(ctx, common.BlockVolumeSnapshot)
 		featureGateTopologyAwareFileVolumeEnabled = containerOrchestratorUtility.IsFSSEnabled(ctx,
 			common.TopologyAwareFileVolume)
+		featureGateDiskFormatEnabled = containerOrchestratorUtility.IsFSSEnabled(ctx, common.DiskFormat)
 
-		if featureGateCsiMigrationEnabled || featureGateBlockVolumeSnapshotEnabled {
+		if featureGateCsiMigrationEnabled || featureGateBlockVolumeSnapshotEnabled || featureGateDiskFormatEnabled {
 			certs, err := tls.LoadX509KeyPair(cfg.WebHookConfig.CertFile, cfg.WebHookConfig.KeyFile)
 			if err != nil {
 				log.Errorf("failed to load key pair. certFile: %q, keyFile: %q err: %v",
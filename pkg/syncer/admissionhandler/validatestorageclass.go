@@ -19,6 +19,8 @@ package admissionhandler
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
 
 	admissionv1 "k8s.io/api/admission/v1"
 	stroagev1 "k8s.io/api/storage/v1"
@@ -45,12 +47,14 @@ var (
 const (
 	migrationParamErrorMessage = "Invalid StorageClass Parameters. " +
 		"Migration specific parameters should not be used in the StorageClass"
+	diskFormatParamErrorMessage = "Invalid StorageClass Parameters. " +
+		"Supported values for parameter diskformat are thin, lazyzeroedthick and eagerzeroedthick"
 )
 
 // validateStorageClass helps validate AdmissionReview requests for StroageClass.
 func validateStorageClass(ctx context.Context, ar *admissionv1.AdmissionReview) *admissionv1.AdmissionResponse {
-	if !featureGateCsiMigrationEnabled {
-		// If CSI migration is disabled and webhook is running,
+	if !featureGateCsiMigrationEnabled && !featureGateDiskFormatEnabled {
+		// If CSI migration and diskformat are disabled and webhook is running,
 		// skip validation for StorageClass.
 		return &admissionv1.AdmissionResponse{
 			Allowed: true,
@@ -74,7 +78,7 @@ func validateStorageClass(ctx context.Context, ar *admissionv1.AdmissionReview)
 			}
 		}
 		log.Infof("Validating StorageClass: %q", sc.Name)
-		if sc.Provisioner == "csi.vsphere.vmware.com" {
+		if sc.Provisioner == "csi.vsphere.vmware.com" && featureGateCsiMigrationEnabled {
 			// Migration parameters check for csi.vsphere.vmware.com provisioner.
 			for param := range sc.Parameters {
 				if unSupportedParameters.Has(param) {
@@ -86,6 +90,16 @@ func validateStorageClass(ctx context.Context, ar *admissionv1.AdmissionReview)
 				}
 			}
 		}
+		if allowed && sc.Provisioner == "csi.vsphere.vmware.com" && featureGateDiskFormatEnabled {
+			// diskformat parameter check for csi.vsphere.vmware.com provisioner.
+			if err := validateDiskFormatParameter(sc.Parameters); err != nil {
+				allowed = false
+				result = &metav1.Status{
+					Reason:  diskFormatParamErrorMessage,
+					Message: err.Error(),
+				}
+			}
+		}
 		if allowed {
 			log.Infof("Validation of StorageClass: %q Passed", sc.Name)
 		} else {
@@ -101,3 +115,17 @@ func validateStorageClass(ctx context.Context, ar *admissionv1.AdmissionReview)
 		Result:  result,
 	}
 }
+
+// validateDiskFormatParameter validates the value of the diskformat parameter
+// in the given StorageClass parameters.
+func validateDiskFormatParameter(params map[string]string) error {
+	for param, value := range params {
+		if strings.ToLower(param) != common.AttributeDiskFormat {
+			continue
+		}
+		if _, ok := common.DiskFormatToProvisioningType[strings.ToLower(value)]; !ok {
+			return fmt.Errorf("invalid value %q for parameter %q", value, param)
+		}
+	}
+	return nil
+}
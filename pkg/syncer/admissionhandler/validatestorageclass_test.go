@@ -119,3 +119,45 @@ func TestValidateStorageClassForValidStorageClass(t *testing.T) {
 	}
 	t.Log("TestValidateStorageClassForValidStorageClass Passed")
 }
+
+// TestValidateStorageClassForDiskFormatParameter is the unit test for
+// validating admissionReview request containing StorageClass with the
+// diskformat parameter.
+func TestValidateStorageClassForDiskFormatParameter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	featureGateCsiMigrationEnabled = false
+	featureGateDiskFormatEnabled = true
+	defer func() {
+		featureGateCsiMigrationEnabled = true
+		featureGateDiskFormatEnabled = false
+	}()
+	admissionReview.Request.Object = runtime.RawExtension{
+		Raw: []byte("{\n  \"kind\": \"StorageClass\",\n  \"apiVersion\": \"storage.k8s.io/v1\",\n  \"metadata\": " +
+			"{\n    \"name\": \"sc\",\n    \"uid\": \"0c1f0b9e-3c55-4d1c-9d52-6f4c1b0f8d21\",\n    " +
+			"\"creationTimestamp\": \"2024-05-14T10:12:00Z\"\n  },\n  " +
+			"\"provisioner\": \"csi.vsphere.vmware.com\",\n  " +
+			"\"parameters\": {\n    \"diskformat\": \"eagerzeroedthick\"\n  },\n  " +
+			"\"reclaimPolicy\": \"Delete\",\n  \"volumeBindingMode\": \"Immediate\"\n}"),
+	}
+	admissionResponse := validateStorageClass(ctx, &admissionReview)
+	if admissionResponse.Result != nil || !admissionResponse.Allowed {
+		t.Fatalf("TestValidateStorageClassForDiskFormatParameter failed. "+
+			"admissionReview.Request: %v, admissionResponse: %v", admissionReview.Request, admissionResponse)
+	}
+	admissionReview.Request.Object = runtime.RawExtension{
+		Raw: []byte("{\n  \"kind\": \"StorageClass\",\n  \"apiVersion\": \"storage.k8s.io/v1\",\n  \"metadata\": " +
+			"{\n    \"name\": \"sc\",\n    \"uid\": \"0c1f0b9e-3c55-4d1c-9d52-6f4c1b0f8d21\",\n    " +
+			"\"creationTimestamp\": \"2024-05-14T10:12:00Z\"\n  },\n  " +
+			"\"provisioner\": \"csi.vsphere.vmware.com\",\n  " +
+			"\"parameters\": {\n    \"diskformat\": \"zeroedthick\"\n  },\n  " +
+			"\"reclaimPolicy\": \"Delete\",\n  \"volumeBindingMode\": \"Immediate\"\n}"),
+	}
+	admissionResponse = validateStorageClass(ctx, &admissionReview)
+	if admissionResponse.Allowed ||
+		!strings.Contains(string(admissionResponse.Result.Reason), diskFormatParamErrorMessage) {
+		t.Fatalf("TestValidateStorageClassForDiskFormatParameter failed. "+
+			"admissionReview.Request: %v, admissionResponse: %v", admissionReview.Request, admissionResponse)
+	}
+	t.Log("TestValidateStorageClassForDiskFormatParameter Passed")
+}
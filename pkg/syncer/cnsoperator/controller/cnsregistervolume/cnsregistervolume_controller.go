@@ -31,6 +31,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	apitypes "k8s.io/apimachinery/pkg/types"
+	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/record"
@@ -56,6 +57,7 @@ import (
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsvolumeinfo"
 	k8s "sigs.k8s.io/vsphere-csi-driver/v3/pkg/kubernetes"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer"
+	cnsoperatorutil "sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer/cnsoperator/util"
 )
 
 const (
@@ -85,7 +87,13 @@ var (
 func Add(mgr manager.Manager, clusterFlavor cnstypes.CnsClusterFlavor,
 	configInfo *commonconfig.ConfigurationInfo, volumeManager volumes.Manager) error {
 	ctx, log := logger.GetNewContextWithLogger()
-	if clusterFlavor != cnstypes.CnsClusterFlavorWorkload {
+	if clusterFlavor == cnstypes.CnsClusterFlavorVanilla {
+		if !commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.VanillaCnsRegisterVolume) {
+			log.Debug("Not initializing the CnsRegisterVolume Controller as the feature is disabled " +
+				"for vanilla CSI deployment")
+			return nil
+		}
+	} else if clusterFlavor != cnstypes.CnsClusterFlavorWorkload {
 		log.Debug("Not initializing the CnsRegisterVolume Controller as its a non-WCP CSI deployment")
 		return nil
 	}
@@ -121,6 +129,15 @@ func Add(mgr manager.Manager, clusterFlavor cnstypes.CnsClusterFlavor,
 			}
 		}
 	}
+	volumeManagers := map[string]volumes.Manager{}
+	if clusterFlavor == cnstypes.CnsClusterFlavorVanilla {
+		var err error
+		volumeManagers, err = cnsoperatorutil.GetVanillaVolumeManagers(ctx, configInfo, volumeManager)
+		if err != nil {
+			log.Errorf("failed to get volume managers for CnsRegisterVolume Controller. Err: %v", err)
+			return err
+		}
+	}
 	// Initializes kubernetes client.
 	k8sclient, err := k8s.NewClient(ctx)
 	if err != nil {
@@ -137,15 +154,18 @@ func Add(mgr manager.Manager, clusterFlavor cnstypes.CnsClusterFlavor,
 		},
 	)
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: apis.GroupName})
-	return add(mgr, newReconciler(mgr, configInfo, volumeManager, recorder, volumeInfoService))
+	return add(mgr, newReconciler(mgr, clusterFlavor, configInfo, volumeManager, volumeManagers, recorder,
+		volumeInfoService))
 }
 
 // newReconciler returns a new reconcile.Reconciler.
-func newReconciler(mgr manager.Manager, configInfo *commonconfig.ConfigurationInfo,
-	volumeManager volumes.Manager, recorder record.EventRecorder,
+func newReconciler(mgr manager.Manager, clusterFlavor cnstypes.CnsClusterFlavor,
+	configInfo *commonconfig.ConfigurationInfo, volumeManager volumes.Manager,
+	volumeManagers map[string]volumes.Manager, recorder record.EventRecorder,
 	volumeInfoService cnsvolumeinfo.VolumeInfoService) reconcile.Reconciler {
 	return &ReconcileCnsRegisterVolume{client: mgr.GetClient(), scheme: mgr.GetScheme(),
-		configInfo: configInfo, volumeManager: volumeManager, recorder: recorder, volumeInfoService: volumeInfoService}
+		clusterFlavor: clusterFlavor, configInfo: configInfo, volumeManager: volumeManager,
+		volumeManagers: volumeManagers, recorder: recorder, volumeInfoService: volumeInfoService}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler.
@@ -181,10 +201,14 @@ var _ reconcile.Reconciler = &ReconcileCnsRegisterVolume{}
 type ReconcileCnsRegisterVolume struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver.
-	client            client.Client
-	scheme            *runtime.Scheme
-	configInfo        *commonconfig.ConfigurationInfo
-	volumeManager     volumes.Manager
+	client        client.Client
+	scheme        *runtime.Scheme
+	clusterFlavor cnstypes.CnsClusterFlavor
+	configInfo    *commonconfig.ConfigurationInfo
+	volumeManager volumes.Manager
+	// volumeManagers maps vCenter host to its volume manager. It is only
+	// populated for vanilla clusters.
+	volumeManagers    map[string]volumes.Manager
 	recorder          record.EventRecorder
 	volumeInfoService cnsvolumeinfo.VolumeInfoService
 }
@@ -249,6 +273,9 @@ func (r *ReconcileCnsRegisterVolume) Reconcile(ctx context.Context,
 		setInstanceError(ctx, r, instance, msg)
 		return reconcile.Result{RequeueAfter: timeout}, nil
 	}
+	if r.clusterFlavor == cnstypes.CnsClusterFlavorVanilla {
+		return r.reconcileForVanilla(ctx, instance, timeout)
+	}
 
 	vc, err := cnsvsphere.GetVirtualCenterInstance(ctx, r.configInfo, false)
 	if err != nil {
@@ -263,7 +290,7 @@ func (r *ReconcileCnsRegisterVolume) Reconcile(ctx context.Context,
 		pvNodeAffinity *v1.VolumeNodeAffinity
 	)
 	// Create Volume for the input CnsRegisterVolume instance.
-	createSpec := constructCreateSpecForInstance(r, instance, vc.Config.Host, isTKGSHAEnabled)
+	createSpec := constructCreateSpecForInstance(r, instance, vc.Config.Username, isTKGSHAEnabled)
 	log.Infof("Creating CNS volume: %+v for CnsRegisterVolume request with name: %q on namespace: %q",
 		instance, instance.Name, instance.Namespace)
 	log.Debugf("CNS Volume create spec is: %+v", createSpec)
@@ -439,112 +466,13 @@ func (r *ReconcileCnsRegisterVolume) Reconcile(ctx context.Context,
 	}
 
 	capacityInMb := volume.BackingObjectDetails.GetCnsBackingObjectDetails().CapacityInMb
-	accessMode := instance.Spec.AccessMode
-	// Set accessMode to ReadWriteOnce if DiskURLPath is used for import.
-	if accessMode == "" && instance.Spec.DiskURLPath != "" {
-		accessMode = v1.ReadWriteOnce
-	}
-	pv, err := k8sclient.CoreV1().PersistentVolumes().Get(ctx, pvName, metav1.GetOptions{})
-	if err != nil {
-		if apierrors.IsNotFound(err) {
-			log.Infof("PV: %s not found. Creating a new PV", pvName)
-			// Create Persistent volume with claimRef.
-			claimRef := &v1.ObjectReference{
-				Kind:       "PersistentVolumeClaim",
-				APIVersion: "v1",
-				Namespace:  instance.Namespace,
-				Name:       instance.Spec.PvcName,
-			}
-			pvSpec := getPersistentVolumeSpec(pvName, volumeID, capacityInMb,
-				accessMode, storageClassName, claimRef)
-			pvSpec.Spec.NodeAffinity = pvNodeAffinity
-			log.Debugf("PV spec is: %+v", pvSpec)
-			pv, err = k8sclient.CoreV1().PersistentVolumes().Create(ctx, pvSpec, metav1.CreateOptions{})
-			if err != nil {
-				log.Errorf("Failed to create PV with spec: %+v. Error: %+v", pvSpec, err)
-				setInstanceError(ctx, r, instance,
-					fmt.Sprintf("Failed to create PV: %s for volume with err: %+v", pvName, err))
-				return reconcile.Result{RequeueAfter: timeout}, nil
-			}
-			log.Infof("PV: %s is created successfully", pvName)
-		} else {
-			msg := fmt.Sprintf("Failed to get PV: %s with error: %+v", pvName, err)
-			log.Error(msg)
-			setInstanceError(ctx, r, instance, msg)
-			return reconcile.Result{RequeueAfter: timeout}, nil
-		}
-	}
-	// If PV is already bound to a different PVC at this point, then its a
-	// duplicate request.
-	if pv.Status.Phase == v1.VolumeBound && pv.Spec.ClaimRef.Name != instance.Spec.PvcName {
-		log.Errorf("Duplicate Request. There already exists a PV: %s which is bound", pvName)
-		setInstanceError(ctx, r, instance, "Duplicate Request")
-		return reconcile.Result{RequeueAfter: timeout}, nil
-	}
-	// Create PVC mapping to above created PV.
-	log.Infof("Creating PVC: %s", instance.Spec.PvcName)
-	pvcSpec, err := getPersistentVolumeClaimSpec(ctx, instance.Spec.PvcName, instance.Namespace, capacityInMb,
-		storageClassName, accessMode, pvName, datastoreAccessibleTopology)
+	pvc, err := r.createStaticPVAndPVC(ctx, k8sclient, r.volumeManager, instance, volumeID, pvName, capacityInMb,
+		storageClassName, pvNodeAffinity, datastoreAccessibleTopology)
 	if err != nil {
-		msg := fmt.Sprintf("Failed to create spec for PVC: %q. Error: %v", instance.Spec.PvcName, err)
-		log.Errorf(msg)
-		setInstanceError(ctx, r, instance, msg)
 		return reconcile.Result{RequeueAfter: timeout}, nil
 	}
-	log.Debugf("PVC spec is: %+v", pvcSpec)
-	pvc, err := k8sclient.CoreV1().PersistentVolumeClaims(instance.Namespace).Create(ctx,
-		pvcSpec, metav1.CreateOptions{})
-	if err != nil {
-		if apierrors.IsAlreadyExists(err) {
-			log.Infof("PVC: %s already exists", instance.Spec.PvcName)
-			pvc, err = k8sclient.CoreV1().PersistentVolumeClaims(instance.Namespace).Get(ctx,
-				instance.Spec.PvcName, metav1.GetOptions{})
-			if err != nil {
-				msg := fmt.Sprintf("Failed to get PVC: %s on namespace: %s", instance.Spec.PvcName, instance.Namespace)
-				log.Errorf(msg)
-				setInstanceError(ctx, r, instance, msg)
-				return reconcile.Result{RequeueAfter: timeout}, nil
-			}
-			if pvc.Status.Phase == v1.ClaimBound && pvc.Spec.VolumeName != pvName {
-				// This is handle cases where PVC with this name already exists and
-				// is bound. This happens when a new CnsRegisterVolume instance is
-				// created to import a new volume with PVC name which is already
-				// created and is bound.
-				msg := fmt.Sprintf("Another PVC: %s already exists in namespace: %s which is Bound to a different PV",
-					instance.Spec.PvcName, instance.Namespace)
-				log.Errorf(msg)
-				setInstanceError(ctx, r, instance, msg)
-				// Untag the CNS volume which was created previously.
-				_, err = common.DeleteVolumeUtil(ctx, r.volumeManager, volumeID, false)
-				if err != nil {
-					log.Errorf("Failed to untag CNS volume: %s with error: %+v", volumeID, err)
-				} else {
-					// Delete PV created above.
-					err = k8sclient.CoreV1().PersistentVolumes().Delete(ctx, pvName, *metav1.NewDeleteOptions(0))
-					if err != nil {
-						log.Errorf("Failed to delete PV: %s with error: %+v", pvName, err)
-					}
-				}
-				return reconcile.Result{RequeueAfter: timeout}, nil
-			}
-		} else {
-			log.Errorf("Failed to create PVC with spec: %+v. Error: %+v", pvcSpec, err)
-			setInstanceError(ctx, r, instance,
-				fmt.Sprintf("Failed to create PVC: %s for volume with err: %+v", instance.Spec.PvcName, err))
-			// Delete PV created above.
-			err = k8sclient.CoreV1().PersistentVolumes().Delete(ctx, pvName, *metav1.NewDeleteOptions(0))
-			if err != nil {
-				log.Errorf("Delete PV %s failed with error: %+v", pvName, err)
-			}
-			setInstanceError(ctx, r, instance,
-				fmt.Sprintf("Delete PV %s failed with error: %+v", pvName, err))
-			return reconcile.Result{RequeueAfter: timeout}, nil
-		}
-	} else {
-		log.Infof("PVC: %s is created successfully", instance.Spec.PvcName)
-	}
 	// Watch for PVC to be bound.
-	isBound, err := isPVCBound(ctx, k8sclient, pvc, time.Duration(1*time.Minute))
+	isBound, err := cnsoperatorutil.IsPVCBound(ctx, k8sclient, pvc, time.Duration(1*time.Minute))
 	if isBound {
 		log.Infof("PVC: %s is bound", instance.Spec.PvcName)
 		if syncer.IsPodVMOnStretchSupervisorFSSEnabled {
@@ -666,6 +594,121 @@ func (r *ReconcileCnsRegisterVolume) Reconcile(ctx context.Context,
 	return reconcile.Result{}, nil
 }
 
+// createStaticPVAndPVC creates the PV for the registered volume and the PVC
+// requested by the CnsRegisterVolume instance which binds to it. On failure the
+// error is recorded on the instance and returned.
+func (r *ReconcileCnsRegisterVolume) createStaticPVAndPVC(ctx context.Context, k8sclient clientset.Interface,
+	volumeManager volumes.Manager, instance *cnsregistervolumev1alpha1.CnsRegisterVolume, volumeID string,
+	pvName string, capacityInMb int64, storageClassName string, pvNodeAffinity *v1.VolumeNodeAffinity,
+	datastoreAccessibleTopology []map[string]string) (*v1.PersistentVolumeClaim, error) {
+	log := logger.GetLogger(ctx)
+	accessMode := instance.Spec.AccessMode
+	// Set accessMode to ReadWriteOnce if DiskURLPath is used for import.
+	if accessMode == "" && instance.Spec.DiskURLPath != "" {
+		accessMode = v1.ReadWriteOnce
+	}
+	pv, err := k8sclient.CoreV1().PersistentVolumes().Get(ctx, pvName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Infof("PV: %s not found. Creating a new PV", pvName)
+			// Create Persistent volume with claimRef.
+			claimRef := &v1.ObjectReference{
+				Kind:       "PersistentVolumeClaim",
+				APIVersion: "v1",
+				Namespace:  instance.Namespace,
+				Name:       instance.Spec.PvcName,
+			}
+			pvSpec := cnsoperatorutil.GetPersistentVolumeSpec(pvName, volumeID, capacityInMb,
+				accessMode, storageClassName, claimRef)
+			pvSpec.Spec.NodeAffinity = pvNodeAffinity
+			log.Debugf("PV spec is: %+v", pvSpec)
+			pv, err = k8sclient.CoreV1().PersistentVolumes().Create(ctx, pvSpec, metav1.CreateOptions{})
+			if err != nil {
+				log.Errorf("Failed to create PV with spec: %+v. Error: %+v", pvSpec, err)
+				setInstanceError(ctx, r, instance,
+					fmt.Sprintf("Failed to create PV: %s for volume with err: %+v", pvName, err))
+				return nil, errors.New(instance.Status.Error)
+			}
+			log.Infof("PV: %s is created successfully", pvName)
+		} else {
+			msg := fmt.Sprintf("Failed to get PV: %s with error: %+v", pvName, err)
+			log.Error(msg)
+			setInstanceError(ctx, r, instance, msg)
+			return nil, errors.New(instance.Status.Error)
+		}
+	}
+	// If PV is already bound to a different PVC at this point, then its a
+	// duplicate request.
+	if pv.Status.Phase == v1.VolumeBound && pv.Spec.ClaimRef.Name != instance.Spec.PvcName {
+		log.Errorf("Duplicate Request. There already exists a PV: %s which is bound", pvName)
+		setInstanceError(ctx, r, instance, "Duplicate Request")
+		return nil, errors.New(instance.Status.Error)
+	}
+	// Create PVC mapping to above created PV.
+	log.Infof("Creating PVC: %s", instance.Spec.PvcName)
+	pvcSpec, err := cnsoperatorutil.GetPersistentVolumeClaimSpec(ctx, instance.Spec.PvcName, instance.Namespace,
+		capacityInMb, storageClassName, accessMode, pvName, datastoreAccessibleTopology)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to create spec for PVC: %q. Error: %v", instance.Spec.PvcName, err)
+		log.Errorf(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return nil, errors.New(instance.Status.Error)
+	}
+	log.Debugf("PVC spec is: %+v", pvcSpec)
+	pvc, err := k8sclient.CoreV1().PersistentVolumeClaims(instance.Namespace).Create(ctx,
+		pvcSpec, metav1.CreateOptions{})
+	if err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			log.Infof("PVC: %s already exists", instance.Spec.PvcName)
+			pvc, err = k8sclient.CoreV1().PersistentVolumeClaims(instance.Namespace).Get(ctx,
+				instance.Spec.PvcName, metav1.GetOptions{})
+			if err != nil {
+				msg := fmt.Sprintf("Failed to get PVC: %s on namespace: %s", instance.Spec.PvcName, instance.Namespace)
+				log.Errorf(msg)
+				setInstanceError(ctx, r, instance, msg)
+				return nil, errors.New(instance.Status.Error)
+			}
+			if pvc.Status.Phase == v1.ClaimBound && pvc.Spec.VolumeName != pvName {
+				// This is handle cases where PVC with this name already exists and
+				// is bound. This happens when a new CnsRegisterVolume instance is
+				// created to import a new volume with PVC name which is already
+				// created and is bound.
+				msg := fmt.Sprintf("Another PVC: %s already exists in namespace: %s which is Bound to a different PV",
+					instance.Spec.PvcName, instance.Namespace)
+				log.Errorf(msg)
+				setInstanceError(ctx, r, instance, msg)
+				// Untag the CNS volume which was created previously.
+				_, err = common.DeleteVolumeUtil(ctx, volumeManager, volumeID, false)
+				if err != nil {
+					log.Errorf("Failed to untag CNS volume: %s with error: %+v", volumeID, err)
+				} else {
+					// Delete PV created above.
+					err = k8sclient.CoreV1().PersistentVolumes().Delete(ctx, pvName, *metav1.NewDeleteOptions(0))
+					if err != nil {
+						log.Errorf("Failed to delete PV: %s with error: %+v", pvName, err)
+					}
+				}
+				return nil, errors.New(instance.Status.Error)
+			}
+		} else {
+			log.Errorf("Failed to create PVC with spec: %+v. Error: %+v", pvcSpec, err)
+			setInstanceError(ctx, r, instance,
+				fmt.Sprintf("Failed to create PVC: %s for volume with err: %+v", instance.Spec.PvcName, err))
+			// Delete PV created above.
+			err = k8sclient.CoreV1().PersistentVolumes().Delete(ctx, pvName, *metav1.NewDeleteOptions(0))
+			if err != nil {
+				log.Errorf("Delete PV %s failed with error: %+v", pvName, err)
+			}
+			setInstanceError(ctx, r, instance,
+				fmt.Sprintf("Delete PV %s failed with error: %+v", pvName, err))
+			return nil, errors.New(instance.Status.Error)
+		}
+	} else {
+		log.Infof("PVC: %s is created successfully", instance.Spec.PvcName)
+	}
+	return pvc, nil
+}
+
 // validateCnsRegisterVolumeSpec validates the input params of
 // CnsRegisterVolume instance.
 func validateCnsRegisterVolumeSpec(ctx context.Context, instance *cnsregistervolumev1alpha1.CnsRegisterVolume) error {
@@ -0,0 +1,175 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cnsregistervolume
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	cnsregistervolumev1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsregistervolume/v1alpha1"
+	volumes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+	k8s "sigs.k8s.io/vsphere-csi-driver/v3/pkg/kubernetes"
+	cnsoperatorutil "sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer/cnsoperator/util"
+)
+
+// reconcileForVanilla registers the volume in the CnsRegisterVolume instance
+// with CNS and creates a PV and PVC for it in a vanilla cluster.
+func (r *ReconcileCnsRegisterVolume) reconcileForVanilla(ctx context.Context,
+	instance *cnsregistervolumev1alpha1.CnsRegisterVolume, timeout time.Duration) (reconcile.Result, error) {
+	log := logger.GetLogger(ctx)
+	vcHost, volumeManager, err := r.getVolumeManagerForInstance(ctx, instance)
+	if err != nil {
+		log.Error(err)
+		setInstanceError(ctx, r, instance, err.Error())
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	vc, err := cnsvsphere.GetVirtualCenterManager(ctx).GetVirtualCenter(ctx, vcHost)
+	if err != nil {
+		log.Errorf("Failed to get virtual center instance for %q with error: %+v", vcHost, err)
+		setInstanceError(ctx, r, instance, "Unable to connect to VC for volume registration")
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	// Create Volume for the input CnsRegisterVolume instance.
+	createSpec := constructCreateSpecForInstance(r, instance, vc.Config.Username, false)
+	log.Infof("Creating CNS volume: %+v for CnsRegisterVolume request with name: %q on namespace: %q",
+		instance, instance.Name, instance.Namespace)
+	log.Debugf("CNS Volume create spec is: %+v", createSpec)
+	volInfo, _, err := volumeManager.CreateVolume(ctx, createSpec, nil)
+	if err != nil {
+		log.Errorf("failed to create CNS volume on vCenter %q. Error: %+v", vcHost, err)
+		setInstanceError(ctx, r, instance, "failed to create CNS volume")
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	volumeID := volInfo.VolumeID.Id
+	log.Infof("Created CNS volume with volumeID: %s on vCenter: %q", volumeID, vcHost)
+	pvName := staticPvNamePrefix + volumeID
+
+	querySelection := cnstypes.CnsQuerySelection{
+		Names: []string{
+			string(cnstypes.QuerySelectionNameTypeVolumeType),
+			string(cnstypes.QuerySelectionNameTypeDataStoreUrl),
+			string(cnstypes.QuerySelectionNameTypePolicyId),
+			string(cnstypes.QuerySelectionNameTypeBackingObjectDetails),
+		},
+	}
+	volume, err := common.QueryVolumeByID(ctx, volumeManager, volumeID, &querySelection)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to query CNS volume: %s with error: %+v", volumeID, err)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	// Restrict the PV to the nodes which can access the datastore of the volume.
+	pvNodeAffinity, err := cnsoperatorutil.GetVolumeNodeAffinityForVanilla(ctx, r.client, vc, volume.DatastoreUrl)
+	if err != nil {
+		log.Errorf("Failed to find nodes with access to volume: %s on datastore: %s. Error: %+v",
+			volumeID, volume.DatastoreUrl, err)
+		setInstanceError(ctx, r, instance, err.Error())
+		// Untag the CNS volume which was created previously.
+		_, err = common.DeleteVolumeUtil(ctx, volumeManager, volumeID, false)
+		if err != nil {
+			log.Errorf("Failed to untag CNS volume: %s with error: %+v", volumeID, err)
+		}
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	k8sclient, err := k8s.NewClient(ctx)
+	if err != nil {
+		log.Errorf("Failed to initialize K8S client when registering the CnsRegisterVolume "+
+			"instance: %s on namespace: %s. Error: %+v", instance.Name, instance.Namespace, err)
+		setInstanceError(ctx, r, instance, "Failed to init K8S client for volume registration")
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	var storageClassName string
+	if volume.StoragePolicyId != "" {
+		storageClassName, err = cnsoperatorutil.GetK8sStorageClassNameForVanillaPolicy(ctx, k8sclient, vc,
+			volume.StoragePolicyId)
+		if err != nil {
+			msg := fmt.Sprintf("Failed to find K8S Storageclass mapping storagepolicyId: %s. Error: %+v",
+				volume.StoragePolicyId, err)
+			log.Error(msg)
+			setInstanceError(ctx, r, instance, msg)
+			return reconcile.Result{RequeueAfter: timeout}, nil
+		}
+	}
+	log.Infof("Volume with storagepolicyId: %q is mapping to K8S storage class: %q",
+		volume.StoragePolicyId, storageClassName)
+
+	capacityInMb := volume.BackingObjectDetails.GetCnsBackingObjectDetails().CapacityInMb
+	pvc, err := r.createStaticPVAndPVC(ctx, k8sclient, volumeManager, instance, volumeID, pvName, capacityInMb,
+		storageClassName, pvNodeAffinity, nil)
+	if err != nil {
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	// Watch for PVC to be bound.
+	isBound, err := cnsoperatorutil.IsPVCBound(ctx, k8sclient, pvc, time.Duration(1*time.Minute))
+	if !isBound {
+		log.Errorf("PVC: %s is not bound. Error: %+v", instance.Spec.PvcName, err)
+		setInstanceError(ctx, r, instance, fmt.Sprintf("PVC: %s is not bound", instance.Spec.PvcName))
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	log.Infof("PVC: %s is bound", instance.Spec.PvcName)
+
+	// Update the instance to indicate the volume registration is successful.
+	msg := fmt.Sprintf("Successfully registered the volume on namespace: %s", instance.Namespace)
+	err = setInstanceSuccess(ctx, r, instance, instance.Spec.PvcName, pvc.UID, msg)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to update CnsRegistered instance with error: %+v", err)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	backOffDurationMapMutex.Lock()
+	delete(backOffDuration, instance.Name)
+	backOffDurationMapMutex.Unlock()
+	log.Info(msg)
+	return reconcile.Result{}, nil
+}
+
+// getVolumeManagerForInstance returns the vCenter host and volume manager
+// owning the disk requested by the CnsRegisterVolume instance. The vCenter is
+// taken from the host of DiskURLPath, or found by looking up VolumeID on each
+// vCenter.
+func (r *ReconcileCnsRegisterVolume) getVolumeManagerForInstance(ctx context.Context,
+	instance *cnsregistervolumev1alpha1.CnsRegisterVolume) (string, volumes.Manager, error) {
+	log := logger.GetLogger(ctx)
+	if len(r.volumeManagers) != 1 && instance.Spec.DiskURLPath != "" {
+		diskURL, err := url.Parse(instance.Spec.DiskURLPath)
+		if err != nil {
+			return "", nil, logger.LogNewErrorf(log, "failed to parse DiskURLPath %q. Error: %v",
+				instance.Spec.DiskURLPath, err)
+		}
+		volumeManager, ok := r.volumeManagers[diskURL.Hostname()]
+		if !ok {
+			return "", nil, logger.LogNewErrorf(log, "vCenter %q in DiskURLPath is not configured for this cluster",
+				diskURL.Hostname())
+		}
+		return diskURL.Hostname(), volumeManager, nil
+	}
+	return cnsoperatorutil.GetVolumeManagerForFcd(ctx, r.volumeManagers, instance.Spec.VolumeID)
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cnsregistervolume
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientset "k8s.io/client-go/kubernetes"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	cnsregistervolumev1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsregistervolume/v1alpha1"
+	volumes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/unittestcommon"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
+)
+
+const (
+	testOtherVCHost  = "vc2.example.com"
+	testInstanceName = "test-register"
+	testDiskURLPath  = "https://vc1.example.com/folder/fcd/disk-1.vmdk?dcPath=dc&dsName=ds"
+)
+
+// newVanillaRegisterReconciler returns a vanilla ReconcileCnsRegisterVolume
+// with the given volume managers and a fake client tracking the instance.
+func newVanillaRegisterReconciler(instance *cnsregistervolumev1alpha1.CnsRegisterVolume,
+	volumeManagers map[string]volumes.Manager) *ReconcileCnsRegisterVolume {
+	fakeClient, s := unittestcommon.NewFakeCnsOperatorClient(instance)
+	backOffDuration = make(map[string]time.Duration)
+	return &ReconcileCnsRegisterVolume{
+		client:         fakeClient,
+		scheme:         s,
+		clusterFlavor:  cnstypes.CnsClusterFlavorVanilla,
+		configInfo:     unittestcommon.NewCnsOperatorConfigInfo(unittestcommon.TestVCHost, testOtherVCHost),
+		volumeManagers: volumeManagers,
+		recorder:       record.NewFakeRecorder(unittestcommon.TestEventsBufferSize),
+	}
+}
+
+// setupVanillaRegisterTest patches the calls of the vanilla reconcile which
+// reach the API server or the nodes of the cluster. PVCs are reported as bound
+// when isBound is set.
+func setupVanillaRegisterTest(t *testing.T, k8sclient clientset.Interface, isBound *bool) *gomonkey.Patches {
+	var err error
+	commonco.ContainerOrchestratorUtility, err = unittestcommon.GetFakeContainerOrchestratorInterface(
+		common.Kubernetes)
+	if err != nil {
+		t.Fatalf("failed to create fake container orchestrator. Error: %v", err)
+	}
+	return unittestcommon.PatchVanillaCnsOperatorCalls(t, k8sclient, isBound, unittestcommon.TestVCHost,
+		testOtherVCHost)
+}
+
+func newTestCnsRegisterVolume(volumeID string, diskURLPath string) *cnsregistervolumev1alpha1.CnsRegisterVolume {
+	instance := &cnsregistervolumev1alpha1.CnsRegisterVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testInstanceName,
+			Namespace: unittestcommon.TestNamespace,
+		},
+		Spec: cnsregistervolumev1alpha1.CnsRegisterVolumeSpec{
+			PvcName:     unittestcommon.TestPVCName,
+			VolumeID:    volumeID,
+			DiskURLPath: diskURLPath,
+		},
+	}
+	if volumeID != "" {
+		instance.Spec.AccessMode = v1.ReadWriteOnce
+	}
+	return instance
+}
+
+func newTestFcd() *unittestcommon.FakeFcd {
+	return &unittestcommon.FakeFcd{
+		ID:           unittestcommon.TestVolumeID,
+		DiskPath:     testDiskURLPath,
+		DatastoreURL: unittestcommon.TestDatastoreURL,
+		CapacityInMB: unittestcommon.TestCapacityInMB,
+	}
+}
+
+func reconcileTestInstance(t *testing.T, r *ReconcileCnsRegisterVolume) (
+	reconcile.Result, *cnsregistervolumev1alpha1.CnsRegisterVolume) {
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{Name: testInstanceName, Namespace: unittestcommon.TestNamespace},
+	}
+	res, err := r.Reconcile(context.TODO(), req)
+	if err != nil {
+		t.Fatalf("unexpected reconcile error: %v", err)
+	}
+	instance := &cnsregistervolumev1alpha1.CnsRegisterVolume{}
+	if err := r.client.Get(context.TODO(), req.NamespacedName, instance); err != nil {
+		t.Fatalf("failed to get CnsRegisterVolume instance: %v", err)
+	}
+	return res, instance
+}
+
+func TestVanillaRegisterVolumeCreatesStaticPVAndPVC(t *testing.T) {
+	tests := []struct {
+		name        string
+		volumeID    string
+		diskURLPath string
+		vcHosts     []string
+	}{
+		{
+			name:     "TestRegisterByVolumeID",
+			volumeID: unittestcommon.TestVolumeID,
+			vcHosts:  []string{unittestcommon.TestVCHost},
+		},
+		{
+			name:        "TestRegisterByDiskURLPath",
+			diskURLPath: testDiskURLPath,
+			vcHosts:     []string{unittestcommon.TestVCHost},
+		},
+		{
+			name:     "TestRegisterByVolumeIDOnMultipleVCenters",
+			volumeID: unittestcommon.TestVolumeID,
+			vcHosts:  []string{testOtherVCHost, unittestcommon.TestVCHost},
+		},
+		{
+			name:        "TestRegisterByDiskURLPathOnMultipleVCenters",
+			diskURLPath: testDiskURLPath,
+			vcHosts:     []string{testOtherVCHost, unittestcommon.TestVCHost},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			k8sclient := testclient.NewSimpleClientset()
+			isBound := true
+			patches := setupVanillaRegisterTest(t, k8sclient, &isBound)
+			defer patches.Reset()
+
+			// The FCD is only on unittestcommon.TestVCHost.
+			volumeManager := unittestcommon.NewFakeVolumeManager(newTestFcd())
+			volumeManagers := map[string]volumes.Manager{unittestcommon.TestVCHost: volumeManager}
+			for _, host := range test.vcHosts {
+				if host != unittestcommon.TestVCHost {
+					volumeManagers[host] = unittestcommon.NewFakeVolumeManager()
+				}
+			}
+			r := newVanillaRegisterReconciler(newTestCnsRegisterVolume(test.volumeID, test.diskURLPath),
+				volumeManagers)
+
+			res, instance := reconcileTestInstance(t, r)
+			assert.Equal(t, reconcile.Result{}, res)
+			assert.True(t, instance.Status.Registered)
+			assert.Empty(t, instance.Status.Error)
+
+			// The FCD is registered with CNS with the metadata of this cluster.
+			cnsVolume, ok := volumeManager.Volumes[unittestcommon.TestVolumeID]
+			if !ok {
+				t.Fatalf("volume %q is not registered with CNS", unittestcommon.TestVolumeID)
+			}
+			assert.Equal(t, unittestcommon.TestClusterID, cnsVolume.Metadata.ContainerCluster.ClusterId)
+			assert.Equal(t, string(cnstypes.CnsClusterFlavorVanilla), cnsVolume.Metadata.ContainerCluster.ClusterFlavor)
+
+			pvName := staticPvNamePrefix + unittestcommon.TestVolumeID
+			pv, err := k8sclient.CoreV1().PersistentVolumes().Get(context.TODO(), pvName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get PV %q: %v", pvName, err)
+			}
+			assert.Equal(t, unittestcommon.TestVolumeID, pv.Spec.CSI.VolumeHandle)
+			assert.Equal(t, unittestcommon.TestPVCName, pv.Spec.ClaimRef.Name)
+			assert.Equal(t, unittestcommon.TestNamespace, pv.Spec.ClaimRef.Namespace)
+			capacity := pv.Spec.Capacity[v1.ResourceStorage]
+			assert.Equal(t, unittestcommon.TestCapacityInMB*common.MbInBytes, capacity.Value())
+
+			pvc, err := k8sclient.CoreV1().PersistentVolumeClaims(unittestcommon.TestNamespace).Get(context.TODO(),
+				unittestcommon.TestPVCName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get PVC %q: %v", unittestcommon.TestPVCName, err)
+			}
+			assert.Equal(t, pvName, pvc.Spec.VolumeName)
+			assert.Equal(t, unittestcommon.TestPVCName, instance.OwnerReferences[0].Name)
+		})
+	}
+}
+
+func TestVanillaRegisterVolumeWithInvalidDisk(t *testing.T) {
+	tests := []struct {
+		name          string
+		volumeID      string
+		diskURLPath   string
+		multipleVCs   bool
+		expectedError string
+	}{
+		{
+			name:          "TestUnknownVolumeID",
+			volumeID:      "unknown-fcd",
+			expectedError: "failed to create CNS volume",
+		},
+		{
+			name:          "TestUnknownVolumeIDOnMultipleVCenters",
+			volumeID:      "unknown-fcd",
+			multipleVCs:   true,
+			expectedError: "volume \"unknown-fcd\" not found on any vCenter",
+		},
+		{
+			name:          "TestUnknownDiskURLPath",
+			diskURLPath:   "https://vc1.example.com/folder/fcd/unknown.vmdk?dcPath=dc&dsName=ds",
+			expectedError: "failed to create CNS volume",
+		},
+		{
+			name:          "TestDiskURLPathOnUnknownVCenter",
+			diskURLPath:   "https://vc3.example.com/folder/fcd/disk-1.vmdk?dcPath=dc&dsName=ds",
+			multipleVCs:   true,
+			expectedError: "vCenter \"vc3.example.com\" in DiskURLPath is not configured for this cluster",
+		},
+		{
+			name:          "TestVolumeIDWithDiskURLPath",
+			volumeID:      unittestcommon.TestVolumeID,
+			diskURLPath:   testDiskURLPath,
+			expectedError: "VolumeID and DiskURLPath cannot be specified together",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			k8sclient := testclient.NewSimpleClientset()
+			isBound := true
+			patches := setupVanillaRegisterTest(t, k8sclient, &isBound)
+			defer patches.Reset()
+
+			volumeManager := unittestcommon.NewFakeVolumeManager(newTestFcd())
+			volumeManagers := map[string]volumes.Manager{unittestcommon.TestVCHost: volumeManager}
+			if test.multipleVCs {
+				volumeManagers[testOtherVCHost] = unittestcommon.NewFakeVolumeManager()
+			}
+			r := newVanillaRegisterReconciler(newTestCnsRegisterVolume(test.volumeID, test.diskURLPath),
+				volumeManagers)
+
+			res, instance := reconcileTestInstance(t, r)
+			assert.Equal(t, reconcile.Result{RequeueAfter: time.Second}, res)
+			assert.False(t, instance.Status.Registered)
+			assert.Contains(t, instance.Status.Error, test.expectedError)
+			assert.Empty(t, volumeManager.Volumes)
+			pvs, err := k8sclient.CoreV1().PersistentVolumes().List(context.TODO(), metav1.ListOptions{})
+			assert.NoError(t, err)
+			assert.Empty(t, pvs.Items)
+		})
+	}
+}
+
+func TestVanillaRegisterVolumeIsIdempotent(t *testing.T) {
+	k8sclient := testclient.NewSimpleClientset()
+	// The PVC does not bind on the first reconcile.
+	isBound := false
+	patches := setupVanillaRegisterTest(t, k8sclient, &isBound)
+	defer patches.Reset()
+
+	volumeManager := unittestcommon.NewFakeVolumeManager(newTestFcd())
+	r := newVanillaRegisterReconciler(newTestCnsRegisterVolume(unittestcommon.TestVolumeID, ""),
+		map[string]volumes.Manager{unittestcommon.TestVCHost: volumeManager})
+
+	res, instance := reconcileTestInstance(t, r)
+	assert.Equal(t, reconcile.Result{RequeueAfter: time.Second}, res)
+	assert.False(t, instance.Status.Registered)
+	assert.Equal(t, "PVC: "+unittestcommon.TestPVCName+" is not bound", instance.Status.Error)
+
+	// The retry reuses the CNS volume, PV and PVC created by the first reconcile.
+	isBound = true
+	res, instance = reconcileTestInstance(t, r)
+	assert.Equal(t, reconcile.Result{}, res)
+	assert.True(t, instance.Status.Registered)
+	assert.Empty(t, instance.Status.Error)
+	assert.Len(t, volumeManager.Volumes, 1)
+	pvs, err := k8sclient.CoreV1().PersistentVolumes().List(context.TODO(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, pvs.Items, 1)
+	pvcs, err := k8sclient.CoreV1().PersistentVolumeClaims(unittestcommon.TestNamespace).List(context.TODO(),
+		metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, pvcs.Items, 1)
+
+	// A registered instance is not processed again.
+	volumeManager.Errors["CreateVolume"] = errors.New("unexpected CreateVolume call")
+	res, instance = reconcileTestInstance(t, r)
+	assert.Equal(t, reconcile.Result{}, res)
+	assert.True(t, instance.Status.Registered)
+}
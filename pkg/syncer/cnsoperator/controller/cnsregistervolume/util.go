@@ -18,20 +18,15 @@ package cnsregistervolume
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 	"os"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/google/uuid"
 	cnstypes "github.com/vmware/govmomi/cns/types"
 	v1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/fields"
 	clientset "k8s.io/client-go/kubernetes"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	cnsregistervolumev1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsregistervolume/v1alpha1"
@@ -39,7 +34,6 @@ import (
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
-	cnsoperatortypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer/cnsoperator/types"
 )
 
 const (
@@ -105,7 +99,7 @@ func isDatastoreAccessibleToAZClusters(ctx context.Context, vc *vsphere.VirtualC
 // constructCreateSpecForInstance creates CNS CreateVolume spec.
 func constructCreateSpecForInstance(r *ReconcileCnsRegisterVolume,
 	instance *cnsregistervolumev1alpha1.CnsRegisterVolume,
-	host string, useSupervisorId bool) *cnstypes.CnsVolumeCreateSpec {
+	vcUser string, useSupervisorId bool) *cnstypes.CnsVolumeCreateSpec {
 	var volumeName string
 	if instance.Spec.VolumeID != "" {
 		volumeName = staticPvNamePrefix + instance.Spec.VolumeID
@@ -120,8 +114,8 @@ func constructCreateSpecForInstance(r *ReconcileCnsRegisterVolume,
 		clusterIDForVolumeMetadata = r.configInfo.Cfg.Global.ClusterID
 	}
 	containerCluster := vsphere.GetContainerCluster(clusterIDForVolumeMetadata,
-		r.configInfo.Cfg.VirtualCenter[host].User,
-		cnstypes.CnsClusterFlavorWorkload, r.configInfo.Cfg.Global.ClusterDistribution)
+		vcUser,
+		r.clusterFlavor, r.configInfo.Cfg.Global.ClusterDistribution)
 	createSpec := &cnstypes.CnsVolumeCreateSpec{
 		Name:       volumeName,
 		VolumeType: common.BlockVolumeType,
@@ -129,6 +123,9 @@ func constructCreateSpecForInstance(r *ReconcileCnsRegisterVolume,
 			ContainerCluster: containerCluster,
 		},
 	}
+	if r.clusterFlavor == cnstypes.CnsClusterFlavorVanilla {
+		createSpec.Metadata.ContainerClusterArray = []cnstypes.CnsContainerCluster{containerCluster}
+	}
 	if instance.Spec.VolumeID != "" {
 		createSpec.BackingObjectDetails = &cnstypes.CnsBlockBackingDetails{
 			BackingDiskId: instance.Spec.VolumeID,
@@ -239,129 +236,6 @@ func getK8sStorageClassNameWithImmediateBindingModeForPolicy(ctx context.Context
 	}
 }
 
-// getPersistentVolumeSpec to create PV volume spec for the given input params.
-func getPersistentVolumeSpec(volumeName string, volumeID string, capacity int64,
-	accessMode v1.PersistentVolumeAccessMode, scName string, claimRef *v1.ObjectReference) *v1.PersistentVolume {
-	capacityInMb := strconv.FormatInt(capacity, 10) + "Mi"
-	pv := &v1.PersistentVolume{
-		TypeMeta: metav1.TypeMeta{},
-		ObjectMeta: metav1.ObjectMeta{
-			Name: volumeName,
-		},
-		Spec: v1.PersistentVolumeSpec{
-			PersistentVolumeReclaimPolicy: v1.PersistentVolumeReclaimDelete,
-			Capacity: v1.ResourceList{
-				v1.ResourceName(v1.ResourceStorage): resource.MustParse(capacityInMb),
-			},
-			PersistentVolumeSource: v1.PersistentVolumeSource{
-				CSI: &v1.CSIPersistentVolumeSource{
-					Driver:       cnsoperatortypes.VSphereCSIDriverName,
-					VolumeHandle: volumeID,
-					ReadOnly:     false,
-					FSType:       "ext4",
-				},
-			},
-			AccessModes: []v1.PersistentVolumeAccessMode{
-				accessMode,
-			},
-			ClaimRef:         claimRef,
-			StorageClassName: scName,
-		},
-		Status: v1.PersistentVolumeStatus{},
-	}
-	annotations := make(map[string]string)
-	annotations["pv.kubernetes.io/provisioned-by"] = cnsoperatortypes.VSphereCSIDriverName
-	pv.Annotations = annotations
-	return pv
-}
-
-// getPersistentVolumeClaimSpec return the PersistentVolumeClaim spec with
-// specified storage class.
-func getPersistentVolumeClaimSpec(ctx context.Context, name string, namespace string, capacity int64,
-	storageClassName string, accessMode v1.PersistentVolumeAccessMode, pvName string,
-	datastoreAccessibleTopology []map[string]string) (*v1.PersistentVolumeClaim, error) {
-
-	log := logger.GetLogger(ctx)
-	capacityInMb := strconv.FormatInt(capacity, 10) + "Mi"
-	var (
-		segmentsArray  []string
-		topoAnnotation = make(map[string]string)
-	)
-	if datastoreAccessibleTopology != nil {
-		for _, topologyTerm := range datastoreAccessibleTopology {
-			jsonSegment, err := json.Marshal(topologyTerm)
-			if err != nil {
-				return nil, logger.LogNewErrorf(log,
-					"failed to marshal topology segment: %+v to json. Error: %+v", topologyTerm, err)
-			}
-			segmentsArray = append(segmentsArray, string(jsonSegment))
-		}
-		topoAnnotation[common.AnnVolumeAccessibleTopology] = "[" + strings.Join(segmentsArray, ",") + "]"
-	}
-
-	claim := &v1.PersistentVolumeClaim{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:        name,
-			Namespace:   namespace,
-			Annotations: topoAnnotation,
-		},
-		Spec: v1.PersistentVolumeClaimSpec{
-			AccessModes: []v1.PersistentVolumeAccessMode{
-				accessMode,
-			},
-			Resources: v1.ResourceRequirements{
-				Requests: v1.ResourceList{
-					v1.ResourceName(v1.ResourceStorage): resource.MustParse(capacityInMb),
-				},
-			},
-			StorageClassName: &storageClassName,
-			VolumeName:       pvName,
-		},
-	}
-	return claim, nil
-}
-
-// isPVCBound return true if the PVC is bound before timeout.
-// Otherwise, return false.
-func isPVCBound(ctx context.Context, client clientset.Interface, claim *v1.PersistentVolumeClaim,
-	timeout time.Duration) (bool, error) {
-	log := logger.GetLogger(ctx)
-	pvcName := claim.Name
-	ns := claim.Namespace
-	timeoutSeconds := int64(timeout.Seconds())
-
-	log.Infof("Waiting up to %d seconds for PersistentVolumeClaim %v in namespace %s to have phase %s",
-		timeoutSeconds, pvcName, ns, v1.ClaimBound)
-	watchClaim, err := client.CoreV1().PersistentVolumeClaims(ns).Watch(
-		ctx,
-		metav1.ListOptions{
-			FieldSelector:  fields.OneTermEqualSelector("metadata.name", pvcName).String(),
-			TimeoutSeconds: &timeoutSeconds,
-			Watch:          true,
-		})
-	if err != nil {
-		errMsg := fmt.Errorf("failed to watch PersistentVolumeClaim %s with Error: %v", pvcName, err)
-		log.Error(errMsg)
-		return false, errMsg
-	}
-	defer watchClaim.Stop()
-
-	for event := range watchClaim.ResultChan() {
-		pvc, ok := event.Object.(*v1.PersistentVolumeClaim)
-		if !ok {
-			continue
-		}
-		log.Debugf("PersistentVolumeClaim %s in namespace %s is in state %s. Received event %v",
-			pvcName, ns, pvc.Status.Phase, event)
-		if pvc.Status.Phase == v1.ClaimBound && pvc.Name == pvcName {
-			log.Infof("PersistentVolumeClaim %s in namespace %s is in state %s", pvcName, ns, pvc.Status.Phase)
-			return true, nil
-		}
-	}
-	return false, fmt.Errorf("persistentVolumeClaim %s in namespace %s not in phase %s within %d seconds",
-		pvcName, ns, v1.ClaimBound, timeoutSeconds)
-}
-
 // getMaxWorkerThreadsToReconcileCnsRegisterVolume returns the maximum number
 // of worker threads which can be run to reconcile CnsRegisterVolume instances.
 // If environment variable WORKER_THREADS_REGISTER_VOLUME is set and valid,
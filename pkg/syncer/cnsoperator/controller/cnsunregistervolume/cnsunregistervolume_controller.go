@@ -32,7 +32,6 @@ import (
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/record"
-	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -51,6 +50,7 @@ import (
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
 	k8s "sigs.k8s.io/vsphere-csi-driver/v3/pkg/kubernetes"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer"
+	cnsoperatorutil "sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer/cnsoperator/util"
 )
 
 const (
@@ -74,7 +74,7 @@ var (
 func Add(mgr manager.Manager, clusterFlavor cnstypes.CnsClusterFlavor,
 	configInfo *commonconfig.ConfigurationInfo, volumeManager volumes.Manager) error {
 	ctx, log := logger.GetNewContextWithLogger()
-	if clusterFlavor != cnstypes.CnsClusterFlavorWorkload {
+	if clusterFlavor != cnstypes.CnsClusterFlavorWorkload && clusterFlavor != cnstypes.CnsClusterFlavorVanilla {
 		log.Debug("Not initializing the CnsUnregisterVolume Controller as its a non-WCP CSI deployment")
 		return nil
 	}
@@ -87,11 +87,24 @@ func Add(mgr manager.Manager, clusterFlavor cnstypes.CnsClusterFlavor,
 		log.Errorf("failed to create CO agnostic interface. Err: %v", err)
 		return err
 	}
-	if !coCommonInterface.IsFSSEnabled(ctx, common.CnsUnregisterVolume) {
+	featureName := common.CnsUnregisterVolume
+	if clusterFlavor == cnstypes.CnsClusterFlavorVanilla {
+		featureName = common.VanillaCnsUnregisterVolume
+	}
+	if !coCommonInterface.IsFSSEnabled(ctx, featureName) {
 		log.Infof("Not initializing the CnsUnregisterVolume Controller as this feature is disabled on the cluster")
 		return nil
 	}
 
+	volumeManagers := map[string]volumes.Manager{}
+	if clusterFlavor == cnstypes.CnsClusterFlavorVanilla {
+		volumeManagers, err = cnsoperatorutil.GetVanillaVolumeManagers(ctx, configInfo, volumeManager)
+		if err != nil {
+			log.Errorf("failed to get volume managers for CnsUnregisterVolume Controller. Err: %v", err)
+			return err
+		}
+	}
+
 	// Initializes kubernetes client.
 	k8sclient, err := k8s.NewClient(ctx)
 	if err != nil {
@@ -108,14 +121,16 @@ func Add(mgr manager.Manager, clusterFlavor cnstypes.CnsClusterFlavor,
 		},
 	)
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: apis.GroupName})
-	return add(mgr, newReconciler(mgr, configInfo, volumeManager, recorder))
+	return add(mgr, newReconciler(mgr, clusterFlavor, configInfo, volumeManager, volumeManagers, recorder))
 }
 
 // newReconciler returns a new reconcile.Reconciler.
-func newReconciler(mgr manager.Manager, configInfo *commonconfig.ConfigurationInfo,
-	volumeManager volumes.Manager, recorder record.EventRecorder) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager, clusterFlavor cnstypes.CnsClusterFlavor,
+	configInfo *commonconfig.ConfigurationInfo, volumeManager volumes.Manager,
+	volumeManagers map[string]volumes.Manager, recorder record.EventRecorder) reconcile.Reconciler {
 	return &ReconcileCnsUnregisterVolume{client: mgr.GetClient(), scheme: mgr.GetScheme(),
-		configInfo: configInfo, volumeManager: volumeManager, recorder: recorder}
+		clusterFlavor: clusterFlavor, configInfo: configInfo, volumeManager: volumeManager,
+		volumeManagers: volumeManagers, recorder: recorder}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler.
@@ -153,9 +168,13 @@ type ReconcileCnsUnregisterVolume struct {
 	// that reads objects from the cache and writes to the apiserver.
 	client        client.Client
 	scheme        *runtime.Scheme
+	clusterFlavor cnstypes.CnsClusterFlavor
 	configInfo    *commonconfig.ConfigurationInfo
 	volumeManager volumes.Manager
-	recorder      record.EventRecorder
+	// volumeManagers maps vCenter host to its volume manager. It is only
+	// populated for vanilla clusters.
+	volumeManagers map[string]volumes.Manager
+	recorder       record.EventRecorder
 }
 
 // Reconcile reads that state of the cluster for a ReconcileCnsUnregisterVolume object
@@ -200,6 +219,9 @@ func (r *ReconcileCnsUnregisterVolume) Reconcile(ctx context.Context,
 	}
 	log.Infof("Reconciling CnsUnregisterVolume instance %q from namespace %q. timeout %q seconds",
 		instance.Name, request.Namespace, timeout)
+	if r.clusterFlavor == cnstypes.CnsClusterFlavorVanilla {
+		return r.reconcileForVanilla(ctx, instance, timeout)
+	}
 
 	// 1. Perform all the necessary validations.
 	// 2. Fetch the PV corresponding to the volume and set on it the ReclaimPolicy to Retain.
@@ -276,69 +298,10 @@ func (r *ReconcileCnsUnregisterVolume) Reconcile(ctx context.Context,
 		return reconcile.Result{RequeueAfter: timeout}, nil
 	}
 
-	if pvName != "" {
-		//Change PV ReclaimPolicy to retain so that underlying FCD doesn't get deleted when deleting PV,PVC
-		pv, err := k8sclient.CoreV1().PersistentVolumes().Get(ctx, pvName, metav1.GetOptions{})
-		if err != nil {
-			if !apierrors.IsNotFound(err) {
-				log.Errorf("Unable to get PV %q", pvName)
-				return reconcile.Result{}, err
-			}
-		}
-
-		if pv.Spec.PersistentVolumeReclaimPolicy != v1.PersistentVolumeReclaimRetain {
-			pv.Spec.PersistentVolumeReclaimPolicy = v1.PersistentVolumeReclaimRetain
-			retryErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-				_, updateErr := k8sclient.CoreV1().PersistentVolumes().Update(context.TODO(), pv, metav1.UpdateOptions{})
-				return updateErr
-			})
-			if retryErr != nil {
-				log.Errorf("Unable to update ReclaimPolicy on PV %q", pvName)
-				return reconcile.Result{}, err
-			}
-			log.Infof("Updated ReclaimPolicy on PV %q to %q", pvName, v1.PersistentVolumeReclaimRetain)
-		}
-	} else {
-		log.Infof("CNS metadata for volume %s has missing pvName."+
-			"PV may have already been deleted. Continuing with other operations..", instance.Spec.VolumeID)
-	}
-
-	// Delete PVC.
-	if pvcName != "" && pvcNamespace != "" {
-		err = k8sclient.CoreV1().PersistentVolumeClaims(pvcNamespace).Delete(ctx,
-			pvcName, *metav1.NewDeleteOptions(0))
-		if err != nil {
-			if apierrors.IsNotFound(err) {
-				log.Infof("PVC %q not found in namespace %q. It may have already been deleted."+
-					"Continuing with other operations..", pvcName, pvcNamespace)
-			} else {
-				log.Errorf("Failed to delete PVC %q in namespace %q with error - %s",
-					pvcName, pvcNamespace, err.Error())
-				return reconcile.Result{}, err
-			}
-		} else {
-			log.Infof("Deleted PVC %q in namespace %q", pvcName, pvcNamespace)
-		}
-	} else {
-		log.Infof("CNS metadata for volume %s has missing pvcName or namespace."+
-			"PVC may have already been deleted. Continuing with other operations..", instance.Spec.VolumeID)
-	}
-
-	if pvName != "" {
-		// Delete PV.
-		// Since reclaimPolicy was set to Retain, we need to explicitly delete it.
-		err = k8sclient.CoreV1().PersistentVolumes().Delete(ctx, pvName, *metav1.NewDeleteOptions(0))
-		if err != nil {
-			if apierrors.IsNotFound(err) {
-				log.Infof("PV %q not found. It may have already been deleted."+
-					"Continuing with other operations..", pvName)
-			} else {
-				log.Errorf("Failed to delete PV %q with error %s", pvName, err.Error())
-				return reconcile.Result{}, err
-			}
-		} else {
-			log.Infof("Deleted PV %q", pvName)
-		}
+	err = cnsoperatorutil.DeletePVAndPVCRetainingVolume(ctx, k8sclient, instance.Spec.VolumeID, pvName, pvcName,
+		pvcNamespace)
+	if err != nil {
+		return reconcile.Result{}, err
 	}
 
 	// Invoke CNS DeleteVolume API with deleteDisk flag set to false.
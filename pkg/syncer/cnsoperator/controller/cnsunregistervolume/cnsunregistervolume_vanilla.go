@@ -0,0 +1,133 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cnsunregistervolume
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	cnsunregistervolumev1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsunregistervolume/v1alpha1"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+	k8s "sigs.k8s.io/vsphere-csi-driver/v3/pkg/kubernetes"
+	cnsoperatorutil "sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer/cnsoperator/util"
+)
+
+// reconcileForVanilla detaches the volume in the CnsUnregisterVolume instance
+// from a vanilla cluster. The PV and PVC of the volume are deleted and the CNS
+// metadata of this cluster is removed, while the FCD backing the volume is
+// retained. The FCD ID and its datastore path are reported in the instance
+// status so that the disk can be handed over to another cluster or VM.
+func (r *ReconcileCnsUnregisterVolume) reconcileForVanilla(ctx context.Context,
+	instance *cnsunregistervolumev1alpha1.CnsUnregisterVolume, timeout time.Duration) (reconcile.Result, error) {
+	log := logger.GetLogger(ctx)
+	volumeID := instance.Spec.VolumeID
+	querySelection := cnstypes.CnsQuerySelection{
+		Names: []string{
+			metadata,
+		},
+	}
+	vcHost, volumeManager, cnsVol, err := cnsoperatorutil.QueryVolumeOnVCenters(ctx, r.volumeManagers, volumeID,
+		&querySelection)
+	if err != nil {
+		log.Error(err)
+		setInstanceError(ctx, r, instance, err.Error())
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	if cnsVol == nil {
+		msg := fmt.Sprintf("Volume: %q not found while querying CNS. It may have already been unregistered.",
+			volumeID)
+		err = setInstanceSuccess(ctx, r, instance, msg)
+		if err != nil {
+			msg := fmt.Sprintf("Failed to update CnsUnregistered instance with error: %+v", err)
+			log.Error(msg)
+			setInstanceError(ctx, r, instance, msg)
+			return reconcile.Result{RequeueAfter: timeout}, nil
+		}
+		backOffDurationMapMutex.Lock()
+		delete(backOffDuration, instance.Name)
+		backOffDurationMapMutex.Unlock()
+		log.Info(msg)
+		return reconcile.Result{}, nil
+	}
+
+	// Only the metadata of this cluster is considered, the volume may also be
+	// registered with other clusters.
+	clusterID := r.configInfo.Cfg.Global.ClusterID
+	clusterEntityMetadata, pvName, pvcName, pvcNamespace := cnsoperatorutil.GetClusterEntityMetadata(cnsVol,
+		clusterID)
+
+	k8sclient, err := k8s.NewClient(ctx)
+	if err != nil {
+		log.Errorf("Failed to initialize K8S client when reconciling CnsUnregisterVolume "+
+			"instance: %s on namespace: %s. Error: %+v", instance.Name, instance.Namespace, err)
+		setInstanceError(ctx, r, instance, "Failed to init K8S client for volume unregistration")
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	err = cnsoperatorutil.ValidateVolumeNotInUseForVanilla(ctx, volumeID, pvName, pvcName, pvcNamespace, k8sclient)
+	if err != nil {
+		log.Error(err)
+		setInstanceError(ctx, r, instance, err.Error())
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	// Look up the disk before the volume is removed from CNS.
+	diskPath, err := cnsoperatorutil.GetFcdDiskPath(ctx, volumeManager, volumeID)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to retrieve FCD for volume %q. Error: %+v", volumeID, err)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	err = cnsoperatorutil.DeletePVAndPVCRetainingVolume(ctx, k8sclient, volumeID, pvName, pvcName, pvcNamespace)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	err = cnsoperatorutil.RemoveClusterFromVolume(ctx, r.configInfo, vcHost, volumeManager, cnsVol,
+		clusterEntityMetadata)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to remove CNS metadata of cluster %q from volume %q. Error: %+v",
+			clusterID, volumeID, err)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	// Update the instance to indicate the volume unregistration is successful.
+	instance.Status.FcdID = volumeID
+	instance.Status.DiskPath = diskPath
+	msg := fmt.Sprintf("Successfully unregistered the volume on namespace: %s. FCD %q at %q is retained",
+		instance.Namespace, volumeID, diskPath)
+	err = setInstanceSuccess(ctx, r, instance, msg)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to update CnsUnregistered instance with error: %+v", err)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	backOffDurationMapMutex.Lock()
+	delete(backOffDuration, instance.Name)
+	backOffDurationMapMutex.Unlock()
+	log.Info(msg)
+	return reconcile.Result{}, nil
+}
@@ -0,0 +1,301 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cnsunregistervolume
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientset "k8s.io/client-go/kubernetes"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	cnsunregistervolumev1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsunregistervolume/v1alpha1"
+	volumes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/unittestcommon"
+	k8s "sigs.k8s.io/vsphere-csi-driver/v3/pkg/kubernetes"
+)
+
+const (
+	testOtherClusterID = "other-cluster"
+	testInstanceName   = "test-unregister"
+)
+
+// newVanillaUnregisterReconciler returns a vanilla
+// ReconcileCnsUnregisterVolume with the given volume manager and a fake client
+// tracking the instance.
+func newVanillaUnregisterReconciler(volumeManager volumes.Manager) *ReconcileCnsUnregisterVolume {
+	instance := &cnsunregistervolumev1alpha1.CnsUnregisterVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testInstanceName,
+			Namespace: unittestcommon.TestNamespace,
+		},
+		Spec: cnsunregistervolumev1alpha1.CnsUnregisterVolumeSpec{
+			VolumeID: unittestcommon.TestVolumeID,
+		},
+	}
+	fakeClient, s := unittestcommon.NewFakeCnsOperatorClient(instance)
+	backOffDuration = make(map[string]time.Duration)
+	return &ReconcileCnsUnregisterVolume{
+		client:         fakeClient,
+		scheme:         s,
+		clusterFlavor:  cnstypes.CnsClusterFlavorVanilla,
+		configInfo:     unittestcommon.NewCnsOperatorConfigInfo(unittestcommon.TestVCHost),
+		volumeManagers: map[string]volumes.Manager{unittestcommon.TestVCHost: volumeManager},
+		recorder:       record.NewFakeRecorder(unittestcommon.TestEventsBufferSize),
+	}
+}
+
+// newTestK8sClient returns a fake clientset with the bound PV and PVC of the
+// test volume, and the given objects.
+func newTestK8sClient(objects ...runtime.Object) *testclient.Clientset {
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: unittestcommon.TestPVName},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeReclaimPolicy: v1.PersistentVolumeReclaimDelete,
+			ClaimRef: &v1.ObjectReference{
+				Name:      unittestcommon.TestPVCName,
+				Namespace: unittestcommon.TestNamespace,
+			},
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				CSI: &v1.CSIPersistentVolumeSource{VolumeHandle: unittestcommon.TestVolumeID},
+			},
+		},
+		Status: v1.PersistentVolumeStatus{Phase: v1.VolumeBound},
+	}
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: unittestcommon.TestPVCName, Namespace: unittestcommon.TestNamespace},
+		Spec:       v1.PersistentVolumeClaimSpec{VolumeName: unittestcommon.TestPVName},
+		Status:     v1.PersistentVolumeClaimStatus{Phase: v1.ClaimBound},
+	}
+	return testclient.NewSimpleClientset(append([]runtime.Object{pv, pvc}, objects...)...)
+}
+
+func patchK8sClient(k8sclient clientset.Interface) *gomonkey.Patches {
+	return gomonkey.ApplyFunc(k8s.NewClient, func(_ context.Context) (clientset.Interface, error) {
+		return k8sclient, nil
+	})
+}
+
+func reconcileTestInstance(t *testing.T, r *ReconcileCnsUnregisterVolume) (
+	reconcile.Result, *cnsunregistervolumev1alpha1.CnsUnregisterVolume) {
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{Name: testInstanceName, Namespace: unittestcommon.TestNamespace},
+	}
+	res, err := r.Reconcile(context.TODO(), req)
+	if err != nil {
+		t.Fatalf("unexpected reconcile error: %v", err)
+	}
+	instance := &cnsunregistervolumev1alpha1.CnsUnregisterVolume{}
+	if err := r.client.Get(context.TODO(), req.NamespacedName, instance); err != nil {
+		t.Fatalf("failed to get CnsUnregisterVolume instance: %v", err)
+	}
+	return res, instance
+}
+
+func TestVanillaUnregisterVolumeInUse(t *testing.T) {
+	pvName := unittestcommon.TestPVName
+	tests := []struct {
+		name          string
+		object        runtime.Object
+		expectedError string
+	}{
+		{
+			name: "TestVolumeUsedByPod",
+			object: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: unittestcommon.TestNamespace},
+				Spec: v1.PodSpec{
+					Volumes: []v1.Volume{{
+						Name: "data",
+						VolumeSource: v1.VolumeSource{
+							PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: unittestcommon.TestPVCName},
+						},
+					}},
+				},
+			},
+			expectedError: "volume fcd-1 is in use by pod test-pod in namespace test-ns",
+		},
+		{
+			name: "TestVolumeAttachedToNode",
+			object: &storagev1.VolumeAttachment{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-va"},
+				Spec: storagev1.VolumeAttachmentSpec{
+					NodeName: "test-node",
+					Source:   storagev1.VolumeAttachmentSource{PersistentVolumeName: &pvName},
+				},
+			},
+			expectedError: "volume fcd-1 is attached to node test-node",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			k8sclient := newTestK8sClient(test.object)
+			patches := patchK8sClient(k8sclient)
+			defer patches.Reset()
+			volumeManager := unittestcommon.NewRegisteredVolumeManager()
+			r := newVanillaUnregisterReconciler(volumeManager)
+
+			res, instance := reconcileTestInstance(t, r)
+			assert.Equal(t, reconcile.Result{RequeueAfter: time.Second}, res)
+			assert.False(t, instance.Status.Unregistered)
+			assert.Equal(t, test.expectedError, instance.Status.Error)
+
+			// Nothing is deleted.
+			_, err := k8sclient.CoreV1().PersistentVolumeClaims(unittestcommon.TestNamespace).Get(context.TODO(),
+				unittestcommon.TestPVCName, metav1.GetOptions{})
+			assert.NoError(t, err)
+			_, err = k8sclient.CoreV1().PersistentVolumes().Get(context.TODO(), unittestcommon.TestPVName, metav1.GetOptions{})
+			assert.NoError(t, err)
+			assert.Contains(t, volumeManager.Volumes, unittestcommon.TestVolumeID)
+		})
+	}
+}
+
+func TestVanillaUnregisterVolumeDeletesPVAndPVC(t *testing.T) {
+	tests := []struct {
+		name            string
+		otherClusterIDs []string
+	}{
+		{
+			name: "TestVolumeOfThisCluster",
+		},
+		{
+			name:            "TestVolumeSharedWithOtherCluster",
+			otherClusterIDs: []string{testOtherClusterID},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			k8sclient := newTestK8sClient()
+			patches := patchK8sClient(k8sclient)
+			defer patches.Reset()
+			volumeManager := unittestcommon.NewRegisteredVolumeManager(test.otherClusterIDs...)
+			r := newVanillaUnregisterReconciler(volumeManager)
+
+			res, instance := reconcileTestInstance(t, r)
+			assert.Equal(t, reconcile.Result{}, res)
+			assert.True(t, instance.Status.Unregistered)
+			assert.Empty(t, instance.Status.Error)
+			assert.Equal(t, unittestcommon.TestVolumeID, instance.Status.FcdID)
+			assert.Equal(t, unittestcommon.TestDiskPath, instance.Status.DiskPath)
+
+			_, err := k8sclient.CoreV1().PersistentVolumeClaims(unittestcommon.TestNamespace).Get(context.TODO(),
+				unittestcommon.TestPVCName, metav1.GetOptions{})
+			assert.True(t, apierrors.IsNotFound(err))
+			_, err = k8sclient.CoreV1().PersistentVolumes().Get(context.TODO(), unittestcommon.TestPVName, metav1.GetOptions{})
+			assert.True(t, apierrors.IsNotFound(err))
+			// The FCD backing the volume is retained.
+			assert.Contains(t, volumeManager.Fcds, unittestcommon.TestVolumeID)
+
+			volume, ok := volumeManager.Volumes[unittestcommon.TestVolumeID]
+			if len(test.otherClusterIDs) == 0 {
+				assert.False(t, ok)
+				return
+			}
+			// Only the metadata of this cluster is removed.
+			if !ok {
+				t.Fatalf("volume %q shared with another cluster was removed from CNS", unittestcommon.TestVolumeID)
+			}
+			assert.Equal(t, []cnstypes.CnsContainerCluster{{ClusterId: testOtherClusterID}},
+				volume.Metadata.ContainerClusterArray)
+			for _, entity := range volume.Metadata.EntityMetadata {
+				assert.Equal(t, testOtherClusterID, entity.(*cnstypes.CnsKubernetesEntityMetadata).ClusterID)
+			}
+		})
+	}
+}
+
+func TestVanillaUnregisterVolumeWithCnsError(t *testing.T) {
+	tests := []struct {
+		name            string
+		otherClusterIDs []string
+		failingMethod   string
+		expectedError   string
+	}{
+		{
+			name:          "TestDeleteVolumeFailure",
+			failingMethod: "DeleteVolume",
+			expectedError: "Failed to remove CNS metadata of cluster \"test-cluster\" from volume \"fcd-1\"",
+		},
+		{
+			name:            "TestUpdateVolumeMetadataFailure",
+			otherClusterIDs: []string{testOtherClusterID},
+			failingMethod:   "UpdateVolumeMetadata",
+			expectedError:   "Failed to remove CNS metadata of cluster \"test-cluster\" from volume \"fcd-1\"",
+		},
+		{
+			name:          "TestQueryVolumeFailure",
+			failingMethod: "QueryVolume",
+			expectedError: "Unable to query volume \"fcd-1\" on vCenter \"vc1.example.com\"",
+		},
+		{
+			name:          "TestRetrieveVStorageObjectFailure",
+			failingMethod: "RetrieveVStorageObject",
+			expectedError: "Failed to retrieve FCD for volume \"fcd-1\"",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			k8sclient := newTestK8sClient()
+			patches := patchK8sClient(k8sclient)
+			defer patches.Reset()
+			volumeManager := unittestcommon.NewRegisteredVolumeManager(test.otherClusterIDs...)
+			volumeManager.Errors[test.failingMethod] = errors.New("CNS failure")
+			r := newVanillaUnregisterReconciler(volumeManager)
+
+			res, instance := reconcileTestInstance(t, r)
+			assert.Equal(t, reconcile.Result{RequeueAfter: time.Second}, res)
+			assert.False(t, instance.Status.Unregistered)
+			assert.Contains(t, instance.Status.Error, test.expectedError)
+			assert.Contains(t, volumeManager.Volumes, unittestcommon.TestVolumeID)
+
+			// Once CNS recovers, the retry completes the unregistration.
+			delete(volumeManager.Errors, test.failingMethod)
+			res, instance = reconcileTestInstance(t, r)
+			assert.Equal(t, reconcile.Result{}, res)
+			assert.True(t, instance.Status.Unregistered)
+			assert.Empty(t, instance.Status.Error)
+		})
+	}
+}
+
+func TestVanillaUnregisterUnknownVolume(t *testing.T) {
+	k8sclient := newTestK8sClient()
+	patches := patchK8sClient(k8sclient)
+	defer patches.Reset()
+	r := newVanillaUnregisterReconciler(unittestcommon.NewFakeVolumeManager())
+
+	res, instance := reconcileTestInstance(t, r)
+	assert.Equal(t, reconcile.Result{}, res)
+	assert.True(t, instance.Status.Unregistered)
+	assert.Empty(t, instance.Status.FcdID)
+	// The PV and PVC are not touched when CNS does not know the volume.
+	_, err := k8sclient.CoreV1().PersistentVolumes().Get(context.TODO(), unittestcommon.TestPVName, metav1.GetOptions{})
+	assert.NoError(t, err)
+}
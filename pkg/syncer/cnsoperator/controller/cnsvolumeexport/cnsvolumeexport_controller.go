@@ -0,0 +1,416 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cnsvolumeexport
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	apis "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator"
+	cnsvolumeexportv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsvolumeexport/v1alpha1"
+	volumes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	commonconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+	k8s "sigs.k8s.io/vsphere-csi-driver/v3/pkg/kubernetes"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer"
+	cnsoperatorutil "sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer/cnsoperator/util"
+)
+
+const (
+	defaultMaxWorkerThreadsForVolumeExport = 10
+	metadata                               = "VOLUME_METADATA"
+)
+
+var (
+	// backOffDuration is a map of cnsvolumeexport name's to the time after
+	// which a request for this instance will be requeued.
+	// Initialized to 1 second for new instances and for instances whose latest
+	// reconcile operation succeeded.
+	// If the reconcile fails, backoff is incremented exponentially.
+	backOffDuration         map[string]time.Duration
+	backOffDurationMapMutex = sync.Mutex{}
+)
+
+// Add creates a new CnsVolumeExport Controller and adds it to the Manager,
+// ConfigurationInfo and VirtualCenterTypes. The Manager will set fields on
+// the Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager, clusterFlavor cnstypes.CnsClusterFlavor,
+	configInfo *commonconfig.ConfigurationInfo, volumeManager volumes.Manager) error {
+	ctx, log := logger.GetNewContextWithLogger()
+	if clusterFlavor != cnstypes.CnsClusterFlavorVanilla {
+		log.Debug("Not initializing the CnsVolumeExport Controller as its a non-Vanilla CSI deployment")
+		return nil
+	}
+
+	coCommonInterface, err := commonco.GetContainerOrchestratorInterface(ctx,
+		common.Kubernetes, clusterFlavor, &syncer.COInitParams)
+	if err != nil {
+		log.Errorf("failed to create CO agnostic interface. Err: %v", err)
+		return err
+	}
+	if !coCommonInterface.IsFSSEnabled(ctx, common.VanillaVolumeHandoff) {
+		log.Infof("Not initializing the CnsVolumeExport Controller as this feature is disabled on the cluster")
+		return nil
+	}
+
+	volumeManagers, err := cnsoperatorutil.GetVanillaVolumeManagers(ctx, configInfo, volumeManager)
+	if err != nil {
+		log.Errorf("failed to get volume managers for CnsVolumeExport Controller. Err: %v", err)
+		return err
+	}
+
+	// Initializes kubernetes client.
+	k8sclient, err := k8s.NewClient(ctx)
+	if err != nil {
+		log.Errorf("Creating Kubernetes client failed. Err: %v", err)
+		return err
+	}
+
+	// eventBroadcaster broadcasts events on cnsvolumeexport instances to the
+	// event sink.
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(
+		&typedcorev1.EventSinkImpl{
+			Interface: k8sclient.CoreV1().Events(""),
+		},
+	)
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: apis.GroupName})
+	return add(mgr, newReconciler(mgr, configInfo, volumeManagers, recorder))
+}
+
+// newReconciler returns a new reconcile.Reconciler.
+func newReconciler(mgr manager.Manager, configInfo *commonconfig.ConfigurationInfo,
+	volumeManagers map[string]volumes.Manager, recorder record.EventRecorder) reconcile.Reconciler {
+	return &ReconcileCnsVolumeExport{client: mgr.GetClient(), scheme: mgr.GetScheme(),
+		configInfo: configInfo, volumeManagers: volumeManagers, recorder: recorder}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler.
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	_, log := logger.GetNewContextWithLogger()
+
+	// Create a new controller.
+	c, err := controller.New("cnsvolumeexport-controller", mgr,
+		controller.Options{Reconciler: r, MaxConcurrentReconciles: defaultMaxWorkerThreadsForVolumeExport})
+	if err != nil {
+		log.Errorf("Failed to create new CnsVolumeExport controller with error: %+v", err)
+		return err
+	}
+
+	backOffDuration = make(map[string]time.Duration)
+
+	// Watch for changes to primary resource CnsVolumeExport.
+	err = c.Watch(source.Kind(mgr.GetCache(), &cnsvolumeexportv1alpha1.CnsVolumeExport{}),
+		&handler.EnqueueRequestForObject{})
+	if err != nil {
+		log.Errorf("Failed to watch for changes to CnsVolumeExport resource with error: %+v", err)
+		return err
+	}
+	return nil
+}
+
+// blank assignment to verify that ReconcileCnsVolumeExport implements
+// reconcile.Reconciler.
+var _ reconcile.Reconciler = &ReconcileCnsVolumeExport{}
+
+// ReconcileCnsVolumeExport reconciles a CnsVolumeExport object.
+type ReconcileCnsVolumeExport struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver.
+	client     client.Client
+	scheme     *runtime.Scheme
+	configInfo *commonconfig.ConfigurationInfo
+	// volumeManagers maps vCenter host to its volume manager.
+	volumeManagers map[string]volumes.Manager
+	recorder       record.EventRecorder
+}
+
+// Reconcile reads that state of the cluster for a CnsVolumeExport object and
+// releases the volume of the PVC in its spec from this cluster, so that it
+// can be imported by another cluster on the same vCenter with a
+// CnsVolumeImport instance.
+// Note:
+// The Controller will requeue the Request to be processed again if the
+// returned error is non-nil or Result.Requeue is true. Otherwise, upon
+// completion it will remove the work from the queue.
+func (r *ReconcileCnsVolumeExport) Reconcile(ctx context.Context,
+	request reconcile.Request) (reconcile.Result, error) {
+	log := logger.GetLogger(ctx)
+
+	// Fetch the CnsVolumeExport instance.
+	instance := &cnsvolumeexportv1alpha1.CnsVolumeExport{}
+	err := r.client.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Infof("CnsVolumeExport resource not found. Ignoring since object must be deleted.")
+			return reconcile.Result{}, nil
+		}
+		log.Errorf("Error reading the CnsVolumeExport with name: %q on namespace: %q. Err: %+v",
+			request.Name, request.Namespace, err)
+		// Error reading the object - return with err.
+		return reconcile.Result{}, err
+	}
+	// Initialize backOffDuration for the instance, if required.
+	backOffDurationMapMutex.Lock()
+	var timeout time.Duration
+	if _, exists := backOffDuration[instance.Name]; !exists {
+		backOffDuration[instance.Name] = time.Second
+	}
+	timeout = backOffDuration[instance.Name]
+	backOffDurationMapMutex.Unlock()
+	// If the CnsVolumeExport instance is already exported, remove the instance
+	// from the queue.
+	if instance.Status.Exported {
+		backOffDurationMapMutex.Lock()
+		delete(backOffDuration, instance.Name)
+		backOffDurationMapMutex.Unlock()
+		return reconcile.Result{}, nil
+	}
+	log.Infof("Reconciling CnsVolumeExport instance %q from namespace %q. timeout %q seconds",
+		instance.Name, request.Namespace, timeout)
+
+	// 1. Find the volume bound to the PVC.
+	// 2. Validate the volume is not in use.
+	// 3. Mark the FCD of the volume as exported by this cluster.
+	// 4. Delete the PVC and PV, retaining the volume.
+	// 5. Remove the CNS metadata of this cluster from the volume.
+	// 6. Set the CnsVolumeExportStatus.Exported to true.
+
+	k8sclient, err := k8s.NewClient(ctx)
+	if err != nil {
+		log.Errorf("Failed to initialize K8S client when reconciling CnsVolumeExport "+
+			"instance: %s on namespace: %s. Error: %+v", instance.Name, instance.Namespace, err)
+		setInstanceError(ctx, r, instance, "Failed to init K8S client for volume export")
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	volumeID, err := getVolumeIDForInstance(ctx, k8sclient, instance)
+	if err != nil {
+		log.Error(err)
+		setInstanceError(ctx, r, instance, err.Error())
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	// Record the volume ID right away, the PVC is gone if a later step fails.
+	instance.Status.VolumeID = volumeID
+
+	vcHost, volumeManager, err := cnsoperatorutil.GetVolumeManagerForFcd(ctx, r.volumeManagers, volumeID)
+	if err != nil {
+		setInstanceError(ctx, r, instance, err.Error())
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	querySelection := cnstypes.CnsQuerySelection{
+		Names: []string{
+			metadata,
+		},
+	}
+	_, _, cnsVol, err := cnsoperatorutil.QueryVolumeOnVCenters(ctx,
+		map[string]volumes.Manager{vcHost: volumeManager}, volumeID, &querySelection)
+	if err != nil {
+		setInstanceError(ctx, r, instance, err.Error())
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	clusterID := r.configInfo.Cfg.Global.ClusterID
+	var (
+		clusterEntityMetadata []cnstypes.BaseCnsEntityMetadata
+		pvName                string
+		pvcName               = instance.Spec.PvcName
+		pvcNamespace          = instance.Namespace
+	)
+	if cnsVol != nil {
+		clusterEntityMetadata, pvName, _, _ = cnsoperatorutil.GetClusterEntityMetadata(cnsVol, clusterID)
+	}
+	err = cnsoperatorutil.ValidateVolumeNotInUseForVanilla(ctx, volumeID, pvName, pvcName, pvcNamespace, k8sclient)
+	if err != nil {
+		log.Error(err)
+		setInstanceError(ctx, r, instance, err.Error())
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	diskPath, err := cnsoperatorutil.GetFcdDiskPath(ctx, volumeManager, volumeID)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to retrieve FCD for volume %q. Error: %+v", volumeID, err)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	// Mark the FCD before this cluster lets go of the volume. An importing
+	// cluster only claims volumes carrying the mark.
+	err = volumeManager.SetVolumeExportedFrom(ctx, volumeID, clusterID)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to mark volume %q as exported. Error: %+v", volumeID, err)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	err = cnsoperatorutil.DeletePVAndPVCRetainingVolume(ctx, k8sclient, volumeID, pvName, pvcName, pvcNamespace)
+	if err != nil {
+		setInstanceError(ctx, r, instance, fmt.Sprintf("Failed to delete PV and PVC of volume %q. Error: %+v",
+			volumeID, err))
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	if cnsVol != nil {
+		err = cnsoperatorutil.RemoveClusterFromVolume(ctx, r.configInfo, vcHost, volumeManager, cnsVol,
+			clusterEntityMetadata)
+		if err != nil {
+			msg := fmt.Sprintf("Failed to remove CNS metadata of cluster %q from volume %q. Error: %+v",
+				clusterID, volumeID, err)
+			log.Error(msg)
+			setInstanceError(ctx, r, instance, msg)
+			return reconcile.Result{RequeueAfter: timeout}, nil
+		}
+	}
+
+	// Update the instance to indicate the volume export is successful.
+	instance.Status.DiskPath = diskPath
+	msg := fmt.Sprintf("Successfully exported volume %q of PVC %q on namespace: %s",
+		volumeID, instance.Spec.PvcName, instance.Namespace)
+	err = setInstanceSuccess(ctx, r, instance, msg)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to update CnsVolumeExport instance with error: %+v", err)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	backOffDurationMapMutex.Lock()
+	delete(backOffDuration, instance.Name)
+	backOffDurationMapMutex.Unlock()
+	log.Info(msg)
+	return reconcile.Result{}, nil
+}
+
+// getVolumeIDForInstance returns the volume ID of the PV bound to the PVC in
+// the CnsVolumeExport instance. Once the PVC has been deleted by an earlier
+// reconcile, the volume ID recorded in the instance status is returned.
+func getVolumeIDForInstance(ctx context.Context, k8sclient clientset.Interface,
+	instance *cnsvolumeexportv1alpha1.CnsVolumeExport) (string, error) {
+	log := logger.GetLogger(ctx)
+	pvc, err := k8sclient.CoreV1().PersistentVolumeClaims(instance.Namespace).Get(ctx,
+		instance.Spec.PvcName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) && instance.Status.VolumeID != "" {
+			return instance.Status.VolumeID, nil
+		}
+		return "", logger.LogNewErrorf(log, "failed to get PVC %q on namespace %q. Error: %v",
+			instance.Spec.PvcName, instance.Namespace, err)
+	}
+	if pvc.Status.Phase != v1.ClaimBound || pvc.Spec.VolumeName == "" {
+		return "", logger.LogNewErrorf(log, "PVC %q on namespace %q is not bound",
+			instance.Spec.PvcName, instance.Namespace)
+	}
+	pv, err := k8sclient.CoreV1().PersistentVolumes().Get(ctx, pvc.Spec.VolumeName, metav1.GetOptions{})
+	if err != nil {
+		return "", logger.LogNewErrorf(log, "failed to get PV %q bound to PVC %q. Error: %v",
+			pvc.Spec.VolumeName, instance.Spec.PvcName, err)
+	}
+	if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != common.VSphereCSIDriverName {
+		return "", logger.LogNewErrorf(log, "PV %q is not provisioned by %q",
+			pv.Name, common.VSphereCSIDriverName)
+	}
+	for _, accessMode := range pv.Spec.AccessModes {
+		if accessMode == v1.ReadWriteMany || accessMode == v1.ReadOnlyMany {
+			return "", logger.LogNewErrorf(log, "PV %q is a file volume, only block volumes can be exported",
+				pv.Name)
+		}
+	}
+	return pv.Spec.CSI.VolumeHandle, nil
+}
+
+// setInstanceError sets error and records an event on the CnsVolumeExport
+// instance.
+func setInstanceError(ctx context.Context, r *ReconcileCnsVolumeExport,
+	instance *cnsvolumeexportv1alpha1.CnsVolumeExport, errMsg string) {
+	log := logger.GetLogger(ctx)
+	instance.Status.Error = errMsg
+	err := updateCnsVolumeExport(ctx, r.client, instance)
+	if err != nil {
+		log.Errorf("updateCnsVolumeExport failed. err: %v", err)
+	}
+	recordEvent(ctx, r, instance, v1.EventTypeWarning, errMsg)
+}
+
+// setInstanceSuccess sets instance to success and records an event on the
+// CnsVolumeExport instance.
+func setInstanceSuccess(ctx context.Context, r *ReconcileCnsVolumeExport,
+	instance *cnsvolumeexportv1alpha1.CnsVolumeExport, msg string) error {
+	instance.Status.Exported = true
+	instance.Status.Error = ""
+	err := updateCnsVolumeExport(ctx, r.client, instance)
+	if err != nil {
+		return err
+	}
+	recordEvent(ctx, r, instance, v1.EventTypeNormal, msg)
+	return nil
+}
+
+// recordEvent records the event, sets the backOffDuration for the instance
+// appropriately and logs the message.
+// backOffDuration is reset to 1 second on success and doubled on failure.
+func recordEvent(ctx context.Context, r *ReconcileCnsVolumeExport,
+	instance *cnsvolumeexportv1alpha1.CnsVolumeExport, eventtype string, msg string) {
+	log := logger.GetLogger(ctx)
+	log.Debugf("Event type is %s", eventtype)
+	switch eventtype {
+	case v1.EventTypeWarning:
+		// Double backOff duration.
+		backOffDurationMapMutex.Lock()
+		backOffDuration[instance.Name] = backOffDuration[instance.Name] * 2
+		r.recorder.Event(instance, v1.EventTypeWarning, "CnsVolumeExportFailed", msg)
+		backOffDurationMapMutex.Unlock()
+	case v1.EventTypeNormal:
+		// Reset backOff duration to one second.
+		backOffDurationMapMutex.Lock()
+		backOffDuration[instance.Name] = time.Second
+		r.recorder.Event(instance, v1.EventTypeNormal, "CnsVolumeExportSucceeded", msg)
+		backOffDurationMapMutex.Unlock()
+	}
+}
+
+// updateCnsVolumeExport updates the CnsVolumeExport instance in K8S.
+func updateCnsVolumeExport(ctx context.Context, client client.Client,
+	instance *cnsvolumeexportv1alpha1.CnsVolumeExport) error {
+	log := logger.GetLogger(ctx)
+	err := client.Update(ctx, instance)
+	if err != nil {
+		log.Errorf("Failed to update CnsVolumeExport instance: %q on namespace: %q. Error: %+v",
+			instance.Name, instance.Namespace, err)
+	}
+	return err
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cnsvolumeexport
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientset "k8s.io/client-go/kubernetes"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	cnsvolumeexportv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsvolumeexport/v1alpha1"
+	volumes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/unittestcommon"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	k8s "sigs.k8s.io/vsphere-csi-driver/v3/pkg/kubernetes"
+)
+
+const testInstanceName = "test-export"
+
+// newTestExportReconciler returns a ReconcileCnsVolumeExport with the given
+// volume manager and a fake client tracking an instance exporting the test
+// PVC.
+func newTestExportReconciler(volumeManager volumes.Manager) *ReconcileCnsVolumeExport {
+	instance := &cnsvolumeexportv1alpha1.CnsVolumeExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testInstanceName,
+			Namespace: unittestcommon.TestNamespace,
+		},
+		Spec: cnsvolumeexportv1alpha1.CnsVolumeExportSpec{
+			PvcName: unittestcommon.TestPVCName,
+		},
+	}
+	fakeClient, s := unittestcommon.NewFakeCnsOperatorClient(instance)
+	backOffDuration = make(map[string]time.Duration)
+	return &ReconcileCnsVolumeExport{
+		client:         fakeClient,
+		scheme:         s,
+		configInfo:     unittestcommon.NewCnsOperatorConfigInfo(unittestcommon.TestVCHost),
+		volumeManagers: map[string]volumes.Manager{unittestcommon.TestVCHost: volumeManager},
+		recorder:       record.NewFakeRecorder(unittestcommon.TestEventsBufferSize),
+	}
+}
+
+// newTestPVAndPVC returns the test PVC bound to a PV of the test volume with
+// the given access mode.
+func newTestPVAndPVC(accessMode v1.PersistentVolumeAccessMode) (*v1.PersistentVolume,
+	*v1.PersistentVolumeClaim) {
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: unittestcommon.TestPVName},
+		Spec: v1.PersistentVolumeSpec{
+			AccessModes:                   []v1.PersistentVolumeAccessMode{accessMode},
+			PersistentVolumeReclaimPolicy: v1.PersistentVolumeReclaimDelete,
+			ClaimRef: &v1.ObjectReference{
+				Name:      unittestcommon.TestPVCName,
+				Namespace: unittestcommon.TestNamespace,
+			},
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				CSI: &v1.CSIPersistentVolumeSource{
+					Driver:       common.VSphereCSIDriverName,
+					VolumeHandle: unittestcommon.TestVolumeID,
+				},
+			},
+		},
+		Status: v1.PersistentVolumeStatus{Phase: v1.VolumeBound},
+	}
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: unittestcommon.TestPVCName, Namespace: unittestcommon.TestNamespace},
+		Spec:       v1.PersistentVolumeClaimSpec{VolumeName: unittestcommon.TestPVName},
+		Status:     v1.PersistentVolumeClaimStatus{Phase: v1.ClaimBound},
+	}
+	return pv, pvc
+}
+
+func patchK8sClient(k8sclient clientset.Interface) *gomonkey.Patches {
+	return gomonkey.ApplyFunc(k8s.NewClient, func(_ context.Context) (clientset.Interface, error) {
+		return k8sclient, nil
+	})
+}
+
+func reconcileTestInstance(t *testing.T, r *ReconcileCnsVolumeExport) (
+	reconcile.Result, *cnsvolumeexportv1alpha1.CnsVolumeExport) {
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{Name: testInstanceName, Namespace: unittestcommon.TestNamespace},
+	}
+	res, err := r.Reconcile(context.TODO(), req)
+	if err != nil {
+		t.Fatalf("unexpected reconcile error: %v", err)
+	}
+	instance := &cnsvolumeexportv1alpha1.CnsVolumeExport{}
+	if err := r.client.Get(context.TODO(), req.NamespacedName, instance); err != nil {
+		t.Fatalf("failed to get CnsVolumeExport instance: %v", err)
+	}
+	return res, instance
+}
+
+func TestVolumeExportReleasesVolume(t *testing.T) {
+	pv, pvc := newTestPVAndPVC(v1.ReadWriteOnce)
+	k8sclient := testclient.NewSimpleClientset(pv, pvc)
+	patches := patchK8sClient(k8sclient)
+	defer patches.Reset()
+	volumeManager := unittestcommon.NewRegisteredVolumeManager()
+	r := newTestExportReconciler(volumeManager)
+
+	res, instance := reconcileTestInstance(t, r)
+	assert.Equal(t, reconcile.Result{}, res)
+	assert.True(t, instance.Status.Exported)
+	assert.Empty(t, instance.Status.Error)
+	assert.Equal(t, unittestcommon.TestVolumeID, instance.Status.VolumeID)
+	assert.Equal(t, unittestcommon.TestDiskPath, instance.Status.DiskPath)
+
+	// The FCD is retained and marked as exported by this cluster.
+	if assert.Contains(t, volumeManager.Fcds, unittestcommon.TestVolumeID) {
+		assert.Equal(t, unittestcommon.TestClusterID, volumeManager.Fcds[unittestcommon.TestVolumeID].ExportedFrom)
+	}
+	assert.NotContains(t, volumeManager.Volumes, unittestcommon.TestVolumeID)
+	_, err := k8sclient.CoreV1().PersistentVolumeClaims(unittestcommon.TestNamespace).Get(context.TODO(),
+		unittestcommon.TestPVCName, metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+	_, err = k8sclient.CoreV1().PersistentVolumes().Get(context.TODO(), unittestcommon.TestPVName, metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestVolumeExportRejectsVolume(t *testing.T) {
+	tests := []struct {
+		name          string
+		accessMode    v1.PersistentVolumeAccessMode
+		isUnbound     bool
+		objects       []runtime.Object
+		expectedError string
+	}{
+		{
+			name:          "TestUnboundPVC",
+			accessMode:    v1.ReadWriteOnce,
+			isUnbound:     true,
+			expectedError: "PVC \"test-pvc\" on namespace \"test-ns\" is not bound",
+		},
+		{
+			name:          "TestFileVolume",
+			accessMode:    v1.ReadWriteMany,
+			expectedError: "PV \"test-pv\" is a file volume, only block volumes can be exported",
+		},
+		{
+			name:       "TestVolumeUsedByPod",
+			accessMode: v1.ReadWriteOnce,
+			objects: []runtime.Object{&v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: unittestcommon.TestNamespace},
+				Spec: v1.PodSpec{
+					Volumes: []v1.Volume{{
+						Name: "data",
+						VolumeSource: v1.VolumeSource{
+							PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: unittestcommon.TestPVCName},
+						},
+					}},
+				},
+			}},
+			expectedError: "volume fcd-1 is in use by pod test-pod in namespace test-ns",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pv, pvc := newTestPVAndPVC(test.accessMode)
+			if test.isUnbound {
+				pvc.Spec.VolumeName = ""
+				pvc.Status.Phase = v1.ClaimPending
+			}
+			k8sclient := testclient.NewSimpleClientset(append([]runtime.Object{pv, pvc}, test.objects...)...)
+			patches := patchK8sClient(k8sclient)
+			defer patches.Reset()
+			volumeManager := unittestcommon.NewRegisteredVolumeManager()
+			r := newTestExportReconciler(volumeManager)
+
+			res, instance := reconcileTestInstance(t, r)
+			assert.Equal(t, reconcile.Result{RequeueAfter: time.Second}, res)
+			assert.False(t, instance.Status.Exported)
+			assert.Equal(t, test.expectedError, instance.Status.Error)
+
+			// The volume stays with this cluster.
+			assert.Empty(t, volumeManager.Fcds[unittestcommon.TestVolumeID].ExportedFrom)
+			assert.Contains(t, volumeManager.Volumes, unittestcommon.TestVolumeID)
+			_, err := k8sclient.CoreV1().PersistentVolumeClaims(unittestcommon.TestNamespace).Get(context.TODO(),
+				unittestcommon.TestPVCName, metav1.GetOptions{})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestVolumeExportResumesAfterPVCDeletion(t *testing.T) {
+	pv, pvc := newTestPVAndPVC(v1.ReadWriteOnce)
+	k8sclient := testclient.NewSimpleClientset(pv, pvc)
+	patches := patchK8sClient(k8sclient)
+	defer patches.Reset()
+	volumeManager := unittestcommon.NewRegisteredVolumeManager()
+	volumeManager.Errors["DeleteVolume"] = errors.New("CNS failure")
+	r := newTestExportReconciler(volumeManager)
+
+	res, instance := reconcileTestInstance(t, r)
+	assert.Equal(t, reconcile.Result{RequeueAfter: time.Second}, res)
+	assert.False(t, instance.Status.Exported)
+	assert.Contains(t, instance.Status.Error,
+		"Failed to remove CNS metadata of cluster \"test-cluster\" from volume \"fcd-1\"")
+	// The PVC is gone, the retry finds the volume from the instance status.
+	assert.Equal(t, unittestcommon.TestVolumeID, instance.Status.VolumeID)
+	_, err := k8sclient.CoreV1().PersistentVolumeClaims(unittestcommon.TestNamespace).Get(context.TODO(),
+		unittestcommon.TestPVCName, metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	delete(volumeManager.Errors, "DeleteVolume")
+	res, instance = reconcileTestInstance(t, r)
+	assert.Equal(t, reconcile.Result{}, res)
+	assert.True(t, instance.Status.Exported)
+	assert.Empty(t, instance.Status.Error)
+	assert.Equal(t, unittestcommon.TestDiskPath, instance.Status.DiskPath)
+	assert.NotContains(t, volumeManager.Volumes, unittestcommon.TestVolumeID)
+	assert.Equal(t, unittestcommon.TestClusterID, volumeManager.Fcds[unittestcommon.TestVolumeID].ExportedFrom)
+}
+
+func TestVolumeExportWithUnknownPVC(t *testing.T) {
+	patches := patchK8sClient(testclient.NewSimpleClientset())
+	defer patches.Reset()
+	r := newTestExportReconciler(unittestcommon.NewRegisteredVolumeManager())
+
+	res, instance := reconcileTestInstance(t, r)
+	assert.Equal(t, reconcile.Result{RequeueAfter: time.Second}, res)
+	assert.False(t, instance.Status.Exported)
+	assert.Contains(t, instance.Status.Error, "failed to get PVC \"test-pvc\" on namespace \"test-ns\"")
+}
@@ -0,0 +1,441 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cnsvolumeimport
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	apis "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator"
+	cnsvolumeimportv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsvolumeimport/v1alpha1"
+	volumes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	commonconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+	k8s "sigs.k8s.io/vsphere-csi-driver/v3/pkg/kubernetes"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer"
+	cnsoperatorutil "sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer/cnsoperator/util"
+)
+
+const (
+	defaultMaxWorkerThreadsForVolumeImport = 10
+	metadata                               = "VOLUME_METADATA"
+	// importedPvNamePrefix is the prefix of the name of the PV created for
+	// an imported volume.
+	importedPvNamePrefix = "imported-pv-"
+)
+
+var (
+	// backOffDuration is a map of cnsvolumeimport name's to the time after
+	// which a request for this instance will be requeued.
+	// Initialized to 1 second for new instances and for instances whose latest
+	// reconcile operation succeeded.
+	// If the reconcile fails, backoff is incremented exponentially.
+	backOffDuration         map[string]time.Duration
+	backOffDurationMapMutex = sync.Mutex{}
+)
+
+// Add creates a new CnsVolumeImport Controller and adds it to the Manager,
+// ConfigurationInfo and VirtualCenterTypes. The Manager will set fields on
+// the Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager, clusterFlavor cnstypes.CnsClusterFlavor,
+	configInfo *commonconfig.ConfigurationInfo, volumeManager volumes.Manager) error {
+	ctx, log := logger.GetNewContextWithLogger()
+	if clusterFlavor != cnstypes.CnsClusterFlavorVanilla {
+		log.Debug("Not initializing the CnsVolumeImport Controller as its a non-Vanilla CSI deployment")
+		return nil
+	}
+
+	coCommonInterface, err := commonco.GetContainerOrchestratorInterface(ctx,
+		common.Kubernetes, clusterFlavor, &syncer.COInitParams)
+	if err != nil {
+		log.Errorf("failed to create CO agnostic interface. Err: %v", err)
+		return err
+	}
+	if !coCommonInterface.IsFSSEnabled(ctx, common.VanillaVolumeHandoff) {
+		log.Infof("Not initializing the CnsVolumeImport Controller as this feature is disabled on the cluster")
+		return nil
+	}
+
+	volumeManagers, err := cnsoperatorutil.GetVanillaVolumeManagers(ctx, configInfo, volumeManager)
+	if err != nil {
+		log.Errorf("failed to get volume managers for CnsVolumeImport Controller. Err: %v", err)
+		return err
+	}
+
+	// Initializes kubernetes client.
+	k8sclient, err := k8s.NewClient(ctx)
+	if err != nil {
+		log.Errorf("Creating Kubernetes client failed. Err: %v", err)
+		return err
+	}
+
+	// eventBroadcaster broadcasts events on cnsvolumeimport instances to the
+	// event sink.
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(
+		&typedcorev1.EventSinkImpl{
+			Interface: k8sclient.CoreV1().Events(""),
+		},
+	)
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: apis.GroupName})
+	return add(mgr, newReconciler(mgr, configInfo, volumeManagers, recorder))
+}
+
+// newReconciler returns a new reconcile.Reconciler.
+func newReconciler(mgr manager.Manager, configInfo *commonconfig.ConfigurationInfo,
+	volumeManagers map[string]volumes.Manager, recorder record.EventRecorder) reconcile.Reconciler {
+	return &ReconcileCnsVolumeImport{client: mgr.GetClient(), scheme: mgr.GetScheme(),
+		configInfo: configInfo, volumeManagers: volumeManagers, recorder: recorder}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler.
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	_, log := logger.GetNewContextWithLogger()
+
+	// Create a new controller.
+	c, err := controller.New("cnsvolumeimport-controller", mgr,
+		controller.Options{Reconciler: r, MaxConcurrentReconciles: defaultMaxWorkerThreadsForVolumeImport})
+	if err != nil {
+		log.Errorf("Failed to create new CnsVolumeImport controller with error: %+v", err)
+		return err
+	}
+
+	backOffDuration = make(map[string]time.Duration)
+
+	// Watch for changes to primary resource CnsVolumeImport.
+	err = c.Watch(source.Kind(mgr.GetCache(), &cnsvolumeimportv1alpha1.CnsVolumeImport{}),
+		&handler.EnqueueRequestForObject{})
+	if err != nil {
+		log.Errorf("Failed to watch for changes to CnsVolumeImport resource with error: %+v", err)
+		return err
+	}
+	return nil
+}
+
+// blank assignment to verify that ReconcileCnsVolumeImport implements
+// reconcile.Reconciler.
+var _ reconcile.Reconciler = &ReconcileCnsVolumeImport{}
+
+// ReconcileCnsVolumeImport reconciles a CnsVolumeImport object.
+type ReconcileCnsVolumeImport struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver.
+	client     client.Client
+	scheme     *runtime.Scheme
+	configInfo *commonconfig.ConfigurationInfo
+	// volumeManagers maps vCenter host to its volume manager.
+	volumeManagers map[string]volumes.Manager
+	recorder       record.EventRecorder
+}
+
+// Reconcile reads that state of the cluster for a CnsVolumeImport object and
+// claims the volume in its spec, exported by another cluster on the same
+// vCenter with a CnsVolumeExport instance, creating a PV and PVC for it.
+// Note:
+// The Controller will requeue the Request to be processed again if the
+// returned error is non-nil or Result.Requeue is true. Otherwise, upon
+// completion it will remove the work from the queue.
+func (r *ReconcileCnsVolumeImport) Reconcile(ctx context.Context,
+	request reconcile.Request) (reconcile.Result, error) {
+	log := logger.GetLogger(ctx)
+
+	// Fetch the CnsVolumeImport instance.
+	instance := &cnsvolumeimportv1alpha1.CnsVolumeImport{}
+	err := r.client.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Infof("CnsVolumeImport resource not found. Ignoring since object must be deleted.")
+			return reconcile.Result{}, nil
+		}
+		log.Errorf("Error reading the CnsVolumeImport with name: %q on namespace: %q. Err: %+v",
+			request.Name, request.Namespace, err)
+		// Error reading the object - return with err.
+		return reconcile.Result{}, err
+	}
+	// Initialize backOffDuration for the instance, if required.
+	backOffDurationMapMutex.Lock()
+	var timeout time.Duration
+	if _, exists := backOffDuration[instance.Name]; !exists {
+		backOffDuration[instance.Name] = time.Second
+	}
+	timeout = backOffDuration[instance.Name]
+	backOffDurationMapMutex.Unlock()
+	// If the CnsVolumeImport instance is already imported, remove the instance
+	// from the queue.
+	if instance.Status.Imported {
+		backOffDurationMapMutex.Lock()
+		delete(backOffDuration, instance.Name)
+		backOffDurationMapMutex.Unlock()
+		return reconcile.Result{}, nil
+	}
+	log.Infof("Reconciling CnsVolumeImport instance %q from namespace %q. timeout %q seconds",
+		instance.Name, request.Namespace, timeout)
+
+	// 1. Validate the volume has been released by the cluster which exported it.
+	// 2. Register the volume with CNS for this cluster.
+	// 3. Clear the export mark on the FCD of the volume.
+	// 4. Create the PV and PVC and wait for the PVC to be bound.
+	// 5. Set the CnsVolumeImportStatus.Imported to true.
+
+	volumeID := instance.Spec.VolumeID
+	clusterID := r.configInfo.Cfg.Global.ClusterID
+	vcHost, volumeManager, err := cnsoperatorutil.GetVolumeManagerForFcd(ctx, r.volumeManagers, volumeID)
+	if err != nil {
+		setInstanceError(ctx, r, instance, err.Error())
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	vc, err := cnsvsphere.GetVirtualCenterManager(ctx).GetVirtualCenter(ctx, vcHost)
+	if err != nil {
+		log.Errorf("Failed to get virtual center instance for %q with error: %+v", vcHost, err)
+		setInstanceError(ctx, r, instance, "Unable to connect to VC for volume import")
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	querySelection := cnstypes.CnsQuerySelection{
+		Names: []string{
+			metadata,
+		},
+	}
+	_, _, cnsVol, err := cnsoperatorutil.QueryVolumeOnVCenters(ctx,
+		map[string]volumes.Manager{vcHost: volumeManager}, volumeID, &querySelection)
+	if err != nil {
+		setInstanceError(ctx, r, instance, err.Error())
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	pvName := importedPvNamePrefix + volumeID
+	if cnsVol != nil {
+		// The volume is only registered with CNS for this cluster if an earlier
+		// reconcile of the instance got past registration.
+		for _, containerCluster := range cnsVol.Metadata.ContainerClusterArray {
+			if containerCluster.ClusterId != clusterID {
+				msg := fmt.Sprintf("Volume %q is still registered with cluster %q. It must be exported "+
+					"from that cluster with a CnsVolumeExport instance first", volumeID, containerCluster.ClusterId)
+				log.Error(msg)
+				setInstanceError(ctx, r, instance, msg)
+				return reconcile.Result{RequeueAfter: timeout}, nil
+			}
+		}
+	} else {
+		exportedFrom, err := volumeManager.GetVolumeExportedFrom(ctx, volumeID)
+		if err != nil {
+			msg := fmt.Sprintf("Failed to retrieve metadata of volume %q. Error: %+v", volumeID, err)
+			log.Error(msg)
+			setInstanceError(ctx, r, instance, msg)
+			return reconcile.Result{RequeueAfter: timeout}, nil
+		}
+		if exportedFrom == "" {
+			msg := fmt.Sprintf("Volume %q has not been exported by any cluster", volumeID)
+			log.Error(msg)
+			setInstanceError(ctx, r, instance, msg)
+			return reconcile.Result{RequeueAfter: timeout}, nil
+		}
+		log.Infof("Importing volume %q exported by cluster %q", volumeID, exportedFrom)
+
+		containerCluster := cnsvsphere.GetContainerCluster(clusterID, vc.Config.Username,
+			cnstypes.CnsClusterFlavorVanilla, r.configInfo.Cfg.Global.ClusterDistribution)
+		createSpec := &cnstypes.CnsVolumeCreateSpec{
+			Name:       pvName,
+			VolumeType: common.BlockVolumeType,
+			Metadata: cnstypes.CnsVolumeMetadata{
+				ContainerCluster:      containerCluster,
+				ContainerClusterArray: []cnstypes.CnsContainerCluster{containerCluster},
+			},
+			BackingObjectDetails: &cnstypes.CnsBlockBackingDetails{
+				BackingDiskId: volumeID,
+			},
+		}
+		log.Debugf("CNS Volume create spec is: %+v", createSpec)
+		_, _, err = volumeManager.CreateVolume(ctx, createSpec, nil)
+		if err != nil {
+			log.Errorf("failed to create CNS volume on vCenter %q. Error: %+v", vcHost, err)
+			setInstanceError(ctx, r, instance, "failed to create CNS volume")
+			return reconcile.Result{RequeueAfter: timeout}, nil
+		}
+		log.Infof("Registered volume %q with CNS on vCenter %q", volumeID, vcHost)
+	}
+
+	// The volume now belongs to this cluster, clear the export mark so that it
+	// cannot be imported by another cluster.
+	err = volumeManager.SetVolumeExportedFrom(ctx, volumeID, "")
+	if err != nil {
+		msg := fmt.Sprintf("Failed to clear export mark of volume %q. Error: %+v", volumeID, err)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	querySelection = cnstypes.CnsQuerySelection{
+		Names: []string{
+			string(cnstypes.QuerySelectionNameTypeDataStoreUrl),
+			string(cnstypes.QuerySelectionNameTypePolicyId),
+			string(cnstypes.QuerySelectionNameTypeBackingObjectDetails),
+		},
+	}
+	volume, err := common.QueryVolumeByID(ctx, volumeManager, volumeID, &querySelection)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to query CNS volume: %s with error: %+v", volumeID, err)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	// Restrict the PV to the nodes which can access the datastore of the volume.
+	pvNodeAffinity, err := cnsoperatorutil.GetVolumeNodeAffinityForVanilla(ctx, r.client, vc, volume.DatastoreUrl)
+	if err != nil {
+		log.Errorf("Failed to find nodes with access to volume: %s on datastore: %s. Error: %+v",
+			volumeID, volume.DatastoreUrl, err)
+		setInstanceError(ctx, r, instance, err.Error())
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	k8sclient, err := k8s.NewClient(ctx)
+	if err != nil {
+		log.Errorf("Failed to initialize K8S client when reconciling CnsVolumeImport "+
+			"instance: %s on namespace: %s. Error: %+v", instance.Name, instance.Namespace, err)
+		setInstanceError(ctx, r, instance, "Failed to init K8S client for volume import")
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	var storageClassName string
+	if volume.StoragePolicyId != "" {
+		storageClassName, err = cnsoperatorutil.GetK8sStorageClassNameForVanillaPolicy(ctx, k8sclient, vc,
+			volume.StoragePolicyId)
+		if err != nil {
+			msg := fmt.Sprintf("Failed to find K8S Storageclass mapping storagepolicyId: %s. Error: %+v",
+				volume.StoragePolicyId, err)
+			log.Error(msg)
+			setInstanceError(ctx, r, instance, msg)
+			return reconcile.Result{RequeueAfter: timeout}, nil
+		}
+	}
+	log.Infof("Volume with storagepolicyId: %q is mapping to K8S storage class: %q",
+		volume.StoragePolicyId, storageClassName)
+
+	capacityInMb := volume.BackingObjectDetails.GetCnsBackingObjectDetails().CapacityInMb
+	pvc, err := cnsoperatorutil.CreatePVAndPVCForVolume(ctx, k8sclient, volumeID, pvName, instance.Spec.PvcName,
+		instance.Namespace, capacityInMb, storageClassName, v1.ReadWriteOnce, pvNodeAffinity)
+	if err != nil {
+		log.Error(err)
+		setInstanceError(ctx, r, instance, err.Error())
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	// Watch for PVC to be bound.
+	isBound, err := cnsoperatorutil.IsPVCBound(ctx, k8sclient, pvc, time.Duration(1*time.Minute))
+	if !isBound {
+		log.Errorf("PVC: %s is not bound. Error: %+v", instance.Spec.PvcName, err)
+		setInstanceError(ctx, r, instance, fmt.Sprintf("PVC: %s is not bound", instance.Spec.PvcName))
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	log.Infof("PVC: %s is bound", instance.Spec.PvcName)
+
+	// Update the instance to indicate the volume import is successful.
+	msg := fmt.Sprintf("Successfully imported volume %q as PVC %q on namespace: %s",
+		volumeID, instance.Spec.PvcName, instance.Namespace)
+	err = setInstanceSuccess(ctx, r, instance, msg)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to update CnsVolumeImport instance with error: %+v", err)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	backOffDurationMapMutex.Lock()
+	delete(backOffDuration, instance.Name)
+	backOffDurationMapMutex.Unlock()
+	log.Info(msg)
+	return reconcile.Result{}, nil
+}
+
+// setInstanceError sets error and records an event on the CnsVolumeImport
+// instance.
+func setInstanceError(ctx context.Context, r *ReconcileCnsVolumeImport,
+	instance *cnsvolumeimportv1alpha1.CnsVolumeImport, errMsg string) {
+	log := logger.GetLogger(ctx)
+	instance.Status.Error = errMsg
+	err := updateCnsVolumeImport(ctx, r.client, instance)
+	if err != nil {
+		log.Errorf("updateCnsVolumeImport failed. err: %v", err)
+	}
+	recordEvent(ctx, r, instance, v1.EventTypeWarning, errMsg)
+}
+
+// setInstanceSuccess sets instance to success and records an event on the
+// CnsVolumeImport instance.
+func setInstanceSuccess(ctx context.Context, r *ReconcileCnsVolumeImport,
+	instance *cnsvolumeimportv1alpha1.CnsVolumeImport, msg string) error {
+	instance.Status.Imported = true
+	instance.Status.Error = ""
+	err := updateCnsVolumeImport(ctx, r.client, instance)
+	if err != nil {
+		return err
+	}
+	recordEvent(ctx, r, instance, v1.EventTypeNormal, msg)
+	return nil
+}
+
+// recordEvent records the event, sets the backOffDuration for the instance
+// appropriately and logs the message.
+// backOffDuration is reset to 1 second on success and doubled on failure.
+func recordEvent(ctx context.Context, r *ReconcileCnsVolumeImport,
+	instance *cnsvolumeimportv1alpha1.CnsVolumeImport, eventtype string, msg string) {
+	log := logger.GetLogger(ctx)
+	log.Debugf("Event type is %s", eventtype)
+	switch eventtype {
+	case v1.EventTypeWarning:
+		// Double backOff duration.
+		backOffDurationMapMutex.Lock()
+		backOffDuration[instance.Name] = backOffDuration[instance.Name] * 2
+		r.recorder.Event(instance, v1.EventTypeWarning, "CnsVolumeImportFailed", msg)
+		backOffDurationMapMutex.Unlock()
+	case v1.EventTypeNormal:
+		// Reset backOff duration to one second.
+		backOffDurationMapMutex.Lock()
+		backOffDuration[instance.Name] = time.Second
+		r.recorder.Event(instance, v1.EventTypeNormal, "CnsVolumeImportSucceeded", msg)
+		backOffDurationMapMutex.Unlock()
+	}
+}
+
+// updateCnsVolumeImport updates the CnsVolumeImport instance in K8S.
+func updateCnsVolumeImport(ctx context.Context, client client.Client,
+	instance *cnsvolumeimportv1alpha1.CnsVolumeImport) error {
+	log := logger.GetLogger(ctx)
+	err := client.Update(ctx, instance)
+	if err != nil {
+		log.Errorf("Failed to update CnsVolumeImport instance: %q on namespace: %q. Error: %+v",
+			instance.Name, instance.Namespace, err)
+	}
+	return err
+}
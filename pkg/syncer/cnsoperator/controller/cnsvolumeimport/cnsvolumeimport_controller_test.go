@@ -0,0 +1,251 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cnsvolumeimport
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientset "k8s.io/client-go/kubernetes"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	cnsvolumeimportv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsvolumeimport/v1alpha1"
+	volumes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/unittestcommon"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+)
+
+const (
+	testExportingCluster = "exporting-cluster"
+	testInstanceName     = "test-import"
+	testImportedPVName   = importedPvNamePrefix + unittestcommon.TestVolumeID
+)
+
+// newTestImportReconciler returns a ReconcileCnsVolumeImport with the given
+// volume manager and a fake client tracking an instance importing the test
+// volume.
+func newTestImportReconciler(volumeManager volumes.Manager) *ReconcileCnsVolumeImport {
+	instance := &cnsvolumeimportv1alpha1.CnsVolumeImport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testInstanceName,
+			Namespace: unittestcommon.TestNamespace,
+		},
+		Spec: cnsvolumeimportv1alpha1.CnsVolumeImportSpec{
+			VolumeID: unittestcommon.TestVolumeID,
+			PvcName:  unittestcommon.TestPVCName,
+		},
+	}
+	fakeClient, s := unittestcommon.NewFakeCnsOperatorClient(instance)
+	backOffDuration = make(map[string]time.Duration)
+	return &ReconcileCnsVolumeImport{
+		client:         fakeClient,
+		scheme:         s,
+		configInfo:     unittestcommon.NewCnsOperatorConfigInfo(unittestcommon.TestVCHost),
+		volumeManagers: map[string]volumes.Manager{unittestcommon.TestVCHost: volumeManager},
+		recorder:       record.NewFakeRecorder(unittestcommon.TestEventsBufferSize),
+	}
+}
+
+// setupImportTest patches the calls of the reconcile which reach the API
+// server or the nodes of the cluster. PVCs are reported as bound when isBound
+// is set.
+func setupImportTest(t *testing.T, k8sclient clientset.Interface, isBound *bool) *gomonkey.Patches {
+	return unittestcommon.PatchVanillaCnsOperatorCalls(t, k8sclient, isBound, unittestcommon.TestVCHost)
+}
+
+// newExportedVolumeManager returns a FakeVolumeManager with the test FCD,
+// marked as exported by the given cluster and not registered with CNS.
+func newExportedVolumeManager(exportedFrom string) *unittestcommon.FakeVolumeManager {
+	return unittestcommon.NewFakeVolumeManager(&unittestcommon.FakeFcd{
+		ID:           unittestcommon.TestVolumeID,
+		DiskPath:     unittestcommon.TestDiskPath,
+		DatastoreURL: unittestcommon.TestDatastoreURL,
+		CapacityInMB: unittestcommon.TestCapacityInMB,
+		ExportedFrom: exportedFrom,
+	})
+}
+
+func reconcileTestInstance(t *testing.T, r *ReconcileCnsVolumeImport) (
+	reconcile.Result, *cnsvolumeimportv1alpha1.CnsVolumeImport) {
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{Name: testInstanceName, Namespace: unittestcommon.TestNamespace},
+	}
+	res, err := r.Reconcile(context.TODO(), req)
+	if err != nil {
+		t.Fatalf("unexpected reconcile error: %v", err)
+	}
+	instance := &cnsvolumeimportv1alpha1.CnsVolumeImport{}
+	if err := r.client.Get(context.TODO(), req.NamespacedName, instance); err != nil {
+		t.Fatalf("failed to get CnsVolumeImport instance: %v", err)
+	}
+	return res, instance
+}
+
+// assertVolumeImported checks that the test volume is registered with CNS for
+// this cluster only, no longer marked as exported, and bound to the PVC.
+func assertVolumeImported(t *testing.T, k8sclient clientset.Interface,
+	volumeManager *unittestcommon.FakeVolumeManager) {
+	volume, ok := volumeManager.Volumes[unittestcommon.TestVolumeID]
+	if !assert.True(t, ok, "volume is not registered with CNS") {
+		return
+	}
+	assert.Equal(t, common.BlockVolumeType, volume.VolumeType)
+	if assert.Len(t, volume.Metadata.ContainerClusterArray, 1) {
+		assert.Equal(t, unittestcommon.TestClusterID, volume.Metadata.ContainerClusterArray[0].ClusterId)
+	}
+	assert.Empty(t, volumeManager.Fcds[unittestcommon.TestVolumeID].ExportedFrom)
+
+	pv, err := k8sclient.CoreV1().PersistentVolumes().Get(context.TODO(), testImportedPVName, metav1.GetOptions{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, unittestcommon.TestVolumeID, pv.Spec.CSI.VolumeHandle)
+		assert.Equal(t, v1.PersistentVolumeReclaimDelete, pv.Spec.PersistentVolumeReclaimPolicy)
+	}
+	pvc, err := k8sclient.CoreV1().PersistentVolumeClaims(unittestcommon.TestNamespace).Get(context.TODO(),
+		unittestcommon.TestPVCName, metav1.GetOptions{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, testImportedPVName, pvc.Spec.VolumeName)
+	}
+}
+
+func TestVolumeImportClaimsExportedVolume(t *testing.T) {
+	k8sclient := testclient.NewSimpleClientset()
+	isBound := true
+	patches := setupImportTest(t, k8sclient, &isBound)
+	defer patches.Reset()
+	volumeManager := newExportedVolumeManager(testExportingCluster)
+	r := newTestImportReconciler(volumeManager)
+
+	res, instance := reconcileTestInstance(t, r)
+	assert.Equal(t, reconcile.Result{}, res)
+	assert.True(t, instance.Status.Imported)
+	assert.Empty(t, instance.Status.Error)
+	assertVolumeImported(t, k8sclient, volumeManager)
+}
+
+func TestVolumeImportRejectsVolume(t *testing.T) {
+	tests := []struct {
+		name          string
+		exportedFrom  string
+		otherClusters []string
+		expectedError string
+	}{
+		{
+			name:          "TestVolumeNotExported",
+			expectedError: "Volume \"fcd-1\" has not been exported by any cluster",
+		},
+		{
+			name:          "TestVolumeStillRegisteredWithOtherCluster",
+			exportedFrom:  testExportingCluster,
+			otherClusters: []string{testExportingCluster},
+			expectedError: "Volume \"fcd-1\" is still registered with cluster \"exporting-cluster\". It must be " +
+				"exported from that cluster with a CnsVolumeExport instance first",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			k8sclient := testclient.NewSimpleClientset()
+			isBound := true
+			patches := setupImportTest(t, k8sclient, &isBound)
+			defer patches.Reset()
+			volumeManager := newExportedVolumeManager(test.exportedFrom)
+			for _, clusterID := range test.otherClusters {
+				volumeManager.Volumes[unittestcommon.TestVolumeID] = &cnstypes.CnsVolume{
+					VolumeId: cnstypes.CnsVolumeId{Id: unittestcommon.TestVolumeID},
+					Metadata: cnstypes.CnsVolumeMetadata{
+						ContainerClusterArray: []cnstypes.CnsContainerCluster{{ClusterId: clusterID}},
+					},
+				}
+			}
+			r := newTestImportReconciler(volumeManager)
+
+			res, instance := reconcileTestInstance(t, r)
+			assert.Equal(t, reconcile.Result{RequeueAfter: time.Second}, res)
+			assert.False(t, instance.Status.Imported)
+			assert.Equal(t, test.expectedError, instance.Status.Error)
+
+			// The volume is left as it was.
+			assert.Equal(t, test.exportedFrom, volumeManager.Fcds[unittestcommon.TestVolumeID].ExportedFrom)
+			assert.Len(t, volumeManager.Volumes, len(test.otherClusters))
+			_, err := k8sclient.CoreV1().PersistentVolumes().Get(context.TODO(), testImportedPVName,
+				metav1.GetOptions{})
+			assert.True(t, apierrors.IsNotFound(err))
+		})
+	}
+}
+
+func TestVolumeImportResumesAfterFailure(t *testing.T) {
+	tests := []struct {
+		name          string
+		failingMethod string
+		isUnbound     bool
+		expectedError string
+	}{
+		{
+			name:          "TestCreateVolumeFailure",
+			failingMethod: "CreateVolume",
+			expectedError: "failed to create CNS volume",
+		},
+		{
+			name:          "TestClearExportMarkFailure",
+			failingMethod: "SetVolumeExportedFrom",
+			expectedError: "Failed to clear export mark of volume \"fcd-1\". Error: CNS failure",
+		},
+		{
+			name:          "TestPVCNotBound",
+			isUnbound:     true,
+			expectedError: "PVC: test-pvc is not bound",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			k8sclient := testclient.NewSimpleClientset()
+			isBound := !test.isUnbound
+			patches := setupImportTest(t, k8sclient, &isBound)
+			defer patches.Reset()
+			volumeManager := newExportedVolumeManager(testExportingCluster)
+			if test.failingMethod != "" {
+				volumeManager.Errors[test.failingMethod] = errors.New("CNS failure")
+			}
+			r := newTestImportReconciler(volumeManager)
+
+			res, instance := reconcileTestInstance(t, r)
+			assert.Equal(t, reconcile.Result{RequeueAfter: time.Second}, res)
+			assert.False(t, instance.Status.Imported)
+			assert.Equal(t, test.expectedError, instance.Status.Error)
+
+			// The retry picks up from where the failed reconcile stopped.
+			delete(volumeManager.Errors, test.failingMethod)
+			isBound = true
+			res, instance = reconcileTestInstance(t, r)
+			assert.Equal(t, reconcile.Result{}, res)
+			assert.True(t, instance.Status.Imported)
+			assert.Empty(t, instance.Status.Error)
+			assertVolumeImported(t, k8sclient, volumeManager)
+		})
+	}
+}
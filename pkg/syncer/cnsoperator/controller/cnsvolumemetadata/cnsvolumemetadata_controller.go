@@ -326,7 +326,6 @@ func (r *ReconcileCnsVolumeMetadata) updateCnsMetadata(ctx context.Context,
 		log.Errorf("ReconcileCnsVolumeMetadata: vcenter config is empty")
 		return false
 	}
-	host := vCenter.Config.Host
 
 	var entityReferences []cnstypes.CnsKubernetesEntityReference
 	for _, reference := range instance.Spec.EntityReferences {
@@ -385,7 +384,7 @@ func (r *ReconcileCnsVolumeMetadata) updateCnsMetadata(ctx context.Context,
 		metadataList = append(metadataList, cnstypes.BaseCnsEntityMetadata(metadata))
 
 		cluster := cnsvsphere.GetContainerCluster(instance.Spec.GuestClusterID,
-			r.configInfo.Cfg.VirtualCenter[host].User, cnstypes.CnsClusterFlavorGuest,
+			vCenter.Config.Username, cnstypes.CnsClusterFlavorGuest,
 			instance.Spec.ClusterDistribution)
 		updateSpec := &cnstypes.CnsVolumeMetadataUpdateSpec{
 			VolumeId: cnstypes.CnsVolumeId{
@@ -0,0 +1,375 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orphanvolume
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	apis "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator"
+	orphanvolumev1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/orphanvolume/v1alpha1"
+	volumes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	commonconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+	k8s "sigs.k8s.io/vsphere-csi-driver/v3/pkg/kubernetes"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer"
+	cnsoperatorutil "sigs.k8s.io/vsphere-csi-driver/v3/pkg/syncer/cnsoperator/util"
+)
+
+const (
+	defaultMaxWorkerThreadsForOrphanVolume = 10
+	// restoredPvNamePrefix is the prefix of the name of the PV created for a
+	// restored volume whose PV name is not known.
+	restoredPvNamePrefix = "restored-pv-"
+)
+
+var (
+	// backOffDuration is a map of orphanvolume name's to the time after
+	// which a request for this instance will be requeued.
+	// Initialized to 1 second for new instances and for instances whose latest
+	// reconcile operation succeeded.
+	// If the reconcile fails, backoff is incremented exponentially.
+	backOffDuration         map[string]time.Duration
+	backOffDurationMapMutex = sync.Mutex{}
+)
+
+// Add creates a new OrphanVolume Controller and adds it to the Manager,
+// ConfigurationInfo and VirtualCenterTypes. The Manager will set fields on
+// the Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager, clusterFlavor cnstypes.CnsClusterFlavor,
+	configInfo *commonconfig.ConfigurationInfo, volumeManager volumes.Manager) error {
+	ctx, log := logger.GetNewContextWithLogger()
+	if clusterFlavor != cnstypes.CnsClusterFlavorVanilla {
+		log.Debug("Not initializing the OrphanVolume Controller as its a non-Vanilla CSI deployment")
+		return nil
+	}
+
+	coCommonInterface, err := commonco.GetContainerOrchestratorInterface(ctx,
+		common.Kubernetes, clusterFlavor, &syncer.COInitParams)
+	if err != nil {
+		log.Errorf("failed to create CO agnostic interface. Err: %v", err)
+		return err
+	}
+	if !coCommonInterface.IsFSSEnabled(ctx, common.OrphanVolumeQuarantine) {
+		log.Infof("Not initializing the OrphanVolume Controller as this feature is disabled on the cluster")
+		return nil
+	}
+
+	volumeManagers, err := cnsoperatorutil.GetVanillaVolumeManagers(ctx, configInfo, volumeManager)
+	if err != nil {
+		log.Errorf("failed to get volume managers for OrphanVolume Controller. Err: %v", err)
+		return err
+	}
+
+	// Initializes kubernetes client.
+	k8sclient, err := k8s.NewClient(ctx)
+	if err != nil {
+		log.Errorf("Creating Kubernetes client failed. Err: %v", err)
+		return err
+	}
+
+	// eventBroadcaster broadcasts events on orphanvolume instances to the
+	// event sink.
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(
+		&typedcorev1.EventSinkImpl{
+			Interface: k8sclient.CoreV1().Events(""),
+		},
+	)
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: apis.GroupName})
+	return add(mgr, newReconciler(mgr, volumeManagers, recorder))
+}
+
+// newReconciler returns a new reconcile.Reconciler.
+func newReconciler(mgr manager.Manager, volumeManagers map[string]volumes.Manager,
+	recorder record.EventRecorder) reconcile.Reconciler {
+	return &ReconcileOrphanVolume{client: mgr.GetClient(), scheme: mgr.GetScheme(),
+		volumeManagers: volumeManagers, recorder: recorder}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler.
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	_, log := logger.GetNewContextWithLogger()
+
+	// Create a new controller.
+	c, err := controller.New("orphanvolume-controller", mgr,
+		controller.Options{Reconciler: r, MaxConcurrentReconciles: defaultMaxWorkerThreadsForOrphanVolume})
+	if err != nil {
+		log.Errorf("Failed to create new OrphanVolume controller with error: %+v", err)
+		return err
+	}
+
+	backOffDuration = make(map[string]time.Duration)
+
+	// Watch for changes to primary resource OrphanVolume.
+	err = c.Watch(source.Kind(mgr.GetCache(), &orphanvolumev1alpha1.OrphanVolume{}),
+		&handler.EnqueueRequestForObject{})
+	if err != nil {
+		log.Errorf("Failed to watch for changes to OrphanVolume resource with error: %+v", err)
+		return err
+	}
+	return nil
+}
+
+// blank assignment to verify that ReconcileOrphanVolume implements
+// reconcile.Reconciler.
+var _ reconcile.Reconciler = &ReconcileOrphanVolume{}
+
+// ReconcileOrphanVolume reconciles an OrphanVolume object.
+type ReconcileOrphanVolume struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver.
+	client client.Client
+	scheme *runtime.Scheme
+	// volumeManagers maps vCenter host to its volume manager.
+	volumeManagers map[string]volumes.Manager
+	recorder       record.EventRecorder
+}
+
+// Reconcile reads that state of the cluster for an OrphanVolume object and,
+// if a restore is requested in its spec, recreates the PV and PVC of the
+// orphan volume from the record. The OrphanVolume instance is deleted by the
+// next full sync once the volume is back in kubernetes.
+// Note:
+// The Controller will requeue the Request to be processed again if the
+// returned error is non-nil or Result.Requeue is true. Otherwise, upon
+// completion it will remove the work from the queue.
+func (r *ReconcileOrphanVolume) Reconcile(ctx context.Context,
+	request reconcile.Request) (reconcile.Result, error) {
+	log := logger.GetLogger(ctx)
+
+	// Fetch the OrphanVolume instance.
+	instance := &orphanvolumev1alpha1.OrphanVolume{}
+	err := r.client.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Infof("OrphanVolume resource not found. Ignoring since object must be deleted.")
+			return reconcile.Result{}, nil
+		}
+		log.Errorf("Error reading the OrphanVolume with name: %q. Err: %+v", request.Name, err)
+		// Error reading the object - return with err.
+		return reconcile.Result{}, err
+	}
+	// Nothing to do until a restore is requested, or once the volume is
+	// restored.
+	if !instance.Spec.Restore || instance.Status.Restored {
+		backOffDurationMapMutex.Lock()
+		delete(backOffDuration, instance.Name)
+		backOffDurationMapMutex.Unlock()
+		return reconcile.Result{}, nil
+	}
+	// Initialize backOffDuration for the instance, if required.
+	backOffDurationMapMutex.Lock()
+	var timeout time.Duration
+	if _, exists := backOffDuration[instance.Name]; !exists {
+		backOffDuration[instance.Name] = time.Second
+	}
+	timeout = backOffDuration[instance.Name]
+	backOffDurationMapMutex.Unlock()
+	log.Infof("Reconciling OrphanVolume instance %q. timeout %q seconds", instance.Name, timeout)
+
+	volumeID := instance.Spec.VolumeID
+	if instance.Spec.PvcName == "" || instance.Spec.PvcNamespace == "" {
+		msg := fmt.Sprintf("PVC of orphan volume %q is not known. Set pvcName and pvcNamespace in the "+
+			"spec to restore the volume", volumeID)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	volumeManager, ok := r.volumeManagers[instance.Spec.VCenter]
+	if !ok {
+		msg := fmt.Sprintf("vCenter %q of orphan volume %q is not configured for this cluster",
+			instance.Spec.VCenter, volumeID)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	vc, err := cnsvsphere.GetVirtualCenterManager(ctx).GetVirtualCenter(ctx, instance.Spec.VCenter)
+	if err != nil {
+		log.Errorf("Failed to get virtual center instance for %q with error: %+v", instance.Spec.VCenter, err)
+		setInstanceError(ctx, r, instance, "Unable to connect to VC for volume restore")
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	querySelection := cnstypes.CnsQuerySelection{
+		Names: []string{
+			string(cnstypes.QuerySelectionNameTypeDataStoreUrl),
+			string(cnstypes.QuerySelectionNameTypePolicyId),
+			string(cnstypes.QuerySelectionNameTypeBackingObjectDetails),
+		},
+	}
+	volume, err := common.QueryVolumeByID(ctx, volumeManager, volumeID, &querySelection)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to query CNS volume: %s with error: %+v", volumeID, err)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	// Restrict the PV to the nodes which can access the datastore of the volume.
+	pvNodeAffinity, err := cnsoperatorutil.GetVolumeNodeAffinityForVanilla(ctx, r.client, vc, volume.DatastoreUrl)
+	if err != nil {
+		log.Errorf("Failed to find nodes with access to volume: %s on datastore: %s. Error: %+v",
+			volumeID, volume.DatastoreUrl, err)
+		setInstanceError(ctx, r, instance, err.Error())
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	k8sclient, err := k8s.NewClient(ctx)
+	if err != nil {
+		log.Errorf("Failed to initialize K8S client when reconciling OrphanVolume instance: %s. Error: %+v",
+			instance.Name, err)
+		setInstanceError(ctx, r, instance, "Failed to init K8S client for volume restore")
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+
+	var storageClassName string
+	if volume.StoragePolicyId != "" {
+		storageClassName, err = cnsoperatorutil.GetK8sStorageClassNameForVanillaPolicy(ctx, k8sclient, vc,
+			volume.StoragePolicyId)
+		if err != nil {
+			msg := fmt.Sprintf("Failed to find K8S Storageclass mapping storagepolicyId: %s. Error: %+v",
+				volume.StoragePolicyId, err)
+			log.Error(msg)
+			setInstanceError(ctx, r, instance, msg)
+			return reconcile.Result{RequeueAfter: timeout}, nil
+		}
+	}
+	log.Infof("Volume with storagepolicyId: %q is mapping to K8S storage class: %q",
+		volume.StoragePolicyId, storageClassName)
+
+	pvName := instance.Spec.PvName
+	if pvName == "" {
+		pvName = restoredPvNamePrefix + instance.Name
+	}
+	accessMode := v1.ReadWriteOnce
+	if volume.VolumeType == common.FileVolumeType {
+		accessMode = v1.ReadWriteMany
+	}
+	capacityInMb := volume.BackingObjectDetails.GetCnsBackingObjectDetails().CapacityInMb
+	pvc, err := cnsoperatorutil.CreatePVAndPVCForVolume(ctx, k8sclient, volumeID, pvName, instance.Spec.PvcName,
+		instance.Spec.PvcNamespace, capacityInMb, storageClassName, accessMode, pvNodeAffinity)
+	if err != nil {
+		log.Error(err)
+		setInstanceError(ctx, r, instance, err.Error())
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	// Watch for PVC to be bound.
+	isBound, err := cnsoperatorutil.IsPVCBound(ctx, k8sclient, pvc, time.Duration(1*time.Minute))
+	if !isBound {
+		log.Errorf("PVC: %s is not bound. Error: %+v", instance.Spec.PvcName, err)
+		setInstanceError(ctx, r, instance, fmt.Sprintf("PVC: %s is not bound", instance.Spec.PvcName))
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	log.Infof("PVC: %s is bound", instance.Spec.PvcName)
+
+	// Update the instance to indicate the volume restore is successful.
+	msg := fmt.Sprintf("Successfully restored orphan volume %q as PVC %q on namespace: %s",
+		volumeID, instance.Spec.PvcName, instance.Spec.PvcNamespace)
+	err = setInstanceSuccess(ctx, r, instance, msg)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to update OrphanVolume instance with error: %+v", err)
+		log.Error(msg)
+		setInstanceError(ctx, r, instance, msg)
+		return reconcile.Result{RequeueAfter: timeout}, nil
+	}
+	backOffDurationMapMutex.Lock()
+	delete(backOffDuration, instance.Name)
+	backOffDurationMapMutex.Unlock()
+	log.Info(msg)
+	return reconcile.Result{}, nil
+}
+
+// setInstanceError sets error and records an event on the OrphanVolume
+// instance.
+func setInstanceError(ctx context.Context, r *ReconcileOrphanVolume,
+	instance *orphanvolumev1alpha1.OrphanVolume, errMsg string) {
+	log := logger.GetLogger(ctx)
+	instance.Status.Error = errMsg
+	err := updateOrphanVolume(ctx, r.client, instance)
+	if err != nil {
+		log.Errorf("updateOrphanVolume failed. err: %v", err)
+	}
+	recordEvent(ctx, r, instance, v1.EventTypeWarning, errMsg)
+}
+
+// setInstanceSuccess sets instance to success and records an event on the
+// OrphanVolume instance.
+func setInstanceSuccess(ctx context.Context, r *ReconcileOrphanVolume,
+	instance *orphanvolumev1alpha1.OrphanVolume, msg string) error {
+	instance.Status.Restored = true
+	instance.Status.Error = ""
+	err := updateOrphanVolume(ctx, r.client, instance)
+	if err != nil {
+		return err
+	}
+	recordEvent(ctx, r, instance, v1.EventTypeNormal, msg)
+	return nil
+}
+
+// recordEvent records the event, sets the backOffDuration for the instance
+// appropriately and logs the message.
+// backOffDuration is reset to 1 second on success and doubled on failure.
+func recordEvent(ctx context.Context, r *ReconcileOrphanVolume,
+	instance *orphanvolumev1alpha1.OrphanVolume, eventtype string, msg string) {
+	log := logger.GetLogger(ctx)
+	log.Debugf("Event type is %s", eventtype)
+	switch eventtype {
+	case v1.EventTypeWarning:
+		// Double backOff duration.
+		backOffDurationMapMutex.Lock()
+		backOffDuration[instance.Name] = backOffDuration[instance.Name] * 2
+		r.recorder.Event(instance, v1.EventTypeWarning, "OrphanVolumeRestoreFailed", msg)
+		backOffDurationMapMutex.Unlock()
+	case v1.EventTypeNormal:
+		// Reset backOff duration to one second.
+		backOffDurationMapMutex.Lock()
+		backOffDuration[instance.Name] = time.Second
+		r.recorder.Event(instance, v1.EventTypeNormal, "OrphanVolumeRestoreSucceeded", msg)
+		backOffDurationMapMutex.Unlock()
+	}
+}
+
+// updateOrphanVolume updates the OrphanVolume instance in K8S.
+func updateOrphanVolume(ctx context.Context, client client.Client,
+	instance *orphanvolumev1alpha1.OrphanVolume) error {
+	log := logger.GetLogger(ctx)
+	err := client.Update(ctx, instance)
+	if err != nil {
+		log.Errorf("Failed to update OrphanVolume instance: %q. Error: %+v", instance.Name, err)
+	}
+	return err
+}
@@ -18,6 +18,7 @@ package triggercsifullsync
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -198,6 +199,18 @@ func (r *ReconcileTriggerCsiFullSync) Reconcile(ctx context.Context,
 		return reconcile.Result{}, nil
 	}
 
+	// Run the requested dry run first. It is tracked through DryRunSyncID so
+	// that full syncs triggered through TriggerSyncID, including the periodic
+	// ones, are never turned into dry runs.
+	if instance.Spec.DryRunSyncID != 0 && instance.Spec.DryRunSyncID != instance.Status.LastDryRunSyncID {
+		result := r.reconcileDryRun(ctx, request, instance, timeout)
+		if result.RequeueAfter == 0 && instance.Spec.TriggerSyncID == instance.Status.LastTriggerSyncID+1 {
+			// A full sync was requested as well, reconcile it next.
+			result.Requeue = true
+		}
+		return result, nil
+	}
+
 	// Ignore any updates on TriggerCsiFullSync instance with TriggerSyncID set
 	// to 0 and TriggerSyncID same as LastTriggerSyncID.
 	if instance.Spec.TriggerSyncID == 0 || instance.Spec.TriggerSyncID == instance.Status.LastTriggerSyncID {
@@ -278,6 +291,96 @@ func (r *ReconcileTriggerCsiFullSync) Reconcile(ctx context.Context,
 	return reconcile.Result{}, nil
 }
 
+// reconcileDryRun runs the dry run full sync requested through DryRunSyncID
+// and reports its result in Status.DryRunReport. The LastSuccessful* and
+// LastRun* timestamps only track full syncs which act on CNS, so a dry run
+// leaves them untouched.
+func (r *ReconcileTriggerCsiFullSync) reconcileDryRun(ctx context.Context, request reconcile.Request,
+	instance *triggercsifullsyncv1alpha1.TriggerCsiFullSync, timeout time.Duration) reconcile.Result {
+	log := logger.GetLogger(ctx)
+	dryRunSyncID := instance.Spec.DryRunSyncID
+
+	// If DryRunSyncID is not one greater than LastDryRunSyncID, raise an event
+	// that the dry run will be ignored.
+	if dryRunSyncID != instance.Status.LastDryRunSyncID+1 {
+		msg := fmt.Sprintf("DryRunSyncID: %d is invalid. DryRunSyncID should be one greater than LastDryRunSyncID.",
+			dryRunSyncID)
+		log.Error(msg)
+		recordEvent(ctx, r, instance, v1.EventTypeWarning, msg)
+		return reconcile.Result{}
+	}
+
+	// LastDryRunSyncID saves the last DryRunSyncID attempted by the user
+	// regardless of success or failure.
+	instance.Status.LastDryRunSyncID = dryRunSyncID
+	if instance.Status.InProgress {
+		err := updateTriggerCsiFullSync(ctx, r.client, instance)
+		if err != nil {
+			log.Errorf("TriggerCsiFullSync failed, %v ", err)
+			recordEvent(ctx, r, instance, v1.EventTypeWarning,
+				fmt.Sprintf("Failed to increment LastDryRunSyncID with DryRunSyncID: %d", dryRunSyncID))
+			return reconcile.Result{RequeueAfter: timeout}
+		}
+		msg := fmt.Sprintf("A full sync is already in progress. Ignoring this dry run with dry run sync ID: %d",
+			dryRunSyncID)
+		log.Warn(msg)
+		recordEvent(ctx, r, instance, v1.EventTypeWarning, msg)
+		backOffDurationMapMutex.Lock()
+		delete(backOffDuration, instance.Name)
+		backOffDurationMapMutex.Unlock()
+		return reconcile.Result{}
+	}
+
+	log.Infof("Reconciling dry run full sync with dryRunSyncID: %d", dryRunSyncID)
+	instance.Status.InProgress = true
+	err := updateTriggerCsiFullSync(ctx, r.client, instance)
+	if err != nil {
+		log.Errorf("TriggerCsiFullSync failed %v", err)
+		recordEvent(ctx, r, instance, v1.EventTypeWarning,
+			fmt.Sprintf("Failed to update LastDryRunSyncID and Inprogress for DryRunSyncID: %d", dryRunSyncID))
+		return reconcile.Result{RequeueAfter: timeout}
+	}
+
+	var dryRunErr error
+	var dryRunReport *triggercsifullsyncv1alpha1.FullSyncReport
+	if r.clusterFlavor == cnstypes.CnsClusterFlavorGuest {
+		dryRunErr = errors.New("dry run is not supported for guest clusters")
+	} else {
+		dryRunReport, dryRunErr = syncer.CsiFullSyncDryRun(ctx, syncer.MetadataSyncer,
+			r.configInfo.Cfg.Global.VCenterIP)
+	}
+	err = r.client.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		return reconcile.Result{}
+	}
+	instance.Status.InProgress = false
+	eventType := v1.EventTypeNormal
+	var msg string
+	if dryRunErr != nil {
+		msg = fmt.Sprintf("Full sync dry run failed for dryRunSyncID: %d with error: %+v", dryRunSyncID, dryRunErr)
+		log.Error(msg)
+		instance.Status.Error = msg
+		eventType = v1.EventTypeWarning
+	} else {
+		msg = fmt.Sprintf("Full sync dry run successful with dryRunSyncID: %d. "+
+			"Volumes to create: %d, to update: %d, to delete: %d", dryRunSyncID,
+			dryRunReport.VolumesToCreate.Count, dryRunReport.VolumesToUpdate.Count,
+			dryRunReport.VolumesToDelete.Count)
+		log.Info(msg)
+		instance.Status.Error = ""
+		instance.Status.DryRunReport = dryRunReport
+	}
+	err = updateTriggerCsiFullSync(ctx, r.client, instance)
+	if err != nil {
+		log.Errorf("updateTriggerCsiFullSync failed. err: %v", err)
+	}
+	recordEvent(ctx, r, instance, eventType, msg)
+	backOffDurationMapMutex.Lock()
+	delete(backOffDuration, instance.Name)
+	backOffDurationMapMutex.Unlock()
+	return reconcile.Result{}
+}
+
 // setInstanceError sets error and records an event on the TriggerCsiFullSync
 // instance.
 func setInstanceError(ctx context.Context, r *ReconcileTriggerCsiFullSync,
@@ -27,6 +27,7 @@ import (
 	cnstypes "github.com/vmware/govmomi/cns/types"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
@@ -154,68 +155,16 @@ func InitCnsOperator(ctx context.Context, clusterFlavor cnstypes.CnsClusterFlavo
 			log.Info("Observed stretchedSupervisor setup")
 		}
 		if !stretchedSupervisor || (stretchedSupervisor && syncer.IsPodVMOnStretchSupervisorFSSEnabled) {
-			// Create CnsRegisterVolume CRD from manifest.
-			log.Infof("Creating %q CRD", cnsoperatorv1alpha1.CnsRegisterVolumePlural)
-			err = k8s.CreateCustomResourceDefinitionFromManifest(ctx, cnsoperatorconfig.EmbedCnsRegisterVolumeCRFile,
-				cnsoperatorconfig.EmbedCnsRegisterVolumeCRFileName)
+			err = initCnsRegisterVolume(ctx, cnsOperator, restConfig)
 			if err != nil {
-				log.Errorf("Failed to create %q CRD. Err: %+v", cnsoperatorv1alpha1.CnsRegisterVolumePlural, err)
 				return err
 			}
-			log.Infof("%q CRD is created successfully", cnsoperatorv1alpha1.CnsRegisterVolumePlural)
-
-			// Clean up routine to cleanup successful CnsRegisterVolume instances.
-			log.Info("Starting go routine to cleanup successful CnsRegisterVolume instances.")
-			err = watcher(ctx, cnsOperator)
-			if err != nil {
-				log.Error("Failed to watch on config file for changes to "+
-					"CnsRegisterVolumesCleanupIntervalInMin. Error: %+v", err)
-				return err
-			}
-			go func() {
-				for {
-					ctx, log = logger.GetNewContextWithLogger()
-					log.Infof("Triggering CnsRegisterVolume cleanup routine")
-					cleanUpCnsRegisterVolumeInstances(ctx, restConfig,
-						cnsOperator.configInfo.Cfg.Global.CnsRegisterVolumesCleanupIntervalInMin)
-					log.Infof("Completed CnsRegisterVolume cleanup")
-					for i := 1; i <= cnsOperator.configInfo.Cfg.Global.CnsRegisterVolumesCleanupIntervalInMin; i++ {
-						time.Sleep(time.Duration(1 * time.Minute))
-					}
-				}
-			}()
 
 			if commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.CnsUnregisterVolume) {
-				// Create CnsUnregisterVolume CRD from manifest.
-				log.Infof("Creating %q CRD", cnsoperatorv1alpha1.CnsUnregisterVolumePlural)
-				err = k8s.CreateCustomResourceDefinitionFromManifest(ctx, cnsoperatorconfig.EmbedCnsUnregisterVolumeCRFile,
-					cnsoperatorconfig.EmbedCnsUnregisterVolumeCRFileName)
-				if err != nil {
-					log.Errorf("Failed to create %q CRD. Err: %+v", cnsoperatorv1alpha1.CnsUnregisterVolumePlural, err)
-					return err
-				}
-				log.Infof("%q CRD is created successfully", cnsoperatorv1alpha1.CnsUnregisterVolumePlural)
-
-				// Clean up routine to cleanup successful CnsUnregisterVolume instances.
-				log.Info("Starting go routine to cleanup successful CnsUnregisterVolume instances.")
-				err = watcher(ctx, cnsOperator)
+				err = initCnsUnregisterVolume(ctx, cnsOperator, restConfig)
 				if err != nil {
-					log.Error("Failed to watch on config file for changes to "+
-						"CnsRegisterVolumesCleanupIntervalInMin. Error: %+v", err)
 					return err
 				}
-				go func() {
-					for {
-						ctx, log = logger.GetNewContextWithLogger()
-						log.Infof("Triggering CnsUnregisterVolume cleanup routine")
-						cleanUpCnsUnregisterVolumeInstances(ctx, restConfig,
-							cnsOperator.configInfo.Cfg.Global.CnsRegisterVolumesCleanupIntervalInMin)
-						log.Infof("Completed CnsUnregisterVolume cleanup")
-						for i := 1; i <= cnsOperator.configInfo.Cfg.Global.CnsRegisterVolumesCleanupIntervalInMin; i++ {
-							time.Sleep(time.Duration(1 * time.Minute))
-						}
-					}
-				}()
 			}
 		}
 
@@ -248,6 +197,35 @@ func InitCnsOperator(ctx context.Context, clusterFlavor cnstypes.CnsClusterFlavo
 			log.Errorf("Failed to create %q CRD. Error: %+v", csinodetopology.CRDSingular, err)
 			return err
 		}
+		if cnsOperator.coCommonInterface.IsFSSEnabled(ctx, common.VanillaCnsRegisterVolume) {
+			err = initCnsRegisterVolume(ctx, cnsOperator, restConfig)
+			if err != nil {
+				return err
+			}
+		}
+		if cnsOperator.coCommonInterface.IsFSSEnabled(ctx, common.VanillaCnsUnregisterVolume) {
+			err = initCnsUnregisterVolume(ctx, cnsOperator, restConfig)
+			if err != nil {
+				return err
+			}
+		}
+		if cnsOperator.coCommonInterface.IsFSSEnabled(ctx, common.VanillaVolumeHandoff) {
+			err = initCnsVolumeHandoff(ctx)
+			if err != nil {
+				return err
+			}
+		}
+		if cnsOperator.coCommonInterface.IsFSSEnabled(ctx, common.OrphanVolumeQuarantine) {
+			// Create OrphanVolume CRD.
+			log.Infof("Creating %q CRD", cnsoperatorv1alpha1.OrphanVolumePlural)
+			err = k8s.CreateCustomResourceDefinitionFromManifest(ctx, cnsoperatorconfig.EmbedOrphanVolumeCRFile,
+				cnsoperatorconfig.EmbedOrphanVolumeCRFileName)
+			if err != nil {
+				log.Errorf("Failed to create %q CRD. Err: %+v", cnsoperatorv1alpha1.OrphanVolumePlural, err)
+				return err
+			}
+			log.Infof("%q CRD is created successfully", cnsoperatorv1alpha1.OrphanVolumePlural)
+		}
 	} else if clusterFlavor == cnstypes.CnsClusterFlavorGuest {
 		if cnsOperator.coCommonInterface.IsFSSEnabled(ctx, common.TKGsHA) {
 			// Create CSINodeTopology CRD.
@@ -303,6 +281,80 @@ func InitCnsOperator(ctx context.Context, clusterFlavor cnstypes.CnsClusterFlavo
 	return nil
 }
 
+// initCnsRegisterVolume creates the CnsRegisterVolume CRD and starts the go
+// routine which cleans up successful CnsRegisterVolume instances.
+func initCnsRegisterVolume(ctx context.Context, cnsOperator *cnsOperatorInfo, restConfig *rest.Config) error {
+	log := logger.GetLogger(ctx)
+	// Create CnsRegisterVolume CRD from manifest.
+	log.Infof("Creating %q CRD", cnsoperatorv1alpha1.CnsRegisterVolumePlural)
+	err := k8s.CreateCustomResourceDefinitionFromManifest(ctx, cnsoperatorconfig.EmbedCnsRegisterVolumeCRFile,
+		cnsoperatorconfig.EmbedCnsRegisterVolumeCRFileName)
+	if err != nil {
+		log.Errorf("Failed to create %q CRD. Err: %+v", cnsoperatorv1alpha1.CnsRegisterVolumePlural, err)
+		return err
+	}
+	log.Infof("%q CRD is created successfully", cnsoperatorv1alpha1.CnsRegisterVolumePlural)
+
+	// Clean up routine to cleanup successful CnsRegisterVolume instances.
+	log.Info("Starting go routine to cleanup successful CnsRegisterVolume instances.")
+	err = watcher(ctx, cnsOperator)
+	if err != nil {
+		log.Error("Failed to watch on config file for changes to "+
+			"CnsRegisterVolumesCleanupIntervalInMin. Error: %+v", err)
+		return err
+	}
+	go func() {
+		for {
+			ctx, log := logger.GetNewContextWithLogger()
+			log.Infof("Triggering CnsRegisterVolume cleanup routine")
+			cleanUpCnsRegisterVolumeInstances(ctx, restConfig,
+				cnsOperator.configInfo.Cfg.Global.CnsRegisterVolumesCleanupIntervalInMin)
+			log.Infof("Completed CnsRegisterVolume cleanup")
+			for i := 1; i <= cnsOperator.configInfo.Cfg.Global.CnsRegisterVolumesCleanupIntervalInMin; i++ {
+				time.Sleep(time.Duration(1 * time.Minute))
+			}
+		}
+	}()
+	return nil
+}
+
+// initCnsUnregisterVolume creates the CnsUnregisterVolume CRD and starts the
+// go routine which cleans up successful CnsUnregisterVolume instances.
+func initCnsUnregisterVolume(ctx context.Context, cnsOperator *cnsOperatorInfo, restConfig *rest.Config) error {
+	log := logger.GetLogger(ctx)
+	// Create CnsUnregisterVolume CRD from manifest.
+	log.Infof("Creating %q CRD", cnsoperatorv1alpha1.CnsUnregisterVolumePlural)
+	err := k8s.CreateCustomResourceDefinitionFromManifest(ctx, cnsoperatorconfig.EmbedCnsUnregisterVolumeCRFile,
+		cnsoperatorconfig.EmbedCnsUnregisterVolumeCRFileName)
+	if err != nil {
+		log.Errorf("Failed to create %q CRD. Err: %+v", cnsoperatorv1alpha1.CnsUnregisterVolumePlural, err)
+		return err
+	}
+	log.Infof("%q CRD is created successfully", cnsoperatorv1alpha1.CnsUnregisterVolumePlural)
+
+	// Clean up routine to cleanup successful CnsUnregisterVolume instances.
+	log.Info("Starting go routine to cleanup successful CnsUnregisterVolume instances.")
+	err = watcher(ctx, cnsOperator)
+	if err != nil {
+		log.Error("Failed to watch on config file for changes to "+
+			"CnsRegisterVolumesCleanupIntervalInMin. Error: %+v", err)
+		return err
+	}
+	go func() {
+		for {
+			ctx, log := logger.GetNewContextWithLogger()
+			log.Infof("Triggering CnsUnregisterVolume cleanup routine")
+			cleanUpCnsUnregisterVolumeInstances(ctx, restConfig,
+				cnsOperator.configInfo.Cfg.Global.CnsRegisterVolumesCleanupIntervalInMin)
+			log.Infof("Completed CnsUnregisterVolume cleanup")
+			for i := 1; i <= cnsOperator.configInfo.Cfg.Global.CnsRegisterVolumesCleanupIntervalInMin; i++ {
+				time.Sleep(time.Duration(1 * time.Minute))
+			}
+		}
+	}()
+	return nil
+}
+
 // InitCommonModules initializes the common modules for all flavors.
 func InitCommonModules(ctx context.Context, clusterFlavor cnstypes.CnsClusterFlavor,
 	coInitParams *interface{}) error {
@@ -439,3 +491,27 @@ func reloadConfiguration(ctx context.Context, cnsOperator *cnsOperatorInfo) erro
 		cnsOperator.configInfo.Cfg.Global.CnsRegisterVolumesCleanupIntervalInMin)
 	return nil
 }
+
+// initCnsVolumeHandoff creates the CnsVolumeExport and CnsVolumeImport CRDs
+// used to hand volumes over between vanilla clusters.
+func initCnsVolumeHandoff(ctx context.Context) error {
+	log := logger.GetLogger(ctx)
+	log.Infof("Creating %q CRD", cnsoperatorv1alpha1.CnsVolumeExportPlural)
+	err := k8s.CreateCustomResourceDefinitionFromManifest(ctx, cnsoperatorconfig.EmbedCnsVolumeExportCRFile,
+		cnsoperatorconfig.EmbedCnsVolumeExportCRFileName)
+	if err != nil {
+		log.Errorf("Failed to create %q CRD. Err: %+v", cnsoperatorv1alpha1.CnsVolumeExportPlural, err)
+		return err
+	}
+	log.Infof("%q CRD is created successfully", cnsoperatorv1alpha1.CnsVolumeExportPlural)
+
+	log.Infof("Creating %q CRD", cnsoperatorv1alpha1.CnsVolumeImportPlural)
+	err = k8s.CreateCustomResourceDefinitionFromManifest(ctx, cnsoperatorconfig.EmbedCnsVolumeImportCRFile,
+		cnsoperatorconfig.EmbedCnsVolumeImportCRFileName)
+	if err != nil {
+		log.Errorf("Failed to create %q CRD. Err: %+v", cnsoperatorv1alpha1.CnsVolumeImportPlural, err)
+		return err
+	}
+	log.Infof("%q CRD is created successfully", cnsoperatorv1alpha1.CnsVolumeImportPlural)
+	return nil
+}
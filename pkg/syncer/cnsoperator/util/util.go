@@ -21,6 +21,7 @@ import (
 	"fmt"
 
 	vmoperatortypes "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	cnstypes "github.com/vmware/govmomi/cns/types"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -28,6 +29,11 @@ import (
 	"k8s.io/client-go/dynamic"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	volumes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	commonconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
 	k8s "sigs.k8s.io/vsphere-csi-driver/v3/pkg/kubernetes"
 
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
@@ -157,3 +163,41 @@ func GetNetworkProvider(ctx context.Context) (string, error) {
 	return "", fmt.Errorf("could not find network provider field in configmap %q in namespace %q",
 		wcpNetworkConfigMap, kubeSystemNamespace)
 }
+
+// GetVanillaVolumeManagers returns the volume managers of all vCenters
+// the vanilla cluster is deployed on, keyed by vCenter host.
+func GetVanillaVolumeManagers(ctx context.Context, configInfo *commonconfig.ConfigurationInfo,
+	volumeManager volumes.Manager) (map[string]volumes.Manager, error) {
+	log := logger.GetLogger(ctx)
+	volumeManagers := make(map[string]volumes.Manager)
+	if !commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.MultiVCenterCSITopology) {
+		vc, err := cnsvsphere.GetVirtualCenterInstance(ctx, configInfo, false)
+		if err != nil {
+			return nil, logger.LogNewErrorf(log, "failed to get virtual center instance. Err: %v", err)
+		}
+		volumeManagers[vc.Config.Host] = volumeManager
+		return volumeManagers, nil
+	}
+	vcconfigs, err := cnsvsphere.GetVirtualCenterConfigs(ctx, configInfo.Cfg)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to get VirtualCenterConfigs. Err: %v", err)
+	}
+	if len(vcconfigs) == 1 {
+		volumeManagers[vcconfigs[0].Host] = volumeManager
+		return volumeManagers, nil
+	}
+	for _, vcconfig := range vcconfigs {
+		vc, err := cnsvsphere.GetVirtualCenterInstanceForVCenterConfig(ctx, vcconfig, false)
+		if err != nil {
+			return nil, logger.LogNewErrorf(log, "failed to get vCenterInstance for vCenter Host: %q. Err: %v",
+				vcconfig.Host, err)
+		}
+		volumeManagers[vcconfig.Host], err = volumes.GetManager(ctx, vc, nil, false, true, true, false,
+			cnstypes.CnsClusterFlavorVanilla)
+		if err != nil {
+			return nil, logger.LogNewErrorf(log, "failed to create an instance of volume manager for "+
+				"vCenter %q. Err: %v", vcconfig.Host, err)
+		}
+	}
+	return volumeManagers, nil
+}
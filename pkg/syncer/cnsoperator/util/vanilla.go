@@ -0,0 +1,607 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	vim25types "github.com/vmware/govmomi/vim25/types"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/node"
+	volumes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
+	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
+	commonconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+	csinodetopologyv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/csinodetopology/v1alpha1"
+)
+
+// sortedVCHosts returns the vCenter hosts of volumeManagers in sorted order.
+func sortedVCHosts(volumeManagers map[string]volumes.Manager) []string {
+	vcHosts := make([]string, 0, len(volumeManagers))
+	for vcHost := range volumeManagers {
+		vcHosts = append(vcHosts, vcHost)
+	}
+	sort.Strings(vcHosts)
+	return vcHosts
+}
+
+// GetVolumeManagerForFcd returns the vCenter host and volume manager of the
+// vCenter on which the FCD with the given ID exists. The FCD need not be
+// registered with CNS.
+func GetVolumeManagerForFcd(ctx context.Context, volumeManagers map[string]volumes.Manager,
+	volumeID string) (string, volumes.Manager, error) {
+	log := logger.GetLogger(ctx)
+	if len(volumeManagers) == 1 {
+		for vcHost, volumeManager := range volumeManagers {
+			return vcHost, volumeManager, nil
+		}
+	}
+	for _, vcHost := range sortedVCHosts(volumeManagers) {
+		_, err := volumeManagers[vcHost].RetrieveVStorageObject(ctx, volumeID)
+		if err == nil {
+			log.Infof("Found volume %q on vCenter %q", volumeID, vcHost)
+			return vcHost, volumeManagers[vcHost], nil
+		}
+		if !cnsvsphere.IsNotFoundError(err) {
+			return "", nil, logger.LogNewErrorf(log, "failed to look up volume %q on vCenter %q. Error: %v",
+				volumeID, vcHost, err)
+		}
+	}
+	return "", nil, logger.LogNewErrorf(log, "volume %q not found on any vCenter", volumeID)
+}
+
+// QueryVolumeOnVCenters looks up volumeID in CNS on each vCenter of the
+// cluster. It returns the vCenter host, its volume manager and the volume. The
+// returned volume is nil if it is not found on any vCenter.
+func QueryVolumeOnVCenters(ctx context.Context, volumeManagers map[string]volumes.Manager, volumeID string,
+	querySelection *cnstypes.CnsQuerySelection) (string, volumes.Manager, *cnstypes.CnsVolume, error) {
+	log := logger.GetLogger(ctx)
+	queryFilter := cnstypes.CnsQueryFilter{
+		VolumeIds: []cnstypes.CnsVolumeId{{Id: volumeID}},
+	}
+	for _, vcHost := range sortedVCHosts(volumeManagers) {
+		volumeManager := volumeManagers[vcHost]
+		queryResult, err := volumeManager.QueryVolumeAsync(ctx, queryFilter, querySelection)
+		if err != nil {
+			return "", nil, nil, logger.LogNewErrorf(log, "Unable to query volume %q on vCenter %q. Error: %+v",
+				volumeID, vcHost, err)
+		}
+		if len(queryResult.Volumes) > 0 {
+			log.Infof("Found volume %q on vCenter %q", volumeID, vcHost)
+			return vcHost, volumeManager, &queryResult.Volumes[0], nil
+		}
+	}
+	return "", nil, nil, nil
+}
+
+// GetClusterEntityMetadata returns the kubernetes entity metadata of the
+// cluster with the given ID on cnsVol, along with the PV name, PVC name and
+// PVC namespace found in it.
+func GetClusterEntityMetadata(cnsVol *cnstypes.CnsVolume, clusterID string) (
+	entityMetadata []cnstypes.BaseCnsEntityMetadata, pvName string, pvcName string, pvcNamespace string) {
+	for _, entity := range cnsVol.Metadata.EntityMetadata {
+		k8sEntityMetadata, ok := entity.(*cnstypes.CnsKubernetesEntityMetadata)
+		if !ok || k8sEntityMetadata.ClusterID != clusterID {
+			continue
+		}
+		entityMetadata = append(entityMetadata, entity)
+		switch k8sEntityMetadata.EntityType {
+		case string(cnstypes.CnsKubernetesEntityTypePV):
+			pvName = k8sEntityMetadata.EntityName
+		case string(cnstypes.CnsKubernetesEntityTypePVC):
+			pvcName = k8sEntityMetadata.EntityName
+			pvcNamespace = k8sEntityMetadata.Namespace
+		}
+	}
+	return entityMetadata, pvName, pvcName, pvcNamespace
+}
+
+// ValidateVolumeNotInUseForVanilla validates that the volume is neither used
+// by a pod nor attached to a node.
+func ValidateVolumeNotInUseForVanilla(ctx context.Context, volumeID string, pvName string, pvcName string,
+	pvcNamespace string, k8sClient clientset.Interface) error {
+	log := logger.GetLogger(ctx)
+	if pvcName != "" && pvcNamespace != "" {
+		pods, err := k8sClient.CoreV1().Pods(pvcNamespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			log.Errorf("Failed to list pods in namespace %s with error - %s", pvcNamespace, err.Error())
+			return err
+		}
+		for _, pod := range pods.Items {
+			for _, podVol := range pod.Spec.Volumes {
+				if podVol.PersistentVolumeClaim != nil &&
+					podVol.PersistentVolumeClaim.ClaimName == pvcName {
+					return fmt.Errorf("volume %s is in use by pod %s in namespace %s",
+						volumeID, pod.Name, pvcNamespace)
+				}
+			}
+		}
+	}
+
+	if pvName != "" {
+		volumeAttachments, err := k8sClient.StorageV1().VolumeAttachments().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			log.Errorf("Failed to list volumeattachments with error - %s", err.Error())
+			return err
+		}
+		for _, va := range volumeAttachments.Items {
+			if va.Spec.Source.PersistentVolumeName != nil && *va.Spec.Source.PersistentVolumeName == pvName {
+				return fmt.Errorf("volume %s is attached to node %s", volumeID, va.Spec.NodeName)
+			}
+		}
+	}
+	return nil
+}
+
+// GetFcdDiskPath returns the datastore path of the virtual disk backing the
+// FCD with the given ID.
+func GetFcdDiskPath(ctx context.Context, volumeManager volumes.Manager, volumeID string) (string, error) {
+	vStorageObject, err := volumeManager.RetrieveVStorageObject(ctx, volumeID)
+	if err != nil {
+		return "", err
+	}
+	backing, ok := vStorageObject.Config.Backing.(*vim25types.BaseConfigInfoDiskFileBackingInfo)
+	if !ok {
+		return "", fmt.Errorf("unexpected backing type %T for FCD %q", vStorageObject.Config.Backing, volumeID)
+	}
+	return backing.FilePath, nil
+}
+
+// DeletePVAndPVCRetainingVolume sets the ReclaimPolicy of the PV to Retain and
+// deletes the PVC and PV of the volume, leaving the underlying FCD intact.
+func DeletePVAndPVCRetainingVolume(ctx context.Context, k8sclient clientset.Interface, volumeID string,
+	pvName string, pvcName string, pvcNamespace string) error {
+	log := logger.GetLogger(ctx)
+	if pvName != "" {
+		//Change PV ReclaimPolicy to retain so that underlying FCD doesn't get deleted when deleting PV,PVC
+		pv, err := k8sclient.CoreV1().PersistentVolumes().Get(ctx, pvName, metav1.GetOptions{})
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				log.Errorf("Unable to get PV %q", pvName)
+				return err
+			}
+		} else if pv.Spec.PersistentVolumeReclaimPolicy != v1.PersistentVolumeReclaimRetain {
+			pv.Spec.PersistentVolumeReclaimPolicy = v1.PersistentVolumeReclaimRetain
+			retryErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+				_, updateErr := k8sclient.CoreV1().PersistentVolumes().Update(context.TODO(), pv, metav1.UpdateOptions{})
+				return updateErr
+			})
+			if retryErr != nil {
+				log.Errorf("Unable to update ReclaimPolicy on PV %q", pvName)
+				return retryErr
+			}
+			log.Infof("Updated ReclaimPolicy on PV %q to %q", pvName, v1.PersistentVolumeReclaimRetain)
+		}
+	} else {
+		log.Infof("CNS metadata for volume %s has missing pvName."+
+			"PV may have already been deleted. Continuing with other operations..", volumeID)
+	}
+
+	// Delete PVC.
+	var err error
+	if pvcName != "" && pvcNamespace != "" {
+		err = k8sclient.CoreV1().PersistentVolumeClaims(pvcNamespace).Delete(ctx,
+			pvcName, *metav1.NewDeleteOptions(0))
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				log.Infof("PVC %q not found in namespace %q. It may have already been deleted."+
+					"Continuing with other operations..", pvcName, pvcNamespace)
+			} else {
+				log.Errorf("Failed to delete PVC %q in namespace %q with error - %s",
+					pvcName, pvcNamespace, err.Error())
+				return err
+			}
+		} else {
+			log.Infof("Deleted PVC %q in namespace %q", pvcName, pvcNamespace)
+		}
+	} else {
+		log.Infof("CNS metadata for volume %s has missing pvcName or namespace."+
+			"PVC may have already been deleted. Continuing with other operations..", volumeID)
+	}
+
+	if pvName != "" {
+		// Delete PV.
+		// Since reclaimPolicy was set to Retain, we need to explicitly delete it.
+		err = k8sclient.CoreV1().PersistentVolumes().Delete(ctx, pvName, *metav1.NewDeleteOptions(0))
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				log.Infof("PV %q not found. It may have already been deleted."+
+					"Continuing with other operations..", pvName)
+			} else {
+				log.Errorf("Failed to delete PV %q with error %s", pvName, err.Error())
+				return err
+			}
+		} else {
+			log.Infof("Deleted PV %q", pvName)
+		}
+	}
+
+	return nil
+}
+
+// RemoveClusterFromVolume removes the CNS metadata of this cluster from the
+// volume. If the volume is not registered with any other cluster, it is
+// removed from CNS with deleteDisk set to false.
+func RemoveClusterFromVolume(ctx context.Context, configInfo *commonconfig.ConfigurationInfo, vcHost string,
+	volumeManager volumes.Manager, cnsVol *cnstypes.CnsVolume,
+	clusterEntityMetadata []cnstypes.BaseCnsEntityMetadata) error {
+	log := logger.GetLogger(ctx)
+	clusterID := configInfo.Cfg.Global.ClusterID
+	var inUseByOtherCluster bool
+	for _, containerCluster := range cnsVol.Metadata.ContainerClusterArray {
+		if containerCluster.ClusterId != clusterID {
+			inUseByOtherCluster = true
+			break
+		}
+	}
+	if !inUseByOtherCluster {
+		_, err := volumeManager.DeleteVolume(ctx, cnsVol.VolumeId.Id, false)
+		if err != nil {
+			if cnsvsphere.IsNotFoundError(err) {
+				log.Infof("VolumeID %q not found in CNS. It may have already been deleted.", cnsVol.VolumeId.Id)
+				return nil
+			}
+			return err
+		}
+		log.Infof("Deleted CNS volume %q with deleteDisk set to false", cnsVol.VolumeId.Id)
+		return nil
+	}
+	if len(clusterEntityMetadata) == 0 {
+		return nil
+	}
+	for _, entity := range clusterEntityMetadata {
+		entity.(*cnstypes.CnsKubernetesEntityMetadata).Delete = true
+	}
+	vcConfig, ok := configInfo.Cfg.VirtualCenter[vcHost]
+	if !ok {
+		return fmt.Errorf("failed to find config for vCenter %q", vcHost)
+	}
+	containerCluster := cnsvsphere.GetContainerCluster(clusterID, cnsvsphere.GetVCenterUser(ctx, vcHost, vcConfig),
+		cnstypes.CnsClusterFlavorVanilla, configInfo.Cfg.Global.ClusterDistribution)
+	updateSpec := &cnstypes.CnsVolumeMetadataUpdateSpec{
+		VolumeId: cnsVol.VolumeId,
+		Metadata: cnstypes.CnsVolumeMetadata{
+			ContainerCluster:      containerCluster,
+			ContainerClusterArray: []cnstypes.CnsContainerCluster{containerCluster},
+			EntityMetadata:        clusterEntityMetadata,
+		},
+	}
+	log.Debugf("Calling UpdateVolumeMetadata for volume %s with updateSpec: %s",
+		updateSpec.VolumeId.Id, spew.Sdump(updateSpec))
+	err := volumeManager.UpdateVolumeMetadata(ctx, updateSpec)
+	if err != nil {
+		return err
+	}
+	log.Infof("Removed CNS metadata of cluster %q from volume %q", clusterID, cnsVol.VolumeId.Id)
+	return nil
+}
+
+// GetVolumeNodeAffinityForVanilla returns the node affinity for a volume on
+// datastoreURL, built from the topology labels of the nodes which can access
+// the datastore. In a cluster without topology, the datastore must be
+// accessible to all nodes on the vCenter and no node affinity is returned.
+func GetVolumeNodeAffinityForVanilla(ctx context.Context, k8sClient client.Client,
+	vc *cnsvsphere.VirtualCenter, datastoreURL string) (*v1.VolumeNodeAffinity, error) {
+	log := logger.GetLogger(ctx)
+	nodeTopologyList := &csinodetopologyv1alpha1.CSINodeTopologyList{}
+	err := k8sClient.List(ctx, nodeTopologyList)
+	if err != nil {
+		return nil, logger.LogNewErrorf(log, "failed to list CSINodeTopology instances. Error: %v", err)
+	}
+	nodeManager := node.GetManager(ctx)
+	var nodeVMs []*cnsvsphere.VirtualMachine
+	nodeLabels := make(map[vim25types.ManagedObjectReference][]csinodetopologyv1alpha1.TopologyLabel)
+	for _, nodeTopology := range nodeTopologyList.Items {
+		if nodeTopology.Spec.NodeUUID == "" {
+			continue
+		}
+		nodeVM, err := nodeManager.GetNodeVMAndUpdateCache(ctx, nodeTopology.Spec.NodeUUID, nil)
+		if err != nil {
+			return nil, logger.LogNewErrorf(log, "failed to get VM for node %q. Error: %v",
+				nodeTopology.Name, err)
+		}
+		if nodeVM.VirtualCenterHost != vc.Config.Host {
+			continue
+		}
+		nodeVMs = append(nodeVMs, nodeVM)
+		nodeLabels[nodeVM.Reference()] = nodeTopology.Status.TopologyLabels
+	}
+	if len(nodeVMs) == 0 {
+		return nil, logger.LogNewErrorf(log, "no nodes found on vCenter %q", vc.Config.Host)
+	}
+	accessibleNodes, err := common.GetNodeVMsWithAccessToDatastore(ctx, vc, datastoreURL, nodeVMs)
+	if err != nil {
+		return nil, err
+	}
+	if len(accessibleNodes) == 0 {
+		return nil, logger.LogNewErrorf(log, "volume on datastore %q is not accessible to any node in the cluster",
+			datastoreURL)
+	}
+
+	var terms []v1.NodeSelectorTerm
+	seenTerms := make(map[string]struct{})
+	for _, accessibleNode := range accessibleNodes {
+		labels := nodeLabels[accessibleNode.Reference()]
+		if len(labels) == 0 {
+			continue
+		}
+		sort.Slice(labels, func(i, j int) bool { return labels[i].Key < labels[j].Key })
+		var segments []string
+		var expressions []v1.NodeSelectorRequirement
+		for _, label := range labels {
+			segments = append(segments, label.Key+"="+label.Value)
+			expressions = append(expressions, v1.NodeSelectorRequirement{
+				Key:      label.Key,
+				Operator: v1.NodeSelectorOpIn,
+				Values:   []string{label.Value},
+			})
+		}
+		termKey := strings.Join(segments, ",")
+		if _, exists := seenTerms[termKey]; exists {
+			continue
+		}
+		seenTerms[termKey] = struct{}{}
+		terms = append(terms, v1.NodeSelectorTerm{MatchExpressions: expressions})
+	}
+	if len(terms) == 0 {
+		// Not a topology aware cluster.
+		if len(accessibleNodes) != len(nodeVMs) {
+			return nil, logger.LogNewErrorf(log, "volume on datastore %q is not accessible to all nodes "+
+				"in the cluster", datastoreURL)
+		}
+		return nil, nil
+	}
+	return &v1.VolumeNodeAffinity{
+		Required: &v1.NodeSelector{
+			NodeSelectorTerms: terms,
+		},
+	}, nil
+}
+
+// GetK8sStorageClassNameForVanillaPolicy returns the name of a vSphere CSI
+// storage class with Immediate volume binding mode whose storage policy is
+// storagePolicyID. An empty name is returned if there is no such storage class.
+func GetK8sStorageClassNameForVanillaPolicy(ctx context.Context, k8sClient clientset.Interface,
+	vc *cnsvsphere.VirtualCenter, storagePolicyID string) (string, error) {
+	log := logger.GetLogger(ctx)
+	scList, err := k8sClient.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", logger.LogNewErrorf(log, "Failed to get Storageclasses from API server. Error: %+v", err)
+	}
+	for _, sc := range scList.Items {
+		if sc.Provisioner != common.VSphereCSIDriverName ||
+			(sc.VolumeBindingMode != nil && *sc.VolumeBindingMode == storagev1.VolumeBindingWaitForFirstConsumer) {
+			continue
+		}
+		for paramName, val := range sc.Parameters {
+			if strings.ToLower(paramName) != common.AttributeStoragePolicyName {
+				continue
+			}
+			policyID, err := vc.GetStoragePolicyIDByName(ctx, val)
+			if err != nil {
+				log.Warnf("Failed to get storage policy ID for policy %q in storage class %q. Error: %v",
+					val, sc.Name, err)
+				continue
+			}
+			if policyID == storagePolicyID {
+				return sc.Name, nil
+			}
+		}
+	}
+	log.Infof("No storage class with Immediate volume binding mode found for storage policy %q", storagePolicyID)
+	return "", nil
+}
+
+// GetPersistentVolumeSpec to create PV volume spec for the given input params.
+func GetPersistentVolumeSpec(volumeName string, volumeID string, capacity int64,
+	accessMode v1.PersistentVolumeAccessMode, scName string, claimRef *v1.ObjectReference) *v1.PersistentVolume {
+	capacityInMb := strconv.FormatInt(capacity, 10) + "Mi"
+	pv := &v1.PersistentVolume{
+		TypeMeta: metav1.TypeMeta{},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: volumeName,
+		},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeReclaimPolicy: v1.PersistentVolumeReclaimDelete,
+			Capacity: v1.ResourceList{
+				v1.ResourceName(v1.ResourceStorage): resource.MustParse(capacityInMb),
+			},
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				CSI: &v1.CSIPersistentVolumeSource{
+					Driver:       common.VSphereCSIDriverName,
+					VolumeHandle: volumeID,
+					ReadOnly:     false,
+					FSType:       "ext4",
+				},
+			},
+			AccessModes: []v1.PersistentVolumeAccessMode{
+				accessMode,
+			},
+			ClaimRef:         claimRef,
+			StorageClassName: scName,
+		},
+		Status: v1.PersistentVolumeStatus{},
+	}
+	annotations := make(map[string]string)
+	annotations["pv.kubernetes.io/provisioned-by"] = common.VSphereCSIDriverName
+	pv.Annotations = annotations
+	return pv
+}
+
+// GetPersistentVolumeClaimSpec return the PersistentVolumeClaim spec with
+// specified storage class.
+func GetPersistentVolumeClaimSpec(ctx context.Context, name string, namespace string, capacity int64,
+	storageClassName string, accessMode v1.PersistentVolumeAccessMode, pvName string,
+	datastoreAccessibleTopology []map[string]string) (*v1.PersistentVolumeClaim, error) {
+
+	log := logger.GetLogger(ctx)
+	capacityInMb := strconv.FormatInt(capacity, 10) + "Mi"
+	var (
+		segmentsArray  []string
+		topoAnnotation = make(map[string]string)
+	)
+	if datastoreAccessibleTopology != nil {
+		for _, topologyTerm := range datastoreAccessibleTopology {
+			jsonSegment, err := json.Marshal(topologyTerm)
+			if err != nil {
+				return nil, logger.LogNewErrorf(log,
+					"failed to marshal topology segment: %+v to json. Error: %+v", topologyTerm, err)
+			}
+			segmentsArray = append(segmentsArray, string(jsonSegment))
+		}
+		topoAnnotation[common.AnnVolumeAccessibleTopology] = "[" + strings.Join(segmentsArray, ",") + "]"
+	}
+
+	claim := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Annotations: topoAnnotation,
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			AccessModes: []v1.PersistentVolumeAccessMode{
+				accessMode,
+			},
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{
+					v1.ResourceName(v1.ResourceStorage): resource.MustParse(capacityInMb),
+				},
+			},
+			StorageClassName: &storageClassName,
+			VolumeName:       pvName,
+		},
+	}
+	return claim, nil
+}
+
+// CreatePVAndPVCForVolume creates a PV for volumeID with a claimRef to the
+// PVC pvcName in namespace, and then the PVC itself. Objects left behind by an
+// earlier attempt are reused.
+func CreatePVAndPVCForVolume(ctx context.Context, k8sclient clientset.Interface, volumeID string, pvName string,
+	pvcName string, namespace string, capacityInMb int64, storageClassName string,
+	accessMode v1.PersistentVolumeAccessMode, pvNodeAffinity *v1.VolumeNodeAffinity) (
+	*v1.PersistentVolumeClaim, error) {
+	log := logger.GetLogger(ctx)
+	pv, err := k8sclient.CoreV1().PersistentVolumes().Get(ctx, pvName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get PV: %s with error: %+v", pvName, err)
+		}
+		claimRef := &v1.ObjectReference{
+			Kind:       "PersistentVolumeClaim",
+			APIVersion: "v1",
+			Namespace:  namespace,
+			Name:       pvcName,
+		}
+		pvSpec := GetPersistentVolumeSpec(pvName, volumeID, capacityInMb, accessMode, storageClassName, claimRef)
+		pvSpec.Spec.NodeAffinity = pvNodeAffinity
+		log.Debugf("PV spec is: %+v", pvSpec)
+		pv, err = k8sclient.CoreV1().PersistentVolumes().Create(ctx, pvSpec, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create PV: %s for volume with err: %+v", pvName, err)
+		}
+		log.Infof("PV: %s is created successfully", pvName)
+	}
+	if pv.Spec.ClaimRef != nil && (pv.Spec.ClaimRef.Name != pvcName || pv.Spec.ClaimRef.Namespace != namespace) {
+		return nil, fmt.Errorf("PV: %s is claimed by PVC %s/%s", pvName,
+			pv.Spec.ClaimRef.Namespace, pv.Spec.ClaimRef.Name)
+	}
+
+	pvcSpec, err := GetPersistentVolumeClaimSpec(ctx, pvcName, namespace, capacityInMb, storageClassName,
+		accessMode, pvName, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spec for PVC: %q. Error: %v", pvcName, err)
+	}
+	log.Debugf("PVC spec is: %+v", pvcSpec)
+	pvc, err := k8sclient.CoreV1().PersistentVolumeClaims(namespace).Create(ctx, pvcSpec, metav1.CreateOptions{})
+	if err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return nil, fmt.Errorf("failed to create PVC: %s on namespace: %s. Error: %+v",
+				pvcName, namespace, err)
+		}
+		pvc, err = k8sclient.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, pvcName, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get PVC: %s on namespace: %s. Error: %+v",
+				pvcName, namespace, err)
+		}
+		if pvc.Spec.VolumeName != pvName {
+			return nil, fmt.Errorf("another PVC: %s already exists in namespace: %s which is not bound to PV: %s",
+				pvcName, namespace, pvName)
+		}
+	}
+	log.Infof("PVC: %s is created successfully", pvcName)
+	return pvc, nil
+}
+
+// IsPVCBound return true if the PVC is bound before timeout.
+// Otherwise, return false.
+func IsPVCBound(ctx context.Context, client clientset.Interface, claim *v1.PersistentVolumeClaim,
+	timeout time.Duration) (bool, error) {
+	log := logger.GetLogger(ctx)
+	pvcName := claim.Name
+	ns := claim.Namespace
+	timeoutSeconds := int64(timeout.Seconds())
+
+	log.Infof("Waiting up to %d seconds for PersistentVolumeClaim %v in namespace %s to have phase %s",
+		timeoutSeconds, pvcName, ns, v1.ClaimBound)
+	watchClaim, err := client.CoreV1().PersistentVolumeClaims(ns).Watch(
+		ctx,
+		metav1.ListOptions{
+			FieldSelector:  fields.OneTermEqualSelector("metadata.name", pvcName).String(),
+			TimeoutSeconds: &timeoutSeconds,
+			Watch:          true,
+		})
+	if err != nil {
+		errMsg := fmt.Errorf("failed to watch PersistentVolumeClaim %s with Error: %v", pvcName, err)
+		log.Error(errMsg)
+		return false, errMsg
+	}
+	defer watchClaim.Stop()
+
+	for event := range watchClaim.ResultChan() {
+		pvc, ok := event.Object.(*v1.PersistentVolumeClaim)
+		if !ok {
+			continue
+		}
+		log.Debugf("PersistentVolumeClaim %s in namespace %s is in state %s. Received event %v",
+			pvcName, ns, pvc.Status.Phase, event)
+		if pvc.Status.Phase == v1.ClaimBound && pvc.Name == pvcName {
+			log.Infof("PersistentVolumeClaim %s in namespace %s is in state %s", pvcName, ns, pvc.Status.Phase)
+			return true, nil
+		}
+	}
+	return false, fmt.Errorf("persistentVolumeClaim %s in namespace %s not in phase %s within %d seconds",
+		pvcName, ns, v1.ClaimBound, timeoutSeconds)
+}
@@ -27,11 +27,15 @@ import (
 	"github.com/vmware/govmomi/cns"
 	cnstypes "github.com/vmware/govmomi/cns/types"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientset "k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	cnsvolumeimportv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsvolumeimport/v1alpha1"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/migration"
 	volumes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
 	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
@@ -40,6 +44,7 @@ import (
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common/commonco"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+	triggercsifullsyncv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsoperator/triggercsifullsync/v1alpha1"
 	cnsvolumeinfov1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsvolumeinfo/v1alpha1"
 	k8s "sigs.k8s.io/vsphere-csi-driver/v3/pkg/kubernetes"
 )
@@ -49,8 +54,27 @@ const allowedRetriesToPatchCNSVolumeInfo = 5
 // CsiFullSync reconciles volume metadata on a vanilla k8s cluster with volume
 // metadata on CNS.
 func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc string) error {
+	_, err := csiFullSync(ctx, metadataSyncer, vc, false)
+	return err
+}
+
+// CsiFullSyncDryRun computes the volumes which CsiFullSync would create,
+// update or delete in CNS for the given VC, without changing them, and returns
+// a summary of them.
+// Unlike CsiFullSync, volumes are reported as soon as they are found to be
+// out of sync, rather than after being out of sync across two cycles.
+func CsiFullSyncDryRun(ctx context.Context, metadataSyncer *metadataSyncInformer,
+	vc string) (*triggercsifullsyncv1alpha1.FullSyncReport, error) {
+	return csiFullSync(ctx, metadataSyncer, vc, true)
+}
+
+// csiFullSync implements CsiFullSync and CsiFullSyncDryRun. If dryRun is set,
+// no changes are made to CNS, to CRs or to the in-memory state used across
+// full sync cycles, and a report of the computed operations is returned.
+func csiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc string,
+	dryRun bool) (*triggercsifullsyncv1alpha1.FullSyncReport, error) {
 	log := logger.GetLogger(ctx)
-	log.Infof("FullSync for VC %s: start", vc)
+	log.Infof("FullSync for VC %s: start (dryRun: %t)", vc, dryRun)
 	fullSyncStartTime := time.Now()
 	var migrationFeatureStateForFullSync bool
 	var err error
@@ -62,27 +86,30 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 		}
 	}
 	// Attempt to create StoragePolicyUsage CRs.
-	if metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload {
+	if metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload && !dryRun {
 		if IsPodVMOnStretchSupervisorFSSEnabled {
 			createStoragePolicyUsageCRS(ctx, metadataSyncer)
 		}
 	}
 	// Sync VolumeInfo CRs for the below conditions:
 	// Either it is a Vanilla k8s deployment with Multi-VC configuration or, it's a StretchSupervisor cluster
-	if isMultiVCenterFssEnabled && len(metadataSyncer.configInfo.Cfg.VirtualCenter) > 1 ||
-		(metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload && IsPodVMOnStretchSupervisorFSSEnabled) {
+	if !dryRun && (isMultiVCenterFssEnabled && len(metadataSyncer.configInfo.Cfg.VirtualCenter) > 1 ||
+		(metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload && IsPodVMOnStretchSupervisorFSSEnabled)) {
 		volumeInfoCRFullSync(ctx, metadataSyncer, vc)
 		cleanUpVolumeInfoCrDeletionMap(ctx, metadataSyncer, vc)
 	}
 	// Attempt to patch StoragePolicyUsage CRs. For storagePolicyUsageCRSync to work,
 	// we need CNSVolumeInfo CRs to be present for all existing volumes.
-	if metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload {
+	if metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload && !dryRun {
 		if IsPodVMOnStretchSupervisorFSSEnabled {
 			storagePolicyUsageCRSync(ctx, metadataSyncer)
 		}
 	}
 
 	defer func() {
+		if dryRun {
+			return
+		}
 		fullSyncStatus := prometheus.PrometheusPassStatus
 		if err != nil {
 			fullSyncStatus = prometheus.PrometheusFailStatus
@@ -95,7 +122,7 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 	k8sPVs, err := getPVsInBoundAvailableOrReleasedForVc(ctx, metadataSyncer, vc)
 	if err != nil {
 		log.Errorf("FullSync for VC %s: Failed to get PVs from kubernetes. Err: %v", vc, err)
-		return err
+		return nil, err
 	}
 
 	// k8sPVMap is useful for clean and quicker look up.
@@ -105,7 +132,7 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 		// Instantiate volumeMigrationService when migration feature state is True.
 		if err = initVolumeMigrationService(ctx, metadataSyncer); err != nil {
 			log.Errorf("FullSync for VC %s: Failed to initialize migration service. Err: %v", vc, err)
-			return err
+			return nil, err
 		}
 	}
 
@@ -123,11 +150,18 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 				VolumePath:        pv.Spec.VsphereVolume.VolumePath,
 				StoragePolicyName: pv.Spec.VsphereVolume.StoragePolicyName}
 			var volumeHandle string
-			volumeHandle, err = volumeMigrationService.GetVolumeID(ctx, migrationVolumeSpec, true)
+			volumeHandle, err = volumeMigrationService.GetVolumeID(ctx, migrationVolumeSpec, !dryRun)
 			if err != nil {
+				if dryRun {
+					// The volume is not registered with CNS yet, a dry run does not
+					// register it.
+					log.Warnf("FullSync for VC %s: Skipping in-tree volume %q which is not registered with CNS",
+						vc, pv.Name)
+					continue
+				}
 				log.Errorf("FullSync for VC %s: Failed to get VolumeID from volumeMigrationService for spec: %v. Err: %+v",
 					vc, migrationVolumeSpec, err)
-				return err
+				return nil, err
 			}
 			k8sPVMap[volumeHandle] = ""
 		}
@@ -137,7 +171,7 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 	pvToPVCMap, pvcToPodMap, err := buildPVCMapPodMap(ctx, k8sPVs, metadataSyncer, vc)
 	if err != nil {
 		log.Errorf("FullSync for VC %s: Failed to build PVCMap and PodMap. Err: %v", vc, err)
-		return err
+		return nil, err
 	}
 	log.Debugf("FullSync for VC %s: pvToPVCMap %v", vc, pvToPVCMap)
 	log.Debugf("FullSyncfor VC %s: pvcToPodMap %v", vc, pvcToPodMap)
@@ -145,19 +179,19 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 	volManager, err := getVolManagerForVcHost(ctx, vc, metadataSyncer)
 	if err != nil {
 		log.Errorf("FullSync for VC %s: Failed to get volume manager. Err: %v", vc, err)
-		return err
+		return nil, err
 	}
 
 	queryAllResult, err := utils.QueryAllVolumesForCluster(ctx, volManager,
 		metadataSyncer.configInfo.Cfg.Global.ClusterID, cnstypes.CnsQuerySelection{})
 	if err != nil {
 		log.Errorf("FullSync for VC %s: QueryVolume failed with err=%+v", vc, err.Error())
-		return err
+		return nil, err
 	}
 	if metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload &&
 		commonco.ContainerOrchestratorUtility.IsFSSEnabled(ctx, common.TKGsHA) {
 		// Replace Volume Metadata using old cluster ID and replace with the new SupervisorID
-		if len(queryAllResult.Volumes) > 0 {
+		if len(queryAllResult.Volumes) > 0 && !dryRun {
 			var updateMetadataSpecArray []cnstypes.CnsVolumeMetadataUpdateSpec
 			for _, volume := range queryAllResult.Volumes {
 				var updatedContainerClusterArray []cnstypes.CnsContainerCluster
@@ -243,10 +277,10 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 			metadataSyncer.configInfo.Cfg.Global.SupervisorID, querySelection)
 		if err != nil {
 			log.Errorf("FullSync for VC %s: QueryVolume failed with err=%+v", vc, err.Error())
-			return err
+			return nil, err
 		}
 	}
-	if metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload && isStorageQuotaM2FSSEnabled {
+	if metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorWorkload && isStorageQuotaM2FSSEnabled && !dryRun {
 		cnsVolumeMap := make(map[string]cnstypes.CnsVolume)
 		for _, vol := range queryAllResult.Volumes {
 			cnsVolumeMap[vol.VolumeId.Id] = vol
@@ -256,21 +290,21 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 		if err != nil {
 			log.Errorf("FullSync for VC %s: Error while sync CNSVolumeinfo snapshot details, failed with err=%+v",
 				vc, err.Error())
-			return err
+			return nil, err
 		}
 	}
 	vcHostObj, vcHostObjFound := metadataSyncer.configInfo.Cfg.VirtualCenter[vc]
 	if !vcHostObjFound {
 		log.Errorf("FullSync for VC %s: Failed to get VC host object.", vc)
-		return errors.New("failed to get VC host object")
+		return nil, errors.New("failed to get VC host object")
 	}
 
 	volumeToCnsEntityMetadataMap, volumeToK8sEntityMetadataMap, volumeClusterDistributionMap, err :=
 		fullSyncConstructVolumeMaps(ctx, k8sPVs, queryAllResult.Volumes, pvToPVCMap,
-			pvcToPodMap, metadataSyncer, migrationFeatureStateForFullSync, volManager, vc)
+			pvcToPodMap, metadataSyncer, migrationFeatureStateForFullSync, volManager, vc, dryRun)
 	if err != nil {
 		log.Errorf("FullSync for VC %s: fullSyncGetEntityMetadata failed with err %+v", vc, err)
-		return err
+		return nil, err
 	}
 	log.Debugf("FullSync for VC %s: pvToCnsEntityMetadataMap %+v \n pvToK8sEntityMetadataMap: %+v \n",
 		vc, spew.Sdump(volumeToCnsEntityMetadataMap), spew.Sdump(volumeToK8sEntityMetadataMap))
@@ -282,27 +316,46 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 		vcenter, err = cnsvsphere.GetVirtualCenterInstanceForVCenterHost(ctx, vc, true)
 		if err != nil {
 			log.Errorf("failed to get virtual center instance for VC: %s. Error: %v", vc, err)
-			return err
+			return nil, err
 		}
 	} else {
 		vcenter, err = cnsvsphere.GetVirtualCenterInstance(ctx, metadataSyncer.configInfo, false)
 		if err != nil {
 			log.Errorf("failed to get virtual center instance with error: %v", err)
-			return err
+			return nil, err
 		}
 	}
 
 	containerCluster := cnsvsphere.GetContainerCluster(clusterIDforVolumeMetadata,
-		vcHostObj.User, metadataSyncer.clusterFlavor,
+		cnsvsphere.GetVCenterUser(ctx, vc, vcHostObj), metadataSyncer.clusterFlavor,
 		metadataSyncer.configInfo.Cfg.Global.ClusterDistribution)
 	createSpecArray, updateSpecArray := fullSyncGetVolumeSpecs(ctx, vcenter.Client.Version, k8sPVs,
 		volumeToCnsEntityMetadataMap, volumeToK8sEntityMetadataMap, volumeClusterDistributionMap,
-		containerCluster, migrationFeatureStateForFullSync, vc)
+		containerCluster, migrationFeatureStateForFullSync, vc, dryRun)
 	volToBeDeleted, err := getVolumesToBeDeleted(ctx, queryAllResult.Volumes, k8sPVMap, metadataSyncer,
-		migrationFeatureStateForFullSync, vc)
+		migrationFeatureStateForFullSync, vc, dryRun)
 	if err != nil {
 		log.Errorf("FullSync for VC %s: failed to get list of volumes to be deleted with err %+v", vc, err)
-		return err
+		return nil, err
+	}
+	if dryRun {
+		report := newFullSyncReport(createSpecArray, updateSpecArray, volToBeDeleted)
+		log.Infof("FullSync for VC %s: dry run end. Volumes to create: %d, to update: %d, to delete: %d",
+			vc, report.VolumesToCreate.Count, report.VolumesToUpdate.Count, report.VolumesToDelete.Count)
+		return report, nil
+	}
+	if metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorVanilla &&
+		metadataSyncer.coCommonInterface.IsFSSEnabled(ctx, common.OrphanVolumeQuarantine) {
+		volToBeDeleted, err = quarantineOrphanVolumes(ctx, metadataSyncer, volToBeDeleted, queryAllResult.Volumes,
+			k8sPVMap, vc)
+		if err != nil {
+			// Keep the CNS metadata of all volumes rather than remove it without
+			// a record.
+			log.Errorf("FullSync for VC %s: failed to quarantine orphan volumes. Skipping removal of CNS "+
+				"metadata in this cycle. Err: %v", vc, err)
+			volToBeDeleted = nil
+			err = nil
+		}
 	}
 
 	wg := sync.WaitGroup{}
@@ -317,7 +370,46 @@ func CsiFullSync(ctx context.Context, metadataSyncer *metadataSyncInformer, vc s
 	log.Debugf("FullSync for VC %s: cnsDeletionMap at end of cycle: %v", vc, cnsDeletionMap)
 	log.Debugf("FullSync for VC %s: cnsCreationMap at end of cycle: %v", vc, cnsCreationMap)
 	log.Infof("FullSync for VC %s: end", vc)
-	return nil
+	return nil, nil
+}
+
+// newFullSyncReport returns a FullSyncReport summarizing the given create,
+// update and delete operations.
+func newFullSyncReport(createSpecArray []cnstypes.CnsVolumeCreateSpec,
+	updateSpecArray []cnstypes.CnsVolumeMetadataUpdateSpec,
+	volToBeDeleted []cnstypes.CnsVolumeId) *triggercsifullsyncv1alpha1.FullSyncReport {
+	report := &triggercsifullsyncv1alpha1.FullSyncReport{}
+	for _, createSpec := range createSpecArray {
+		var volumeID string
+		switch backingDetails := createSpec.BackingObjectDetails.(type) {
+		case *cnstypes.CnsBlockBackingDetails:
+			volumeID = backingDetails.BackingDiskId
+		case *cnstypes.CnsVsanFileShareBackingDetails:
+			volumeID = backingDetails.BackingFileId
+		}
+		addToFullSyncVolumeSummary(&report.VolumesToCreate, volumeID)
+	}
+	// A volume can have more than one update spec, count it once.
+	updatedVolumes := make(map[string]bool)
+	for _, updateSpec := range updateSpecArray {
+		if !updatedVolumes[updateSpec.VolumeId.Id] {
+			updatedVolumes[updateSpec.VolumeId.Id] = true
+			addToFullSyncVolumeSummary(&report.VolumesToUpdate, updateSpec.VolumeId.Id)
+		}
+	}
+	for _, volumeID := range volToBeDeleted {
+		addToFullSyncVolumeSummary(&report.VolumesToDelete, volumeID.Id)
+	}
+	return report
+}
+
+// addToFullSyncVolumeSummary counts volumeID in summary and lists it, if the
+// summary lists less than FullSyncReportMaxVolumeIDs volumes.
+func addToFullSyncVolumeSummary(summary *triggercsifullsyncv1alpha1.FullSyncVolumeSummary, volumeID string) {
+	summary.Count++
+	if len(summary.VolumeIDs) < triggercsifullsyncv1alpha1.FullSyncReportMaxVolumeIDs {
+		summary.VolumeIDs = append(summary.VolumeIDs, volumeID)
+	}
 }
 
 // cleanUpVolumeInfoCrDeletionMap removes volumes from the VolumeInfo CR deletion map
@@ -806,7 +898,7 @@ func buildCnsMetadataList(ctx context.Context, pv *v1.PersistentVolume, pvToPVCM
 func fullSyncConstructVolumeMaps(ctx context.Context, pvList []*v1.PersistentVolume,
 	cnsVolumeList []cnstypes.CnsVolume, pvToPVCMap pvcMap, pvcToPodMap podMap,
 	metadataSyncer *metadataSyncInformer, migrationFeatureStateForFullSync bool,
-	volManager volumes.Manager, vc string) (
+	volManager volumes.Manager, vc string, dryRun bool) (
 	map[string][]cnstypes.BaseCnsEntityMetadata, map[string][]cnstypes.BaseCnsEntityMetadata,
 	map[string]bool, error) {
 	log := logger.GetLogger(ctx)
@@ -831,8 +923,11 @@ func fullSyncConstructVolumeMaps(ctx context.Context, pvList []*v1.PersistentVol
 			migrationVolumeSpec := &migration.VolumeSpec{
 				VolumePath:        pv.Spec.VsphereVolume.VolumePath,
 				StoragePolicyName: pv.Spec.VsphereVolume.StoragePolicyName}
-			volumeHandle, err = volumeMigrationService.GetVolumeID(ctx, migrationVolumeSpec, true)
+			volumeHandle, err = volumeMigrationService.GetVolumeID(ctx, migrationVolumeSpec, !dryRun)
 			if err != nil {
+				if dryRun {
+					continue
+				}
 				log.Errorf("FullSync for VC %s: Failed to get VolumeID from volumeMigrationService for spec: %v. Err: %+v",
 					vc, migrationVolumeSpec, err)
 				return nil, nil, nil, err
@@ -893,7 +988,7 @@ func fullSyncGetVolumeSpecs(ctx context.Context, vCenterVersion string, pvList [
 	volumeToCnsEntityMetadataMap map[string][]cnstypes.BaseCnsEntityMetadata,
 	volumeToK8sEntityMetadataMap map[string][]cnstypes.BaseCnsEntityMetadata,
 	volumeClusterDistributionMap map[string]bool, containerCluster cnstypes.CnsContainerCluster,
-	migrationFeatureStateForFullSync bool, vc string) (
+	migrationFeatureStateForFullSync bool, vc string, dryRun bool) (
 	[]cnstypes.CnsVolumeCreateSpec, []cnstypes.CnsVolumeMetadataUpdateSpec) {
 	log := logger.GetLogger(ctx)
 	var createSpecArray []cnstypes.CnsVolumeCreateSpec
@@ -907,7 +1002,7 @@ func fullSyncGetVolumeSpecs(ctx context.Context, vCenterVersion string, pvList [
 			migrationVolumeSpec := &migration.VolumeSpec{
 				VolumePath:        pv.Spec.VsphereVolume.VolumePath,
 				StoragePolicyName: pv.Spec.VsphereVolume.StoragePolicyName}
-			volumeHandle, err = volumeMigrationService.GetVolumeID(ctx, migrationVolumeSpec, true)
+			volumeHandle, err = volumeMigrationService.GetVolumeID(ctx, migrationVolumeSpec, !dryRun)
 			if err != nil {
 				log.Warnf("FullSync for VC %s: Failed to get VolumeID from volumeMigrationService for spec: %v. Err: %+v",
 					vc, migrationVolumeSpec, err)
@@ -926,8 +1021,9 @@ func fullSyncGetVolumeSpecs(ctx context.Context, vCenterVersion string, pvList [
 		}
 		if !presentInCNS {
 			// PV exist in K8S but not in CNS cache, need to create
-			if _, existsInCnsCreationMap := cnsCreationMap[vc][volumeHandle]; existsInCnsCreationMap {
-				// Volume was present in cnsCreationMap across two full-sync cycles.
+			if _, existsInCnsCreationMap := cnsCreationMap[vc][volumeHandle]; existsInCnsCreationMap || dryRun {
+				// Volume was present in cnsCreationMap across two full-sync cycles,
+				// a dry run reports it right away.
 				log.Infof("FullSync for VC %s: create is required for volume: %q", vc, volumeHandle)
 				operationType = "createVolume"
 			} else {
@@ -1064,10 +1160,11 @@ func fullSyncGetVolumeSpecs(ctx context.Context, vCenterVersion string, pvList [
 
 // getVolumesToBeDeleted return list of volumeIds that need to be deleted.
 // A volumeId is added to this list only if it was present in cnsDeletionMap
-// across two cycles of full sync.
+// across two cycles of full sync. If dryRun is set, cnsDeletionMap is not
+// used and every volumeId which would be added to it is returned.
 func getVolumesToBeDeleted(ctx context.Context, cnsVolumeList []cnstypes.CnsVolume, k8sPVMap map[string]string,
 	metadataSyncer *metadataSyncInformer, migrationFeatureStateForFullSync bool,
-	vc string) ([]cnstypes.CnsVolumeId, error) {
+	vc string, dryRun bool) ([]cnstypes.CnsVolumeId, error) {
 	log := logger.GetLogger(ctx)
 	var volToBeDeleted []cnstypes.CnsVolumeId
 	// inlineVolumeMap holds the volume path information for migrated volumes
@@ -1075,20 +1172,44 @@ func getVolumesToBeDeleted(ctx context.Context, cnsVolumeList []cnstypes.CnsVolu
 	inlineVolumeMap := make(map[string]string)
 	var err error
 	if migrationFeatureStateForFullSync {
-		inlineVolumeMap, err = fullSyncGetInlineMigratedVolumesInfo(ctx, metadataSyncer, migrationFeatureStateForFullSync)
+		inlineVolumeMap, err = fullSyncGetInlineMigratedVolumesInfo(ctx, metadataSyncer,
+			migrationFeatureStateForFullSync, !dryRun)
 		if err != nil {
 			log.Errorf("FullSync for VC %s: Failed to get inline migrated volumes. Err: %v", vc, err)
 			return volToBeDeleted, err
 		}
 	}
+	// importVolumeIDs holds the IDs of volumes which are being imported with
+	// CnsVolumeImport. Such a volume is registered with CNS before its PV is
+	// created and must not be deleted in the meantime.
+	importVolumeIDs := make(map[string]struct{})
+	if metadataSyncer.clusterFlavor == cnstypes.CnsClusterFlavorVanilla &&
+		metadataSyncer.coCommonInterface.IsFSSEnabled(ctx, common.VanillaVolumeHandoff) {
+		importVolumeIDs, err = getPendingImportVolumeIDs(ctx, metadataSyncer.cnsOperatorClient)
+		if err != nil {
+			log.Errorf("FullSync for VC %s: Failed to get volumes being imported. Err: %v", vc, err)
+			return volToBeDeleted, err
+		}
+	}
 	for _, vol := range cnsVolumeList {
 		if _, existsInK8s := k8sPVMap[vol.VolumeId.Id]; !existsInK8s {
+			if _, beingImported := importVolumeIDs[vol.VolumeId.Id]; beingImported {
+				log.Debugf("FullSync for VC %s: Volume with id %s is being imported. Skipping for deletion",
+					vc, vol.VolumeId.Id)
+				continue
+			}
 			if _, existsInCnsDeletionMap := cnsDeletionMap[vc][vol.VolumeId.Id]; existsInCnsDeletionMap {
 				// Volume does not exist in K8s across two fullsync cycles, because
 				// it was present in cnsDeletionMap across two full sync cycles.
 				// Add it to delete list.
 				log.Debugf("FullSync for VC %s: Volume with id %s added to delete list", vc, vol.VolumeId.Id)
 				volToBeDeleted = append(volToBeDeleted, vol.VolumeId)
+			} else if dryRun {
+				if _, existsInInlineVolumeMap := inlineVolumeMap[vol.VolumeId.Id]; !existsInInlineVolumeMap {
+					log.Debugf("FullSync for VC %s: Volume with id %s added to delete list of dry run",
+						vc, vol.VolumeId.Id)
+					volToBeDeleted = append(volToBeDeleted, vol.VolumeId)
+				}
 			} else {
 				// Add to cnsDeletionMap.
 				if migrationFeatureStateForFullSync {
@@ -1110,6 +1231,29 @@ func getVolumesToBeDeleted(ctx context.Context, cnsVolumeList []cnstypes.CnsVolu
 	return volToBeDeleted, nil
 }
 
+// getPendingImportVolumeIDs returns the IDs of volumes in CnsVolumeImport
+// instances which have not been imported yet. No volume is pending if the
+// CnsVolumeImport CRD is not installed on the cluster.
+func getPendingImportVolumeIDs(ctx context.Context, cnsOperatorClient client.Client) (map[string]struct{}, error) {
+	log := logger.GetLogger(ctx)
+	volumeIDs := make(map[string]struct{})
+	importList := &cnsvolumeimportv1alpha1.CnsVolumeImportList{}
+	err := cnsOperatorClient.List(ctx, importList)
+	if err != nil {
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			log.Debugf("CnsVolumeImport CRD is not installed. Err: %v", err)
+			return volumeIDs, nil
+		}
+		return nil, err
+	}
+	for _, instance := range importList.Items {
+		if !instance.Status.Imported {
+			volumeIDs[instance.Spec.VolumeID] = struct{}{}
+		}
+	}
+	return volumeIDs, nil
+}
+
 // buildPVCMapPodMap build two maps to help find
 // 1) PVC for given PV, and 2) POD mounted to given PVC.
 // pvToPVCMap maps PV name to corresponding PVC, key is pv name.
@@ -427,6 +427,16 @@ func InitMetadataSyncer(ctx context.Context, clusterFlavor cnstypes.CnsClusterFl
 					csinodetopology.CRDSingular, err)
 			}
 		}
+		// Full sync lists CnsVolumeImport instances on this cluster with this
+		// client, so that volumes being imported are not deleted from CNS.
+		restConfig, err := config.GetConfig()
+		if err != nil {
+			return logger.LogNewErrorf(log, "failed to get Kubernetes config. Err: %v", err)
+		}
+		metadataSyncer.cnsOperatorClient, err = k8s.NewClientForGroup(ctx, restConfig, cnsoperatorv1alpha1.GroupName)
+		if err != nil {
+			return logger.LogNewErrorf(log, "failed to create cns operator client. Err: %v", err)
+		}
 	}
 
 	cfgPath := cnsconfig.GetConfigPath(ctx)
@@ -1572,9 +1582,18 @@ func ReloadConfiguration(metadataSyncer *metadataSyncInformer, reconnectToVCFrom
 		if newVCConfig != nil {
 			var vcenter *cnsvsphere.VirtualCenter
 			newVCConfig.ReloadVCConfigForNewClient = true
+			// Compare with the credentials the current vCenter instance uses, as
+			// the user and password are not in the config secret if a credential
+			// provider is configured.
+			var currentVC *cnsvsphere.VirtualCenter
+			currentVC, err = cnsvsphere.GetVirtualCenterInstance(ctx, metadataSyncer.configInfo, false)
+			if err != nil {
+				return logger.LogNewErrorf(log, "failed to get VirtualCenter. err=%v", err)
+			}
 			if metadataSyncer.host != newVCConfig.Host ||
-				metadataSyncer.configInfo.Cfg.VirtualCenter[metadataSyncer.host].User != newVCConfig.Username ||
-				metadataSyncer.configInfo.Cfg.VirtualCenter[metadataSyncer.host].Password != newVCConfig.Password ||
+				currentVC.Config.Username != newVCConfig.Username ||
+				currentVC.Config.Password != newVCConfig.Password ||
+				currentVC.Config.CredentialProvider != newVCConfig.CredentialProvider ||
 				reconnectToVCFromNewConfig {
 				// Verify if new configuration has valid credentials by connecting
 				// to vCenter. Proceed only if the connection succeeds, else return
@@ -2141,7 +2160,7 @@ func csiPVCUpdated(ctx context.Context, pvc *v1.PersistentVolumeClaim,
 
 	metadataList = append(metadataList, cnstypes.BaseCnsEntityMetadata(pvcMetadata))
 	containerCluster := cnsvsphere.GetContainerCluster(clusterIDforVolumeMetadata,
-		vcHostObj.User, metadataSyncer.clusterFlavor,
+		cnsvsphere.GetVCenterUser(ctx, vcHost, vcHostObj), metadataSyncer.clusterFlavor,
 		metadataSyncer.configInfo.Cfg.Global.ClusterDistribution)
 
 	updateSpec := &cnstypes.CnsVolumeMetadataUpdateSpec{
@@ -2214,7 +2233,8 @@ func csiPVCDeleted(ctx context.Context, pvc *v1.PersistentVolumeClaim,
 	}
 
 	containerCluster := cnsvsphere.GetContainerCluster(clusterIDforVolumeMetadata,
-		vcHostObj.User, metadataSyncer.clusterFlavor, metadataSyncer.configInfo.Cfg.Global.ClusterDistribution)
+		cnsvsphere.GetVCenterUser(ctx, vcHost, vcHostObj), metadataSyncer.clusterFlavor,
+		metadataSyncer.configInfo.Cfg.Global.ClusterDistribution)
 	updateSpec := &cnstypes.CnsVolumeMetadataUpdateSpec{
 		VolumeId: cnstypes.CnsVolumeId{
 			Id: volumeHandle,
@@ -2379,7 +2399,7 @@ func csiPVUpdated(ctx context.Context, newPv *v1.PersistentVolume, oldPv *v1.Per
 		}
 
 		containerCluster = cnsvsphere.GetContainerCluster(clusterIDforVolumeMetadata,
-			vcHostObj.User, metadataSyncer.clusterFlavor,
+			cnsvsphere.GetVCenterUser(ctx, vcHost, vcHostObj), metadataSyncer.clusterFlavor,
 			metadataSyncer.configInfo.Cfg.Global.ClusterDistribution)
 
 		if volumeType == common.BlockVolumeType || len(metadataSyncer.configInfo.Cfg.VirtualCenter) == 1 {
@@ -2430,7 +2450,7 @@ func csiPVUpdated(ctx context.Context, newPv *v1.PersistentVolume, oldPv *v1.Per
 		}
 
 		containerCluster = cnsvsphere.GetContainerCluster(clusterIDforVolumeMetadata,
-			vcHostObj.User, metadataSyncer.clusterFlavor,
+			cnsvsphere.GetVCenterUser(ctx, vcHost, vcHostObj), metadataSyncer.clusterFlavor,
 			metadataSyncer.configInfo.Cfg.Global.ClusterDistribution)
 	}
 	// Call UpdateVolumeMetadata for all other cases.
@@ -2563,7 +2583,8 @@ func csiPVDeleted(ctx context.Context, pv *v1.PersistentVolume, metadataSyncer *
 		metadataList = append(metadataList, cnstypes.BaseCnsEntityMetadata(pvMetadata))
 
 		containerCluster := cnsvsphere.GetContainerCluster(clusterIDforVolumeMetadata,
-			vcHostObj.User, metadataSyncer.clusterFlavor, metadataSyncer.configInfo.Cfg.Global.ClusterDistribution)
+			cnsvsphere.GetVCenterUser(ctx, vcHost, vcHostObj), metadataSyncer.clusterFlavor,
+			metadataSyncer.configInfo.Cfg.Global.ClusterDistribution)
 		updateSpec := &cnstypes.CnsVolumeMetadataUpdateSpec{
 			VolumeId: cnstypes.CnsVolumeId{
 				Id: pv.Spec.CSI.VolumeHandle,
@@ -2791,7 +2812,7 @@ func csiUpdatePod(ctx context.Context, pod *v1.Pod, metadataSyncer *metadataSync
 		}
 
 		containerCluster := cnsvsphere.GetContainerCluster(clusterIDforVolumeMetadata,
-			vcHostObj.User, metadataSyncer.clusterFlavor,
+			cnsvsphere.GetVCenterUser(ctx, vcHost, vcHostObj), metadataSyncer.clusterFlavor,
 			metadataSyncer.configInfo.Cfg.Global.ClusterDistribution)
 		updateSpec := &cnstypes.CnsVolumeMetadataUpdateSpec{
 			VolumeId: cnstypes.CnsVolumeId{
@@ -0,0 +1,158 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	cnstypes "github.com/vmware/govmomi/cns/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	orphanvolumev1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/orphanvolume/v1alpha1"
+	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/logger"
+)
+
+// getOrphanVolumeName returns the name of the OrphanVolume instance of the
+// volume. Volume IDs of file volumes contain ":" which is not allowed in
+// names.
+func getOrphanVolumeName(volumeID string) string {
+	return strings.ToLower(strings.ReplaceAll(volumeID, ":", "-"))
+}
+
+// newOrphanVolume returns an OrphanVolume instance recording the CNS volume
+// vol on vCenter vc, which was found orphaned at firstSeen.
+func newOrphanVolume(vol cnstypes.CnsVolume, clusterID string, vc string,
+	firstSeen time.Time) *orphanvolumev1alpha1.OrphanVolume {
+	orphanVolume := &orphanvolumev1alpha1.OrphanVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: getOrphanVolumeName(vol.VolumeId.Id),
+		},
+		Spec: orphanvolumev1alpha1.OrphanVolumeSpec{
+			VolumeID:           vol.VolumeId.Id,
+			VCenter:            vc,
+			VolumeType:         vol.VolumeType,
+			DatastoreURL:       vol.DatastoreUrl,
+			StoragePolicyID:    vol.StoragePolicyId,
+			FirstSeenTimeStamp: metav1.NewTime(firstSeen),
+		},
+	}
+	if vol.BackingObjectDetails != nil {
+		orphanVolume.Spec.CapacityInMb = vol.BackingObjectDetails.GetCnsBackingObjectDetails().CapacityInMb
+	}
+	for _, entityMetadata := range vol.Metadata.EntityMetadata {
+		k8sEntityMetadata, ok := entityMetadata.(*cnstypes.CnsKubernetesEntityMetadata)
+		if !ok || k8sEntityMetadata.ClusterID != clusterID {
+			continue
+		}
+		switch k8sEntityMetadata.EntityType {
+		case string(cnstypes.CnsKubernetesEntityTypePV):
+			orphanVolume.Spec.PvName = k8sEntityMetadata.EntityName
+		case string(cnstypes.CnsKubernetesEntityTypePVC):
+			orphanVolume.Spec.PvcName = k8sEntityMetadata.EntityName
+			orphanVolume.Spec.PvcNamespace = k8sEntityMetadata.Namespace
+		}
+	}
+	return orphanVolume
+}
+
+// quarantineOrphanVolumes returns the volumes in volToBeDeleted whose CNS
+// metadata can be removed by full sync. Every volume in volToBeDeleted is
+// first recorded in an OrphanVolume instance. The volume is returned and the
+// instance is deleted once the user approves its cleanup in the instance, or
+// once its grace period has expired if auto cleanup is enabled. A volume whose
+// restore was requested is never returned.
+// OrphanVolume instances of volumes which have a PV again or are no longer in
+// CNS are deleted.
+func quarantineOrphanVolumes(ctx context.Context, metadataSyncer *metadataSyncInformer,
+	volToBeDeleted []cnstypes.CnsVolumeId, cnsVolumeList []cnstypes.CnsVolume, k8sPVMap map[string]string,
+	vc string) ([]cnstypes.CnsVolumeId, error) {
+	log := logger.GetLogger(ctx)
+	cnsOperatorClient := metadataSyncer.cnsOperatorClient
+	orphanVolumeList := &orphanvolumev1alpha1.OrphanVolumeList{}
+	err := cnsOperatorClient.List(ctx, orphanVolumeList)
+	if err != nil {
+		return nil, err
+	}
+	cnsVolumeMap := make(map[string]cnstypes.CnsVolume)
+	for _, vol := range cnsVolumeList {
+		cnsVolumeMap[vol.VolumeId.Id] = vol
+	}
+
+	orphanVolumes := make(map[string]*orphanvolumev1alpha1.OrphanVolume)
+	for i := range orphanVolumeList.Items {
+		orphanVolume := &orphanVolumeList.Items[i]
+		if orphanVolume.Spec.VCenter != vc {
+			continue
+		}
+		volumeID := orphanVolume.Spec.VolumeID
+		_, existsInK8s := k8sPVMap[volumeID]
+		_, existsInCns := cnsVolumeMap[volumeID]
+		if existsInK8s || !existsInCns {
+			log.Infof("FullSync for VC %s: Deleting OrphanVolume %q as volume %q is no longer orphaned",
+				vc, orphanVolume.Name, volumeID)
+			err = cnsOperatorClient.Delete(ctx, orphanVolume)
+			if err != nil {
+				log.Warnf("FullSync for VC %s: Failed to delete OrphanVolume %q. Err: %v", vc, orphanVolume.Name, err)
+			}
+			continue
+		}
+		orphanVolumes[volumeID] = orphanVolume
+	}
+
+	gracePeriod := time.Duration(metadataSyncer.configInfo.Cfg.Global.OrphanVolumeGracePeriodInMin) * time.Minute
+	autoCleanup := metadataSyncer.configInfo.Cfg.Global.OrphanVolumeAutoCleanup
+	var volumesToCleanup []cnstypes.CnsVolumeId
+	for _, volumeID := range volToBeDeleted {
+		orphanVolume, found := orphanVolumes[volumeID.Id]
+		if !found {
+			orphanVolume = newOrphanVolume(cnsVolumeMap[volumeID.Id], metadataSyncer.configInfo.Cfg.Global.ClusterID,
+				vc, time.Now())
+			err = cnsOperatorClient.Create(ctx, orphanVolume)
+			if err != nil {
+				log.Errorf("FullSync for VC %s: Failed to create OrphanVolume for volume %q. Err: %v",
+					vc, volumeID.Id, err)
+				continue
+			}
+			log.Infof("FullSync for VC %s: Volume %q is not present in kubernetes. Recorded it in OrphanVolume %q "+
+				"instead of removing its CNS metadata", vc, volumeID.Id, orphanVolume.Name)
+			continue
+		}
+		if orphanVolume.Spec.Restore {
+			log.Debugf("FullSync for VC %s: Keeping orphan volume %q as its restore was requested", vc, volumeID.Id)
+			continue
+		}
+		if orphanVolume.Spec.Cleanup {
+			log.Infof("FullSync for VC %s: Cleanup of orphan volume %q was approved. Removing its CNS metadata",
+				vc, volumeID.Id)
+		} else if autoCleanup && time.Since(orphanVolume.Spec.FirstSeenTimeStamp.Time) >= gracePeriod {
+			log.Infof("FullSync for VC %s: Grace period of orphan volume %q has expired. Removing its CNS metadata",
+				vc, volumeID.Id)
+		} else {
+			log.Debugf("FullSync for VC %s: Keeping orphan volume %q", vc, volumeID.Id)
+			continue
+		}
+		err = cnsOperatorClient.Delete(ctx, orphanVolume)
+		if err != nil {
+			log.Errorf("FullSync for VC %s: Failed to delete OrphanVolume %q. Err: %v", vc, orphanVolume.Name, err)
+			continue
+		}
+		volumesToCleanup = append(volumesToCleanup, volumeID)
+	}
+	return volumesToCleanup, nil
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
@@ -30,17 +31,28 @@ import (
 	"github.com/vmware/govmomi/simulator"
 	vimtypes "github.com/vmware/govmomi/vim25/types"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	clientset "k8s.io/client-go/kubernetes"
 	testclient "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 
+	cnsoperatorv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator"
+	cnsvolumeimportv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/cnsvolumeimport/v1alpha1"
+	orphanvolumev1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/orphanvolume/v1alpha1"
 	cnsvolumes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/volume"
 	cnsvsphere "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/cns-lib/vsphere"
 	cnsconfig "sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/config"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/common/unittestcommon"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/service/common"
 	csitypes "sigs.k8s.io/vsphere-csi-driver/v3/pkg/csi/types"
+	triggercsifullsyncv1alpha1 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/internalapis/cnsoperator/triggercsifullsync/v1alpha1"
 	k8s "sigs.k8s.io/vsphere-csi-driver/v3/pkg/kubernetes"
 )
 
@@ -976,3 +988,344 @@ func TestGetVCForTopologySegments(t *testing.T) {
 		})
 	}
 }
+
+func TestGetVolumesToBeDeletedDryRun(t *testing.T) {
+	// Create context.
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	vc := "test-vc-dry-run"
+	cnsDeletionMap = map[string]map[string]bool{vc: {"vol-2": true}}
+	syncer := &metadataSyncInformer{clusterFlavor: cnstypes.CnsClusterFlavorWorkload}
+	cnsVolumes := []cnstypes.CnsVolume{
+		{VolumeId: cnstypes.CnsVolumeId{Id: "vol-1"}},
+		{VolumeId: cnstypes.CnsVolumeId{Id: "vol-2"}},
+		{VolumeId: cnstypes.CnsVolumeId{Id: "vol-3"}},
+	}
+	k8sPVMap := map[string]string{"vol-3": ""}
+
+	volToBeDeleted, err := getVolumesToBeDeleted(ctx, cnsVolumes, k8sPVMap, syncer, false, vc, true)
+	if err != nil {
+		t.Fatalf("getVolumesToBeDeleted failed with dry run. Err: %v", err)
+	}
+	if len(volToBeDeleted) != 2 || volToBeDeleted[0].Id != "vol-1" || volToBeDeleted[1].Id != "vol-2" {
+		t.Errorf("Expected vol-1 and vol-2 to be deleted with dry run, got %+v", volToBeDeleted)
+	}
+	if len(cnsDeletionMap[vc]) != 1 {
+		t.Errorf("Expected cnsDeletionMap to be unchanged by dry run, got %v", cnsDeletionMap[vc])
+	}
+
+	volToBeDeleted, err = getVolumesToBeDeleted(ctx, cnsVolumes, k8sPVMap, syncer, false, vc, false)
+	if err != nil {
+		t.Fatalf("getVolumesToBeDeleted failed. Err: %v", err)
+	}
+	if len(volToBeDeleted) != 1 || volToBeDeleted[0].Id != "vol-2" {
+		t.Errorf("Expected only vol-2 to be deleted, got %+v", volToBeDeleted)
+	}
+	if !cnsDeletionMap[vc]["vol-1"] {
+		t.Errorf("Expected vol-1 to be added to cnsDeletionMap, got %v", cnsDeletionMap[vc])
+	}
+}
+
+func TestGetPendingImportVolumeIDs(t *testing.T) {
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	scheme := runtime.NewScheme()
+	if err := cnsoperatorv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add CNS operator types to scheme. Err: %v", err)
+	}
+	importInstances := []runtime.Object{
+		&cnsvolumeimportv1alpha1.CnsVolumeImport{
+			ObjectMeta: metav1.ObjectMeta{Name: "import-1", Namespace: "test-ns"},
+			Spec:       cnsvolumeimportv1alpha1.CnsVolumeImportSpec{VolumeID: "vol-1"},
+		},
+		&cnsvolumeimportv1alpha1.CnsVolumeImport{
+			ObjectMeta: metav1.ObjectMeta{Name: "import-2", Namespace: "test-ns"},
+			Spec:       cnsvolumeimportv1alpha1.CnsVolumeImportSpec{VolumeID: "vol-2"},
+			Status:     cnsvolumeimportv1alpha1.CnsVolumeImportStatus{Imported: true},
+		},
+	}
+	tests := []struct {
+		name              string
+		listErr           error
+		expectedVolumeIDs map[string]struct{}
+		expectErr         bool
+	}{
+		{
+			name:              "TestPendingImports",
+			expectedVolumeIDs: map[string]struct{}{"vol-1": {}},
+		},
+		{
+			name: "TestCRDNotInstalled",
+			listErr: &meta.NoKindMatchError{
+				GroupKind: schema.GroupKind{Group: cnsoperatorv1alpha1.GroupName, Kind: "CnsVolumeImport"},
+			},
+			expectedVolumeIDs: map[string]struct{}{},
+		},
+		{
+			name: "TestNotFound",
+			listErr: apierrors.NewNotFound(schema.GroupResource{Group: cnsoperatorv1alpha1.GroupName,
+				Resource: "cnsvolumeimports"}, ""),
+			expectedVolumeIDs: map[string]struct{}{},
+		},
+		{
+			name:      "TestListFailure",
+			listErr:   apierrors.NewServiceUnavailable("API server unavailable"),
+			expectErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(importInstances...)
+			if test.listErr != nil {
+				builder = builder.WithInterceptorFuncs(interceptor.Funcs{
+					List: func(_ context.Context, _ client.WithWatch, _ client.ObjectList,
+						_ ...client.ListOption) error {
+						return test.listErr
+					},
+				})
+			}
+			volumeIDs, err := getPendingImportVolumeIDs(ctx, builder.Build())
+			if test.expectErr {
+				if err == nil {
+					t.Fatalf("Expected getPendingImportVolumeIDs to fail, got %v", volumeIDs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getPendingImportVolumeIDs failed. Err: %v", err)
+			}
+			if !reflect.DeepEqual(test.expectedVolumeIDs, volumeIDs) {
+				t.Errorf("Expected pending imports %v, got %v", test.expectedVolumeIDs, volumeIDs)
+			}
+		})
+	}
+}
+
+func TestNewFullSyncReport(t *testing.T) {
+	var createSpecArray []cnstypes.CnsVolumeCreateSpec
+	for i := 0; i < triggercsifullsyncv1alpha1.FullSyncReportMaxVolumeIDs+2; i++ {
+		createSpecArray = append(createSpecArray, cnstypes.CnsVolumeCreateSpec{
+			BackingObjectDetails: &cnstypes.CnsBlockBackingDetails{BackingDiskId: fmt.Sprintf("block-%d", i)},
+		})
+	}
+	createSpecArray = append(createSpecArray, cnstypes.CnsVolumeCreateSpec{
+		BackingObjectDetails: &cnstypes.CnsVsanFileShareBackingDetails{
+			CnsFileBackingDetails: cnstypes.CnsFileBackingDetails{BackingFileId: "file-0"},
+		},
+	})
+	// Volumes with metadata to delete and to add in CNS have two update specs.
+	updateSpecArray := []cnstypes.CnsVolumeMetadataUpdateSpec{
+		{VolumeId: cnstypes.CnsVolumeId{Id: "update-0"}},
+		{VolumeId: cnstypes.CnsVolumeId{Id: "update-0"}},
+		{VolumeId: cnstypes.CnsVolumeId{Id: "update-1"}},
+	}
+	volToBeDeleted := []cnstypes.CnsVolumeId{{Id: "delete-0"}}
+
+	report := newFullSyncReport(createSpecArray, updateSpecArray, volToBeDeleted)
+	if report.VolumesToCreate.Count != triggercsifullsyncv1alpha1.FullSyncReportMaxVolumeIDs+3 {
+		t.Errorf("Unexpected count of volumes to create: %d", report.VolumesToCreate.Count)
+	}
+	if len(report.VolumesToCreate.VolumeIDs) != triggercsifullsyncv1alpha1.FullSyncReportMaxVolumeIDs ||
+		report.VolumesToCreate.VolumeIDs[0] != "block-0" {
+		t.Errorf("Unexpected volumes to create: %v", report.VolumesToCreate.VolumeIDs)
+	}
+	if report.VolumesToUpdate.Count != 2 || len(report.VolumesToUpdate.VolumeIDs) != 2 {
+		t.Errorf("Unexpected volumes to update: %+v", report.VolumesToUpdate)
+	}
+	if report.VolumesToDelete.Count != 1 || report.VolumesToDelete.VolumeIDs[0] != "delete-0" {
+		t.Errorf("Unexpected volumes to delete: %+v", report.VolumesToDelete)
+	}
+}
+
+func TestNewOrphanVolume(t *testing.T) {
+	firstSeen := time.Now()
+	vol := cnstypes.CnsVolume{
+		VolumeId:        cnstypes.CnsVolumeId{Id: "file:8F2C8D4B-1A5E-4B1C-9C7E-2B3D4E5F6A7B"},
+		VolumeType:      common.FileVolumeType,
+		DatastoreUrl:    "ds:///vmfs/volumes/vsan:52f7/",
+		StoragePolicyId: "policy-1",
+		BackingObjectDetails: &cnstypes.CnsVsanFileShareBackingDetails{
+			CnsFileBackingDetails: cnstypes.CnsFileBackingDetails{
+				CnsBackingObjectDetails: cnstypes.CnsBackingObjectDetails{CapacityInMb: gbInMb},
+			},
+		},
+		Metadata: cnstypes.CnsVolumeMetadata{
+			EntityMetadata: []cnstypes.BaseCnsEntityMetadata{
+				&cnstypes.CnsKubernetesEntityMetadata{
+					CnsEntityMetadata: cnstypes.CnsEntityMetadata{EntityName: testVolumeName,
+						ClusterID: testClusterName},
+					EntityType: string(cnstypes.CnsKubernetesEntityTypePV),
+				},
+				&cnstypes.CnsKubernetesEntityMetadata{
+					CnsEntityMetadata: cnstypes.CnsEntityMetadata{EntityName: testPVCName,
+						ClusterID: testClusterName},
+					EntityType: string(cnstypes.CnsKubernetesEntityTypePVC),
+					Namespace:  testNamespace,
+				},
+				&cnstypes.CnsKubernetesEntityMetadata{
+					CnsEntityMetadata: cnstypes.CnsEntityMetadata{EntityName: "other-pvc",
+						ClusterID: "other-cluster"},
+					EntityType: string(cnstypes.CnsKubernetesEntityTypePVC),
+					Namespace:  "other-namespace",
+				},
+			},
+		},
+	}
+
+	orphanVolume := newOrphanVolume(vol, testClusterName, "vc-1", firstSeen)
+	if orphanVolume.Name != "file-8f2c8d4b-1a5e-4b1c-9c7e-2b3d4e5f6a7b" {
+		t.Errorf("Unexpected OrphanVolume name: %q", orphanVolume.Name)
+	}
+	spec := orphanVolume.Spec
+	if spec.VolumeID != vol.VolumeId.Id || spec.VCenter != "vc-1" || spec.VolumeType != common.FileVolumeType ||
+		spec.DatastoreURL != vol.DatastoreUrl || spec.StoragePolicyID != "policy-1" || spec.CapacityInMb != gbInMb {
+		t.Errorf("Unexpected OrphanVolume spec: %+v", spec)
+	}
+	if spec.PvName != testVolumeName || spec.PvcName != testPVCName || spec.PvcNamespace != testNamespace {
+		t.Errorf("Unexpected PV and PVC in OrphanVolume spec: %+v", spec)
+	}
+	if !spec.FirstSeenTimeStamp.Time.Equal(firstSeen) {
+		t.Errorf("Unexpected first seen time in OrphanVolume spec: %v", spec.FirstSeenTimeStamp)
+	}
+}
+
+func TestQuarantineOrphanVolumes(t *testing.T) {
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	scheme := runtime.NewScheme()
+	if err := cnsoperatorv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add CNS operator types to scheme. Err: %v", err)
+	}
+	vc := "vc-1"
+	volumeID := "vol-1"
+	cnsVolumeList := []cnstypes.CnsVolume{{VolumeId: cnstypes.CnsVolumeId{Id: volumeID}}}
+	volToBeDeleted := []cnstypes.CnsVolumeId{{Id: volumeID}}
+	expired := time.Now().Add(-2 * time.Hour)
+	tests := []struct {
+		name            string
+		orphanVolume    *orphanvolumev1alpha1.OrphanVolume
+		autoCleanup     bool
+		expectedCleanup bool
+	}{
+		{
+			name:            "TestNewOrphanVolumeRecorded",
+			autoCleanup:     true,
+			expectedCleanup: false,
+		},
+		{
+			name:            "TestAutoCleanupDisabled",
+			orphanVolume:    newOrphanVolume(cnsVolumeList[0], testClusterName, vc, expired),
+			autoCleanup:     false,
+			expectedCleanup: false,
+		},
+		{
+			name:            "TestGracePeriodExpired",
+			orphanVolume:    newOrphanVolume(cnsVolumeList[0], testClusterName, vc, expired),
+			autoCleanup:     true,
+			expectedCleanup: true,
+		},
+		{
+			name:            "TestGracePeriodNotExpired",
+			orphanVolume:    newOrphanVolume(cnsVolumeList[0], testClusterName, vc, time.Now()),
+			autoCleanup:     true,
+			expectedCleanup: false,
+		},
+		{
+			name: "TestCleanupApproved",
+			orphanVolume: func() *orphanvolumev1alpha1.OrphanVolume {
+				orphanVolume := newOrphanVolume(cnsVolumeList[0], testClusterName, vc, time.Now())
+				orphanVolume.Spec.Cleanup = true
+				return orphanVolume
+			}(),
+			autoCleanup:     false,
+			expectedCleanup: true,
+		},
+		{
+			name: "TestRestoreRequested",
+			orphanVolume: func() *orphanvolumev1alpha1.OrphanVolume {
+				orphanVolume := newOrphanVolume(cnsVolumeList[0], testClusterName, vc, expired)
+				orphanVolume.Spec.Cleanup = true
+				orphanVolume.Spec.Restore = true
+				return orphanVolume
+			}(),
+			autoCleanup:     true,
+			expectedCleanup: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder().WithScheme(scheme)
+			if test.orphanVolume != nil {
+				builder = builder.WithObjects(test.orphanVolume)
+			}
+			cnsOperatorClient := builder.Build()
+			syncer := &metadataSyncInformer{
+				configInfo:        &cnsconfig.ConfigurationInfo{Cfg: &cnsconfig.Config{}},
+				cnsOperatorClient: cnsOperatorClient,
+			}
+			syncer.configInfo.Cfg.Global.ClusterID = testClusterName
+			syncer.configInfo.Cfg.Global.OrphanVolumeGracePeriodInMin = 60
+			syncer.configInfo.Cfg.Global.OrphanVolumeAutoCleanup = test.autoCleanup
+
+			volumesToCleanup, err := quarantineOrphanVolumes(ctx, syncer, volToBeDeleted, cnsVolumeList,
+				map[string]string{}, vc)
+			if err != nil {
+				t.Fatalf("quarantineOrphanVolumes failed. Err: %v", err)
+			}
+			if test.expectedCleanup != (len(volumesToCleanup) == 1) {
+				t.Errorf("Expected cleanup of volume %q: %t, got volumes %v", volumeID, test.expectedCleanup,
+					volumesToCleanup)
+			}
+			orphanVolume := &orphanvolumev1alpha1.OrphanVolume{}
+			err = cnsOperatorClient.Get(ctx, client.ObjectKey{Name: getOrphanVolumeName(volumeID)}, orphanVolume)
+			if test.expectedCleanup != apierrors.IsNotFound(err) {
+				t.Errorf("Expected OrphanVolume of volume %q to be deleted: %t, got err %v", volumeID,
+					test.expectedCleanup, err)
+			}
+		})
+	}
+}
+
+func TestUpdateVolumeHealthStatusTransition(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testPVCName,
+			Namespace: testNamespace,
+		},
+	}
+	k8sclient := testclient.NewSimpleClientset(pvc)
+	getPVC := func() *v1.PersistentVolumeClaim {
+		pvc, err := k8sclient.CoreV1().PersistentVolumeClaims(testNamespace).Get(ctx, testPVCName,
+			metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get PVC. Err: %v", err)
+		}
+		return pvc
+	}
+
+	// Setting the initial health is not a transition.
+	updateVolumeHealthStatus(ctx, k8sclient, getPVC(), common.VolHealthStatusAccessible)
+	updatedPVC := getPVC()
+	if updatedPVC.Annotations[annVolumeHealth] != common.VolHealthStatusAccessible {
+		t.Errorf("Unexpected volume health annotation: %q", updatedPVC.Annotations[annVolumeHealth])
+	}
+	if _, found := updatedPVC.Annotations[annVolumeHealthTransitionTS]; found {
+		t.Errorf("Unexpected volume health transition annotation on PVC with initial health")
+	}
+
+	updateVolumeHealthStatus(ctx, k8sclient, updatedPVC, common.VolHealthStatusInaccessible)
+	updatedPVC = getPVC()
+	if updatedPVC.Annotations[annVolumeHealth] != common.VolHealthStatusInaccessible {
+		t.Errorf("Unexpected volume health annotation: %q", updatedPVC.Annotations[annVolumeHealth])
+	}
+	if updatedPVC.Annotations[annVolumeHealthTransitionTS] != updatedPVC.Annotations[annVolumeHealthTS] {
+		t.Errorf("Volume health transition annotation %q not set to %q",
+			updatedPVC.Annotations[annVolumeHealthTransitionTS], updatedPVC.Annotations[annVolumeHealthTS])
+	}
+}
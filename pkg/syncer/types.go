@@ -47,6 +47,10 @@ const (
 	// key for expressing timestamp for volume health annotation
 	annVolumeHealthTS = "volumehealth.storage.kubernetes.io/health-timestamp"
 
+	// key for expressing timestamp of the last transition of the volume
+	// between accessible and inaccessible
+	annVolumeHealthTransitionTS = "volumehealth.storage.kubernetes.io/health-transition-timestamp"
+
 	// default interval for csi volume health
 	defaultVolumeHealthIntervalInMin = 5
 
@@ -108,8 +112,10 @@ type metadataSyncInformer struct {
 	volumeManager volumes.Manager
 	// map of VC Host to Volume Manager
 	// Use this for Vanilla flavor Multi vCenter Topology feature
-	volumeManagers     map[string]volumes.Manager
-	host               string
+	volumeManagers map[string]volumes.Manager
+	host           string
+	// cnsOperatorClient is a client for the CNS operator CRs of the supervisor
+	// cluster in guest clusters, and of this cluster in vanilla clusters.
 	cnsOperatorClient  client.Client
 	supervisorClient   clientset.Interface
 	configInfo         *config.ConfigurationInfo
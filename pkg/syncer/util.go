@@ -23,6 +23,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	apitypes "k8s.io/apimachinery/pkg/types"
+	clientset "k8s.io/client-go/kubernetes"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	storagepolicyusagev1alpha2 "sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/cnsoperator/storagepolicy/v1alpha2"
 	"sigs.k8s.io/vsphere-csi-driver/v3/pkg/apis/migration"
@@ -46,6 +47,10 @@ const (
 	staticVolumeProvisioningSuccessReason = "static volume provisioning succeeded"
 	// message for successful PV creation for static volumes
 	staticVolumeProvisioningSuccessMessage = "Successfully created container volume"
+	// reason for the transition of a volume from accessible to inaccessible
+	volumeInaccessibleReason = "VolumeInaccessible"
+	// reason for the transition of a volume from inaccessible to accessible
+	volumeAccessibleReason = "VolumeAccessible"
 
 	// allowedRetriesToPatchStoragePolicyUsage indicates number of retries allowed for patching StoragePolicyUsage CR
 	allowedRetriesToPatchStoragePolicyUsage = 5
@@ -104,7 +109,8 @@ func getBoundPVs(ctx context.Context, metadataSyncer *metadataSyncInformer) ([]*
 // fullSyncGetInlineMigratedVolumesInfo is a helper function for retrieving
 // inline PV information from Pods.
 func fullSyncGetInlineMigratedVolumesInfo(ctx context.Context,
-	metadataSyncer *metadataSyncInformer, migrationFeatureState bool) (map[string]string, error) {
+	metadataSyncer *metadataSyncInformer, migrationFeatureState bool,
+	registerIfNotFound bool) (map[string]string, error) {
 	log := logger.GetLogger(ctx)
 	inlineVolumes := make(map[string]string)
 	// Get all Pods from kubernetes.
@@ -119,7 +125,7 @@ func fullSyncGetInlineMigratedVolumesInfo(ctx context.Context,
 			if migrationFeatureState && volume.VsphereVolume != nil {
 				volumeHandle, err := volumeMigrationService.GetVolumeID(ctx,
 					&migration.VolumeSpec{VolumePath: volume.VsphereVolume.VolumePath,
-						StoragePolicyName: volume.VsphereVolume.StoragePolicyName}, true)
+						StoragePolicyName: volume.VsphereVolume.StoragePolicyName}, registerIfNotFound)
 				if err != nil {
 					log.Warnf(
 						"FullSync: Failed to get VolumeID from volumeMigrationService for volumePath: %s with error %+v",
@@ -712,6 +718,14 @@ func generateEventOnPv(ctx context.Context, pv *v1.PersistentVolume,
 	eventRecorder.Event(pv, eventType, failureReason, errorMsg)
 }
 
+func generateEventOnPvc(k8sClient clientset.Interface, pvc *v1.PersistentVolumeClaim,
+	eventType string, reason string, msg string) {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: k8sClient.CoreV1().Events("")})
+	eventRecorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: syncerComponent})
+	eventRecorder.Event(pvc, eventType, reason, msg)
+}
+
 func createCnsVolume(ctx context.Context, pv *v1.PersistentVolume,
 	metadataSyncer *metadataSyncInformer, cnsVolumeMgr volumes.Manager, volumeType string,
 	vcHost string, metadataList []cnstypes.BaseCnsEntityMetadata, volumeHandle string) error {
@@ -724,7 +738,7 @@ func createCnsVolume(ctx context.Context, pv *v1.PersistentVolume,
 	}
 
 	containerCluster := cnsvsphere.GetContainerCluster(clusterIDforVolumeMetadata,
-		vcHostObj.User, metadataSyncer.clusterFlavor,
+		cnsvsphere.GetVCenterUser(ctx, vcHost, vcHostObj), metadataSyncer.clusterFlavor,
 		metadataSyncer.configInfo.Cfg.Global.ClusterDistribution)
 
 	createSpec := &cnstypes.CnsVolumeCreateSpec{
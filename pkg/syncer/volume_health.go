@@ -18,6 +18,7 @@ package syncer
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	cnstypes "github.com/vmware/govmomi/cns/types"
@@ -40,6 +41,8 @@ func csiGetVolumeHealthStatus(ctx context.Context, k8sclient clientset.Interface
 	querySelection := cnstypes.CnsQuerySelection{
 		Names: []string{
 			string(cnstypes.QuerySelectionNameTypeHealthStatus),
+			string(cnstypes.QuerySelectionNameTypeDataStoreUrl),
+			string(cnstypes.QuerySelectionNameTypePolicyId),
 		},
 	}
 	queryAllResult, err := utils.QueryAllVolumesForCluster(ctx, metadataSyncer.volumeManager,
@@ -77,11 +80,19 @@ func csiGetVolumeHealthStatus(ctx context.Context, k8sclient clientset.Interface
 
 	// volumeIdToHealthStatusMap maps vol.VolumeId.Id to vol.HealthStatus.
 	volumeIdToHealthStatusMap := make(volumeIdHealthStatusMap, len(queryAllResult.Volumes))
+	// volumeIdToVolumeMap maps vol.VolumeId.Id to the CNS volume, used to
+	// label the per volume health metric.
+	volumeIdToVolumeMap := make(map[string]cnstypes.CnsVolume, len(queryAllResult.Volumes))
 
 	for _, vol := range queryAllResult.Volumes {
 		volumeIdToHealthStatusMap[vol.VolumeId.Id] = vol.HealthStatus
+		volumeIdToVolumeMap[vol.VolumeId.Id] = vol
 	}
 
+	// Series of PVCs which are deleted or no longer bound are dropped by
+	// resetting the per volume health metric.
+	prometheus.VolumeHealthPerVolumeGaugeVec.Reset()
+
 	accessibleVolumeCount := 0
 	inaccessibleVolumeCount := 0
 	for volID, pvc := range volumeHandleToPvcMap {
@@ -112,11 +123,16 @@ func csiGetVolumeHealthStatus(ctx context.Context, k8sclient clientset.Interface
 			volHealthStatusAnn = common.VolHealthStatusInaccessible
 			updateVolumeHealthStatus(ctx, k8sclient, pvc, volHealthStatusAnn)
 		}
+		vol := volumeIdToVolumeMap[volID]
 		switch volHealthStatusAnn {
 		case common.VolHealthStatusAccessible:
 			accessibleVolumeCount += 1
+			prometheus.VolumeHealthPerVolumeGaugeVec.WithLabelValues(pvc.Namespace, pvc.Name,
+				vol.DatastoreUrl, vol.StoragePolicyId).Set(1)
 		case common.VolHealthStatusInaccessible:
 			inaccessibleVolumeCount += 1
+			prometheus.VolumeHealthPerVolumeGaugeVec.WithLabelValues(pvc.Namespace, pvc.Name,
+				vol.DatastoreUrl, vol.StoragePolicyId).Set(0)
 		}
 	}
 	prometheus.VolumeHealthGaugeVec.WithLabelValues(
@@ -127,6 +143,39 @@ func csiGetVolumeHealthStatus(ctx context.Context, k8sclient clientset.Interface
 	log.Infof("GetVolumeHealthStatus: end")
 }
 
+// isVolumeHealthTransition returns true if the health of a volume changes
+// from accessible to inaccessible or from inaccessible to accessible.
+func isVolumeHealthTransition(oldVolHealthStatus string, newVolHealthStatus string) bool {
+	return (oldVolHealthStatus == common.VolHealthStatusAccessible &&
+		newVolHealthStatus == common.VolHealthStatusInaccessible) ||
+		(oldVolHealthStatus == common.VolHealthStatusInaccessible &&
+			newVolHealthStatus == common.VolHealthStatusAccessible)
+}
+
+// setVolumeHealthAnnotations sets the volume health annotations on the pvc.
+// The transition timestamp is only set if the health of the volume changed
+// between accessible and inaccessible.
+func setVolumeHealthAnnotations(pvc *v1.PersistentVolumeClaim, volHealthStatus string, timeNow string,
+	transition bool) {
+	metav1.SetMetaDataAnnotation(&pvc.ObjectMeta, annVolumeHealth, volHealthStatus)
+	metav1.SetMetaDataAnnotation(&pvc.ObjectMeta, annVolumeHealthTS, timeNow)
+	if transition {
+		metav1.SetMetaDataAnnotation(&pvc.ObjectMeta, annVolumeHealthTransitionTS, timeNow)
+	}
+}
+
+// generateVolumeHealthEventOnPvc records a Warning event on the pvc when its
+// volume becomes inaccessible and a Normal event when it is accessible again.
+func generateVolumeHealthEventOnPvc(k8sclient clientset.Interface, pvc *v1.PersistentVolumeClaim,
+	oldVolHealthStatus string, newVolHealthStatus string) {
+	eventType, reason := v1.EventTypeNormal, volumeAccessibleReason
+	if newVolHealthStatus == common.VolHealthStatusInaccessible {
+		eventType, reason = v1.EventTypeWarning, volumeInaccessibleReason
+	}
+	generateEventOnPvc(k8sclient, pvc, eventType, reason, fmt.Sprintf("Health of volume %s changed from %s to %s",
+		pvc.Spec.VolumeName, oldVolHealthStatus, newVolHealthStatus))
+}
+
 func updateVolumeHealthStatus(ctx context.Context, k8sclient clientset.Interface,
 	pvc *v1.PersistentVolumeClaim, volHealthStatus string) {
 	log := logger.GetLogger(ctx)
@@ -134,10 +183,10 @@ func updateVolumeHealthStatus(ctx context.Context, k8sclient clientset.Interface
 	val, found := pvc.Annotations[annVolumeHealth]
 	_, foundAnnHealthTS := pvc.Annotations[annVolumeHealthTS]
 	if !found || val != volHealthStatus || !foundAnnHealthTS {
+		transition := found && isVolumeHealthTransition(val, volHealthStatus)
 		// VolumeHealth annotation on pvc is changed, set it to new value.
-		metav1.SetMetaDataAnnotation(&pvc.ObjectMeta, annVolumeHealth, volHealthStatus)
 		timeNow := time.Now().Format(time.UnixDate)
-		metav1.SetMetaDataAnnotation(&pvc.ObjectMeta, annVolumeHealthTS, timeNow)
+		setVolumeHealthAnnotations(pvc, volHealthStatus, timeNow, transition)
 		log.Infof("updateVolumeHealthStatus: set volumehealth annotation for pvc %s/%s from old "+
 			"value %s to new value %s and volumehealthTS annotation to %s",
 			pvc.Namespace, pvc.Name, val, volHealthStatus, timeNow)
@@ -155,13 +204,14 @@ func updateVolumeHealthStatus(ctx context.Context, k8sclient clientset.Interface
 					log.Infof("updateVolumeHealthStatus: updating volume health annotation for pvc %s/%s which "+
 						"get from API server from old value %s to new value %s and volumehealthTS annotation to %s",
 						newPvc.Namespace, newPvc.Name, val, volHealthStatus, timeUpdate)
-					metav1.SetMetaDataAnnotation(&newPvc.ObjectMeta, annVolumeHealth, volHealthStatus)
-					metav1.SetMetaDataAnnotation(&newPvc.ObjectMeta, annVolumeHealthTS, timeUpdate)
+					setVolumeHealthAnnotations(newPvc, volHealthStatus, timeUpdate, transition)
 					_, err := k8sclient.CoreV1().PersistentVolumeClaims(newPvc.Namespace).Update(ctx,
 						newPvc, metav1.UpdateOptions{})
 					if err != nil {
 						log.Errorf("updateVolumeHealthStatus: Failed to update pvc %s/%s with err:%+v",
 							newPvc.Namespace, newPvc.Name, err)
+					} else if transition {
+						generateVolumeHealthEventOnPvc(k8sclient, newPvc, val, volHealthStatus)
 					}
 				} else {
 					log.Errorf("updateVolumeHealthStatus: volume health annotation for pvc %s/%s is not updated because "+
@@ -172,6 +222,8 @@ func updateVolumeHealthStatus(ctx context.Context, k8sclient clientset.Interface
 				log.Errorf("updateVolumeHealthStatus: Failed to update pvc %s/%s with err:%+v",
 					pvc.Namespace, pvc.Name, err)
 			}
+		} else if transition {
+			generateVolumeHealthEventOnPvc(k8sclient, pvc, val, volHealthStatus)
 		}
 	}
 }